@@ -0,0 +1,217 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Saintrad/todo-server-client/internal/apiclient"
+)
+
+// ANSI colours of the agenda headings.
+const (
+	red    = "31"
+	yellow = "33"
+	cyan   = "36"
+)
+
+// paint colours s when stdout is a terminal and NO_COLOR is not set.
+func paint(color, s string) string {
+	if !colorOutput {
+		return s
+	}
+	return "\x1b[1;" + color + "m" + s + "\x1b[0m"
+}
+
+var colorOutput = func() bool {
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	fi, err := os.Stdout.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}()
+
+// localTimeZone names the zone of this machine for the server, which judges
+// due days in it: $TZ, else the zone /etc/localtime links to, else the
+// current UTC offset.
+func localTimeZone() string {
+	if tz, ok := os.LookupEnv("TZ"); ok {
+		tz = strings.TrimPrefix(tz, ":")
+		switch {
+		case tz == "":
+			return "UTC"
+		case !filepath.IsAbs(tz):
+			return tz
+		}
+	}
+	if target, err := os.Readlink("/etc/localtime"); err == nil {
+		if _, name, ok := strings.Cut(target, "zoneinfo/"); ok {
+			return name
+		}
+	}
+	return time.Now().Format("Z07:00")
+}
+
+// agendaParams parses the filters the agenda commands share: +tag (must
+// have all of them) and --priority.
+func agendaParams(name string, args []string) (apiclient.ListTasksParams, error) {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(ioDiscard{})
+	priority := fs.String("priority", "", "comma-separated priorities: none, low, medium, high")
+
+	tags, err := parseWithTags(fs, args)
+	if err != nil {
+		return apiclient.ListTasksParams{}, err
+	}
+	return apiclient.ListTasksParams{
+		TagsAll:    tags,
+		Priorities: splitTags(*priority),
+		TimeZone:   localTimeZone(),
+		Sort:       "due_date",
+		Order:      "asc",
+		Limit:      200,
+	}, nil
+}
+
+// cmdToday prints what is overdue and what is due today.
+func cmdToday(ctx context.Context, c *apiclient.Client, args []string) error {
+	p, err := agendaParams("today", args)
+	if err != nil {
+		return err
+	}
+	a, err := c.Agenda(ctx, p)
+	if err != nil {
+		return err
+	}
+
+	if len(a.Overdue.Items) == 0 && len(a.Today.Items) == 0 {
+		fmt.Println("(nothing due today)")
+		return nil
+	}
+	if len(a.Overdue.Items) > 0 {
+		fmt.Println(paint(red, fmt.Sprintf("Overdue (%d)", a.Overdue.Total)))
+		for _, t := range a.Overdue.Items {
+			fmt.Printf("  %s  %s\n", taskLine(t), relativeDay(t.DueIn))
+		}
+	}
+	if len(a.Today.Items) > 0 {
+		day := a.Date
+		if d, err := time.Parse(time.DateOnly, a.Date); err == nil {
+			day = d.Format("Mon 2 Jan")
+		}
+		fmt.Println(paint(yellow, fmt.Sprintf("Today, %s (%d)", day, a.Today.Total)))
+		for _, t := range a.Today.Items {
+			fmt.Println("  " + taskLine(t))
+		}
+	}
+	return nil
+}
+
+// cmdOverdue prints the overdue tasks grouped by the day they were due.
+func cmdOverdue(ctx context.Context, c *apiclient.Client, args []string) error {
+	p, err := agendaParams("overdue", args)
+	if err != nil {
+		return err
+	}
+	p.Due = "overdue"
+	tasks, err := listAll(ctx, c, p)
+	if err != nil {
+		return err
+	}
+	if len(tasks) == 0 {
+		fmt.Println("(nothing overdue)")
+		return nil
+	}
+	printByDay(tasks, red)
+	return nil
+}
+
+// cmdUpcoming prints the tasks due in the rest of the week grouped by day.
+func cmdUpcoming(ctx context.Context, c *apiclient.Client, args []string) error {
+	p, err := agendaParams("upcoming", args)
+	if err != nil {
+		return err
+	}
+	p.Due = "upcoming"
+	undone := false
+	p.IsDone = &undone
+	tasks, err := listAll(ctx, c, p)
+	if err != nil {
+		return err
+	}
+	if len(tasks) == 0 {
+		fmt.Println("(nothing due this week)")
+		return nil
+	}
+	printByDay(tasks, cyan)
+	return nil
+}
+
+// listAll fetches every page of tasks matching p.
+func listAll(ctx context.Context, c *apiclient.Client, p apiclient.ListTasksParams) ([]apiclient.Task, error) {
+	var tasks []apiclient.Task
+	for {
+		page, err := c.ListTasks(ctx, p)
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, page.Items...)
+		if len(page.Items) == 0 || page.Offset+len(page.Items) >= page.Total {
+			return tasks, nil
+		}
+		p.Offset = page.Offset + len(page.Items)
+	}
+}
+
+// printByDay prints tasks under a heading per due day, keeping their order
+// within a day. The server's due_in places them, so days follow the zone
+// it judged them in.
+func printByDay(tasks []apiclient.Task, color string) {
+	slices.SortStableFunc(tasks, func(a, b apiclient.Task) int {
+		return cmp.Compare(dueIn(a), dueIn(b))
+	})
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	for i, t := range tasks {
+		if t.DueIn == nil {
+			continue
+		}
+		if i == 0 || tasks[i-1].DueIn == nil || *tasks[i-1].DueIn != *t.DueIn {
+			day := today.AddDate(0, 0, *t.DueIn).Format("Mon 2 Jan")
+			fmt.Println(paint(color, day+" · "+relativeDay(t.DueIn)))
+		}
+		fmt.Println("  " + taskLine(t))
+	}
+}
+
+func dueIn(t apiclient.Task) int {
+	if t.DueIn == nil {
+		return 0
+	}
+	return *t.DueIn
+}
+
+// relativeDay describes a due_in such as -3 as "3 days ago".
+func relativeDay(dueIn *int) string {
+	if dueIn == nil {
+		return ""
+	}
+	switch n := *dueIn; {
+	case n == 0:
+		return "today"
+	case n == 1:
+		return "tomorrow"
+	case n == -1:
+		return "yesterday"
+	case n > 1:
+		return fmt.Sprintf("in %d days", n)
+	default:
+		return fmt.Sprintf("%d days ago", -n)
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/Saintrad/todo-server-client/internal/apiclient"
+)
+
+// cmdBatch sends operations read from a file (or stdin) in one request.
+// Each operation has the wire shape, e.g.
+//
+//	{"op":"create","task":{"title":"Buy milk"}}
+//	{"op":"update","id":3,"if_version":2,"task":{"is_done":true}}
+//	{"op":"delete","id":4}
+func cmdBatch(ctx context.Context, c *apiclient.Client, args []string) error {
+	fs := flag.NewFlagSet("batch", flag.ContinueOnError)
+	fs.SetOutput(ioDiscard{})
+
+	atomic := fs.Bool("atomic", false, "apply all operations or none")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 1 {
+		return fmt.Errorf("usage: client batch [--atomic] [file]")
+	}
+
+	in := io.Reader(os.Stdin)
+	if name := fs.Arg(0); name != "" && name != "-" {
+		f, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+
+	ops, err := readBatchOps(in)
+	if err != nil {
+		return err
+	}
+	if len(ops) == 0 {
+		return fmt.Errorf("no operations given")
+	}
+
+	results, err := c.Batch(ctx, ops, *atomic)
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+	for i, res := range results {
+		if err := res.Err(); err != nil {
+			failed++
+			fmt.Printf("%d: %s failed: %v\n", i, ops[i].Op, err)
+			continue
+		}
+		if res.Task != nil {
+			fmt.Printf("%d: %s task %d (version %d)\n", i, ops[i].Op, res.Task.ID, res.Task.Version)
+		} else {
+			fmt.Printf("%d: %s task %d\n", i, ops[i].Op, ops[i].ID)
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d operations failed", failed, len(results))
+	}
+	return nil
+}
+
+// readBatchOps accepts a JSON array of operations, one operation per line,
+// or any mix of the two.
+func readBatchOps(r io.Reader) ([]apiclient.BatchOp, error) {
+	dec := json.NewDecoder(r)
+
+	var ops []apiclient.BatchOp
+	for {
+		var raw json.RawMessage
+		err := dec.Decode(&raw)
+		if errors.Is(err, io.EOF) {
+			return ops, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read operations: %w", err)
+		}
+
+		strict := json.NewDecoder(bytes.NewReader(raw))
+		strict.DisallowUnknownFields()
+
+		if bytes.HasPrefix(bytes.TrimSpace(raw), []byte("[")) {
+			var list []apiclient.BatchOp
+			if err := strict.Decode(&list); err != nil {
+				return nil, fmt.Errorf("read operations: %w", err)
+			}
+			ops = append(ops, list...)
+			continue
+		}
+
+		var op apiclient.BatchOp
+		if err := strict.Decode(&op); err != nil {
+			return nil, fmt.Errorf("read operation %d: %w", len(ops), err)
+		}
+		ops = append(ops, op)
+	}
+}
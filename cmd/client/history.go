@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/Saintrad/todo-server-client/internal/apiclient"
+)
+
+func cmdHistory(ctx context.Context, c *apiclient.Client, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: client history <id>")
+	}
+	id, err := strconv.Atoi(args[0])
+	if err != nil || id <= 0 {
+		return fmt.Errorf("invalid id: %s", args[0])
+	}
+
+	events, err := c.History(ctx, id)
+	if err != nil {
+		return err
+	}
+	if len(events) == 0 {
+		fmt.Println("(no history)")
+	}
+	for _, e := range events {
+		by := ""
+		if e.Actor != "" {
+			by = " by " + e.Actor
+		}
+		fmt.Printf("%s  %s%s\n", e.At.Local().Format("2006-01-02 15:04:05"), e.Kind, by)
+		for _, ch := range e.Changes {
+			fmt.Printf("    %s\n", changeLine(ch))
+		}
+	}
+	return nil
+}
+
+// changeLine is "field: old -> new", or "field: new" when the field was
+// unset before.
+func changeLine(ch apiclient.Change) string {
+	value := func(v *string) string {
+		if v == nil {
+			return "(none)"
+		}
+		return strconv.Quote(*v)
+	}
+	if ch.Old == nil {
+		return ch.Field + ": " + value(ch.New)
+	}
+	return ch.Field + ": " + value(ch.Old) + " -> " + value(ch.New)
+}
@@ -1,9 +1,11 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"os"
+	"os/signal"
 	"strconv"
 	"strings"
 	"time"
@@ -23,30 +25,102 @@ func main() {
 	args := os.Args[2:]
 
 	c := apiclient.New(baseURL)
+	if token := os.Getenv("TODO_TOKEN"); token != "" {
+		c.SetToken(token)
+	}
+
+	// Ctrl-C cancels the request in flight instead of waiting for it
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
 
 	switch cmd {
 	case "list":
-		if err := cmdList(c); err != nil {
+		if err := cmdList(ctx, c, args); err != nil {
+			fail(err)
+		}
+
+	case "today":
+		if err := cmdToday(ctx, c, args); err != nil {
+			fail(err)
+		}
+
+	case "overdue":
+		if err := cmdOverdue(ctx, c, args); err != nil {
+			fail(err)
+		}
+
+	case "upcoming":
+		if err := cmdUpcoming(ctx, c, args); err != nil {
 			fail(err)
 		}
 
 	case "create":
-		if err := cmdCreate(c, args); err != nil {
+		if err := cmdCreate(ctx, c, args); err != nil {
 			fail(err)
 		}
 
 	case "get":
-		if err := cmdGet(c, args); err != nil {
+		if err := cmdGet(ctx, c, args); err != nil {
 			fail(err)
 		}
 
 	case "update":
-		if err := cmdUpdate(c, args); err != nil {
+		if err := cmdUpdate(ctx, c, args); err != nil {
 			fail(err)
 		}
 
 	case "delete":
-		if err := cmdDelete(c, args); err != nil {
+		if err := cmdDelete(ctx, c, args); err != nil {
+			fail(err)
+		}
+
+	case "history":
+		if err := cmdHistory(ctx, c, args); err != nil {
+			fail(err)
+		}
+
+	case "watch":
+		if err := cmdWatch(ctx, c, args); err != nil {
+			fail(err)
+		}
+
+	case "export":
+		if err := cmdExport(ctx, c, args); err != nil {
+			fail(err)
+		}
+
+	case "import":
+		if err := cmdImport(ctx, c, args); err != nil {
+			fail(err)
+		}
+
+	case "webhooks":
+		if err := cmdWebhooks(ctx, c, args); err != nil {
+			fail(err)
+		}
+
+	case "tags":
+		if err := cmdTags(ctx, c, args); err != nil {
+			fail(err)
+		}
+
+	case "trash":
+		if err := cmdTrash(ctx, c, args); err != nil {
+			fail(err)
+		}
+
+	case "register":
+		if err := cmdRegister(ctx, c, args); err != nil {
+			fail(err)
+		}
+
+	case "login":
+		if err := cmdLogin(ctx, c, args); err != nil {
+			fail(err)
+		}
+
+	case "batch":
+		if err := cmdBatch(ctx, c, args); err != nil {
 			fail(err)
 		}
 
@@ -58,16 +132,44 @@ func main() {
 }
 
 func usage() {
-	fmt.Fprintln(os.Stderr, `Usage:
-  client list
-
-  client create --title "..." [--category "work"] [--due "2026-01-10"]
-  client get <id>
-  client update <id> [--title "..."] [--category "..."] [--due "YYYY-MM-DD"] [--done | --undone]
-  client delete <id>
+	fmt.Fprint(os.Stderr, `Usage:
+  client list [--done | --undone] [--search "..."] [--priority high,medium]
+              [--sort smart|created_at|due_date|updated_at]
+              [--order asc|desc] [--limit N] [--offset N] [--all] [--tree]
+              [+tag ...] [--tags-any a,b] [--tags-none a,b]   (+tag: must have all of them)
+              [--due overdue|today|upcoming|week|none]
+  client today | overdue | upcoming [--priority high,medium] [+tag ...]
+              (open tasks by due day in the local time zone; upcoming is the next 6 days)
+
+  client create --title "..." [--category "work"] [--due "2026-01-10"] [--parent ID]
+                [--priority none|low|medium|high]
+                [--repeat daily|weekly[:mon,thu]|monthly[:15]|after:N] [+tag ...]
+                [--remind due-1h | --remind "2026-01-09 18:00" ...]
+  client get <id>   (with its subtasks as a tree)
+  client update <id> [--title "..."] [--category "..." | --clear-category]
+                     [--due "YYYY-MM-DD" | --clear-due] [--parent ID | --clear-parent]
+                     [--repeat RULE | --clear-repeat]
+                     [--remind WHEN ... | --clear-reminders]
+                     [+tag ...] [--untag TAG ...] [--clear-tags] [--priority LEVEL]
+                     [--done | --undone] [--if-version N]
+  client delete <id> [--if-version N]   (moves the task to the trash)
+  client history <id>   (who changed what and when)
+  client watch   (prints changes live until Ctrl-C)
+  client trash [restore ID | purge ID | empty]   (without arguments: list the trash)
+  client batch [--atomic] [file]   (operations as a JSON array or JSON lines; default stdin)
+  client export [--format json|csv|todotxt|ics] [--output FILE] [--done | --undone]
+                [--priority high,medium] [+tag ...]   (default stdout)
+  client import [--format json|csv|todotxt] [--duplicates skip|update|create]
+                [--dry-run] [file]   (format from the file name; default stdin)
+  client tags [rename OLD NEW | delete TAG]   (without arguments: tags with task counts)
+  client webhooks [add URL [--events created,completed] [--secret S] | delete ID | deliveries ID]
+
+  client register --username "..." --password "..."
+  client login --username "..." --password "..."
 
 Environment:
   TODO_BASE_URL (default http://localhost:8080)
+  TODO_TOKEN    (optional v2 bearer token from "client login"; tasks are then per-user)
 `)
 }
 
@@ -76,41 +178,98 @@ func fail(err error) {
 	os.Exit(1)
 }
 
-func cmdList(c *apiclient.Client) error {
-	tasks, err := c.ListTasks()
+func cmdList(ctx context.Context, c *apiclient.Client, args []string) error {
+	fs := flag.NewFlagSet("list", flag.ContinueOnError)
+	fs.SetOutput(ioDiscard{})
+
+	done := fs.Bool("done", false, "only done tasks")
+	undone := fs.Bool("undone", false, "only open tasks")
+	search := fs.String("search", "", "search in title and category")
+	priority := fs.String("priority", "", "comma-separated priorities: none, low, medium, high")
+	sortBy := fs.String("sort", "", "sort field: smart (default), created_at, due_date or updated_at")
+	order := fs.String("order", "", "sort order: asc or desc")
+	limit := fs.Int("limit", 0, "page size (server default 50, max 200)")
+	offset := fs.Int("offset", 0, "number of tasks to skip")
+	all := fs.Bool("all", false, "fetch every page")
+	tree := fs.Bool("tree", false, "fetch every page and show subtasks under their parents")
+	tagsAny := fs.String("tags-any", "", "comma-separated tags, at least one must match")
+	tagsNone := fs.String("tags-none", "", "comma-separated tags, none may match")
+	due := fs.String("due", "", "due day preset: overdue, today, upcoming, week or none")
+
+	tagsAll, err := parseWithTags(fs, args)
 	if err != nil {
 		return err
 	}
+	*all = *all || *tree
+	if *done && *undone {
+		return fmt.Errorf("use only one of --done or --undone")
+	}
+
+	p := apiclient.ListTasksParams{
+		Query:      strings.TrimSpace(*search),
+		TagsAny:    splitTags(*tagsAny),
+		TagsAll:    tagsAll,
+		TagsNone:   splitTags(*tagsNone),
+		Priorities: splitTags(*priority),
+		Due:        *due,
+		TimeZone:   localTimeZone(),
+		Sort:       *sortBy,
+		Order:      *order,
+		Limit:      *limit,
+		Offset:     *offset,
+	}
+	if *done || *undone {
+		v := *done
+		p.IsDone = &v
+	}
+
+	var tasks []apiclient.Task
+	total := 0
+	for {
+		page, err := c.ListTasks(ctx, p)
+		if err != nil {
+			return err
+		}
+		tasks = append(tasks, page.Items...)
+		total = page.Total
+
+		if !*all || len(page.Items) == 0 || page.Offset+len(page.Items) >= page.Total {
+			break
+		}
+		p.Offset = page.Offset + len(page.Items)
+	}
+
 	if len(tasks) == 0 {
 		fmt.Println("(no tasks)")
 		return nil
 	}
+	if *tree {
+		printTree(tasks)
+		return nil
+	}
 	for _, t := range tasks {
-		box := " "
-		if t.IsDone {
-			box = "x"
-		}
-		cat := ""
-		if t.Category != nil && *t.Category != "" {
-			cat = " (" + *t.Category + ")"
-		}
-		due := ""
-		if t.DueDate != nil {
-			due = " due:" + t.DueDate.Format("2006-01-02")
-		}
-		fmt.Printf("%d [%s] %s%s%s\n", t.ID, box, t.Title, cat, due)
+		fmt.Println(taskLine(t))
+	}
+	if len(tasks) < total {
+		fmt.Printf("(showing %d of %d; use --offset or --all for more)\n", len(tasks), total)
 	}
 	return nil
 }
 
-func cmdCreate(c *apiclient.Client, args []string) error {
+func cmdCreate(ctx context.Context, c *apiclient.Client, args []string) error {
 	fs := flag.NewFlagSet("create", flag.ContinueOnError)
 	fs.SetOutput(ioDiscard{}) // suppress default flag error printing; we handle errors ourselves
 
 	title := fs.String("title", "", "task title (required)")
 	category := fs.String("category", "", "optional category")
 	due := fs.String("due", "", "optional due date in YYYY-MM-DD")
-	if err := fs.Parse(args); err != nil {
+	parent := fs.Int("parent", 0, "create as a subtask of this task")
+	repeat := fs.String("repeat", "", `recurrence: daily, weekly[:mon,thu], monthly[:15] or after:N`)
+	priority := fs.String("priority", "", "none, low, medium or high")
+	var remind stringList
+	fs.Var(&remind, "remind", "reminder: due[-+]offset such as due-1h, or a time (repeatable)")
+	tags, err := parseWithTags(fs, args)
+	if err != nil {
 		return err
 	}
 	if strings.TrimSpace(*title) == "" {
@@ -132,11 +291,20 @@ func cmdCreate(c *apiclient.Client, args []string) error {
 		duePtr = &tm
 	}
 
-	created, err := c.CreateTask(apiclient.CreateTaskRequest{
-		Title:    strings.TrimSpace(*title),
-		Category: catPtr,
-		DueDate:  duePtr,
-	})
+	req := apiclient.CreateTaskRequest{
+		Title:     strings.TrimSpace(*title),
+		Category:  catPtr,
+		DueDate:   duePtr,
+		Repeat:    strings.TrimSpace(*repeat),
+		Tags:      tags,
+		Priority:  strings.TrimSpace(*priority),
+		Reminders: reminderArgs(remind),
+	}
+	if *parent > 0 {
+		req.ParentID = parent
+	}
+
+	created, err := c.CreateTask(ctx, req)
 	if err != nil {
 		return err
 	}
@@ -145,7 +313,7 @@ func cmdCreate(c *apiclient.Client, args []string) error {
 	return nil
 }
 
-func cmdGet(c *apiclient.Client, args []string) error {
+func cmdGet(ctx context.Context, c *apiclient.Client, args []string) error {
 	if len(args) != 1 {
 		return fmt.Errorf("usage: client get <id>")
 	}
@@ -154,17 +322,26 @@ func cmdGet(c *apiclient.Client, args []string) error {
 		return fmt.Errorf("invalid id: %s", args[0])
 	}
 
-	t, err := c.GetTask(id)
+	t, err := c.GetTask(ctx, id)
 	if err != nil {
 		return err
 	}
 	printTask(t)
+
+	subtasks, err := fetchSubtree(ctx, c, id)
+	if err != nil {
+		return err
+	}
+	if len(subtasks) > 0 {
+		fmt.Println("Subtasks:")
+		printBranches(childrenByParent(subtasks), id, "")
+	}
 	return nil
 }
 
-func cmdUpdate(c *apiclient.Client, args []string) error {
+func cmdUpdate(ctx context.Context, c *apiclient.Client, args []string) error {
 	if len(args) < 1 {
-		return fmt.Errorf("usage: client update <id> [--title ...] [--category ...] [--due ...] [--done|--undone]")
+		return fmt.Errorf("usage: client update <id> [--title ...] [--category ...|--clear-category] [--due ...|--clear-due] [--parent N|--clear-parent] [--repeat RULE|--clear-repeat] [--remind WHEN ...|--clear-reminders] [+tag ...] [--untag TAG ...] [--clear-tags] [--priority LEVEL] [--done|--undone] [--if-version N]")
 	}
 	id, err := strconv.Atoi(args[0])
 	if err != nil || id <= 0 {
@@ -177,15 +354,48 @@ func cmdUpdate(c *apiclient.Client, args []string) error {
 	title := fs.String("title", "", "new title")
 	category := fs.String("category", "", "new category")
 	due := fs.String("due", "", "new due date in YYYY-MM-DD")
+	clearCategory := fs.Bool("clear-category", false, "remove the category")
+	clearDue := fs.Bool("clear-due", false, "remove the due date")
+	parent := fs.Int("parent", 0, "move under this task")
+	clearParent := fs.Bool("clear-parent", false, "make a top-level task")
+	repeat := fs.String("repeat", "", "new recurrence rule")
+	clearRepeat := fs.Bool("clear-repeat", false, "stop repeating")
+	var remind stringList
+	fs.Var(&remind, "remind", "replace the reminders with this one (repeatable)")
+	clearReminders := fs.Bool("clear-reminders", false, "remove all reminders")
+	var untag stringList
+	fs.Var(&untag, "untag", "remove this tag (repeatable)")
+	clearTags := fs.Bool("clear-tags", false, "remove all tags")
+	priority := fs.String("priority", "", "new priority: none, low, medium or high")
 	done := fs.Bool("done", false, "mark as done")
 	undone := fs.Bool("undone", false, "mark as not done")
+	ifVersion := fs.Int("if-version", 0, "only update if the task is still at this version")
 
-	if err := fs.Parse(args[1:]); err != nil {
+	addTags, err := parseWithTags(fs, args[1:])
+	if err != nil {
 		return err
 	}
 	if *done && *undone {
 		return fmt.Errorf("use only one of --done or --undone")
 	}
+	if *clearCategory && strings.TrimSpace(*category) != "" {
+		return fmt.Errorf("use only one of --category or --clear-category")
+	}
+	if *clearDue && strings.TrimSpace(*due) != "" {
+		return fmt.Errorf("use only one of --due or --clear-due")
+	}
+	if *clearParent && *parent != 0 {
+		return fmt.Errorf("use only one of --parent or --clear-parent")
+	}
+	if *clearRepeat && strings.TrimSpace(*repeat) != "" {
+		return fmt.Errorf("use only one of --repeat or --clear-repeat")
+	}
+	if *clearReminders && len(remind) > 0 {
+		return fmt.Errorf("use only one of --remind or --clear-reminders")
+	}
+	if *clearTags && (len(addTags) > 0 || len(untag) > 0) {
+		return fmt.Errorf("use only one of +tag/--untag or --clear-tags")
+	}
 
 	var req apiclient.UpdateTaskRequest
 	changed := false
@@ -202,9 +412,11 @@ func cmdUpdate(c *apiclient.Client, args []string) error {
 	}
 
 	if fs.Lookup("category").Value.String() != "" {
-		v := strings.TrimSpace(*category)
-		// This sets category to provided string; clearing category requires an explicit design later.
-		req.Category = &v
+		req.Category = apiclient.Value(strings.TrimSpace(*category))
+		changed = true
+	}
+	if *clearCategory {
+		req.Category = apiclient.Null[string]()
 		changed = true
 	}
 
@@ -213,7 +425,54 @@ func cmdUpdate(c *apiclient.Client, args []string) error {
 		if err != nil {
 			return fmt.Errorf("invalid --due (expected YYYY-MM-DD): %w", err)
 		}
-		req.DueDate = &tm
+		req.DueDate = apiclient.Value(tm)
+		changed = true
+	}
+	if *clearDue {
+		req.DueDate = apiclient.Null[time.Time]()
+		changed = true
+	}
+
+	if *parent != 0 {
+		req.ParentID = apiclient.Value(*parent)
+		changed = true
+	}
+	if *clearParent {
+		req.ParentID = apiclient.Null[int]()
+		changed = true
+	}
+
+	if strings.TrimSpace(*repeat) != "" {
+		req.Repeat = apiclient.Value(strings.TrimSpace(*repeat))
+		changed = true
+	}
+	if *clearRepeat {
+		req.Repeat = apiclient.Null[string]()
+		changed = true
+	}
+
+	if len(remind) > 0 {
+		req.Reminders = apiclient.Value(reminderArgs(remind))
+		changed = true
+	}
+	if *clearReminders {
+		req.Reminders = apiclient.Null[[]string]()
+		changed = true
+	}
+
+	if len(addTags) > 0 || len(untag) > 0 {
+		req.AddTags = addTags
+		req.RemoveTags = untag
+		changed = true
+	}
+	if *clearTags {
+		req.Tags = apiclient.Null[[]string]()
+		changed = true
+	}
+
+	if strings.TrimSpace(*priority) != "" {
+		v := strings.TrimSpace(*priority)
+		req.Priority = &v
 		changed = true
 	}
 
@@ -232,39 +491,142 @@ func cmdUpdate(c *apiclient.Client, args []string) error {
 		return fmt.Errorf("no update fields provided")
 	}
 
-	updated, err := c.UpdateTask(id, req)
+	var updated apiclient.Task
+	if *ifVersion > 0 {
+		updated, err = c.UpdateTaskIfVersion(ctx, id, *ifVersion, req)
+	} else {
+		updated, err = c.UpdateTask(ctx, id, req)
+	}
 	if err != nil {
-		return err
+		return conflictHint(err, id, *ifVersion)
 	}
-	fmt.Printf("updated task %d\n", updated.ID)
+	fmt.Printf("updated task %d (version %d)\n", updated.ID, updated.Version)
 	return nil
 }
 
-func cmdDelete(c *apiclient.Client, args []string) error {
-	if len(args) != 1 {
-		return fmt.Errorf("usage: client delete <id>")
+func cmdDelete(ctx context.Context, c *apiclient.Client, args []string) error {
+	if len(args) < 1 {
+		return fmt.Errorf("usage: client delete <id> [--if-version N]")
 	}
 	id, err := strconv.Atoi(args[0])
 	if err != nil || id <= 0 {
 		return fmt.Errorf("invalid id: %s", args[0])
 	}
-	if err := c.DeleteTask(id); err != nil {
+
+	fs := flag.NewFlagSet("delete", flag.ContinueOnError)
+	fs.SetOutput(ioDiscard{})
+	ifVersion := fs.Int("if-version", 0, "only delete if the task is still at this version")
+	if err := fs.Parse(args[1:]); err != nil {
 		return err
 	}
-	fmt.Printf("deleted task %d\n", id)
+
+	if *ifVersion > 0 {
+		err = c.DeleteTaskIfVersion(ctx, id, *ifVersion)
+	} else {
+		err = c.DeleteTask(ctx, id)
+	}
+	if err != nil {
+		return conflictHint(err, id, *ifVersion)
+	}
+	fmt.Printf("moved task %d to the trash (undo with \"client trash restore %d\")\n", id, id)
 	return nil
 }
 
+// conflictHint explains a stale --if-version; other errors pass through.
+func conflictHint(err error, id, version int) error {
+	if version > 0 && apiclient.IsConflict(err) {
+		return fmt.Errorf("task %d was changed by someone else since version %d; run \"client get %d\" and retry", id, version, id)
+	}
+	return err
+}
+
+func cmdRegister(ctx context.Context, c *apiclient.Client, args []string) error {
+	username, password, err := parseCredentials("register", args)
+	if err != nil {
+		return err
+	}
+
+	u, err := c.Register(ctx, username, password)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("registered user %d: %s\n", u.ID, u.Username)
+	return nil
+}
+
+func cmdLogin(ctx context.Context, c *apiclient.Client, args []string) error {
+	username, password, err := parseCredentials("login", args)
+	if err != nil {
+		return err
+	}
+
+	tok, err := c.Login(ctx, username, password)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("export TODO_TOKEN=%s\n", tok.AccessToken)
+	fmt.Fprintf(os.Stderr, "token expires at %s\n", tok.ExpiresAt.Format(time.RFC3339))
+	return nil
+}
+
+func parseCredentials(name string, args []string) (string, string, error) {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(ioDiscard{})
+
+	username := fs.String("username", "", "account username (required)")
+	password := fs.String("password", "", "account password (required)")
+	if err := fs.Parse(args); err != nil {
+		return "", "", err
+	}
+	if strings.TrimSpace(*username) == "" || *password == "" {
+		return "", "", fmt.Errorf("--username and --password are required")
+	}
+	return strings.TrimSpace(*username), *password, nil
+}
+
+// reminderArgs turns --remind values into API reminders: local times such
+// as "2026-01-09 18:00" become RFC 3339, anything else is sent as is.
+func reminderArgs(args []string) []string {
+	out := make([]string, 0, len(args))
+	for _, a := range args {
+		a = strings.TrimSpace(a)
+		for _, layout := range []string{"2006-01-02 15:04", "2006-01-02T15:04"} {
+			if tm, err := time.ParseInLocation(layout, a, time.Local); err == nil {
+				a = tm.Format(time.RFC3339)
+				break
+			}
+		}
+		out = append(out, a)
+	}
+	return out
+}
+
 func printTask(t apiclient.Task) {
 	fmt.Printf("ID: %d\n", t.ID)
 	fmt.Printf("Title: %s\n", t.Title)
 	fmt.Printf("Done: %v\n", t.IsDone)
+	fmt.Printf("Version: %d\n", t.Version)
+	if t.ParentID != nil {
+		fmt.Printf("Parent: %d\n", *t.ParentID)
+	}
 	if t.Category != nil && *t.Category != "" {
 		fmt.Printf("Category: %s\n", *t.Category)
 	}
 	if t.DueDate != nil {
 		fmt.Printf("Due: %s\n", t.DueDate.Format("2006-01-02"))
 	}
+	if t.Repeat != "" {
+		fmt.Printf("Repeat: %s\n", t.Repeat)
+	}
+	if t.Priority != "" && t.Priority != "none" {
+		fmt.Printf("Priority: %s\n", t.Priority)
+	}
+	if len(t.Tags) > 0 {
+		fmt.Printf("Tags: +%s\n", strings.Join(t.Tags, " +"))
+	}
+	if len(t.Reminders) > 0 {
+		fmt.Printf("Reminders: %s\n", strings.Join(t.Reminders, ", "))
+	}
 	fmt.Printf("CreatedAt: %s\n", t.CreatedAt.Format(time.RFC3339))
 	if t.UpdatedAt != nil {
 		fmt.Printf("UpdatedAt: %s\n", t.UpdatedAt.Format(time.RFC3339))
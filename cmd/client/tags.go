@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/Saintrad/todo-server-client/internal/apiclient"
+)
+
+// parseWithTags parses args like fs.Parse but also accepts "+tag" words
+// between and after the flags. It returns the tags without the "+".
+func parseWithTags(fs *flag.FlagSet, args []string) ([]string, error) {
+	var tags []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return tags, nil
+		}
+		tag, ok := strings.CutPrefix(args[0], "+")
+		if !ok || tag == "" {
+			return nil, fmt.Errorf("unexpected argument: %s", args[0])
+		}
+		tags = append(tags, tag)
+		args = args[1:]
+	}
+}
+
+// stringList is a repeatable string flag.
+type stringList []string
+
+func (l *stringList) String() string { return strings.Join(*l, ",") }
+
+func (l *stringList) Set(v string) error {
+	*l = append(*l, strings.TrimPrefix(strings.TrimSpace(v), "+"))
+	return nil
+}
+
+func cmdTags(ctx context.Context, c *apiclient.Client, args []string) error {
+	if len(args) == 0 {
+		tags, err := c.ListTags(ctx)
+		if err != nil {
+			return err
+		}
+		if len(tags) == 0 {
+			fmt.Println("(no tags)")
+		}
+		for _, t := range tags {
+			fmt.Printf("+%s (%d)\n", t.Tag, t.Count)
+		}
+		return nil
+	}
+
+	var (
+		change apiclient.TagChange
+		err    error
+	)
+	switch {
+	case args[0] == "rename" && len(args) == 3:
+		change, err = c.RenameTag(ctx, strings.TrimPrefix(args[1], "+"), strings.TrimPrefix(args[2], "+"))
+		if err == nil {
+			fmt.Printf("renamed %s to +%s on %d task(s)\n", args[1], change.Tag, change.Tasks)
+		}
+	case args[0] == "delete" && len(args) == 2:
+		change, err = c.DeleteTag(ctx, strings.TrimPrefix(args[1], "+"))
+		if err == nil {
+			fmt.Printf("removed +%s from %d task(s)\n", change.Tag, change.Tasks)
+		}
+	default:
+		return fmt.Errorf("usage: client tags [rename OLD NEW | delete TAG]")
+	}
+	return err
+}
+
+// splitTags splits a comma-separated tag flag, dropping empty entries.
+func splitTags(s string) []string {
+	var out []string
+	for _, tag := range strings.Split(s, ",") {
+		if tag = strings.TrimPrefix(strings.TrimSpace(tag), "+"); tag != "" {
+			out = append(out, tag)
+		}
+	}
+	return out
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Saintrad/todo-server-client/internal/apiclient"
+)
+
+// formatOfFile guesses the import/export format from a file name.
+func formatOfFile(name string) string {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".json":
+		return "json"
+	case ".csv":
+		return "csv"
+	case ".txt":
+		return "todotxt"
+	case ".ics":
+		return "ics"
+	default:
+		return ""
+	}
+}
+
+// cmdExport writes the tasks, optionally filtered like list, to stdout or
+// a file.
+func cmdExport(ctx context.Context, c *apiclient.Client, args []string) error {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	fs.SetOutput(ioDiscard{})
+
+	format := fs.String("format", "", "json, csv, todotxt or ics (default from --output, else json)")
+	output := fs.String("output", "", "file to write (default stdout)")
+	done := fs.Bool("done", false, "only done tasks")
+	undone := fs.Bool("undone", false, "only open tasks")
+	priority := fs.String("priority", "", "comma-separated priorities: none, low, medium, high")
+
+	tagsAll, err := parseWithTags(fs, args)
+	if err != nil {
+		return err
+	}
+	if *done && *undone {
+		return fmt.Errorf("use only one of --done or --undone")
+	}
+	if *format == "" {
+		*format = formatOfFile(*output)
+	}
+	if *format == "" {
+		*format = "json"
+	}
+
+	p := apiclient.ListTasksParams{TagsAll: tagsAll, Priorities: splitTags(*priority)}
+	if *done || *undone {
+		v := *done
+		p.IsDone = &v
+	}
+
+	data, err := c.ExportTasks(ctx, *format, p)
+	if err != nil {
+		return err
+	}
+	if *output == "" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	return os.WriteFile(*output, data, 0o644)
+}
+
+// cmdImport uploads a file (or stdin) of tasks and prints the failed rows;
+// a dry run prints what would happen to every row.
+func cmdImport(ctx context.Context, c *apiclient.Client, args []string) error {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	fs.SetOutput(ioDiscard{})
+
+	format := fs.String("format", "", "json, csv or todotxt (default from the file name)")
+	duplicates := fs.String("duplicates", "", "tasks with an existing title: skip (default), update or create")
+	dryRun := fs.Bool("dry-run", false, "only show what would be imported")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 1 {
+		return fmt.Errorf("usage: client import [--format json|csv|todotxt] [--duplicates skip|update|create] [--dry-run] [file]")
+	}
+
+	in := io.Reader(os.Stdin)
+	if name := fs.Arg(0); name != "" && name != "-" {
+		f, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+		if *format == "" {
+			*format = formatOfFile(name)
+		}
+	}
+	if *format == "" {
+		return fmt.Errorf("cannot tell the format; use --format json, csv or todotxt")
+	}
+
+	report, err := c.ImportTasks(ctx, in, apiclient.ImportOptions{Format: *format, Duplicates: *duplicates, DryRun: *dryRun})
+	if err != nil {
+		return err
+	}
+
+	for _, res := range report.Results {
+		switch {
+		case res.Error != nil:
+			fmt.Printf("row %d: failed: %v\n", res.Row, res.Error)
+		case report.DryRun && res.Task != nil:
+			fmt.Printf("row %d: %s %q\n", res.Row, res.Status, res.Task.Title)
+		}
+	}
+
+	summary := fmt.Sprintf("created %d, updated %d, skipped %d, failed %d", report.Created, report.Updated, report.Skipped, report.Failed)
+	if report.DryRun {
+		summary += " (dry run: nothing was written)"
+	}
+	fmt.Println(summary)
+	return nil
+}
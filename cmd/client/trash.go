@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/Saintrad/todo-server-client/internal/apiclient"
+)
+
+func cmdTrash(ctx context.Context, c *apiclient.Client, args []string) error {
+	if len(args) == 0 {
+		p := apiclient.ListTasksParams{Limit: 200}
+		for {
+			page, err := c.ListTrash(ctx, p)
+			if err != nil {
+				return err
+			}
+			if page.Total == 0 {
+				fmt.Println("(trash is empty)")
+			}
+			for _, t := range page.Items {
+				fmt.Printf("%s  deleted %s\n", taskLine(t), t.DeletedAt.Local().Format("2006-01-02 15:04"))
+			}
+			if len(page.Items) == 0 || page.Offset+len(page.Items) >= page.Total {
+				return nil
+			}
+			p.Offset = page.Offset + len(page.Items)
+		}
+	}
+
+	if args[0] == "empty" && len(args) == 1 {
+		n, err := c.EmptyTrash(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("purged %d task(s)\n", n)
+		return nil
+	}
+
+	if len(args) != 2 || (args[0] != "restore" && args[0] != "purge") {
+		return fmt.Errorf("usage: client trash [restore ID | purge ID | empty]")
+	}
+	id, err := strconv.Atoi(args[1])
+	if err != nil || id <= 0 {
+		return fmt.Errorf("invalid id: %s", args[1])
+	}
+
+	if args[0] == "restore" {
+		t, err := c.RestoreTask(ctx, id)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("restored task %d: %s\n", t.ID, t.Title)
+		return nil
+	}
+
+	if err := c.PurgeTask(ctx, id); err != nil {
+		return err
+	}
+	fmt.Printf("purged task %d\n", id)
+	return nil
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Saintrad/todo-server-client/internal/apiclient"
+)
+
+// taskLine is the one-line form of a task used by list and the trees.
+func taskLine(t apiclient.Task) string {
+	box := " "
+	if t.IsDone {
+		box = "x"
+	}
+	title := t.Title
+	if mark := priorityMarks[t.Priority]; mark != "" {
+		title = mark + " " + title
+	}
+	cat := ""
+	if t.Category != nil && *t.Category != "" {
+		cat = " (" + *t.Category + ")"
+	}
+	due := ""
+	if t.DueDate != nil {
+		due = " due:" + t.DueDate.Format("2006-01-02")
+	}
+	repeat := ""
+	if t.Repeat != "" {
+		repeat = " repeat:" + t.Repeat
+	}
+	tags := ""
+	for _, tag := range t.Tags {
+		tags += " +" + tag
+	}
+	return fmt.Sprintf("%d [%s] %s%s%s%s%s", t.ID, box, title, cat, due, repeat, tags)
+}
+
+// priorityMarks prefix the title in taskLine.
+var priorityMarks = map[string]string{"low": "!", "medium": "!!", "high": "!!!"}
+
+// printTree prints tasks as a forest. Tasks whose parent is not among tasks
+// are printed at the top level; order within a level is kept.
+func printTree(tasks []apiclient.Task) {
+	present := make(map[int]bool, len(tasks))
+	for _, t := range tasks {
+		present[t.ID] = true
+	}
+
+	kids := childrenByParent(tasks)
+	for _, t := range tasks {
+		if t.ParentID == nil || !present[*t.ParentID] {
+			fmt.Println(taskLine(t))
+			printBranches(kids, t.ID, "")
+		}
+	}
+}
+
+func childrenByParent(tasks []apiclient.Task) map[int][]apiclient.Task {
+	kids := make(map[int][]apiclient.Task)
+	for _, t := range tasks {
+		if t.ParentID != nil {
+			kids[*t.ParentID] = append(kids[*t.ParentID], t)
+		}
+	}
+	return kids
+}
+
+// printBranches prints the subtasks of id below it, drawing the branches.
+func printBranches(kids map[int][]apiclient.Task, id int, indent string) {
+	children := kids[id]
+	for i, t := range children {
+		branch, next := "├─ ", "│  "
+		if i == len(children)-1 {
+			branch, next = "└─ ", "   "
+		}
+		fmt.Println(indent + branch + taskLine(t))
+		printBranches(kids, t.ID, indent+next)
+	}
+}
+
+// fetchSubtree returns every task below id, oldest first within a level.
+func fetchSubtree(ctx context.Context, c *apiclient.Client, id int) ([]apiclient.Task, error) {
+	var out []apiclient.Task
+	for next := 0; ; next++ {
+		p := apiclient.ListTasksParams{Sort: "created_at", Order: "asc", Limit: 200}
+		for {
+			page, err := c.ListChildren(ctx, id, p)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, page.Items...)
+			if len(page.Items) == 0 || page.Offset+len(page.Items) >= page.Total {
+				break
+			}
+			p.Offset = page.Offset + len(page.Items)
+		}
+
+		if next == len(out) {
+			return out, nil
+		}
+		id = out[next].ID
+	}
+}
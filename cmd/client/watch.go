@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Saintrad/todo-server-client/internal/apiclient"
+)
+
+// cmdWatch prints task changes as they happen until interrupted,
+// reconnecting where it left off when the stream drops.
+func cmdWatch(ctx context.Context, c *apiclient.Client, args []string) error {
+	if len(args) != 0 {
+		return fmt.Errorf("usage: client watch")
+	}
+
+	lastID := ""
+	backoff := time.Second
+	for {
+		stream, err := c.Subscribe(ctx, lastID)
+		if err == nil {
+			backoff = time.Second
+			err = printStream(stream)
+			lastID = stream.LastEventID()
+			stream.Close()
+		}
+		if ctx.Err() != nil {
+			return nil
+		}
+		var apiErr *apiclient.APIError
+		if errors.As(err, &apiErr) {
+			return err
+		}
+
+		fmt.Fprintf(os.Stderr, "stream lost (%v), reconnecting in %s\n", err, backoff)
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(backoff):
+		}
+		backoff = min(2*backoff, 30*time.Second)
+	}
+}
+
+// printStream prints events until the stream ends.
+func printStream(stream *apiclient.Stream) error {
+	for {
+		ev, err := stream.Next()
+		if err != nil {
+			return err
+		}
+		if ev.Kind == "reset" {
+			fmt.Println("(some changes were missed while disconnected)")
+			continue
+		}
+
+		by := ""
+		if ev.Actor != "" {
+			by = " by " + ev.Actor
+		}
+		fmt.Printf("%s  %-9s %s%s\n", ev.At.Local().Format("15:04:05"), ev.Kind, taskLine(ev.Task), by)
+		if ev.Kind == "updated" {
+			var changes []string
+			for _, ch := range ev.Changes {
+				changes = append(changes, changeLine(ch))
+			}
+			fmt.Printf("          %s\n", strings.Join(changes, ", "))
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Saintrad/todo-server-client/internal/apiclient"
+)
+
+func cmdWebhooks(ctx context.Context, c *apiclient.Client, args []string) error {
+	const usage = "usage: client webhooks [add URL [--events created,completed] [--secret S] | delete ID | deliveries ID]"
+
+	if len(args) == 0 {
+		hooks, err := c.ListWebhooks(ctx)
+		if err != nil {
+			return err
+		}
+		if len(hooks) == 0 {
+			fmt.Println("(no webhooks)")
+		}
+		for _, h := range hooks {
+			events := "all events"
+			if len(h.Events) > 0 {
+				events = strings.Join(h.Events, ",")
+			}
+			fmt.Printf("%d %s (%s)\n", h.ID, h.URL, events)
+		}
+		return nil
+	}
+
+	switch args[0] {
+	case "add":
+		if len(args) < 2 {
+			return fmt.Errorf(usage)
+		}
+		fs := flag.NewFlagSet("webhooks add", flag.ContinueOnError)
+		fs.SetOutput(ioDiscard{})
+		events := fs.String("events", "", "comma-separated event kinds (default all)")
+		secret := fs.String("secret", "", "signing secret (default generated)")
+		if err := fs.Parse(args[2:]); err != nil {
+			return err
+		}
+		if fs.NArg() > 0 {
+			return fmt.Errorf("unexpected argument: %s", fs.Arg(0))
+		}
+
+		var kinds []string
+		for _, k := range strings.Split(*events, ",") {
+			if k = strings.TrimSpace(k); k != "" {
+				kinds = append(kinds, k)
+			}
+		}
+		h, err := c.CreateWebhook(ctx, apiclient.CreateWebhookRequest{URL: args[1], Events: kinds, Secret: *secret})
+		if err != nil {
+			return err
+		}
+		fmt.Printf("created webhook %d\nsecret: %s\n", h.ID, h.Secret)
+		return nil
+
+	case "delete", "deliveries":
+		if len(args) != 2 {
+			return fmt.Errorf(usage)
+		}
+		id, err := strconv.Atoi(args[1])
+		if err != nil || id <= 0 {
+			return fmt.Errorf("invalid id: %s", args[1])
+		}
+
+		if args[0] == "delete" {
+			if err := c.DeleteWebhook(ctx, id); err != nil {
+				return err
+			}
+			fmt.Printf("deleted webhook %d\n", id)
+			return nil
+		}
+
+		log, err := c.ListDeliveries(ctx, id)
+		if err != nil {
+			return err
+		}
+		if len(log) == 0 {
+			fmt.Println("(no deliveries)")
+		}
+		for _, d := range log {
+			result := strconv.Itoa(d.StatusCode)
+			if d.Error != "" {
+				result = d.Error
+			}
+			fmt.Printf("%s  %-9s task %d  attempt %d  %s (%dms)\n",
+				d.At.Local().Format("2006-01-02 15:04:05"), d.Event, d.TaskID, d.Attempt, result, d.DurationMS)
+		}
+		return nil
+
+	default:
+		return fmt.Errorf(usage)
+	}
+}
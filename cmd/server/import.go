@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"path/filepath"
+
+	"github.com/Saintrad/todo-server-client/internal/storage"
+)
+
+// runImport implements "server import": a one-shot copy of the JSON file
+// storage into a new SQLite database.
+func runImport(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+
+	dataDir := fs.String("data-dir", "data", "directory holding tasks.JSON and users.json")
+	from := fs.String("from", "", "tasks JSON file (default <data-dir>/tasks.JSON)")
+	users := fs.String("users", "", "users JSON file (default <data-dir>/users.json)")
+	to := fs.String("to", "", "SQLite database to create (default <data-dir>/tasks.db)")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *from == "" {
+		*from = filepath.Join(*dataDir, "tasks.JSON")
+	}
+	if *users == "" {
+		*users = filepath.Join(*dataDir, "users.json")
+	}
+	if *to == "" {
+		*to = filepath.Join(*dataDir, "tasks.db")
+	}
+
+	db, err := storage.OpenSQLite(*to)
+	if err != nil {
+		return err
+	}
+	taskRepo := storage.NewSQLiteTaskRepo(db)
+	defer taskRepo.Close()
+
+	nTasks, err := storage.ImportTaskFile(ctx, *from, taskRepo)
+	if err != nil {
+		return err
+	}
+	nUsers, err := storage.ImportUserFile(ctx, *users, storage.NewSQLiteUserRepo(db))
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("imported %d tasks and %d users into %s\n", nTasks, nUsers, *to)
+	fmt.Println("start the server with -storage sqlite to use it")
+	return nil
+}
@@ -1,24 +1,272 @@
 package main
 
 import (
-	"log"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
 	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+	// Time zone names in the tz query parameter work without a system
+	// zone database
+	_ "time/tzdata"
 
+	"github.com/Saintrad/todo-server-client/internal/auth"
+	"github.com/Saintrad/todo-server-client/internal/config"
+	"github.com/Saintrad/todo-server-client/internal/events"
 	"github.com/Saintrad/todo-server-client/internal/httpapi"
+	"github.com/Saintrad/todo-server-client/internal/reminder"
 	"github.com/Saintrad/todo-server-client/internal/storage"
 	"github.com/Saintrad/todo-server-client/internal/todo"
+	"github.com/Saintrad/todo-server-client/internal/webhook"
 )
 
-
 func main() {
-	repo, err := storage.NewFileTaskRepo("data/tasks.JSON")
+	if len(os.Args) > 1 && os.Args[1] == "import" {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		err := runImport(ctx, os.Args[2:])
+		stop()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "import:", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	cfg, err := config.Load(os.Args[1:], os.Getenv)
+	if errors.Is(err, flag.ErrHelp) {
+		config.Usage(os.Stderr)
+		os.Exit(0)
+	}
 	if err != nil {
-		log.Fatal(err)
+		fmt.Fprintln(os.Stderr, "config error:", err)
+		config.Usage(os.Stderr)
+		os.Exit(2)
 	}
 
-	svc := todo.NewService(repo)
-	api := httpapi.NewServer(svc)
+	level, _ := cfg.SlogLevel() // validated by Load
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: level}))
+	slog.SetDefault(logger)
+
+	logger.Info("effective configuration", "config", cfg)
 
-	log.Println("listening on :8080")
-	log.Fatal(http.ListenAndServe(":8080", api.Routes()))
-}
\ No newline at end of file
+	if err := run(cfg, logger); err != nil {
+		logger.Error("server failed", "err", err)
+		os.Exit(1)
+	}
+	logger.Info("shutdown complete")
+}
+
+// run serves until SIGINT/SIGTERM, then drains in-flight requests within
+// cfg.ShutdownTimeout and closes storage.
+func run(cfg config.Config, logger *slog.Logger) (err error) {
+	st, err := openStorage(cfg)
+	if err != nil {
+		return fmt.Errorf("open storage: %w", err)
+	}
+	defer func() {
+		// The task repo goes last: with SQLite it owns the shared database.
+		err = errors.Join(err, st.reminders.Close(), st.webhooks.Close(), st.history.Close(), st.users.Close(), st.tasks.Close())
+	}()
+
+	// Without a configured secret, v2 tokens are invalidated on restart.
+	authSvc, err := auth.NewService(st.users, []byte(cfg.AuthSecret))
+	if err != nil {
+		return fmt.Errorf("init auth: %w", err)
+	}
+
+	broker := events.NewBroker(events.DefaultBufferSize)
+	dispatcher := webhook.NewDispatcher(st.webhooks, httpapi.EncodeEvent,
+		webhook.WithWorkers(cfg.WebhookWorkers), webhook.WithLogger(logger))
+	defer dispatcher.Close()
+
+	svc := todo.NewService(st.tasks,
+		todo.WithHistory(st.history),
+		todo.WithPublisher(broker),
+		todo.WithPublisher(dispatcher),
+		todo.WithCompletePolicy(todo.CompletePolicy(cfg.SubtaskComplete)),
+		todo.WithDeletePolicy(todo.DeletePolicy(cfg.SubtaskDelete)),
+	)
+	api := httpapi.NewServer(svc, authSvc,
+		httpapi.WithEvents(broker),
+		httpapi.WithWebhooks(webhook.NewService(st.webhooks)),
+	)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Background jobs write to the repos and publish to the dispatcher;
+	// stop them and wait before either is closed.
+	bgCtx, cancelBg := context.WithCancel(ctx)
+	var bg sync.WaitGroup
+	defer func() {
+		cancelBg()
+		bg.Wait()
+	}()
+
+	if cfg.TrashRetentionDays > 0 {
+		bg.Add(1)
+		go func() {
+			defer bg.Done()
+			purgeTrash(bgCtx, svc, cfg.TrashRetentionDays, logger)
+		}()
+	}
+	if sinks := reminderSinks(cfg, logger, broker, dispatcher); len(sinks) > 0 {
+		opts := append(sinks, reminder.WithInterval(time.Duration(cfg.ReminderInterval)), reminder.WithLogger(logger))
+		scheduler := reminder.NewScheduler(svc, st.reminders, opts...)
+		bg.Add(1)
+		go func() {
+			defer bg.Done()
+			runReminders(bgCtx, scheduler, logger)
+		}()
+	}
+
+	srv := &http.Server{
+		Addr:         cfg.Addr,
+		Handler:      httpapi.LogRequests(api.Routes(), logger),
+		ReadTimeout:  time.Duration(cfg.ReadTimeout),
+		WriteTimeout: time.Duration(cfg.WriteTimeout),
+		IdleTimeout:  time.Duration(cfg.IdleTimeout),
+	}
+	// Event streams never go idle; end them so Shutdown can drain.
+	srv.RegisterOnShutdown(broker.Close)
+
+	serveErr := make(chan error, 1)
+	go func() {
+		logger.Info("listening", "addr", cfg.Addr)
+		serveErr <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serveErr:
+		return err
+	case <-ctx.Done():
+	}
+	stop() // a second signal kills the process immediately
+
+	logger.Info("shutting down", "timeout", time.Duration(cfg.ShutdownTimeout))
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.ShutdownTimeout))
+	defer cancel()
+
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("drain requests: %w", err)
+	}
+	return nil
+}
+
+// purgeTrash deletes tasks that have been in the trash for more than days,
+// at startup and then hourly, until ctx is done.
+func purgeTrash(ctx context.Context, svc todo.Service, days int, logger *slog.Logger) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	for {
+		n, err := svc.PurgeExpired(ctx, time.Now().AddDate(0, 0, -days))
+		switch {
+		case err != nil && ctx.Err() == nil:
+			logger.Error("purge trash", "err", err)
+		case n > 0:
+			logger.Info("purged trash", "tasks", n)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// reminderSinks returns a WithSink option per configured reminder sink.
+func reminderSinks(cfg config.Config, logger *slog.Logger, broker *events.Broker, dispatcher *webhook.Dispatcher) []reminder.Option {
+	var opts []reminder.Option
+	for _, name := range cfg.ReminderSinkList() {
+		var sink reminder.Sink
+		switch name {
+		case config.ReminderSinkLog:
+			sink = reminder.LogSink(logger)
+		case config.ReminderSinkEvents:
+			sink = reminder.PublisherSink(broker)
+		case config.ReminderSinkWebhooks:
+			sink = reminder.PublisherSink(dispatcher)
+		case config.ReminderSinkCommand:
+			sink = reminder.CommandSink(cfg.ReminderCommand)
+		}
+		opts = append(opts, reminder.WithSink(sink))
+	}
+	return opts
+}
+
+// runReminders runs the reminder scheduler until ctx is done.
+func runReminders(ctx context.Context, s *reminder.Scheduler, logger *slog.Logger) {
+	if err := s.Run(ctx); err != nil && ctx.Err() == nil {
+		logger.Error("reminder scheduler stopped", "err", err)
+	}
+}
+
+type stores struct {
+	tasks     todo.TaskRepo
+	users     auth.UserRepo
+	history   todo.HistoryRepo
+	webhooks  webhook.Repo
+	reminders reminder.Repo
+}
+
+// openStorage creates the repos for the configured backend.
+func openStorage(cfg config.Config) (stores, error) {
+	switch cfg.Storage {
+	case config.StorageMemory:
+		return stores{
+			storage.NewMemoryTaskRepo(), storage.NewMemoryUserRepo(),
+			storage.NewMemoryHistoryRepo(), storage.NewMemoryWebhookRepo(),
+			storage.NewMemoryReminderRepo(),
+		}, nil
+
+	case config.StorageFile:
+		var opts []storage.FileOption
+		if cfg.FileJournal {
+			opts = append(opts, storage.WithJournal(storage.DefaultCompactEvery))
+		}
+		repo, err := storage.NewFileTaskRepo(filepath.Join(cfg.DataDir, "tasks.JSON"), opts...)
+		if err != nil {
+			return stores{}, err
+		}
+		users, err := storage.NewFileUserRepo(filepath.Join(cfg.DataDir, "users.json"))
+		if err != nil {
+			return stores{}, errors.Join(err, repo.Close())
+		}
+		history, err := storage.NewFileHistoryRepo(filepath.Join(cfg.DataDir, "history.jsonl"))
+		if err != nil {
+			return stores{}, errors.Join(err, repo.Close(), users.Close())
+		}
+		webhooks, err := storage.NewFileWebhookRepo(filepath.Join(cfg.DataDir, "webhooks.json"))
+		if err != nil {
+			return stores{}, errors.Join(err, repo.Close(), users.Close(), history.Close())
+		}
+		reminders, err := storage.NewFileReminderRepo(filepath.Join(cfg.DataDir, "reminders.json"))
+		if err != nil {
+			return stores{}, errors.Join(err, repo.Close(), users.Close(), history.Close(), webhooks.Close())
+		}
+		return stores{repo, users, history, webhooks, reminders}, nil
+
+	case config.StorageSQLite:
+		db, err := storage.OpenSQLite(filepath.Join(cfg.DataDir, "tasks.db"))
+		if err != nil {
+			return stores{}, err
+		}
+		return stores{
+			storage.NewSQLiteTaskRepo(db), storage.NewSQLiteUserRepo(db),
+			storage.NewSQLiteHistoryRepo(db), storage.NewSQLiteWebhookRepo(db),
+			storage.NewSQLiteReminderRepo(db),
+		}, nil
+
+	default:
+		return stores{}, fmt.Errorf("unknown storage backend %q", cfg.Storage)
+	}
+}
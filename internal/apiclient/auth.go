@@ -0,0 +1,21 @@
+package apiclient
+
+import (
+	"context"
+	"net/http"
+)
+
+// Register creates a v2 user account.
+func (c *Client) Register(ctx context.Context, username, password string) (User, error) {
+	var out User
+	_, err := c.do(ctx, http.MethodPost, "/v2/auth/register", Credentials{Username: username, Password: password}, &out)
+	return out, err
+}
+
+// Login exchanges credentials for a bearer token. Pass the token to SetToken
+// to use it.
+func (c *Client) Login(ctx context.Context, username, password string) (Token, error) {
+	var out Token
+	_, err := c.do(ctx, http.MethodPost, "/v2/auth/login", Credentials{Username: username, Password: password}, &out)
+	return out, err
+}
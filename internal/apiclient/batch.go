@@ -0,0 +1,64 @@
+package apiclient
+
+import (
+	"context"
+	"net/http"
+)
+
+// BatchOp is one operation of a batch request. Build it with CreateOp,
+// UpdateOp or DeleteOp; set IfVersion to guard an update or delete.
+type BatchOp struct {
+	Op        string `json:"op"`
+	ID        int    `json:"id,omitempty"`
+	IfVersion int    `json:"if_version,omitempty"`
+	// Task is a CreateTaskRequest or UpdateTaskRequest (or its JSON form).
+	Task any `json:"task,omitempty"`
+}
+
+func CreateOp(req CreateTaskRequest) BatchOp {
+	return BatchOp{Op: "create", Task: req}
+}
+
+func UpdateOp(id int, req UpdateTaskRequest) BatchOp {
+	return BatchOp{Op: "update", ID: id, Task: req}
+}
+
+func DeleteOp(id int) BatchOp {
+	return BatchOp{Op: "delete", ID: id}
+}
+
+// BatchResult is the outcome of one operation. Status is the HTTP status
+// the operation would have had on its own.
+type BatchResult struct {
+	Status int       `json:"status"`
+	Task   *Task     `json:"task,omitempty"`
+	Error  *APIError `json:"error,omitempty"`
+}
+
+// Err returns the operation's error, or nil if it succeeded.
+func (r BatchResult) Err() error {
+	if r.Error == nil {
+		return nil
+	}
+	r.Error.StatusCode = r.Status
+	return r.Error
+}
+
+type batchRequest struct {
+	Atomic     bool      `json:"atomic"`
+	Operations []BatchOp `json:"operations"`
+}
+
+type batchResponse struct {
+	Results []BatchResult `json:"results"`
+}
+
+// Batch sends ops in one request, which the server applies in one storage
+// commit. With atomic set, either all operations are applied or Batch
+// returns the failing operation's error and nothing changes. Otherwise check
+// each result's Err.
+func (c *Client) Batch(ctx context.Context, ops []BatchOp, atomic bool) ([]BatchResult, error) {
+	var out batchResponse
+	_, err := c.do(ctx, http.MethodPost, c.tasksPath()+":batch", batchRequest{Atomic: atomic, Operations: ops}, &out)
+	return out.Results, err
+}
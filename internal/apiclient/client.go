@@ -2,7 +2,9 @@ package apiclient
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -12,6 +14,7 @@ import (
 
 type Client struct {
 	baseURL string
+	token   string
 	http    *http.Client
 }
 
@@ -24,11 +27,82 @@ func New(baseURL string) *Client {
 	}
 }
 
+// SetToken makes the client authenticate with a v2 bearer token. Task calls
+// then go to the per-user /v2 routes instead of /v1.
+func (c *Client) SetToken(token string) {
+	c.token = token
+}
+
+// tasksPath returns the tasks collection path for the API version in use.
+func (c *Client) tasksPath() string {
+	if c.token != "" {
+		return "/v2/tasks"
+	}
+	return "/v1/tasks"
+}
+
+// Error codes returned by the server (API.md §3.1).
+const (
+	CodeValidation   = "VALIDATION_ERROR"
+	CodeNotFound     = "NOT_FOUND"
+	CodeUnauthorized = "UNAUTHORIZED"
+	CodeForbidden    = "FORBIDDEN"
+	CodeConflict     = "CONFLICT"
+	CodeInternal     = "INTERNAL"
+)
+
+// APIError is returned for every non-2xx response. Inspect it with errors.As
+// and branch on Code rather than on Message.
 type APIError struct {
-	Error string `json:"error"`
+	StatusCode int           `json:"-"`
+	Code       string        `json:"code"`
+	Message    string        `json:"message"`
+	Details    []ErrorDetail `json:"details,omitempty"`
+}
+
+type ErrorDetail struct {
+	Field string `json:"field"`
+	Issue string `json:"issue"`
+}
+
+func (e *APIError) Error() string {
+	msg := e.Message
+	if e.Code != "" {
+		msg = e.Code + ": " + msg
+	}
+	// The server takes a validation message from the first issue
+	var issues []string
+	for _, d := range e.Details {
+		if issue := d.Field + " " + d.Issue; issue != e.Message {
+			issues = append(issues, issue)
+		}
+	}
+	if len(issues) > 0 {
+		msg += " (" + strings.Join(issues, "; ") + ")"
+	}
+	return msg
+}
+
+// IsConflict reports whether err is a CONFLICT error, including a stale
+// version rejected by UpdateTaskIfVersion or DeleteTaskIfVersion.
+func IsConflict(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.Code == CodeConflict
+}
+
+// errorEnvelope is the wire shape of an error response.
+type errorEnvelope struct {
+	Error *APIError `json:"error"`
+}
+
+// do sends one request. ctx bounds the whole call, including reading the
+// response body.
+func (c *Client) do(ctx context.Context, method, path string, reqBody any, respBody any) (int, error) {
+	return c.doWithHeader(ctx, method, path, nil, reqBody, respBody)
 }
 
-func (c *Client) do(method, path string, reqBody any, respBody any) (int, error) {
+// doWithHeader is do with extra request headers.
+func (c *Client) doWithHeader(ctx context.Context, method, path string, header http.Header, reqBody any, respBody any) (int, error) {
 	var body io.Reader
 	if reqBody != nil {
 		b, err := json.Marshal(reqBody)
@@ -38,13 +112,19 @@ func (c *Client) do(method, path string, reqBody any, respBody any) (int, error)
 		body = bytes.NewReader(b)
 	}
 
-	req, err := http.NewRequest(method, c.baseURL+path, body)
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
 	if err != nil {
 		return 0, err
 	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
 	if reqBody != nil {
 		req.Header.Set("Content-Type", "application/json")
 	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
 
 	resp, err := c.http.Do(req)
 	if err != nil {
@@ -52,14 +132,8 @@ func (c *Client) do(method, path string, reqBody any, respBody any) (int, error)
 	}
 	defer resp.Body.Close()
 
-	// Non-2xx: try to decode JSON error
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		raw, _ := io.ReadAll(resp.Body)
-		var apiErr APIError
-		if json.Unmarshal(raw, &apiErr) == nil && apiErr.Error != "" {
-			return resp.StatusCode, fmt.Errorf(apiErr.Error)
-		}
-		return resp.StatusCode, fmt.Errorf("http %d: %s", resp.StatusCode, strings.TrimSpace(string(raw)))
+		return resp.StatusCode, responseError(resp)
 	}
 
 	if respBody != nil {
@@ -67,3 +141,18 @@ func (c *Client) do(method, path string, reqBody any, respBody any) (int, error)
 	}
 	return resp.StatusCode, nil
 }
+
+// responseError reads the error of a non-2xx response: the JSON error body,
+// or the raw body if there is none.
+func responseError(resp *http.Response) error {
+	raw, _ := io.ReadAll(resp.Body)
+	var env errorEnvelope
+	if json.Unmarshal(raw, &env) == nil && env.Error != nil && env.Error.Code != "" {
+		env.Error.StatusCode = resp.StatusCode
+		return env.Error
+	}
+	return &APIError{
+		StatusCode: resp.StatusCode,
+		Message:    fmt.Sprintf("http %d: %s", resp.StatusCode, strings.TrimSpace(string(raw))),
+	}
+}
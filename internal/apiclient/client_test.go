@@ -0,0 +1,352 @@
+package apiclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAPIErrorDecoding(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error":{"code":"VALIDATION_ERROR","message":"title is required","details":[{"field":"title","issue":"must not be empty"}]}}`))
+	}))
+	defer ts.Close()
+
+	_, err := New(ts.URL).CreateTask(t.Context(), CreateTaskRequest{})
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("expected *APIError, got %T", err)
+	}
+	if apiErr.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected status 400, got %d", apiErr.StatusCode)
+	}
+	if apiErr.Code != CodeValidation {
+		t.Fatalf("expected code %s, got %s", CodeValidation, apiErr.Code)
+	}
+	if len(apiErr.Details) != 1 || apiErr.Details[0].Field != "title" {
+		t.Fatalf("expected one title detail, got %v", apiErr.Details)
+	}
+}
+
+func TestAPIErrorStringDoesNotRepeatMessage(t *testing.T) {
+	err := &APIError{Code: CodeValidation, Message: "title must not be empty", Details: []ErrorDetail{
+		{Field: "title", Issue: "must not be empty"},
+		{Field: "due_date", Issue: "must be a date"},
+	}}
+	if want := "VALIDATION_ERROR: title must not be empty (due_date must be a date)"; err.Error() != want {
+		t.Fatalf("expected %q, got %q", want, err.Error())
+	}
+}
+
+func TestAPIErrorNonJSONBody(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}))
+	defer ts.Close()
+
+	err := New(ts.URL).DeleteTask(t.Context(), 1)
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("expected *APIError, got %T", err)
+	}
+	if apiErr.StatusCode != http.StatusMethodNotAllowed {
+		t.Fatalf("expected status 405, got %d", apiErr.StatusCode)
+	}
+	if apiErr.Code != "" {
+		t.Fatalf("expected empty code, got %s", apiErr.Code)
+	}
+}
+
+func TestContextCancelsRequest(t *testing.T) {
+	release := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer ts.Close()
+	defer close(release)
+
+	ctx, cancel := context.WithTimeout(t.Context(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := New(ts.URL).GetTask(ctx, 1)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected %v, got %v", context.DeadlineExceeded, err)
+	}
+}
+
+func TestUpdateTaskIfVersion(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("If-Match"); got != `"3"` {
+			t.Errorf(`expected If-Match "3", got %s`, got)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusPreconditionFailed)
+		w.Write([]byte(`{"error":{"code":"CONFLICT","message":"task was modified concurrently"}}`))
+	}))
+	defer ts.Close()
+
+	_, err := New(ts.URL).UpdateTaskIfVersion(t.Context(), 1, 3, UpdateTaskRequest{})
+	if !IsConflict(err) {
+		t.Fatalf("expected conflict, got %v", err)
+	}
+
+	var apiErr *APIError
+	if errors.As(err, &apiErr); apiErr.StatusCode != http.StatusPreconditionFailed {
+		t.Fatalf("expected status 412, got %d", apiErr.StatusCode)
+	}
+}
+
+func TestBatch(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/tasks:batch" {
+			t.Errorf("expected /v1/tasks:batch, got %s", r.URL.Path)
+		}
+		body, _ := io.ReadAll(r.Body)
+		want := `{"atomic":false,"operations":[{"op":"create","task":{"title":"a"}},{"op":"update","id":2,"if_version":3,"task":{"category":null}},{"op":"delete","id":4}]}`
+		if string(body) != want {
+			t.Errorf("expected body %s, got %s", want, body)
+		}
+		w.Write([]byte(`{"results":[{"status":201,"task":{"id":5,"title":"a"}},{"status":412,"error":{"code":"CONFLICT","message":"stale"}},{"status":204}]}`))
+	}))
+	defer ts.Close()
+
+	update := UpdateOp(2, UpdateTaskRequest{Category: Null[string]()})
+	update.IfVersion = 3
+
+	results, err := New(ts.URL).Batch(t.Context(), []BatchOp{
+		CreateOp(CreateTaskRequest{Title: "a"}),
+		update,
+		DeleteOp(4),
+	}, false)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if results[0].Err() != nil || results[0].Task.ID != 5 {
+		t.Fatalf("unexpected create result %+v", results[0])
+	}
+	if !IsConflict(results[1].Err()) {
+		t.Fatalf("expected conflict, got %v", results[1].Err())
+	}
+	if results[2].Err() != nil || results[2].Status != http.StatusNoContent {
+		t.Fatalf("unexpected delete result %+v", results[2])
+	}
+}
+
+func TestListChildren(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.String(); got != "/v1/tasks/4/children?is_done=false" {
+			t.Errorf("expected children URL, got %s", got)
+		}
+		w.Write([]byte(`{"items":[{"id":5,"title":"step","parent_id":4}],"total":1}`))
+	}))
+	defer ts.Close()
+
+	undone := false
+	page, err := New(ts.URL).ListChildren(t.Context(), 4, ListTasksParams{IsDone: &undone})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if page.Total != 1 || page.Items[0].ParentID == nil || *page.Items[0].ParentID != 4 {
+		t.Fatalf("unexpected page %+v", page)
+	}
+
+	// Check roots-only listing
+	if got := (ListTasksParams{Parent: Null[int]()}).encode(); got != "?parent_id=null" {
+		t.Fatalf("expected ?parent_id=null, got %s", got)
+	}
+}
+
+func TestTrash(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/v1/trash/3/restore" {
+			t.Errorf("expected POST /v1/trash/3/restore, got %s %s", r.Method, r.URL.Path)
+		}
+		w.Write([]byte(`{"id":3,"title":"back"}`))
+	}))
+	defer ts.Close()
+
+	task, err := New(ts.URL).RestoreTask(t.Context(), 3)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if task.ID != 3 || task.DeletedAt != nil {
+		t.Fatalf("unexpected task %+v", task)
+	}
+
+	// Check v2 clients use the per-user trash
+	c := New(ts.URL)
+	c.SetToken("t")
+	if got := c.trashPath(); got != "/v2/trash" {
+		t.Fatalf("expected /v2/trash, got %s", got)
+	}
+}
+
+func TestHistory(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/tasks/5/history" {
+			t.Errorf("expected /v1/tasks/5/history, got %s", r.URL.Path)
+		}
+		w.Write([]byte(`{"items":[{"id":1,"task_id":5,"kind":"updated","actor":"alice","at":"2026-03-01T10:00:00Z",` +
+			`"changes":[{"field":"title","old":"a","new":"b"},{"field":"category","old":"home"}]}]}`))
+	}))
+	defer ts.Close()
+
+	events, err := New(ts.URL).History(t.Context(), 5)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(events) != 1 || events[0].Actor != "alice" || len(events[0].Changes) != 2 {
+		t.Fatalf("unexpected events %+v", events)
+	}
+	if c := events[0].Changes[1]; *c.Old != "home" || c.New != nil {
+		t.Fatalf("expected category cleared, got %+v", c)
+	}
+}
+
+func TestSubscribe(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/events" || r.Header.Get("Last-Event-ID") != "6" {
+			t.Errorf("expected /v1/events after 6, got %s after %q", r.URL.Path, r.Header.Get("Last-Event-ID"))
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write([]byte("event: reset\ndata: {}\n\n: keep-alive\n\n" +
+			"id: 7\nevent: updated\ndata: {\"kind\":\"updated\",\"task_id\":3,\n" +
+			"data: \"task\":{\"id\":3,\"title\":\"b\"}}\n\n"))
+	}))
+	defer ts.Close()
+
+	stream, err := New(ts.URL).Subscribe(t.Context(), "6")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	defer stream.Close()
+
+	// Check keep-alives are skipped and multi-line data is joined
+	if ev, err := stream.Next(); err != nil || ev.Kind != "reset" {
+		t.Fatalf("expected reset, got %+v, %v", ev, err)
+	}
+	ev, err := stream.Next()
+	if err != nil || ev.ID != "7" || ev.Kind != "updated" || ev.Task.Title != "b" {
+		t.Fatalf("unexpected event %+v, %v", ev, err)
+	}
+	if stream.LastEventID() != "7" {
+		t.Fatalf("expected last ID 7, got %s", stream.LastEventID())
+	}
+	if _, err := stream.Next(); err != io.EOF {
+		t.Fatalf("expected EOF, got %v", err)
+	}
+}
+
+func TestUpdateTaskRequestEncoding(t *testing.T) {
+	high := "high"
+	cases := []struct {
+		req  UpdateTaskRequest
+		want string
+	}{
+		{UpdateTaskRequest{}, `{}`},
+		{UpdateTaskRequest{Category: Null[string]()}, `{"category":null}`},
+		{UpdateTaskRequest{Category: Value("work")}, `{"category":"work"}`},
+		{UpdateTaskRequest{DueDate: Null[time.Time]()}, `{"due_date":null}`},
+		{UpdateTaskRequest{ParentID: Null[int]()}, `{"parent_id":null}`},
+		{UpdateTaskRequest{Tags: Null[[]string]()}, `{"tags":null}`},
+		{UpdateTaskRequest{AddTags: []string{"home"}}, `{"add_tags":["home"]}`},
+		{UpdateTaskRequest{Priority: &high}, `{"priority":"high"}`},
+		{UpdateTaskRequest{Reminders: Value([]string{"due-1h"})}, `{"reminders":["due-1h"]}`},
+		{UpdateTaskRequest{Reminders: Null[[]string]()}, `{"reminders":null}`},
+	}
+
+	for _, tc := range cases {
+		b, err := json.Marshal(tc.req)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if string(b) != tc.want {
+			t.Fatalf("expected %s, got %s", tc.want, b)
+		}
+	}
+}
+
+func TestTags(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPatch || r.URL.Path != "/v1/tags/work" {
+			t.Errorf("expected PATCH /v1/tags/work, got %s %s", r.Method, r.URL.Path)
+		}
+		body, _ := io.ReadAll(r.Body)
+		if string(body) != `{"name":"job"}` {
+			t.Errorf("expected rename body, got %s", body)
+		}
+		w.Write([]byte(`{"tag":"job","tasks":2}`))
+	}))
+	defer ts.Close()
+
+	change, err := New(ts.URL).RenameTag(t.Context(), "work", "job")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if change != (TagChange{Tag: "job", Tasks: 2}) {
+		t.Fatalf("unexpected result %+v", change)
+	}
+
+	// Check tag filters are comma-separated
+	p := ListTasksParams{TagsAll: []string{"work", "urgent"}, TagsNone: []string{"home"}}
+	if got := p.encode(); got != "?tags_all=work%2Curgent&tags_none=home" {
+		t.Fatalf("unexpected query %s", got)
+	}
+}
+
+func TestImportTasks(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/tasks:import" || r.URL.RawQuery != "dry_run=true&duplicates=update&format=csv" {
+			t.Errorf("expected /v1/tasks:import with options, got %s?%s", r.URL.Path, r.URL.RawQuery)
+		}
+		if body, _ := io.ReadAll(r.Body); string(body) != "title\na\n" {
+			t.Errorf("expected the file as body, got %q", body)
+		}
+		w.Write([]byte(`{"dry_run":true,"failed":1,"results":[{"row":2,"status":"failed",` +
+			`"error":{"code":"VALIDATION_ERROR","message":"title is required"}}]}`))
+	}))
+	defer ts.Close()
+
+	report, err := New(ts.URL).ImportTasks(t.Context(), strings.NewReader("title\na\n"), ImportOptions{Format: "csv", Duplicates: "update", DryRun: true})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !report.DryRun || report.Failed != 1 || report.Results[0].Error.Code != CodeValidation {
+		t.Fatalf("unexpected report %+v", report)
+	}
+}
+
+func TestAgenda(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.String(); got != "/v2/agenda?tz=Europe%2FParis" {
+			t.Errorf("expected the v2 agenda URL, got %s", got)
+		}
+		w.Write([]byte(`{"date":"2026-03-09","time_zone":"Europe/Paris","overdue":{"items":[{"id":1,"title":"late","is_overdue":true,"due_in":-2}],"total":1}}`))
+	}))
+	defer ts.Close()
+
+	c := New(ts.URL)
+	c.SetToken("t")
+	a, err := c.Agenda(t.Context(), ListTasksParams{TimeZone: "Europe/Paris"})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if a.Date != "2026-03-09" || len(a.Overdue.Items) != 1 || !a.Overdue.Items[0].IsOverdue || *a.Overdue.Items[0].DueIn != -2 {
+		t.Fatalf("unexpected agenda %+v", a)
+	}
+
+	// Check the list presets are encoded
+	if got := (ListTasksParams{Due: "week", TimeZone: "+01:00"}).encode(); got != "?due=week&tz=%2B01%3A00" {
+		t.Fatalf("expected the due and tz params, got %s", got)
+	}
+}
@@ -0,0 +1,112 @@
+package apiclient
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Stream is a subscription to the server's task changes, opened with
+// Subscribe.
+type Stream struct {
+	body   io.ReadCloser
+	r      *bufio.Reader
+	lastID string
+}
+
+// Subscribe opens the change stream. With a lastEventID from an earlier
+// stream, the server first sends the changes made since; pass "" to only
+// get new ones. The stream ends when ctx is done or on Close.
+func (c *Client) Subscribe(ctx context.Context, lastEventID string) (*Stream, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+c.eventsPath(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	// The client's timeout would cut the stream off, so only its transport
+	// is shared.
+	hc := &http.Client{Transport: c.http.Transport}
+	resp, err := hc.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		return nil, responseError(resp)
+	}
+
+	return &Stream{body: resp.Body, r: bufio.NewReader(resp.Body), lastID: lastEventID}, nil
+}
+
+// Next blocks until the next change. A "reset" event means changes were
+// missed and the caller should reload its tasks. At the end of the stream
+// it returns io.EOF; reconnect with LastEventID to resume.
+func (s *Stream) Next() (StreamEvent, error) {
+	var ev StreamEvent
+	var data strings.Builder
+	for {
+		line, err := s.r.ReadString('\n')
+		if err != nil {
+			if err == io.ErrUnexpectedEOF {
+				err = io.EOF
+			}
+			return StreamEvent{}, err
+		}
+		line = strings.TrimRight(line, "\r\n")
+
+		if line == "" {
+			if ev.Kind == "" && data.Len() == 0 {
+				continue
+			}
+			if ev.Kind != "reset" && data.Len() > 0 {
+				if err := json.Unmarshal([]byte(data.String()), &ev); err != nil {
+					return StreamEvent{}, fmt.Errorf("event %s: %w", ev.ID, err)
+				}
+			}
+			if ev.ID != "" {
+				s.lastID = ev.ID
+			}
+			return ev, nil
+		}
+
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "id":
+			ev.ID = value
+		case "event":
+			ev.Kind = value
+		case "data":
+			if data.Len() > 0 {
+				data.WriteByte('\n')
+			}
+			data.WriteString(value)
+		}
+		// Other fields and ":" comments (keep-alives) are ignored
+	}
+}
+
+// LastEventID is the ID of the last change received, for resuming.
+func (s *Stream) LastEventID() string {
+	return s.lastID
+}
+
+func (s *Stream) Close() error {
+	return s.body.Close()
+}
+
+// eventsPath returns the change stream path for the API version in use.
+func (c *Client) eventsPath() string {
+	return strings.TrimSuffix(c.tasksPath(), "tasks") + "events"
+}
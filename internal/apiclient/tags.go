@@ -0,0 +1,40 @@
+package apiclient
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// ListTags returns every tag in use with its number of tasks, most used
+// first.
+func (c *Client) ListTags(ctx context.Context) ([]TagCount, error) {
+	var out struct {
+		Items []TagCount `json:"items"`
+	}
+	_, err := c.do(ctx, http.MethodGet, c.tagsPath(), nil, &out)
+	return out.Items, err
+}
+
+// RenameTag renames tag to name on every task.
+func (c *Client) RenameTag(ctx context.Context, tag, name string) (TagChange, error) {
+	var out TagChange
+	req := struct {
+		Name string `json:"name"`
+	}{name}
+	_, err := c.do(ctx, http.MethodPatch, c.tagsPath()+"/"+url.PathEscape(tag), req, &out)
+	return out, err
+}
+
+// DeleteTag removes tag from every task.
+func (c *Client) DeleteTag(ctx context.Context, tag string) (TagChange, error) {
+	var out TagChange
+	_, err := c.do(ctx, http.MethodDelete, c.tagsPath()+"/"+url.PathEscape(tag), nil, &out)
+	return out, err
+}
+
+// tagsPath returns the tags collection path for the API version in use.
+func (c *Client) tagsPath() string {
+	return strings.TrimSuffix(c.tasksPath(), "tasks") + "tags"
+}
@@ -1,40 +1,143 @@
 package apiclient
 
 import (
+	"context"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
 )
 
-func (c *Client) ListTasks() ([]Task, error) {
-	var out []Task
-	_, err := c.do(http.MethodGet, "/v1/tasks", nil, &out)
+func (c *Client) ListTasks(ctx context.Context, p ListTasksParams) (TaskList, error) {
+	var out TaskList
+	_, err := c.do(ctx, http.MethodGet, c.tasksPath()+p.encode(), nil, &out)
 	return out, err
 }
 
-func (c *Client) CreateTask(req CreateTaskRequest) (Task, error) {
+// ListChildren lists the direct subtasks of a task.
+func (c *Client) ListChildren(ctx context.Context, id int, p ListTasksParams) (TaskList, error) {
+	var out TaskList
+	_, err := c.do(ctx, http.MethodGet, c.tasksPath()+"/"+itoa(id)+"/children"+p.encode(), nil, &out)
+	return out, err
+}
+
+// Agenda returns the open tasks that are overdue, due today, due in the
+// rest of the week and not due, judged in p.TimeZone. The other params
+// apply to every section; p.Due and p.IsDone must be empty.
+func (c *Client) Agenda(ctx context.Context, p ListTasksParams) (Agenda, error) {
+	var out Agenda
+	_, err := c.do(ctx, http.MethodGet, strings.TrimSuffix(c.tasksPath(), "tasks")+"agenda"+p.encode(), nil, &out)
+	return out, err
+}
+
+// History returns the changes made to a task, oldest first.
+func (c *Client) History(ctx context.Context, id int) ([]Event, error) {
+	var out struct {
+		Items []Event `json:"items"`
+	}
+	_, err := c.do(ctx, http.MethodGet, c.tasksPath()+"/"+itoa(id)+"/history", nil, &out)
+	return out.Items, err
+}
+
+func (c *Client) CreateTask(ctx context.Context, req CreateTaskRequest) (Task, error) {
 	var out Task
-	_, err := c.do(http.MethodPost, "/v1/tasks", req, &out)
+	_, err := c.do(ctx, http.MethodPost, c.tasksPath(), req, &out)
 	return out, err
 }
 
-func (c *Client) GetTask(id int) (Task, error) {
+func (c *Client) GetTask(ctx context.Context, id int) (Task, error) {
 	var out Task
-	_, err := c.do(http.MethodGet, "/v1/tasks/"+itoa(id), nil, &out)
+	_, err := c.do(ctx, http.MethodGet, c.tasksPath()+"/"+itoa(id), nil, &out)
 	return out, err
 }
 
-func (c *Client) UpdateTask(id int, req UpdateTaskRequest) (Task, error) {
+func (c *Client) UpdateTask(ctx context.Context, id int, req UpdateTaskRequest) (Task, error) {
 	var out Task
-	_, err := c.do(http.MethodPatch, "/v1/tasks/"+itoa(id), req, &out)
+	_, err := c.do(ctx, http.MethodPatch, c.tasksPath()+"/"+itoa(id), req, &out)
 	return out, err
 }
 
-func (c *Client) DeleteTask(id int) error {
-	_, err := c.do(http.MethodDelete, "/v1/tasks/"+itoa(id), nil, nil)
+// UpdateTaskIfVersion is UpdateTask that only applies if the task is still
+// at version. Otherwise it fails with a CONFLICT error (see IsConflict).
+func (c *Client) UpdateTaskIfVersion(ctx context.Context, id, version int, req UpdateTaskRequest) (Task, error) {
+	var out Task
+	_, err := c.doWithHeader(ctx, http.MethodPatch, c.tasksPath()+"/"+itoa(id), ifMatch(version), req, &out)
+	return out, err
+}
+
+func (c *Client) DeleteTask(ctx context.Context, id int) error {
+	_, err := c.do(ctx, http.MethodDelete, c.tasksPath()+"/"+itoa(id), nil, nil)
 	return err
 }
 
+// DeleteTaskIfVersion is DeleteTask that only applies if the task is still
+// at version. Otherwise it fails with a CONFLICT error (see IsConflict).
+func (c *Client) DeleteTaskIfVersion(ctx context.Context, id, version int) error {
+	_, err := c.doWithHeader(ctx, http.MethodDelete, c.tasksPath()+"/"+itoa(id), ifMatch(version), nil, nil)
+	return err
+}
+
+// ifMatch builds the precondition header for a task version; the server's
+// ETag for a task is its quoted version.
+func ifMatch(version int) http.Header {
+	return http.Header{"If-Match": {`"` + itoa(version) + `"`}}
+}
+
 // small helper to avoid fmt.Sprintf in hot paths
 func itoa(n int) string {
 	return strconv.Itoa(n)
 }
+
+// encode renders the non-zero params as a query string, including the "?".
+func (p ListTasksParams) encode() string {
+	v := p.values()
+	if len(v) == 0 {
+		return ""
+	}
+	return "?" + v.Encode()
+}
+
+// values returns the non-zero params as query values.
+func (p ListTasksParams) values() url.Values {
+	v := url.Values{}
+	if p.IsDone != nil {
+		v.Set("is_done", strconv.FormatBool(*p.IsDone))
+	}
+	if p.Query != "" {
+		v.Set("q", p.Query)
+	}
+	if p.Parent.Set {
+		if p.Parent.Value == nil {
+			v.Set("parent_id", "null")
+		} else {
+			v.Set("parent_id", itoa(*p.Parent.Value))
+		}
+	}
+	for _, f := range []struct {
+		key    string
+		values []string
+	}{{"tags_any", p.TagsAny}, {"tags_all", p.TagsAll}, {"tags_none", p.TagsNone}, {"priority", p.Priorities}} {
+		if len(f.values) > 0 {
+			v.Set(f.key, strings.Join(f.values, ","))
+		}
+	}
+	if p.Due != "" {
+		v.Set("due", p.Due)
+	}
+	if p.TimeZone != "" {
+		v.Set("tz", p.TimeZone)
+	}
+	if p.Sort != "" {
+		v.Set("sort", p.Sort)
+	}
+	if p.Order != "" {
+		v.Set("order", p.Order)
+	}
+	if p.Limit != 0 {
+		v.Set("limit", itoa(p.Limit))
+	}
+	if p.Offset != 0 {
+		v.Set("offset", itoa(p.Offset))
+	}
+	return v
+}
@@ -0,0 +1,79 @@
+package apiclient
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// ImportOptions control ImportTasks. Format is json, csv or todotxt.
+// Duplicates says what to do with tasks whose title already exists: skip
+// (the server default), update or create. DryRun only reports what the
+// import would do.
+type ImportOptions struct {
+	Format     string
+	Duplicates string
+	DryRun     bool
+}
+
+// ExportTasks returns every task matching p as a file in format (json,
+// csv or todotxt). Paging in p is ignored.
+func (c *Client) ExportTasks(ctx context.Context, format string, p ListTasksParams) ([]byte, error) {
+	v := p.values()
+	v.Set("format", format)
+
+	resp, err := c.doRaw(ctx, http.MethodGet, c.tasksPath()+":export?"+v.Encode(), "", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	return io.ReadAll(resp.Body)
+}
+
+// ImportTasks uploads a file of tasks. Rows that cannot be imported do not
+// fail the call; check the report.
+func (c *Client) ImportTasks(ctx context.Context, file io.Reader, opts ImportOptions) (ImportReport, error) {
+	v := url.Values{"format": {opts.Format}}
+	if opts.Duplicates != "" {
+		v.Set("duplicates", opts.Duplicates)
+	}
+	if opts.DryRun {
+		v.Set("dry_run", strconv.FormatBool(opts.DryRun))
+	}
+
+	var out ImportReport
+	resp, err := c.doRaw(ctx, http.MethodPost, c.tasksPath()+":import?"+v.Encode(), "application/octet-stream", file)
+	if err != nil {
+		return out, err
+	}
+	defer resp.Body.Close()
+	return out, json.NewDecoder(resp.Body).Decode(&out)
+}
+
+// doRaw sends a request with a body that is not JSON and returns the 2xx
+// response for the caller to read and close.
+func (c *Client) doRaw(ctx context.Context, method, path, contentType string, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		return nil, err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		return nil, responseError(resp)
+	}
+	return resp, nil
+}
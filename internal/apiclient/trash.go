@@ -0,0 +1,44 @@
+package apiclient
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+// ListTrash lists deleted tasks, most recently deleted first unless p sorts
+// otherwise.
+func (c *Client) ListTrash(ctx context.Context, p ListTasksParams) (TaskList, error) {
+	var out TaskList
+	_, err := c.do(ctx, http.MethodGet, c.trashPath()+p.encode(), nil, &out)
+	return out, err
+}
+
+// RestoreTask takes a task out of the trash, with the subtasks deleted
+// together with it.
+func (c *Client) RestoreTask(ctx context.Context, id int) (Task, error) {
+	var out Task
+	_, err := c.do(ctx, http.MethodPost, c.trashPath()+"/"+itoa(id)+"/restore", nil, &out)
+	return out, err
+}
+
+// PurgeTask permanently deletes a task that is in the trash.
+func (c *Client) PurgeTask(ctx context.Context, id int) error {
+	_, err := c.do(ctx, http.MethodDelete, c.trashPath()+"/"+itoa(id), nil, nil)
+	return err
+}
+
+// EmptyTrash permanently deletes every task in the trash and returns how
+// many there were.
+func (c *Client) EmptyTrash(ctx context.Context) (int, error) {
+	var out struct {
+		Purged int `json:"purged"`
+	}
+	_, err := c.do(ctx, http.MethodDelete, c.trashPath(), nil, &out)
+	return out.Purged, err
+}
+
+// trashPath returns the trash path for the API version in use.
+func (c *Client) trashPath() string {
+	return strings.TrimSuffix(c.tasksPath(), "tasks") + "trash"
+}
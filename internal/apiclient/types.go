@@ -1,26 +1,244 @@
 package apiclient
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 type Task struct {
-	ID        int        `json:"id"`
-	Title     string     `json:"title"`
-	Category  *string    `json:"category,omitempty"`
-	DueDate   *time.Time `json:"due_date,omitempty"`
-	IsDone    bool       `json:"is_done"`
+	ID       int        `json:"id"`
+	Title    string     `json:"title"`
+	Category *string    `json:"category,omitempty"`
+	DueDate  *time.Time `json:"due_date,omitempty"`
+	IsDone   bool       `json:"is_done"`
+	ParentID *int       `json:"parent_id,omitempty"`
+	Repeat   string     `json:"repeat,omitempty"`
+	Tags     []string   `json:"tags,omitempty"`
+	// Priority is none, low, medium or high.
+	Priority string `json:"priority,omitempty"`
+	// Reminders are RFC 3339 times or offsets from the due date such as
+	// "due-1h".
+	Reminders []string `json:"reminders,omitempty"`
+	// IsOverdue and DueIn, the days until the due day (negative once it
+	// passed), are judged in ListTasksParams.TimeZone, UTC by default.
+	IsOverdue bool       `json:"is_overdue"`
+	DueIn     *int       `json:"due_in,omitempty"`
 	CreatedAt time.Time  `json:"created_at"`
 	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+	// DeletedAt is set on tasks in the trash.
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	// Version changes on every update; pass it to UpdateTaskIfVersion or
+	// DeleteTaskIfVersion to detect concurrent edits.
+	Version int `json:"version"`
 }
 
 type CreateTaskRequest struct {
 	Title    string     `json:"title"`
 	Category *string    `json:"category,omitempty"`
 	DueDate  *time.Time `json:"due_date,omitempty"`
+	ParentID *int       `json:"parent_id,omitempty"`
+	// Repeat is a recurrence rule such as "weekly:mon,thu" or "after:3".
+	Repeat string   `json:"repeat,omitempty"`
+	Tags   []string `json:"tags,omitempty"`
+	// Priority is none, low, medium or high; empty means none.
+	Priority  string   `json:"priority,omitempty"`
+	Reminders []string `json:"reminders,omitempty"`
 }
 
+// UpdateTaskRequest is a partial update. Zero Optional fields are omitted
+// (unchanged); use Null to clear a value.
 type UpdateTaskRequest struct {
-	Title    *string    `json:"title,omitempty"`
-	Category *string    `json:"category,omitempty"`
-	DueDate  *time.Time `json:"due_date,omitempty"`
-	IsDone   *bool      `json:"is_done,omitempty"`
+	Title    *string             `json:"title,omitempty"`
+	Category Optional[string]    `json:"category,omitzero"`
+	DueDate  Optional[time.Time] `json:"due_date,omitzero"`
+	IsDone   *bool               `json:"is_done,omitempty"`
+	// ParentID moves the task under another one; Null makes it top-level.
+	ParentID Optional[int]    `json:"parent_id,omitzero"`
+	Repeat   Optional[string] `json:"repeat,omitzero"`
+	// Tags replaces all tags; Null removes them. AddTags and RemoveTags
+	// edit the current ones.
+	Tags       Optional[[]string] `json:"tags,omitzero"`
+	AddTags    []string           `json:"add_tags,omitempty"`
+	RemoveTags []string           `json:"remove_tags,omitempty"`
+	Priority   *string            `json:"priority,omitempty"`
+	// Reminders replaces all reminders; Null removes them.
+	Reminders Optional[[]string] `json:"reminders,omitzero"`
+}
+
+// Optional is a tri-state request field: the zero value is omitted from the
+// body, Value(v) sends v and Null() sends an explicit null.
+type Optional[T any] struct {
+	Set   bool
+	Value *T
+}
+
+func Value[T any](v T) Optional[T] {
+	return Optional[T]{Set: true, Value: &v}
+}
+
+func Null[T any]() Optional[T] {
+	return Optional[T]{Set: true}
+}
+
+func (o Optional[T]) IsZero() bool {
+	return !o.Set
+}
+
+func (o Optional[T]) MarshalJSON() ([]byte, error) {
+	if o.Value == nil {
+		return []byte("null"), nil
+	}
+	return json.Marshal(*o.Value)
+}
+
+// ListTasksParams mirrors the GET /v1/tasks query parameters. Zero values
+// are omitted and the server defaults apply. Parent set to Null lists only
+// top-level tasks. TagsAny, TagsAll and TagsNone match tasks with at least
+// one, all or none of the tags; Priorities matches any of the priorities.
+// The server sorts by "smart" (what to do next first) unless Sort is set.
+type ListTasksParams struct {
+	IsDone     *bool
+	Query      string
+	Parent     Optional[int]
+	TagsAny    []string
+	TagsAll    []string
+	TagsNone   []string
+	Priorities []string
+	// Due is a preset: overdue, today, upcoming, week or none.
+	Due string
+	// TimeZone is the IANA name or UTC offset the due presets and the
+	// computed task fields are judged in.
+	TimeZone string
+	Sort     string
+	Order    string
+	Limit    int
+	Offset   int
+}
+
+type TaskList struct {
+	Items  []Task `json:"items"`
+	Total  int    `json:"total"`
+	Limit  int    `json:"limit"`
+	Offset int    `json:"offset"`
+}
+
+// Agenda is the open tasks by due day; see Client.Agenda.
+type Agenda struct {
+	// Date is today in TimeZone, as YYYY-MM-DD.
+	Date      string   `json:"date"`
+	TimeZone  string   `json:"time_zone"`
+	Overdue   TaskList `json:"overdue"`
+	Today     TaskList `json:"today"`
+	Upcoming  TaskList `json:"upcoming"`
+	NoDueDate TaskList `json:"no_due_date"`
+}
+
+type TagCount struct {
+	Tag   string `json:"tag"`
+	Count int    `json:"count"`
+}
+
+// TagChange is the result of renaming or deleting a tag: the resulting tag
+// and the number of tasks changed.
+type TagChange struct {
+	Tag   string `json:"tag"`
+	Tasks int    `json:"tasks"`
+}
+
+// Event is one recorded change to a task.
+type Event struct {
+	ID      int       `json:"id"`
+	TaskID  int       `json:"task_id"`
+	Kind    string    `json:"kind"`
+	Actor   string    `json:"actor,omitempty"`
+	At      time.Time `json:"at"`
+	Changes []Change  `json:"changes"`
+}
+
+// Change is one field before and after an event; nil means unset.
+type Change struct {
+	Field string  `json:"field"`
+	Old   *string `json:"old,omitempty"`
+	New   *string `json:"new,omitempty"`
+}
+
+// StreamEvent is one message of the change stream. Kind is an Event kind,
+// or "reset" when the server could not replay the changes missed since the
+// last ID; a reset carries no task.
+type StreamEvent struct {
+	ID      string    `json:"-"`
+	Kind    string    `json:"kind"`
+	TaskID  int       `json:"task_id"`
+	Actor   string    `json:"actor,omitempty"`
+	At      time.Time `json:"at"`
+	Changes []Change  `json:"changes"`
+	Task    Task      `json:"task"`
+}
+
+type Webhook struct {
+	ID        int       `json:"id"`
+	URL       string    `json:"url"`
+	Events    []string  `json:"events"`
+	Secret    string    `json:"secret,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// CreateWebhookRequest subscribes URL to Events, or to all events if
+// empty. An empty Secret lets the server generate one.
+type CreateWebhookRequest struct {
+	URL    string   `json:"url"`
+	Events []string `json:"events,omitempty"`
+	Secret string   `json:"secret,omitempty"`
+}
+
+// Delivery is one attempt to deliver an event to a webhook.
+type Delivery struct {
+	ID         int       `json:"id"`
+	Key        string    `json:"key"`
+	Event      string    `json:"event"`
+	TaskID     int       `json:"task_id"`
+	Attempt    int       `json:"attempt"`
+	StatusCode int       `json:"status_code,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	DurationMS int64     `json:"duration_ms"`
+	At         time.Time `json:"at"`
+}
+
+// ImportReport is the outcome of an import: counts per status and one
+// result per record of the file.
+type ImportReport struct {
+	DryRun  bool           `json:"dry_run"`
+	Created int            `json:"created"`
+	Updated int            `json:"updated"`
+	Skipped int            `json:"skipped"`
+	Failed  int            `json:"failed"`
+	Results []ImportResult `json:"results"`
+}
+
+// ImportResult is the outcome of one record. Row is its line in CSV and
+// todo.txt files and its position in JSON arrays. Status is created,
+// updated or skipped with the task, or failed with the error.
+type ImportResult struct {
+	Row    int       `json:"row"`
+	Status string    `json:"status"`
+	Task   *Task     `json:"task,omitempty"`
+	Error  *APIError `json:"error,omitempty"`
+}
+
+type Credentials struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+type User struct {
+	ID        int       `json:"id"`
+	Username  string    `json:"username"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type Token struct {
+	AccessToken string    `json:"access_token"`
+	TokenType   string    `json:"token_type"`
+	ExpiresAt   time.Time `json:"expires_at"`
 }
@@ -0,0 +1,45 @@
+package apiclient
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+// ListWebhooks returns the webhooks, without their secrets.
+func (c *Client) ListWebhooks(ctx context.Context) ([]Webhook, error) {
+	var out struct {
+		Items []Webhook `json:"items"`
+	}
+	_, err := c.do(ctx, http.MethodGet, c.webhooksPath(), nil, &out)
+	return out.Items, err
+}
+
+// CreateWebhook subscribes a URL to task events. The returned webhook
+// carries the signing secret; it is not shown again.
+func (c *Client) CreateWebhook(ctx context.Context, req CreateWebhookRequest) (Webhook, error) {
+	var out Webhook
+	_, err := c.do(ctx, http.MethodPost, c.webhooksPath(), req, &out)
+	return out, err
+}
+
+func (c *Client) DeleteWebhook(ctx context.Context, id int) error {
+	_, err := c.do(ctx, http.MethodDelete, c.webhooksPath()+"/"+itoa(id), nil, nil)
+	return err
+}
+
+// ListDeliveries returns the latest delivery attempts of a webhook, newest
+// first.
+func (c *Client) ListDeliveries(ctx context.Context, id int) ([]Delivery, error) {
+	var out struct {
+		Items []Delivery `json:"items"`
+	}
+	_, err := c.do(ctx, http.MethodGet, c.webhooksPath()+"/"+itoa(id)+"/deliveries", nil, &out)
+	return out.Items, err
+}
+
+// webhooksPath returns the webhooks collection path for the API version in
+// use.
+func (c *Client) webhooksPath() string {
+	return strings.TrimSuffix(c.tasksPath(), "tasks") + "webhooks"
+}
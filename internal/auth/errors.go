@@ -0,0 +1,8 @@
+package auth
+
+import "github.com/Saintrad/todo-server-client/internal/todo"
+
+var ErrUserNotFound = todo.NewNotFoundError("user not found")
+var ErrUsernameTaken = todo.NewConflictError("username already exists")
+var ErrInvalidCredentials = &todo.Error{Code: todo.CodeUnauthorized, Message: "invalid username or password"}
+var ErrInvalidToken = &todo.Error{Code: todo.CodeUnauthorized, Message: "invalid or expired token"}
@@ -0,0 +1,175 @@
+package auth
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/Saintrad/todo-server-client/internal/todo"
+)
+
+const (
+	DefaultTokenTTL  = 24 * time.Hour
+	MinPasswordLen   = 8
+	maxPasswordBytes = 72 // bcrypt ignores anything longer
+)
+
+type Service struct {
+	users  UserRepo
+	secret []byte
+	ttl    time.Duration
+	cost   int
+	now    func() time.Time
+}
+
+type Option func(*Service)
+
+// WithTokenTTL sets how long issued tokens stay valid.
+func WithTokenTTL(d time.Duration) Option {
+	return func(s *Service) { s.ttl = d }
+}
+
+// WithHashCost sets the bcrypt cost; tests use bcrypt.MinCost.
+func WithHashCost(cost int) Option {
+	return func(s *Service) { s.cost = cost }
+}
+
+// NewService creates an auth service signing tokens with secret. An empty
+// secret generates a random one, so tokens do not survive a restart.
+func NewService(users UserRepo, secret []byte, opts ...Option) (*Service, error) {
+	if len(secret) == 0 {
+		secret = make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return nil, err
+		}
+	}
+
+	s := &Service{
+		users:  users,
+		secret: secret,
+		ttl:    DefaultTokenTTL,
+		cost:   bcrypt.DefaultCost,
+		now:    time.Now,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s, nil
+}
+
+func (s *Service) Register(ctx context.Context, i RegisterInput) (User, error) {
+	username := strings.ToLower(strings.TrimSpace(i.Username))
+	if err := validateCredentials(username, i.Password); err != nil {
+		return User{}, err
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(i.Password), s.cost)
+	if err != nil {
+		return User{}, todo.NewInternalError(err)
+	}
+
+	return s.users.CreateUser(ctx, User{
+		Username:     username,
+		PasswordHash: hash,
+		CreatedAt:    s.now(),
+	})
+}
+
+func (s *Service) Login(ctx context.Context, username, password string) (Token, error) {
+	u, err := s.users.GetUserByUsername(ctx, strings.ToLower(strings.TrimSpace(username)))
+	if errors.Is(err, ErrUserNotFound) {
+		// Compare against a dummy hash so unknown usernames take as long as
+		// wrong passwords.
+		_ = bcrypt.CompareHashAndPassword(dummyHash, []byte(password))
+		return Token{}, ErrInvalidCredentials
+	}
+	if err != nil {
+		return Token{}, err
+	}
+
+	if err := bcrypt.CompareHashAndPassword(u.PasswordHash, []byte(password)); err != nil {
+		return Token{}, ErrInvalidCredentials
+	}
+
+	return s.issue(u.ID), nil
+}
+
+// Authenticate verifies a bearer token and returns its user.
+func (s *Service) Authenticate(ctx context.Context, token string) (User, error) {
+	payload, sig, ok := strings.Cut(token, ".")
+	if !ok {
+		return User{}, ErrInvalidToken
+	}
+
+	want := s.sign(payload)
+	got, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil || !hmac.Equal(got, want) {
+		return User{}, ErrInvalidToken
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return User{}, ErrInvalidToken
+	}
+	uidStr, expStr, ok := strings.Cut(string(raw), ":")
+	if !ok {
+		return User{}, ErrInvalidToken
+	}
+	uid, err1 := strconv.Atoi(uidStr)
+	exp, err2 := strconv.ParseInt(expStr, 10, 64)
+	if err1 != nil || err2 != nil || s.now().Unix() >= exp {
+		return User{}, ErrInvalidToken
+	}
+
+	u, err := s.users.GetUserByID(ctx, uid)
+	if errors.Is(err, ErrUserNotFound) {
+		return User{}, ErrInvalidToken
+	}
+	return u, err
+}
+
+// issue creates a token of the form base64(uid:exp).base64(hmac).
+func (s *Service) issue(uid int) Token {
+	exp := s.now().Add(s.ttl).Truncate(time.Second)
+	payload := base64.RawURLEncoding.EncodeToString([]byte(strconv.Itoa(uid) + ":" + strconv.FormatInt(exp.Unix(), 10)))
+	sig := base64.RawURLEncoding.EncodeToString(s.sign(payload))
+	return Token{Value: payload + "." + sig, ExpiresAt: exp}
+}
+
+func (s *Service) sign(payload string) []byte {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(payload))
+	return mac.Sum(nil)
+}
+
+func validateCredentials(username, password string) error {
+	var issues []todo.FieldIssue
+
+	if n := utf8.RuneCountInString(username); n < 3 || n > 32 {
+		issues = append(issues, todo.FieldIssue{Field: "username", Issue: "must be 3 to 32 characters"})
+	} else if strings.IndexFunc(username, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '_' || r == '-' || r == '.')
+	}) >= 0 {
+		issues = append(issues, todo.FieldIssue{Field: "username", Issue: "may only contain letters, digits, '_', '-' and '.'"})
+	}
+
+	if len(password) < MinPasswordLen || len(password) > maxPasswordBytes {
+		issues = append(issues, todo.FieldIssue{Field: "password", Issue: "must be 8 to 72 bytes"})
+	}
+
+	if len(issues) > 0 {
+		return todo.NewValidationError(issues...)
+	}
+	return nil
+}
+
+var dummyHash, _ = bcrypt.GenerateFromPassword([]byte("dummy password"), bcrypt.DefaultCost)
@@ -0,0 +1,150 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/Saintrad/todo-server-client/internal/todo"
+)
+
+type fakeUserRepo struct {
+	mu    sync.Mutex
+	users []User
+}
+
+func (r *fakeUserRepo) CreateUser(_ context.Context, u User) (User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.users {
+		if existing.Username == u.Username {
+			return User{}, ErrUsernameTaken
+		}
+	}
+	u.ID = len(r.users) + 1
+	r.users = append(r.users, u)
+	return u, nil
+}
+
+func (r *fakeUserRepo) GetUserByID(_ context.Context, id int) (User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, u := range r.users {
+		if u.ID == id {
+			return u, nil
+		}
+	}
+	return User{}, ErrUserNotFound
+}
+
+func (r *fakeUserRepo) GetUserByUsername(_ context.Context, username string) (User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, u := range r.users {
+		if u.Username == username {
+			return u, nil
+		}
+	}
+	return User{}, ErrUserNotFound
+}
+
+func (r *fakeUserRepo) Close() error {
+	return nil
+}
+
+func newTestService(t *testing.T) *Service {
+	t.Helper()
+
+	s, err := NewService(&fakeUserRepo{}, []byte("secret"), WithHashCost(bcrypt.MinCost))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	return s
+}
+
+func TestRegister(t *testing.T) {
+	s := newTestService(t)
+
+	u, err := s.Register(t.Context(), RegisterInput{Username: "Alice", Password: "correct horse"})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	// Check username is normalized and password is not stored in clear
+	if u.Username != "alice" {
+		t.Fatalf("expected username alice, got %s", u.Username)
+	}
+	if strings.Contains(string(u.PasswordHash), "correct horse") {
+		t.Fatalf("password stored in clear text")
+	}
+
+	// Check duplicate username
+	_, err = s.Register(t.Context(), RegisterInput{Username: "alice", Password: "another one"})
+	if todo.CodeOf(err) != todo.CodeConflict {
+		t.Fatalf("expected %s, got %v", todo.CodeConflict, err)
+	}
+
+	// Check validation
+	_, err = s.Register(t.Context(), RegisterInput{Username: "a!", Password: "short"})
+	var de *todo.Error
+	if !errors.As(err, &de) || len(de.Details) != 2 {
+		t.Fatalf("expected two validation issues, got %v", err)
+	}
+}
+
+func TestLoginAndAuthenticate(t *testing.T) {
+	s := newTestService(t)
+
+	u, err := s.Register(t.Context(), RegisterInput{Username: "bob", Password: "hunter2hunter2"})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	// Check wrong password and unknown user look the same
+	_, err = s.Login(t.Context(), "bob", "wrong password")
+	if !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("expected %v, got %v", ErrInvalidCredentials, err)
+	}
+	_, err = s.Login(t.Context(), "nobody", "hunter2hunter2")
+	if !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("expected %v, got %v", ErrInvalidCredentials, err)
+	}
+
+	tok, err := s.Login(t.Context(), "bob", "hunter2hunter2")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	got, err := s.Authenticate(t.Context(), tok.Value)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if got.ID != u.ID {
+		t.Fatalf("expected user %d, got %d", u.ID, got.ID)
+	}
+
+	// Check tampered token
+	if _, err := s.Authenticate(t.Context(), tok.Value+"x"); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("expected %v, got %v", ErrInvalidToken, err)
+	}
+
+	// Check token signed with another secret
+	other, _ := NewService(s.users, []byte("other secret"))
+	if _, err := other.Authenticate(t.Context(), tok.Value); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("expected %v, got %v", ErrInvalidToken, err)
+	}
+
+	// Check expired token
+	s.now = func() time.Time { return time.Now().Add(DefaultTokenTTL + time.Minute) }
+	if _, err := s.Authenticate(t.Context(), tok.Value); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("expected %v, got %v", ErrInvalidToken, err)
+	}
+}
@@ -0,0 +1,31 @@
+package auth
+
+import (
+	"context"
+	"time"
+)
+
+type User struct {
+	ID           int
+	Username     string
+	PasswordHash []byte
+	CreatedAt    time.Time
+}
+
+type UserRepo interface {
+	CreateUser(context.Context, User) (User, error)
+	GetUserByID(context.Context, int) (User, error)
+	GetUserByUsername(context.Context, string) (User, error)
+	Close() error
+}
+
+type RegisterInput struct {
+	Username string
+	Password string
+}
+
+// Token is a signed bearer token issued by Login.
+type Token struct {
+	Value     string
+	ExpiresAt time.Time
+}
@@ -0,0 +1,422 @@
+// Package config loads the server configuration.
+//
+// Every setting can come from four places. Later sources override earlier
+// ones:
+//
+//  1. built-in defaults
+//  2. a JSON config file (-config flag or TODO_CONFIG)
+//  3. environment variables (TODO_*)
+//  4. command-line flags
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Config struct {
+	Addr    string `json:"addr"`
+	DataDir string `json:"data_dir"`
+	Storage string `json:"storage"`
+	// FileJournal makes the file backend append to a journal instead of
+	// rewriting tasks.json on every change.
+	FileJournal  bool     `json:"file_journal"`
+	ReadTimeout  Duration `json:"read_timeout"`
+	WriteTimeout Duration `json:"write_timeout"`
+	IdleTimeout  Duration `json:"idle_timeout"`
+	// ShutdownTimeout bounds how long in-flight requests may drain on
+	// SIGINT/SIGTERM.
+	ShutdownTimeout Duration `json:"shutdown_timeout"`
+	LogLevel        string   `json:"log_level"`
+	AuthSecret      string   `json:"auth_secret"`
+	// SubtaskComplete and SubtaskDelete decide what completing or deleting
+	// a task does to its subtasks.
+	SubtaskComplete string `json:"subtask_complete"`
+	SubtaskDelete   string `json:"subtask_delete"`
+	// TrashRetentionDays is how long deleted tasks stay in the trash before
+	// they are purged for good; 0 keeps them until purged by hand.
+	TrashRetentionDays int `json:"trash_retention_days"`
+	// WebhookWorkers is how many webhook deliveries run at once.
+	WebhookWorkers int `json:"webhook_workers"`
+	// ReminderSinks is a comma list of where fired reminders go, from
+	// ReminderSinkNames; empty or "none" turns the reminder scheduler off.
+	ReminderSinks string `json:"reminder_sinks"`
+	// ReminderCommand is run for every reminder by the command sink.
+	ReminderCommand string `json:"reminder_command"`
+	// ReminderInterval is how often the scheduler looks for new reminders.
+	ReminderInterval Duration `json:"reminder_interval"`
+
+	// ConfigFile is the file the settings were read from, if any.
+	ConfigFile string `json:"-"`
+}
+
+// Storage backends accepted by Config.Storage.
+const (
+	StorageFile   = "file"
+	StorageMemory = "memory"
+	StorageSQLite = "sqlite"
+)
+
+var storageBackends = []string{StorageFile, StorageMemory, StorageSQLite}
+
+// Sinks accepted in Config.ReminderSinks.
+const (
+	ReminderSinkLog      = "log"
+	ReminderSinkEvents   = "events"
+	ReminderSinkWebhooks = "webhooks"
+	ReminderSinkCommand  = "command"
+)
+
+var ReminderSinkNames = []string{ReminderSinkLog, ReminderSinkEvents, ReminderSinkWebhooks, ReminderSinkCommand}
+
+// Rules accepted by Config.SubtaskComplete and Config.SubtaskDelete; they
+// match todo.CompletePolicy and todo.DeletePolicy.
+var (
+	subtaskCompleteRules = []string{"independent", "cascade", "require"}
+	subtaskDeleteRules   = []string{"restrict", "cascade"}
+)
+
+func Default() Config {
+	return Config{
+		Addr:            ":8080",
+		DataDir:         "data",
+		Storage:         StorageFile,
+		ReadTimeout:     Duration(10 * time.Second),
+		WriteTimeout:    Duration(30 * time.Second),
+		IdleTimeout:     Duration(120 * time.Second),
+		ShutdownTimeout: Duration(15 * time.Second),
+		LogLevel:        "info",
+		SubtaskComplete: "independent",
+		SubtaskDelete:   "restrict",
+
+		TrashRetentionDays: 30,
+		WebhookWorkers:     4,
+		ReminderSinks:      "log,events,webhooks",
+		ReminderInterval:   Duration(time.Minute),
+	}
+}
+
+// envVars maps environment variables to the flag with the same meaning.
+var envVars = []struct{ env, flag string }{
+	{"TODO_ADDR", "addr"},
+	{"TODO_DATA_DIR", "data-dir"},
+	{"TODO_STORAGE", "storage"},
+	{"TODO_FILE_JOURNAL", "file-journal"},
+	{"TODO_READ_TIMEOUT", "read-timeout"},
+	{"TODO_WRITE_TIMEOUT", "write-timeout"},
+	{"TODO_IDLE_TIMEOUT", "idle-timeout"},
+	{"TODO_SHUTDOWN_TIMEOUT", "shutdown-timeout"},
+	{"TODO_LOG_LEVEL", "log-level"},
+	{"TODO_AUTH_SECRET", "auth-secret"},
+	{"TODO_SUBTASK_COMPLETE", "subtask-complete"},
+	{"TODO_SUBTASK_DELETE", "subtask-delete"},
+	{"TODO_TRASH_RETENTION_DAYS", "trash-retention-days"},
+	{"TODO_WEBHOOK_WORKERS", "webhook-workers"},
+	{"TODO_REMINDER_SINKS", "reminder-sinks"},
+	{"TODO_REMINDER_COMMAND", "reminder-command"},
+	{"TODO_REMINDER_INTERVAL", "reminder-interval"},
+}
+
+// Load builds the effective configuration from args (without the program
+// name) and getenv, which is os.Getenv outside tests.
+func Load(args []string, getenv func(string) string) (Config, error) {
+	cfg := Default()
+
+	fs := newFlagSet()
+	fs.SetOutput(io.Discard)
+
+	if err := fs.Parse(args); err != nil {
+		return Config{}, err
+	}
+	if fs.NArg() > 0 {
+		return Config{}, fmt.Errorf("unexpected argument %q", fs.Arg(0))
+	}
+
+	// Config file
+	path := fs.Lookup("config").Value.String()
+	if path == "" {
+		path = getenv("TODO_CONFIG")
+	}
+	if path != "" {
+		if err := cfg.loadFile(path); err != nil {
+			return Config{}, err
+		}
+		cfg.ConfigFile = path
+	}
+
+	// Environment
+	for _, e := range envVars {
+		if v := getenv(e.env); v != "" {
+			if err := cfg.set(e.flag, v); err != nil {
+				return Config{}, fmt.Errorf("%s: %w", e.env, err)
+			}
+		}
+	}
+
+	// Flags, only those given explicitly
+	var flagErr error
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == "config" || flagErr != nil {
+			return
+		}
+		if err := cfg.set(f.Name, f.Value.String()); err != nil {
+			flagErr = fmt.Errorf("-%s: %w", f.Name, err)
+		}
+	})
+	if flagErr != nil {
+		return Config{}, flagErr
+	}
+
+	return cfg, cfg.Validate()
+}
+
+// Usage prints the flag help to w.
+func Usage(w io.Writer) {
+	fs := newFlagSet()
+	fs.SetOutput(w)
+	fmt.Fprintln(w, "Usage: server [flags]")
+	fmt.Fprintln(w, "Precedence: flags > environment > config file > defaults.")
+	fs.PrintDefaults()
+}
+
+func newFlagSet() *flag.FlagSet {
+	fs := flag.NewFlagSet("server", flag.ContinueOnError)
+
+	fs.String("config", "", "path to a JSON config file (env TODO_CONFIG)")
+	fs.String("addr", "", "listen address (default :8080, env TODO_ADDR)")
+	fs.String("data-dir", "", "directory for data files (default data, env TODO_DATA_DIR)")
+	fs.String("storage", "", "storage backend: "+strings.Join(storageBackends, ", ")+" (default file, env TODO_STORAGE)")
+	fs.Bool("file-journal", false, "file backend: append changes to a journal (env TODO_FILE_JOURNAL)")
+	fs.String("read-timeout", "", "HTTP read timeout (default 10s, env TODO_READ_TIMEOUT)")
+	fs.String("write-timeout", "", "HTTP write timeout (default 30s, env TODO_WRITE_TIMEOUT)")
+	fs.String("idle-timeout", "", "HTTP keep-alive idle timeout (default 2m0s, env TODO_IDLE_TIMEOUT)")
+	fs.String("shutdown-timeout", "", "time allowed to drain requests on shutdown (default 15s, env TODO_SHUTDOWN_TIMEOUT)")
+	fs.String("log-level", "", "debug, info, warn or error (default info, env TODO_LOG_LEVEL)")
+	fs.String("auth-secret", "", "secret for signing v2 tokens (env TODO_AUTH_SECRET)")
+	fs.String("subtask-complete", "", "completing a task: "+strings.Join(subtaskCompleteRules, ", ")+" its subtasks (default independent, env TODO_SUBTASK_COMPLETE)")
+	fs.String("subtask-delete", "", "deleting a task: "+strings.Join(subtaskDeleteRules, ", ")+" its subtasks (default restrict, env TODO_SUBTASK_DELETE)")
+	fs.String("trash-retention-days", "", "purge deleted tasks after this many days, 0 to keep them (default 30, env TODO_TRASH_RETENTION_DAYS)")
+	fs.String("webhook-workers", "", "concurrent webhook deliveries (default 4, env TODO_WEBHOOK_WORKERS)")
+	fs.String("reminder-sinks", "", "where reminders go, comma-separated: "+strings.Join(ReminderSinkNames, ", ")+"; \"none\" turns them off (default log,events,webhooks, env TODO_REMINDER_SINKS)")
+	fs.String("reminder-command", "", "command run for every reminder by the command sink (env TODO_REMINDER_COMMAND)")
+	fs.String("reminder-interval", "", "how often to look for new reminders (default 1m0s, env TODO_REMINDER_INTERVAL)")
+
+	return fs
+}
+
+func (c *Config) loadFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("config file: %w", err)
+	}
+	defer f.Close()
+
+	dec := json.NewDecoder(f)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(c); err != nil {
+		return fmt.Errorf("config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// set assigns one setting by its flag name.
+func (c *Config) set(name, value string) error {
+	switch name {
+	case "addr":
+		c.Addr = value
+	case "data-dir":
+		c.DataDir = value
+	case "storage":
+		c.Storage = value
+	case "file-journal":
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		c.FileJournal = b
+	case "log-level":
+		c.LogLevel = value
+	case "auth-secret":
+		c.AuthSecret = value
+	case "subtask-complete":
+		c.SubtaskComplete = value
+	case "subtask-delete":
+		c.SubtaskDelete = value
+	case "trash-retention-days":
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		c.TrashRetentionDays = n
+	case "webhook-workers":
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		c.WebhookWorkers = n
+	case "reminder-sinks":
+		c.ReminderSinks = value
+	case "reminder-command":
+		c.ReminderCommand = value
+	case "read-timeout", "write-timeout", "idle-timeout", "shutdown-timeout", "reminder-interval":
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		switch name {
+		case "read-timeout":
+			c.ReadTimeout = Duration(d)
+		case "write-timeout":
+			c.WriteTimeout = Duration(d)
+		case "idle-timeout":
+			c.IdleTimeout = Duration(d)
+		case "reminder-interval":
+			c.ReminderInterval = Duration(d)
+		default:
+			c.ShutdownTimeout = Duration(d)
+		}
+	default:
+		return fmt.Errorf("unknown setting %q", name)
+	}
+	return nil
+}
+
+func (c Config) Validate() error {
+	var errs []error
+
+	if c.Addr == "" {
+		errs = append(errs, errors.New("addr must not be empty"))
+	}
+	if c.DataDir == "" && c.Storage != StorageMemory {
+		errs = append(errs, errors.New("data_dir must not be empty"))
+	}
+
+	for _, e := range []struct {
+		name, value string
+		allowed     []string
+	}{
+		{"storage", c.Storage, storageBackends},
+		{"subtask_complete", c.SubtaskComplete, subtaskCompleteRules},
+		{"subtask_delete", c.SubtaskDelete, subtaskDeleteRules},
+	} {
+		if !slices.Contains(e.allowed, e.value) {
+			errs = append(errs, fmt.Errorf("%s must be one of %s, got %q", e.name, strings.Join(e.allowed, ", "), e.value))
+		}
+	}
+
+	if _, err := c.SlogLevel(); err != nil {
+		errs = append(errs, err)
+	}
+	if c.TrashRetentionDays < 0 {
+		errs = append(errs, errors.New("trash_retention_days must not be negative"))
+	}
+	if c.WebhookWorkers < 1 {
+		errs = append(errs, errors.New("webhook_workers must be at least 1"))
+	}
+	for _, sink := range c.ReminderSinkList() {
+		if !slices.Contains(ReminderSinkNames, sink) {
+			errs = append(errs, fmt.Errorf("reminder_sinks must only contain %s, got %q", strings.Join(ReminderSinkNames, ", "), sink))
+		}
+	}
+	if slices.Contains(c.ReminderSinkList(), ReminderSinkCommand) && strings.TrimSpace(c.ReminderCommand) == "" {
+		errs = append(errs, errors.New("reminder_command must be set for the command sink"))
+	}
+
+	for _, d := range []struct {
+		name string
+		v    Duration
+	}{
+		{"read_timeout", c.ReadTimeout},
+		{"write_timeout", c.WriteTimeout},
+		{"idle_timeout", c.IdleTimeout},
+		{"shutdown_timeout", c.ShutdownTimeout},
+		{"reminder_interval", c.ReminderInterval},
+	} {
+		if d.v <= 0 {
+			errs = append(errs, fmt.Errorf("%s must be positive", d.name))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// ReminderSinkList splits ReminderSinks into sink names.
+func (c Config) ReminderSinkList() []string {
+	var out []string
+	for _, name := range strings.Split(c.ReminderSinks, ",") {
+		if name = strings.ToLower(strings.TrimSpace(name)); name != "" && name != "none" {
+			out = append(out, name)
+		}
+	}
+	return out
+}
+
+// SlogLevel parses LogLevel.
+func (c Config) SlogLevel() (slog.Level, error) {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(c.LogLevel)); err != nil {
+		return 0, fmt.Errorf("log_level must be debug, info, warn or error, got %q", c.LogLevel)
+	}
+	return lvl, nil
+}
+
+// LogValue prints the effective configuration, hiding the auth secret.
+func (c Config) LogValue() slog.Value {
+	secret := "(random per start)"
+	if c.AuthSecret != "" {
+		secret = "(set)"
+	}
+	configFile := c.ConfigFile
+	if configFile == "" {
+		configFile = "(none)"
+	}
+	return slog.GroupValue(
+		slog.String("config_file", configFile),
+		slog.String("addr", c.Addr),
+		slog.String("storage", c.Storage),
+		slog.String("data_dir", c.DataDir),
+		slog.Bool("file_journal", c.FileJournal),
+		slog.Duration("read_timeout", time.Duration(c.ReadTimeout)),
+		slog.Duration("write_timeout", time.Duration(c.WriteTimeout)),
+		slog.Duration("idle_timeout", time.Duration(c.IdleTimeout)),
+		slog.Duration("shutdown_timeout", time.Duration(c.ShutdownTimeout)),
+		slog.String("log_level", c.LogLevel),
+		slog.String("auth_secret", secret),
+		slog.String("subtask_complete", c.SubtaskComplete),
+		slog.String("subtask_delete", c.SubtaskDelete),
+		slog.Int("trash_retention_days", c.TrashRetentionDays),
+		slog.Int("webhook_workers", c.WebhookWorkers),
+		slog.String("reminder_sinks", c.ReminderSinks),
+		slog.String("reminder_command", c.ReminderCommand),
+		slog.Duration("reminder_interval", time.Duration(c.ReminderInterval)),
+	)
+}
+
+// Duration is a time.Duration that reads and writes as "10s" in JSON.
+type Duration time.Duration
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return fmt.Errorf("duration must be a string like \"10s\": %w", err)
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
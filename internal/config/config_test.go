@@ -0,0 +1,167 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func envMap(m map[string]string) func(string) string {
+	return func(k string) string { return m[k] }
+}
+
+func TestLoadDefaults(t *testing.T) {
+	cfg, err := Load(nil, envMap(nil))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if cfg != Default() {
+		t.Fatalf("expected defaults, got %+v", cfg)
+	}
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "server.json")
+	data := `{"addr":":7000","data_dir":"/srv/file","storage":"memory","read_timeout":"3s","log_level":"warn"}`
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	env := envMap(map[string]string{
+		"TODO_CONFIG":   path,
+		"TODO_ADDR":     ":7001",
+		"TODO_DATA_DIR": "/srv/env",
+	})
+
+	cfg, err := Load([]string{"-addr", ":7002"}, env)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	// Flag beats env beats file beats default
+	if cfg.Addr != ":7002" {
+		t.Fatalf("expected flag addr, got %s", cfg.Addr)
+	}
+	if cfg.DataDir != "/srv/env" {
+		t.Fatalf("expected env data dir, got %s", cfg.DataDir)
+	}
+	if cfg.Storage != StorageMemory || cfg.LogLevel != "warn" || time.Duration(cfg.ReadTimeout) != 3*time.Second {
+		t.Fatalf("expected file values, got %+v", cfg)
+	}
+	if cfg.WriteTimeout != Default().WriteTimeout {
+		t.Fatalf("expected default write timeout, got %v", cfg.WriteTimeout)
+	}
+	if cfg.ConfigFile != path {
+		t.Fatalf("expected config file %s, got %s", path, cfg.ConfigFile)
+	}
+}
+
+func TestLoadInvalid(t *testing.T) {
+	cases := [][]string{
+		{"-storage", "postgres"},
+		{"-log-level", "loud"},
+		{"-read-timeout", "soon"},
+		{"-idle-timeout", "0s"},
+		{"-subtask-complete", "sometimes"},
+		{"-subtask-delete", "orphan"},
+		{"extra"},
+	}
+	for _, args := range cases {
+		if _, err := Load(args, envMap(nil)); err == nil {
+			t.Fatalf("%v: expected an error", args)
+		}
+	}
+
+	if _, err := Load([]string{"-h"}, envMap(nil)); !errors.Is(err, flag.ErrHelp) {
+		t.Fatalf("expected %v, got %v", flag.ErrHelp, err)
+	}
+
+	path := filepath.Join(t.TempDir(), "server.json")
+	os.WriteFile(path, []byte(`{"adress":":1"}`), 0o644)
+	if _, err := Load([]string{"-config", path}, envMap(nil)); err == nil {
+		t.Fatalf("expected unknown config key to be rejected")
+	}
+}
+
+func TestLoadFileJournal(t *testing.T) {
+	cfg, err := Load(nil, envMap(map[string]string{"TODO_FILE_JOURNAL": "true"}))
+	if err != nil || !cfg.FileJournal {
+		t.Fatalf("expected journal from env, got %v, %v", cfg.FileJournal, err)
+	}
+
+	// The flag overrides the environment
+	cfg, err = Load([]string{"-file-journal=false"}, envMap(map[string]string{"TODO_FILE_JOURNAL": "1"}))
+	if err != nil || cfg.FileJournal {
+		t.Fatalf("expected flag to disable journal, got %v, %v", cfg.FileJournal, err)
+	}
+
+	if _, err := Load(nil, envMap(map[string]string{"TODO_FILE_JOURNAL": "maybe"})); err == nil {
+		t.Fatalf("expected invalid boolean to be rejected")
+	}
+}
+
+func TestLoadSubtaskRules(t *testing.T) {
+	cfg, err := Load([]string{"-subtask-delete", "cascade"}, envMap(map[string]string{"TODO_SUBTASK_COMPLETE": "require"}))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if cfg.SubtaskComplete != "require" || cfg.SubtaskDelete != "cascade" {
+		t.Fatalf("unexpected rules %q, %q", cfg.SubtaskComplete, cfg.SubtaskDelete)
+	}
+}
+
+func TestLoadTrashRetention(t *testing.T) {
+	cfg, err := Load(nil, envMap(map[string]string{"TODO_TRASH_RETENTION_DAYS": "7"}))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if cfg.TrashRetentionDays != 7 {
+		t.Fatalf("expected 7 days, got %d", cfg.TrashRetentionDays)
+	}
+
+	if _, err := Load([]string{"-trash-retention-days", "-1"}, envMap(nil)); err == nil {
+		t.Fatalf("expected negative retention to be rejected")
+	}
+}
+
+func TestLoadWebhookWorkers(t *testing.T) {
+	cfg, err := Load([]string{"-webhook-workers", "8"}, envMap(nil))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if cfg.WebhookWorkers != 8 {
+		t.Fatalf("expected 8 workers, got %d", cfg.WebhookWorkers)
+	}
+
+	if _, err := Load(nil, envMap(map[string]string{"TODO_WEBHOOK_WORKERS": "0"})); err == nil {
+		t.Fatalf("expected 0 workers to be rejected")
+	}
+}
+
+func TestLoadReminders(t *testing.T) {
+	cfg, err := Load([]string{"-reminder-sinks", "log, Command", "-reminder-interval", "30s"}, envMap(map[string]string{"TODO_REMINDER_COMMAND": "notify-send"}))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if got := cfg.ReminderSinkList(); len(got) != 2 || got[1] != ReminderSinkCommand || cfg.ReminderCommand != "notify-send" {
+		t.Fatalf("expected log and command sinks, got %v, %q", got, cfg.ReminderCommand)
+	}
+	if time.Duration(cfg.ReminderInterval) != 30*time.Second {
+		t.Fatalf("expected a 30s interval, got %v", cfg.ReminderInterval)
+	}
+
+	// Check "none" turns reminders off
+	if cfg, _ := Load([]string{"-reminder-sinks", "none"}, envMap(nil)); len(cfg.ReminderSinkList()) != 0 {
+		t.Fatalf("expected no sinks, got %v", cfg.ReminderSinkList())
+	}
+
+	// Check unknown sinks and a command sink without command are rejected
+	for _, args := range [][]string{{"-reminder-sinks", "log,email"}, {"-reminder-sinks", "command"}, {"-reminder-interval", "0s"}} {
+		if _, err := Load(args, envMap(nil)); err == nil {
+			t.Fatalf("%v: expected an error", args)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+// Package events fans task changes out to live subscribers, such as the
+// SSE stream, and keeps the most recent ones so a reconnecting subscriber
+// can catch up.
+package events
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Saintrad/todo-server-client/internal/todo"
+)
+
+// DefaultBufferSize is the number of messages a Broker keeps for replay
+// when NewBroker is given a non-positive size.
+const DefaultBufferSize = 1000
+
+// subscriberQueue is how many messages a subscriber may fall behind before
+// it is dropped.
+const subscriberQueue = 64
+
+// Message is one published task change. IDs increase by one per message and
+// restart from 1 with the process; Broker.Epoch tells processes apart.
+type Message struct {
+	ID    uint64
+	Event todo.Event
+	Task  todo.Task
+}
+
+var _ todo.Publisher = (*Broker)(nil)
+
+// Broker is an in-process pub/sub of task changes. Subscribers only get the
+// changes of their owner.
+type Broker struct {
+	epoch  string
+	mu     sync.Mutex
+	buf    []Message // ring of the last len(buf) messages
+	lastID uint64
+	subs   map[*Subscription]struct{}
+	closed bool
+}
+
+func NewBroker(size int) *Broker {
+	if size <= 0 {
+		size = DefaultBufferSize
+	}
+	return &Broker{
+		epoch: strconv.FormatInt(time.Now().UnixNano(), 36),
+		buf:   make([]Message, size),
+		subs:  make(map[*Subscription]struct{}),
+	}
+}
+
+// Epoch identifies this broker, and so the process, among the brokers
+// whose message IDs a client may have seen.
+func (b *Broker) Epoch() string {
+	return b.epoch
+}
+
+// Publish stores the change for replay and hands it to the subscribers of
+// its owner. A subscriber too slow to take it is dropped.
+func (b *Broker) Publish(e todo.Event, t todo.Task) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return
+	}
+
+	b.lastID++
+	m := Message{ID: b.lastID, Event: e, Task: t}
+	b.buf[b.lastID%uint64(len(b.buf))] = m
+
+	for sub := range b.subs {
+		if sub.owner != e.OwnerID {
+			continue
+		}
+		select {
+		case sub.c <- m:
+		default:
+			b.dropLocked(sub)
+		}
+	}
+}
+
+// Subscribe starts delivering the changes of owner published from now on.
+// It also returns the buffered changes of owner published after lastID;
+// complete is false when some of them are no longer buffered, or lastID
+// was never published, so the subscriber should reload its state.
+func (b *Broker) Subscribe(owner int, lastID uint64) (sub *Subscription, missed []Message, complete bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	sub = &Subscription{b: b, owner: owner, c: make(chan Message, subscriberQueue)}
+	if b.closed {
+		close(sub.c)
+		return sub, nil, true
+	}
+	b.subs[sub] = struct{}{}
+
+	oldest := uint64(1)
+	if b.lastID > uint64(len(b.buf)) {
+		oldest = b.lastID - uint64(len(b.buf)) + 1
+	}
+	complete = lastID <= b.lastID && lastID+1 >= oldest
+	for id := max(lastID+1, oldest); id <= b.lastID; id++ {
+		if m := b.buf[id%uint64(len(b.buf))]; m.Event.OwnerID == owner {
+			missed = append(missed, m)
+		}
+	}
+	return sub, missed, complete
+}
+
+// LastID returns the ID of the latest message, 0 if there is none.
+func (b *Broker) LastID() uint64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.lastID
+}
+
+// Close ends every subscription; later Publish calls are ignored.
+func (b *Broker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	for sub := range b.subs {
+		b.dropLocked(sub)
+	}
+}
+
+func (b *Broker) dropLocked(sub *Subscription) {
+	if _, ok := b.subs[sub]; ok {
+		delete(b.subs, sub)
+		close(sub.c)
+	}
+}
+
+// Subscription receives the changes published after Subscribe.
+type Subscription struct {
+	b     *Broker
+	owner int
+	c     chan Message
+}
+
+// C delivers the changes in order. It is closed when the subscriber falls
+// too far behind, on Close and when the broker is closed.
+func (s *Subscription) C() <-chan Message {
+	return s.c
+}
+
+// Close stops the deliveries. It is safe to call more than once.
+func (s *Subscription) Close() {
+	s.b.mu.Lock()
+	defer s.b.mu.Unlock()
+
+	s.b.dropLocked(s)
+}
@@ -0,0 +1,96 @@
+package events
+
+import (
+	"testing"
+
+	"github.com/Saintrad/todo-server-client/internal/todo"
+)
+
+func publish(b *Broker, owner, taskID int) {
+	b.Publish(todo.Event{TaskID: taskID, OwnerID: owner, Kind: todo.EventCreated}, todo.Task{ID: taskID, OwnerID: owner})
+}
+
+func TestBrokerDelivers(t *testing.T) {
+	b := NewBroker(10)
+	sub, missed, complete := b.Subscribe(1, b.LastID())
+	defer sub.Close()
+	if len(missed) != 0 || !complete {
+		t.Fatalf("expected nothing missed, got %v, %v", missed, complete)
+	}
+
+	// Check only the owner's changes are delivered, in order
+	publish(b, 1, 10)
+	publish(b, 2, 20)
+	publish(b, 1, 11)
+	for _, want := range []int{10, 11} {
+		if m := <-sub.C(); m.Task.ID != want {
+			t.Fatalf("expected task %d, got %+v", want, m)
+		}
+	}
+
+	// Check Close ends the subscription
+	sub.Close()
+	sub.Close()
+	if _, ok := <-sub.C(); ok {
+		t.Fatalf("expected closed channel")
+	}
+}
+
+func TestBrokerReplay(t *testing.T) {
+	b := NewBroker(3)
+	for id := 1; id <= 5; id++ {
+		publish(b, 1, id)
+	}
+
+	// Messages 3 to 5 are buffered
+	sub, missed, complete := b.Subscribe(1, 3)
+	sub.Close()
+	if !complete || len(missed) != 2 || missed[0].ID != 4 || missed[1].ID != 5 {
+		t.Fatalf("expected messages 4 and 5, got %+v, %v", missed, complete)
+	}
+
+	// Check a gap is reported
+	sub, missed, complete = b.Subscribe(1, 1)
+	sub.Close()
+	if complete || len(missed) != 3 || missed[0].ID != 3 {
+		t.Fatalf("expected incomplete replay from 3, got %+v, %v", missed, complete)
+	}
+
+	// Check an ID from before a restart is reported
+	sub, _, complete = b.Subscribe(1, 99)
+	sub.Close()
+	if complete {
+		t.Fatalf("expected incomplete replay")
+	}
+
+	// Check other owners' messages are not replayed
+	sub, missed, _ = b.Subscribe(2, 3)
+	sub.Close()
+	if len(missed) != 0 {
+		t.Fatalf("expected no messages, got %+v", missed)
+	}
+}
+
+func TestBrokerDropsSlowSubscribers(t *testing.T) {
+	b := NewBroker(0)
+	sub, _, _ := b.Subscribe(1, 0)
+
+	for id := 1; id <= subscriberQueue+1; id++ {
+		publish(b, 1, id)
+	}
+	n := 0
+	for range sub.C() {
+		n++
+	}
+	if n != subscriberQueue {
+		t.Fatalf("expected %d messages before the drop, got %d", subscriberQueue, n)
+	}
+
+	// Check Close ends new and existing subscriptions
+	other, _, _ := b.Subscribe(1, b.LastID())
+	b.Close()
+	if _, ok := <-other.C(); ok {
+		t.Fatalf("expected closed channel")
+	}
+	publish(b, 1, 99)
+}
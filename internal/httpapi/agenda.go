@@ -0,0 +1,70 @@
+package httpapi
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Saintrad/todo-server-client/internal/todo"
+)
+
+var tzIssue = todo.FieldIssue{Field: "tz", Issue: "must be an IANA time zone such as Europe/Paris or a UTC offset such as +01:00"}
+
+// parseTimeZone reads the tz query parameter: an IANA time zone name or a
+// UTC offset. Empty is nil, which the domain takes as UTC.
+func parseTimeZone(s string) (*time.Location, bool) {
+	if s == "" {
+		return nil, true
+	}
+	// An unescaped + in a query string arrives as a space
+	if rest, ok := strings.CutPrefix(s, " "); ok {
+		s = "+" + rest
+	}
+	if t, err := time.Parse("Z07:00", s); err == nil {
+		_, offset := t.Zone()
+		return time.FixedZone(s, offset), true
+	}
+	// Local is the server's zone, meaningless to the caller
+	if s == "Local" {
+		return nil, false
+	}
+	loc, err := time.LoadLocation(s)
+	if err != nil {
+		return nil, false
+	}
+	return loc, true
+}
+
+// nowIn returns the current time in loc, UTC when nil.
+func nowIn(loc *time.Location) time.Time {
+	if loc == nil {
+		loc = time.UTC
+	}
+	return time.Now().In(loc)
+}
+
+// agendaHandler serves GET /v1/agenda: the open tasks that are overdue, due
+// today, due in the rest of the week and not due, in the tz query
+// parameter's zone. The other list parameters apply to every section.
+func (s *Server) agendaHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	q, err := ParseListQuery(r.URL.Query())
+	if err != nil {
+		s.writeDomainError(w, err)
+		return
+	}
+	q.Now = nowIn(q.Location)
+
+	agenda, err := s.service(r).Agenda(r.Context(), q)
+	if err != nil {
+		s.writeDomainError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, ToAgendaResponse(agenda, q.Now))
+}
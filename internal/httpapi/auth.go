@@ -0,0 +1,95 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/Saintrad/todo-server-client/internal/auth"
+)
+
+type ctxKey int
+
+const userKey ctxKey = iota
+
+func userFromContext(ctx context.Context) (auth.User, bool) {
+	u, ok := ctx.Value(userKey).(auth.User)
+	return u, ok
+}
+
+// requireAuth rejects requests without a valid bearer token and stores the
+// authenticated user in the request context.
+func (s *Server) requireAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || strings.TrimSpace(token) == "" {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="todo"`)
+			s.writeDomainError(w, auth.ErrInvalidToken)
+			return
+		}
+
+		u, err := s.auth.Authenticate(r.Context(), strings.TrimSpace(token))
+		if err != nil {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="todo", error="invalid_token"`)
+			s.writeDomainError(w, err)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), userKey, u)))
+	})
+}
+
+// POST /v2/auth/register
+func (s *Server) registerHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req CredentialsRequest
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		writeBadJSON(w, err)
+		return
+	}
+
+	u, err := s.auth.Register(r.Context(), auth.RegisterInput{Username: req.Username, Password: req.Password})
+	if err != nil {
+		s.writeDomainError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, ToUserResponse(u))
+}
+
+// POST /v2/auth/login
+func (s *Server) loginHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req CredentialsRequest
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		writeBadJSON(w, err)
+		return
+	}
+
+	tok, err := s.auth.Login(r.Context(), req.Username, req.Password)
+	if err != nil {
+		s.writeDomainError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, TokenResponse{
+		AccessToken: tok.Value,
+		TokenType:   "Bearer",
+		ExpiresAt:   tok.ExpiresAt,
+	})
+}
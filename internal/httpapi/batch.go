@@ -0,0 +1,84 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/Saintrad/todo-server-client/internal/todo"
+)
+
+// batchHandler serves POST /v1/tasks:batch. The request is rejected as a
+// whole if any operation is malformed. Otherwise the response is 200 with one
+// result per operation, unless an atomic batch fails: then nothing is
+// written and the response is the failing operation's error.
+func (s *Server) batchHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req BatchRequest
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		writeBadJSON(w, err)
+		return
+	}
+
+	ops, err := req.ToDomain()
+	if err != nil {
+		s.writeDomainError(w, err)
+		return
+	}
+
+	results, err := s.service(r).Batch(r.Context(), ops, req.Atomic)
+	if err != nil {
+		var be *todo.BatchError
+		if !errors.As(err, &be) {
+			s.writeDomainError(w, err)
+			return
+		}
+		writeBatchError(w, be, ops[be.Index])
+		return
+	}
+
+	resp := BatchResponse{Results: make([]BatchResult, len(results))}
+	for i, res := range results {
+		resp.Results[i] = toBatchResult(ops[i], res)
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func toBatchResult(op todo.BatchOp, res todo.BatchResult) BatchResult {
+	if res.Err != nil {
+		body := ToErrorResponse(publicError(res.Err)).Error
+		return BatchResult{Status: statusForError(res.Err, op.IfVersion != nil), Error: &body}
+	}
+
+	switch op.Kind {
+	case todo.BatchCreate:
+		task := ToTaskResponse(res.Task)
+		return BatchResult{Status: http.StatusCreated, Task: &task}
+	case todo.BatchDelete:
+		return BatchResult{Status: http.StatusNoContent}
+	default:
+		task := ToTaskResponse(res.Task)
+		return BatchResult{Status: http.StatusOK, Task: &task}
+	}
+}
+
+// writeBatchError reports the operation that aborted an atomic batch, with
+// its index in the message and in every detail field.
+func writeBatchError(w http.ResponseWriter, be *todo.BatchError, op todo.BatchOp) {
+	de := publicError(be.Err)
+	prefix := "operations[" + strconv.Itoa(be.Index) + "]"
+
+	out := &todo.Error{Code: de.Code, Message: prefix + ": " + de.Message}
+	for _, d := range de.Details {
+		out.Details = append(out.Details, todo.FieldIssue{Field: prefix + ".task." + d.Field, Issue: d.Issue})
+	}
+	writeJSON(w, statusForError(be.Err, op.IfVersion != nil), ToErrorResponse(out))
+}
@@ -1,69 +1,702 @@
 package httpapi
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/Saintrad/todo-server-client/internal/auth"
 	"github.com/Saintrad/todo-server-client/internal/todo"
+	"github.com/Saintrad/todo-server-client/internal/webhook"
 )
 
 // POST /v1/tasks
 type CreateTaskRequest struct {
 	Title    string     `json:"title"`
 	Category *string    `json:"category,omitempty"`
+	Tags     []string   `json:"tags,omitempty"`
 	DueDate  *time.Time `json:"due_date,omitempty"`
+	ParentID *int       `json:"parent_id,omitempty"`
+	// Repeat is a recurrence rule such as "weekly:mon,thu".
+	Repeat *string `json:"repeat,omitempty"`
+	// Priority is none, low, medium or high.
+	Priority *string `json:"priority,omitempty"`
+	// Reminders are RFC 3339 times or offsets from the due date such as
+	// "due-1h".
+	Reminders []string `json:"reminders,omitempty"`
 }
 
 // PATCH /v1/tasks/{id}
+// Omitted fields are left unchanged; null clears nullable fields (API.md A1.4).
+// Tags replaces all tags, add_tags and remove_tags edit them.
 type UpdateTaskRequest struct {
-	Title    *string    `json:"title,omitempty"`
-	Category *string    `json:"category,omitempty"`
-	DueDate  *time.Time `json:"due_date,omitempty"`
-	IsDone   *bool      `json:"is_done,omitempty"`
+	Title      *string             `json:"title,omitempty"`
+	Category   Optional[string]    `json:"category"`
+	Tags       Optional[[]string]  `json:"tags"`
+	AddTags    []string            `json:"add_tags,omitempty"`
+	RemoveTags []string            `json:"remove_tags,omitempty"`
+	DueDate    Optional[time.Time] `json:"due_date"`
+	IsDone     *bool               `json:"is_done,omitempty"`
+	ParentID   Optional[int]       `json:"parent_id"`
+	Repeat     Optional[string]    `json:"repeat"`
+	Priority   *string             `json:"priority,omitempty"`
+	Reminders  Optional[[]string]  `json:"reminders"`
+}
+
+// empty reports whether the request changes nothing.
+func (r UpdateTaskRequest) empty() bool {
+	return r.Title == nil && !r.Category.Set && !r.Tags.Set && len(r.AddTags) == 0 && len(r.RemoveTags) == 0 &&
+		!r.DueDate.Set && r.IsDone == nil && !r.ParentID.Set && !r.Repeat.Set && r.Priority == nil &&
+		!r.Reminders.Set
+}
+
+// Optional tells an omitted JSON field apart from an explicit null.
+// Set is true whenever the field was present in the body.
+type Optional[T any] struct {
+	Set   bool
+	Value *T
+}
+
+func (o *Optional[T]) UnmarshalJSON(b []byte) error {
+	o.Set = true
+	if bytes.Equal(b, []byte("null")) {
+		o.Value = nil
+		return nil
+	}
+	var v T
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	o.Value = &v
+	return nil
+}
+
+func (o Optional[T]) toDomain() todo.Optional[T] {
+	return todo.Optional[T]{Set: o.Set, Value: o.Value}
 }
 
 type TaskResponse struct {
 	ID        int        `json:"id"`
 	Title     string     `json:"title"`
 	Category  *string    `json:"category,omitempty"`
+	Tags      []string   `json:"tags,omitempty"`
 	DueDate   *time.Time `json:"due_date,omitempty"`
 	IsDone    bool       `json:"is_done"`
+	ParentID  *int       `json:"parent_id,omitempty"`
+	Repeat    string     `json:"repeat,omitempty"`
+	Priority  string     `json:"priority"`
+	Reminders []string   `json:"reminders,omitempty"`
+	// IsOverdue and DueIn are computed for the caller's time zone (the tz
+	// query parameter, UTC by default). DueIn is in days, negative once
+	// the due day passed.
+	IsOverdue bool       `json:"is_overdue"`
+	DueIn     *int       `json:"due_in,omitempty"`
 	CreatedAt time.Time  `json:"created_at"`
 	UpdatedAt time.Time  `json:"updated_at,omitempty"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	Version   int        `json:"version"`
+}
+
+// GET /v1/tasks
+type TaskListResponse struct {
+	Items  []TaskResponse `json:"items"`
+	Total  int            `json:"total"`
+	Limit  int            `json:"limit"`
+	Offset int            `json:"offset"`
+}
+
+// GET /v1/agenda
+type AgendaResponse struct {
+	// Date is today in TimeZone, as YYYY-MM-DD.
+	Date      string           `json:"date"`
+	TimeZone  string           `json:"time_zone"`
+	Overdue   TaskListResponse `json:"overdue"`
+	Today     TaskListResponse `json:"today"`
+	Upcoming  TaskListResponse `json:"upcoming"`
+	NoDueDate TaskListResponse `json:"no_due_date"`
+}
+
+// DELETE /v1/trash
+type PurgeResponse struct {
+	Purged int `json:"purged"`
+}
+
+// GET /v1/tags
+type TagListResponse struct {
+	Items []TagCountResponse `json:"items"`
+}
+
+type TagCountResponse struct {
+	Tag   string `json:"tag"`
+	Count int    `json:"count"`
+}
+
+// PATCH /v1/tags/{tag}
+type RenameTagRequest struct {
+	Name string `json:"name"`
+}
+
+// PATCH and DELETE /v1/tags/{tag}: the resulting tag and how many tasks
+// were changed.
+type TagChangeResponse struct {
+	Tag   string `json:"tag"`
+	Tasks int    `json:"tasks"`
+}
+
+// GET /v1/tasks/{id}/history
+type HistoryResponse struct {
+	Items []EventResponse `json:"items"`
+}
+
+type EventResponse struct {
+	ID      int              `json:"id"`
+	TaskID  int              `json:"task_id"`
+	Kind    string           `json:"kind"`
+	Actor   string           `json:"actor,omitempty"`
+	At      time.Time        `json:"at"`
+	Changes []ChangeResponse `json:"changes"`
+}
+
+// ChangeResponse is one field of a task before and after a change; a
+// missing old or new value means the field was unset.
+type ChangeResponse struct {
+	Field string  `json:"field"`
+	Old   *string `json:"old,omitempty"`
+	New   *string `json:"new,omitempty"`
+}
+
+// The data of a GET /v1/events event, and the body of webhook deliveries.
+// The SSE event's id and name carry the message ID and kind.
+type StreamEventResponse struct {
+	Kind    string           `json:"kind"`
+	TaskID  int              `json:"task_id"`
+	Actor   string           `json:"actor,omitempty"`
+	At      time.Time        `json:"at"`
+	Changes []ChangeResponse `json:"changes"`
+	Task    TaskResponse     `json:"task"`
+}
+
+// POST /v1/webhooks
+type CreateWebhookRequest struct {
+	URL    string   `json:"url"`
+	Events []string `json:"events"`
+	Secret string   `json:"secret"`
+}
+
+// PATCH /v1/webhooks/{id}
+type UpdateWebhookRequest struct {
+	URL    *string   `json:"url"`
+	Events *[]string `json:"events"`
+	Secret *string   `json:"secret"`
+}
+
+// WebhookResponse shows the secret only in the response to its creation.
+type WebhookResponse struct {
+	ID        int       `json:"id"`
+	URL       string    `json:"url"`
+	Events    []string  `json:"events"`
+	Secret    string    `json:"secret,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// GET /v1/webhooks
+type WebhookListResponse struct {
+	Items []WebhookResponse `json:"items"`
+}
+
+// GET /v1/webhooks/{id}/deliveries
+type DeliveryListResponse struct {
+	Items []DeliveryResponse `json:"items"`
+}
+
+type DeliveryResponse struct {
+	ID         int       `json:"id"`
+	Key        string    `json:"key"`
+	Event      string    `json:"event"`
+	TaskID     int       `json:"task_id"`
+	Attempt    int       `json:"attempt"`
+	StatusCode int       `json:"status_code,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	DurationMS int64     `json:"duration_ms"`
+	At         time.Time `json:"at"`
+}
+
+// POST /v1/tasks:import: the outcome counts and one result per record, in
+// file order.
+type ImportResponse struct {
+	DryRun  bool           `json:"dry_run"`
+	Created int            `json:"created"`
+	Updated int            `json:"updated"`
+	Skipped int            `json:"skipped"`
+	Failed  int            `json:"failed"`
+	Results []ImportResult `json:"results"`
+}
+
+// ImportResult is the outcome of one record: created, updated or skipped
+// with the task, or failed with the error.
+type ImportResult struct {
+	Row    int           `json:"row"`
+	Status string        `json:"status"`
+	Task   *TaskResponse `json:"task,omitempty"`
+	Error  *ErrorBody    `json:"error,omitempty"`
+}
+
+// POST /v2/auth/register, POST /v2/auth/login
+type CredentialsRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+type UserResponse struct {
+	ID        int       `json:"id"`
+	Username  string    `json:"username"`
+	CreatedAt time.Time `json:"created_at"`
 }
 
+type TokenResponse struct {
+	AccessToken string    `json:"access_token"`
+	TokenType   string    `json:"token_type"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+// ErrorResponse is the standard error envelope (API.md §3).
 type ErrorResponse struct {
-	Error string `json:"error"`
+	Error ErrorBody `json:"error"`
+}
+
+// POST /v1/tasks:batch
+type BatchRequest struct {
+	// Atomic applies all operations or none.
+	Atomic     bool             `json:"atomic"`
+	Operations []BatchOperation `json:"operations"`
+}
+
+// BatchOperation is one create, update or delete. Task holds a
+// CreateTaskRequest or UpdateTaskRequest, depending on Op.
+type BatchOperation struct {
+	Op        string          `json:"op"`
+	ID        int             `json:"id,omitempty"`
+	IfVersion *int            `json:"if_version,omitempty"`
+	Task      json.RawMessage `json:"task,omitempty"`
+}
+
+type BatchResponse struct {
+	Results []BatchResult `json:"results"`
+}
+
+// BatchResult mirrors the response the operation would get on its own:
+// status 201, 200 or 204 with the task, or an error status and body.
+type BatchResult struct {
+	Status int           `json:"status"`
+	Task   *TaskResponse `json:"task,omitempty"`
+	Error  *ErrorBody    `json:"error,omitempty"`
+}
+
+type ErrorBody struct {
+	Code    string        `json:"code"`
+	Message string        `json:"message"`
+	Details []ErrorDetail `json:"details,omitempty"`
+}
+
+type ErrorDetail struct {
+	Field string `json:"field"`
+	Issue string `json:"issue"`
 }
 
 // ---------- Mapping helpers (DTO -> domain) ----------
 
+// ParseListQuery reads the list query parameters (API.md A1.2). Range and
+// enum checks are left to todo.ListQuery.Normalize.
+func ParseListQuery(v url.Values) (todo.ListQuery, error) {
+	var q todo.ListQuery
+	var issues []todo.FieldIssue
+
+	if raw := v.Get("is_done"); raw != "" {
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			issues = append(issues, todo.FieldIssue{Field: "is_done", Issue: "must be true or false"})
+		} else {
+			q.IsDone = &b
+		}
+	}
+
+	q.Search = v.Get("q")
+
+	// Tag lists may be comma-separated, repeated or both
+	for _, p := range []struct {
+		name string
+		dst  *[]string
+	}{{"tags_any", &q.TagsAny}, {"tags_all", &q.TagsAll}, {"tags_none", &q.TagsNone}} {
+		for _, raw := range v[p.name] {
+			*p.dst = append(*p.dst, strings.Split(raw, ",")...)
+		}
+	}
+
+	for _, raw := range v["priority"] {
+		for _, name := range strings.Split(raw, ",") {
+			p, err := todo.ParsePriority(name)
+			if err != nil {
+				issues = append(issues, todo.FieldIssue{Field: "priority", Issue: "must be a comma-separated list of none, low, medium, high"})
+				break
+			}
+			q.Priorities = append(q.Priorities, p)
+		}
+	}
+
+	// parent_id=null lists the top-level tasks
+	if raw := v.Get("parent_id"); raw != "" {
+		if raw == "null" {
+			q.ParentID = todo.Clear[int]()
+		} else if id, err := strconv.Atoi(raw); err == nil && id > 0 {
+			q.ParentID = todo.Some(id)
+		} else {
+			issues = append(issues, todo.FieldIssue{Field: "parent_id", Issue: "must be a task ID or null"})
+		}
+	}
+
+	q.Due = todo.DueFilter(v.Get("due"))
+	if loc, ok := parseTimeZone(v.Get("tz")); ok {
+		q.Location = loc
+	} else {
+		issues = append(issues, tzIssue)
+	}
+
+	q.Sort = todo.SortField(v.Get("sort"))
+	if q.Sort == "due_at" {
+		// API.md spells the field due_at; accept both names.
+		q.Sort = todo.SortDueDate
+	}
+	q.Order = todo.SortOrder(v.Get("order"))
+
+	for _, p := range []struct {
+		name string
+		dst  *int
+	}{{"limit", &q.Limit}, {"offset", &q.Offset}} {
+		raw := v.Get(p.name)
+		if raw == "" {
+			continue
+		}
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			issues = append(issues, todo.FieldIssue{Field: p.name, Issue: "must be an integer"})
+			continue
+		}
+		*p.dst = n
+	}
+
+	if len(issues) > 0 {
+		return todo.ListQuery{}, todo.NewValidationError(issues...)
+	}
+	return q, nil
+}
+
 func (r CreateTaskRequest) ToDomain() todo.CreateTaskInput {
 	return todo.CreateTaskInput{
-		Title:    r.Title,
-		Category: r.Category,
-		DueDate:  r.DueDate,
+		Title:     r.Title,
+		Category:  r.Category,
+		Tags:      r.Tags,
+		DueDate:   r.DueDate,
+		ParentID:  r.ParentID,
+		Repeat:    r.Repeat,
+		Priority:  r.Priority,
+		Reminders: r.Reminders,
 	}
 }
 
 func (r UpdateTaskRequest) ToDomain() todo.UpdateTaskInput {
 	return todo.UpdateTaskInput{
-		Title:    r.Title,
-		Category: r.Category,
-		DueDate:  r.DueDate,
-		IsDone:   r.IsDone,
+		Title:      r.Title,
+		Category:   r.Category.toDomain(),
+		Tags:       r.Tags.toDomain(),
+		AddTags:    r.AddTags,
+		RemoveTags: r.RemoveTags,
+		DueDate:    r.DueDate.toDomain(),
+		IsDone:     r.IsDone,
+		ParentID:   r.ParentID.toDomain(),
+		Repeat:     r.Repeat.toDomain(),
+		Priority:   r.Priority,
+		Reminders:  r.Reminders.toDomain(),
 	}
 }
 
+// ToDomain checks the shape of every operation and decodes its task. All
+// problems are reported at once, with fields like "operations[2].id".
+func (r BatchRequest) ToDomain() ([]todo.BatchOp, error) {
+	ops := make([]todo.BatchOp, len(r.Operations))
+	var issues []todo.FieldIssue
+
+	for i, o := range r.Operations {
+		field := "operations[" + strconv.Itoa(i) + "]."
+		issue := func(name, msg string) {
+			issues = append(issues, todo.FieldIssue{Field: field + name, Issue: msg})
+		}
+
+		op := todo.BatchOp{Kind: todo.BatchOpKind(o.Op), ID: o.ID, IfVersion: o.IfVersion}
+
+		switch op.Kind {
+		case todo.BatchCreate:
+			var req CreateTaskRequest
+			if err := decodeBatchTask(o.Task, &req); err != nil {
+				issue("task", err.Error())
+			}
+			op.Create = req.ToDomain()
+			if o.ID != 0 || o.IfVersion != nil {
+				issue("id", "not allowed for create")
+			}
+
+		case todo.BatchUpdate:
+			var req UpdateTaskRequest
+			if err := decodeBatchTask(o.Task, &req); err != nil {
+				issue("task", err.Error())
+			} else if req.empty() {
+				issue("task", "no fields provided for update")
+			}
+			op.Update = req.ToDomain()
+
+		case todo.BatchDelete:
+			if len(o.Task) > 0 {
+				issue("task", "not allowed for delete")
+			}
+
+		default:
+			issue("op", "must be create, update or delete")
+		}
+
+		if op.Kind == todo.BatchUpdate || op.Kind == todo.BatchDelete {
+			if o.ID <= 0 {
+				issue("id", "must be a positive task ID")
+			}
+			if o.IfVersion != nil && *o.IfVersion <= 0 {
+				issue("if_version", "must be positive")
+			}
+		}
+
+		ops[i] = op
+	}
+
+	if len(issues) > 0 {
+		return nil, todo.NewValidationError(issues...)
+	}
+	return ops, nil
+}
+
+func decodeBatchTask(raw json.RawMessage, dst any) error {
+	if len(raw) == 0 {
+		return errors.New("is required")
+	}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.DisallowUnknownFields()
+	return dec.Decode(dst)
+}
+
 // ---------- Mapping helper (domain -> DTO) ----------
 
+// ToTaskResponse maps t with its computed fields judged in UTC.
 func ToTaskResponse(t todo.Task) TaskResponse {
-	return TaskResponse{
+	return ToTaskResponseAt(t, time.Now().UTC())
+}
+
+// ToTaskResponseAt maps t with its computed fields judged at now, in now's
+// location.
+func ToTaskResponseAt(t todo.Task, now time.Time) TaskResponse {
+	resp := TaskResponse{
 		ID:        t.ID,
 		Title:     t.Title,
 		Category:  t.Category,
+		Tags:      t.Tags,
 		DueDate:   t.DueDate,
 		IsDone:    t.IsDone,
+		ParentID:  t.ParentID,
+		Priority:  t.Priority.String(),
 		CreatedAt: t.CreatedAt,
 		UpdatedAt: t.UpdatedAt,
+		DeletedAt: t.DeletedAt,
+		Version:   t.Version,
+	}
+	if t.Recurrence != nil {
+		resp.Repeat = t.Recurrence.String()
+	}
+	for _, r := range t.Reminders {
+		resp.Reminders = append(resp.Reminders, r.String())
+	}
+	if days, ok := t.DueIn(now); ok {
+		resp.DueIn = &days
+		resp.IsOverdue = t.IsOverdue(now)
+	}
+	return resp
+}
+
+func ToTaskListResponse(p todo.TaskPage) TaskListResponse {
+	return ToTaskListResponseAt(p, time.Now().UTC())
+}
+
+func ToTaskListResponseAt(p todo.TaskPage, now time.Time) TaskListResponse {
+	out := TaskListResponse{
+		Items:  make([]TaskResponse, 0, len(p.Items)),
+		Total:  p.Total,
+		Limit:  p.Limit,
+		Offset: p.Offset,
+	}
+	for _, t := range p.Items {
+		out.Items = append(out.Items, ToTaskResponseAt(t, now))
+	}
+	return out
+}
+
+func ToAgendaResponse(a todo.Agenda, now time.Time) AgendaResponse {
+	return AgendaResponse{
+		Date:      a.Day.Format(time.DateOnly),
+		TimeZone:  now.Location().String(),
+		Overdue:   ToTaskListResponseAt(a.Overdue, now),
+		Today:     ToTaskListResponseAt(a.Today, now),
+		Upcoming:  ToTaskListResponseAt(a.Upcoming, now),
+		NoDueDate: ToTaskListResponseAt(a.NoDueDate, now),
+	}
+}
+
+func ToTagListResponse(tags []todo.TagCount) TagListResponse {
+	out := TagListResponse{Items: make([]TagCountResponse, 0, len(tags))}
+	for _, t := range tags {
+		out.Items = append(out.Items, TagCountResponse{Tag: t.Tag, Count: t.Count})
+	}
+	return out
+}
+
+func ToHistoryResponse(history []todo.Event) HistoryResponse {
+	out := HistoryResponse{Items: make([]EventResponse, 0, len(history))}
+	for _, e := range history {
+		out.Items = append(out.Items, EventResponse{
+			ID:      e.ID,
+			TaskID:  e.TaskID,
+			Kind:    string(e.Kind),
+			Actor:   e.Actor,
+			At:      e.At,
+			Changes: toChangeResponses(e.Changes),
+		})
+	}
+	return out
+}
+
+func ToStreamEventResponse(e todo.Event, t todo.Task) StreamEventResponse {
+	return StreamEventResponse{
+		Kind:    string(e.Kind),
+		TaskID:  e.TaskID,
+		Actor:   e.Actor,
+		At:      e.At,
+		Changes: toChangeResponses(e.Changes),
+		Task:    ToTaskResponse(t),
+	}
+}
+
+// EncodeEvent is the webhook.Encoder of the server: deliveries carry the
+// same JSON as the event stream.
+func EncodeEvent(e todo.Event, t todo.Task) ([]byte, error) {
+	return json.Marshal(ToStreamEventResponse(e, t))
+}
+
+func toChangeResponses(changes []todo.Change) []ChangeResponse {
+	out := make([]ChangeResponse, 0, len(changes))
+	for _, c := range changes {
+		out = append(out, ChangeResponse{Field: c.Field, Old: c.Old, New: c.New})
+	}
+	return out
+}
+
+func (r CreateWebhookRequest) ToDomain() webhook.CreateInput {
+	return webhook.CreateInput{URL: r.URL, Events: r.Events, Secret: r.Secret}
+}
+
+func (r UpdateWebhookRequest) ToDomain() webhook.UpdateInput {
+	return webhook.UpdateInput{URL: r.URL, Events: r.Events, Secret: r.Secret}
+}
+
+func ToWebhookResponse(w webhook.Webhook) WebhookResponse {
+	events := make([]string, len(w.Events))
+	for i, k := range w.Events {
+		events[i] = string(k)
+	}
+	return WebhookResponse{
+		ID:        w.ID,
+		URL:       w.URL,
+		Events:    events,
+		CreatedAt: w.CreatedAt,
+		UpdatedAt: w.UpdatedAt,
+	}
+}
+
+func ToWebhookListResponse(hooks []webhook.Webhook) WebhookListResponse {
+	out := WebhookListResponse{Items: make([]WebhookResponse, 0, len(hooks))}
+	for _, w := range hooks {
+		out.Items = append(out.Items, ToWebhookResponse(w))
+	}
+	return out
+}
+
+func ToDeliveryListResponse(log []webhook.Delivery) DeliveryListResponse {
+	out := DeliveryListResponse{Items: make([]DeliveryResponse, 0, len(log))}
+	for _, d := range log {
+		out.Items = append(out.Items, DeliveryResponse{
+			ID:         d.ID,
+			Key:        d.Key,
+			Event:      string(d.Event),
+			TaskID:     d.TaskID,
+			Attempt:    d.Attempt,
+			StatusCode: d.StatusCode,
+			Error:      d.Error,
+			DurationMS: d.Duration.Milliseconds(),
+			At:         d.At,
+		})
+	}
+	return out
+}
+
+func ToImportResponse(results []todo.ImportResult, dryRun bool) ImportResponse {
+	out := ImportResponse{DryRun: dryRun, Results: make([]ImportResult, 0, len(results))}
+	for _, res := range results {
+		item := ImportResult{Row: res.Row, Status: string(res.Outcome)}
+		switch res.Outcome {
+		case todo.ImportCreated:
+			out.Created++
+		case todo.ImportUpdated:
+			out.Updated++
+		case todo.ImportSkipped:
+			out.Skipped++
+		case todo.ImportFailed:
+			out.Failed++
+		}
+		if res.Err != nil {
+			body := ToErrorResponse(publicError(res.Err)).Error
+			item.Error = &body
+		} else {
+			task := ToTaskResponse(res.Task)
+			item.Task = &task
+		}
+		out.Results = append(out.Results, item)
+	}
+	return out
+}
+
+func ToUserResponse(u auth.User) UserResponse {
+	return UserResponse{
+		ID:        u.ID,
+		Username:  u.Username,
+		CreatedAt: u.CreatedAt,
+	}
+}
+
+// ToErrorResponse maps a domain error to the wire error envelope.
+func ToErrorResponse(e *todo.Error) ErrorResponse {
+	body := ErrorBody{
+		Code:    string(e.Code),
+		Message: e.Message,
+	}
+	for _, d := range e.Details {
+		body.Details = append(body.Details, ErrorDetail{Field: d.Field, Issue: d.Issue})
 	}
+	return ErrorResponse{Error: body}
 }
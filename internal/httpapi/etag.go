@@ -0,0 +1,34 @@
+package httpapi
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Saintrad/todo-server-client/internal/todo"
+)
+
+// A task's ETag is its version as a strong entity tag, e.g. "3".
+func formatETag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// parseIfMatch reads the If-Match header as the task version the client
+// expects. A missing header and "*" impose no condition and yield nil.
+// Only a single strong ETag as issued by formatETag is accepted.
+func parseIfMatch(h http.Header) (*int, error) {
+	v := strings.TrimSpace(h.Get("If-Match"))
+	if v == "" || v == "*" {
+		return nil, nil
+	}
+
+	unquoted, ok := strings.CutPrefix(v, `"`)
+	if ok {
+		unquoted, ok = strings.CutSuffix(unquoted, `"`)
+	}
+	version, err := strconv.Atoi(unquoted)
+	if !ok || err != nil || version <= 0 {
+		return nil, todo.NewValidationError(todo.FieldIssue{Field: "If-Match", Issue: `must be a single ETag such as "3"`})
+	}
+	return &version, nil
+}
@@ -0,0 +1,95 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Saintrad/todo-server-client/internal/events"
+	"github.com/Saintrad/todo-server-client/internal/todo"
+)
+
+// keepAliveInterval is how often an idle event stream sends a comment so
+// proxies do not close it.
+var keepAliveInterval = 20 * time.Second
+
+// eventsHandler serves GET /v1/events, a text/event-stream of the caller's
+// task changes. A client that reconnects with Last-Event-ID first gets the
+// changes it missed; if they are no longer buffered, or the ID is from
+// before a server restart, it gets a "reset" event and should reload its
+// tasks.
+func (s *Server) eventsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	lastID, current := s.events.LastID(), true
+	if h := strings.TrimSpace(r.Header.Get("Last-Event-ID")); h != "" {
+		epoch, seq, _ := strings.Cut(h, "-")
+		id, err := strconv.ParseUint(seq, 10, 64)
+		if err != nil || epoch == "" {
+			s.writeDomainError(w, todo.NewValidationError(todo.FieldIssue{Field: "Last-Event-ID", Issue: "must be an event ID from this stream"}))
+			return
+		}
+		// IDs of an earlier process would replay unrelated changes
+		if current = epoch == s.events.Epoch(); current {
+			lastID = id
+		}
+	}
+
+	sub, missed, complete := s.events.Subscribe(ownerID(r), lastID)
+	defer sub.Close()
+	complete = complete && current
+
+	// The stream outlives the server's write timeout
+	rc := http.NewResponseController(w)
+	_ = rc.SetWriteDeadline(time.Time{})
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+
+	if !complete {
+		fmt.Fprint(w, "event: reset\ndata: {}\n\n")
+	}
+	for _, m := range missed {
+		s.writeEvent(w, m)
+	}
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	keepAlive := time.NewTicker(keepAliveInterval)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case m, ok := <-sub.C():
+			if !ok {
+				// Dropped for falling behind or shutting down; the client
+				// resumes from its Last-Event-ID
+				return
+			}
+			s.writeEvent(w, m)
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// writeEvent writes m as one SSE event named after its kind. Its ID is
+// prefixed with the broker's epoch, so IDs stay unique across restarts.
+func (s *Server) writeEvent(w http.ResponseWriter, m events.Message) {
+	data, _ := json.Marshal(ToStreamEventResponse(m.Event, m.Task))
+	fmt.Fprintf(w, "id: %s-%d\nevent: %s\ndata: %s\n\n", s.events.Epoch(), m.ID, m.Event.Kind, data)
+}
@@ -6,16 +6,40 @@ import (
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/Saintrad/todo-server-client/internal/auth"
+	"github.com/Saintrad/todo-server-client/internal/events"
 	"github.com/Saintrad/todo-server-client/internal/todo"
+	"github.com/Saintrad/todo-server-client/internal/webhook"
 )
 
 type Server struct {
-	svc todo.Service
+	svc      todo.Service
+	auth     *auth.Service
+	events   *events.Broker
+	webhooks *webhook.Service
 }
 
-func NewServer(svc todo.Service) *Server {
-	return &Server{svc: svc}
+type ServerOption func(*Server)
+
+// WithEvents serves the changes published to b at /v1/events.
+func WithEvents(b *events.Broker) ServerOption {
+	return func(s *Server) { s.events = b }
+}
+
+// WithWebhooks serves webhook management at /v1/webhooks.
+func WithWebhooks(w *webhook.Service) ServerOption {
+	return func(s *Server) { s.webhooks = w }
+}
+
+// NewServer creates the HTTP API. A nil auth service disables the v2 routes.
+func NewServer(svc todo.Service, authSvc *auth.Service, opts ...ServerOption) *Server {
+	s := &Server{svc: svc, auth: authSvc}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
 func (s *Server) Routes() http.Handler {
@@ -23,65 +47,153 @@ func (s *Server) Routes() http.Handler {
 
 	mux.HandleFunc("/v1/tasks", s.tasksHandler)     // exact path
 	mux.HandleFunc("/v1/tasks/", s.taskByIDHandler) // prefix match
+	mux.HandleFunc("/v1/tasks:batch", s.batchHandler)
+	mux.HandleFunc("/v1/tasks:export", s.exportHandler)
+	mux.HandleFunc("/v1/tasks:import", s.importHandler)
+	mux.HandleFunc("/v1/tasks.ics", s.icsHandler)
+	mux.HandleFunc("/v1/agenda", s.agendaHandler)
+	mux.HandleFunc("/v1/tags", s.tagsHandler)
+	mux.HandleFunc("/v1/tags/", s.tagHandler)
+	mux.HandleFunc("/v1/trash", s.trashHandler)
+	mux.HandleFunc("/v1/trash/", s.trashItemHandler)
+	if s.events != nil {
+		mux.HandleFunc("/v1/events", s.eventsHandler)
+	}
+	if s.webhooks != nil {
+		mux.HandleFunc("/v1/webhooks", s.webhooksHandler)
+		mux.HandleFunc("/v1/webhooks/", s.webhookHandler)
+	}
+
+	if s.auth != nil {
+		mux.HandleFunc("/v2/auth/register", s.registerHandler)
+		mux.HandleFunc("/v2/auth/login", s.loginHandler)
+		mux.Handle("/v2/tasks", s.requireAuth(http.HandlerFunc(s.tasksHandler)))
+		mux.Handle("/v2/tasks/", s.requireAuth(http.HandlerFunc(s.taskByIDHandler)))
+		mux.Handle("/v2/tasks:batch", s.requireAuth(http.HandlerFunc(s.batchHandler)))
+		mux.Handle("/v2/tasks:export", s.requireAuth(http.HandlerFunc(s.exportHandler)))
+		mux.Handle("/v2/tasks:import", s.requireAuth(http.HandlerFunc(s.importHandler)))
+		mux.Handle("/v2/tasks.ics", s.requireAuth(http.HandlerFunc(s.icsHandler)))
+		mux.Handle("/v2/agenda", s.requireAuth(http.HandlerFunc(s.agendaHandler)))
+		mux.Handle("/v2/tags", s.requireAuth(http.HandlerFunc(s.tagsHandler)))
+		mux.Handle("/v2/tags/", s.requireAuth(http.HandlerFunc(s.tagHandler)))
+		mux.Handle("/v2/trash", s.requireAuth(http.HandlerFunc(s.trashHandler)))
+		mux.Handle("/v2/trash/", s.requireAuth(http.HandlerFunc(s.trashItemHandler)))
+		if s.events != nil {
+			mux.Handle("/v2/events", s.requireAuth(http.HandlerFunc(s.eventsHandler)))
+		}
+		if s.webhooks != nil {
+			mux.Handle("/v2/webhooks", s.requireAuth(http.HandlerFunc(s.webhooksHandler)))
+			mux.Handle("/v2/webhooks/", s.requireAuth(http.HandlerFunc(s.webhookHandler)))
+		}
+	}
 
 	return mux
 }
 
+// service returns the task service scoped to the authenticated v2 user, or
+// the unscoped v1 service when the request carries no user.
+func (s *Server) service(r *http.Request) todo.Service {
+	if u, ok := userFromContext(r.Context()); ok {
+		return s.svc.ForOwner(u.ID).As(u.Username)
+	}
+	return s.svc
+}
+
+// ownerID returns the ID of the v2 user making r, 0 for v1 requests.
+func ownerID(r *http.Request) int {
+	if u, ok := userFromContext(r.Context()); ok {
+		return u.ID
+	}
+	return 0
+}
+
 func (s *Server) taskByIDHandler(w http.ResponseWriter, r *http.Request) {
 	path := r.URL.Path
-	tail := strings.TrimPrefix(path, "/v1/tasks/")
-	if tail == path || tail == "" {
+	_, tail, found := strings.Cut(path, "/tasks/")
+	if !found || tail == "" {
 		http.NotFound(w, r)
 		return
 	}
-	if strings.Contains(tail, "/") {
+	tail, sub, hasSub := strings.Cut(tail, "/")
+
+	id, err := strconv.Atoi(tail)
+	if err != nil || id <= 0 {
 		http.NotFound(w, r)
 		return
 	}
 
-	id, err := strconv.Atoi(tail)
-	if err != nil || id <= 0 {
+	switch {
+	case !hasSub:
+	case sub == "children":
+		s.childrenHandler(w, r, id)
+		return
+	case sub == "history":
+		s.historyHandler(w, r, id)
+		return
+	default:
 		http.NotFound(w, r)
 		return
 	}
 
+	svc := s.service(r)
+	loc, ok := parseTimeZone(r.URL.Query().Get("tz"))
+	if !ok {
+		s.writeDomainError(w, todo.NewValidationError(tzIssue))
+		return
+	}
+
 	switch r.Method {
 	case http.MethodGet:
-		task, err := s.svc.GetByID(id)
+		task, err := svc.GetByID(r.Context(), id)
 		if err != nil {
 			s.writeDomainError(w, err)
 			return
 		}
-		writeJSON(w, http.StatusOK, ToTaskResponse(task))
+		writeTask(w, http.StatusOK, task, loc)
 
 	case http.MethodPatch:
+		ifVersion, err := parseIfMatch(r.Header)
+		if err != nil {
+			s.writeDomainError(w, err)
+			return
+		}
+
 		var req UpdateTaskRequest
 		dec := json.NewDecoder(r.Body)
 		dec.DisallowUnknownFields()
 		if err := dec.Decode(&req); err != nil {
-			writeError(w, http.StatusBadRequest, "invalid JSON body")
+			writeBadJSON(w, err)
 			return
 		}
 
 		// Optional: reject empty PATCH (no fields provided)
-		if req.Title == nil && req.Category == nil && req.DueDate == nil && req.IsDone == nil {
-			writeError(w, http.StatusBadRequest, "no fields provided for update")
+		if req.empty() {
+			s.writeDomainError(w, todo.NewValidationError(todo.FieldIssue{Field: "body", Issue: "no fields provided for update"}))
 			return
 		}
 
-		task, err := s.svc.UpdateTask(id, req.ToDomain())
+		in := req.ToDomain()
+		in.IfVersion = ifVersion
+
+		task, err := svc.UpdateTask(r.Context(), id, in)
 		if err != nil {
-			s.writeDomainError(w, err)
+			s.writeVersionedError(w, err, ifVersion)
 			return
 		}
-		writeJSON(w, http.StatusOK, ToTaskResponse(task))
+		writeTask(w, http.StatusOK, task, loc)
 
 	case http.MethodDelete:
-		_, err := s.svc.Delete(id)
+		ifVersion, err := parseIfMatch(r.Header)
 		if err != nil {
 			s.writeDomainError(w, err)
 			return
 		}
+
+		_, err = svc.Delete(r.Context(), id, ifVersion)
+		if err != nil {
+			s.writeVersionedError(w, err, ifVersion)
+			return
+		}
 		// Common: 204 No Content on successful delete
 		w.WriteHeader(http.StatusNoContent)
 
@@ -91,48 +203,86 @@ func (s *Server) taskByIDHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// childrenHandler serves GET /v1/tasks/{id}/children: the direct subtasks
+// of a task, with the same query parameters as the task list.
+func (s *Server) childrenHandler(w http.ResponseWriter, r *http.Request, id int) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	q, err := ParseListQuery(r.URL.Query())
+	if err != nil {
+		s.writeDomainError(w, err)
+		return
+	}
+	q.Now = nowIn(q.Location)
+
+	page, err := s.service(r).ListChildren(r.Context(), id, q)
+	if err != nil {
+		s.writeDomainError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, ToTaskListResponseAt(page, q.Now))
+}
+
+// historyHandler serves GET /v1/tasks/{id}/history: the changes made to a
+// task, oldest first. It stays available after the task is purged.
+func (s *Server) historyHandler(w http.ResponseWriter, r *http.Request, id int) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	history, err := s.service(r).History(r.Context(), id)
+	if err != nil {
+		s.writeDomainError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, ToHistoryResponse(history))
+}
 
 func (s *Server) tasksHandler(w http.ResponseWriter, r *http.Request) {
+	svc := s.service(r)
+
 	switch r.Method {
 	case http.MethodGet:
-		tasks, err := s.svc.ListTask()
+		q, err := ParseListQuery(r.URL.Query())
 		if err != nil {
 			s.writeDomainError(w, err)
 			return
 		}
+		q.Now = nowIn(q.Location)
 
-		// Convert domain tasks to response DTOs
-		out := make([]TaskResponse, 0, len(tasks))
-		for _, t := range tasks {
-			out = append(out, ToTaskResponse(t))
+		page, err := svc.ListTask(r.Context(), q)
+		if err != nil {
+			s.writeDomainError(w, err)
+			return
 		}
 
-		writeJSON(w, http.StatusOK, out)
+		writeJSON(w, http.StatusOK, ToTaskListResponseAt(page, q.Now))
 
 	case http.MethodPost:
 		var req CreateTaskRequest
 		dec := json.NewDecoder(r.Body)
 		dec.DisallowUnknownFields()
 		if err := dec.Decode(&req); err != nil {
-			writeError(w, http.StatusBadRequest, "invalid JSON body")
-			return
-		}
-
-		// Minimal validation at transport boundary (optional).
-		// Domain should still validate as the source of truth.
-		if req.Title == "" {
-			writeError(w, http.StatusBadRequest, "title is required")
+			writeBadJSON(w, err)
 			return
 		}
 
-		task, err := s.svc.CreateTask(req.ToDomain())
+		task, err := svc.CreateTask(r.Context(), req.ToDomain())
 		if err != nil {
 			s.writeDomainError(w, err)
 			return
 		}
 
 		// 201 for resource creation
-		writeJSON(w, http.StatusCreated, ToTaskResponse(task))
+		writeTask(w, http.StatusCreated, task, nil)
 
 	default:
 		w.Header().Set("Allow", "GET, POST")
@@ -140,19 +290,64 @@ func (s *Server) tasksHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-// writeDomainError maps domain sentinel errors to HTTP status codes and returns JSON error body.
+// writeDomainError maps domain errors to HTTP status codes and returns JSON error body.
 func (s *Server) writeDomainError(w http.ResponseWriter, err error) {
-	switch {
-	case errors.Is(err, todo.ErrTaskNotFound):
-		writeError(w, http.StatusNotFound, "task not found")
-	case errors.Is(err, todo.ErrEmptyTitle):
-		writeError(w, http.StatusBadRequest, "title is required")
+	de := publicError(err)
+	writeJSON(w, statusForCode(de.Code), ToErrorResponse(de))
+}
+
+// writeVersionedError is writeDomainError for calls that may carry an
+// If-Match precondition: a stale version the client asserted is 412, while
+// a conflict without one stays 409.
+func (s *Server) writeVersionedError(w http.ResponseWriter, err error, ifVersion *int) {
+	de := publicError(err)
+	writeJSON(w, statusForError(err, ifVersion != nil), ToErrorResponse(de))
+}
+
+// publicError returns the domain error carried by err, or a generic internal
+// error so internal details do not leak to clients.
+func publicError(err error) *todo.Error {
+	var de *todo.Error
+	if !errors.As(err, &de) || de.Code == todo.CodeInternal {
+		return &todo.Error{Code: todo.CodeInternal, Message: "internal server error"}
+	}
+	return de
+}
+
+// statusForError is statusForCode, except that a version conflict on a call
+// with a precondition is 412 Precondition Failed.
+func statusForError(err error, preconditioned bool) int {
+	if preconditioned && errors.Is(err, todo.ErrVersionConflict) {
+		return http.StatusPreconditionFailed
+	}
+	return statusForCode(publicError(err).Code)
+}
+
+// statusForCode maps error codes to HTTP status codes (API.md §3.1).
+func statusForCode(code todo.ErrorCode) int {
+	switch code {
+	case todo.CodeValidation:
+		return http.StatusBadRequest
+	case todo.CodeNotFound:
+		return http.StatusNotFound
+	case todo.CodeUnauthorized:
+		return http.StatusUnauthorized
+	case todo.CodeForbidden:
+		return http.StatusForbidden
+	case todo.CodeConflict:
+		return http.StatusConflict
 	default:
-		// Avoid leaking internal details to clients
-		writeError(w, http.StatusInternalServerError, "internal server error")
+		return http.StatusInternalServerError
 	}
 }
 
+// writeTask writes a single task with its version as the ETag, judging
+// its computed fields in loc (UTC when nil).
+func writeTask(w http.ResponseWriter, status int, t todo.Task, loc *time.Location) {
+	w.Header().Set("ETag", formatETag(t.Version))
+	writeJSON(w, status, ToTaskResponseAt(t, nowIn(loc)))
+}
+
 // writeJSON writes a JSON response with status code.
 func writeJSON(w http.ResponseWriter, status int, v any) {
 	w.Header().Set("Content-Type", "application/json")
@@ -160,7 +355,9 @@ func writeJSON(w http.ResponseWriter, status int, v any) {
 	_ = json.NewEncoder(w).Encode(v)
 }
 
-// writeError writes a consistent JSON error shape.
-func writeError(w http.ResponseWriter, status int, msg string) {
-	writeJSON(w, status, ErrorResponse{Error: msg})
+// writeBadJSON reports a request body that could not be decoded.
+func writeBadJSON(w http.ResponseWriter, err error) {
+	de := todo.NewValidationError(todo.FieldIssue{Field: "body", Issue: err.Error()})
+	de.Message = "invalid JSON body"
+	writeJSON(w, http.StatusBadRequest, ToErrorResponse(de))
 }
@@ -0,0 +1,1347 @@
+package httpapi
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/Saintrad/todo-server-client/internal/auth"
+	"github.com/Saintrad/todo-server-client/internal/events"
+	"github.com/Saintrad/todo-server-client/internal/storage"
+	"github.com/Saintrad/todo-server-client/internal/todo"
+	"github.com/Saintrad/todo-server-client/internal/webhook"
+)
+
+func newTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	repo, err := storage.NewFileTaskRepo(filepath.Join(t.TempDir(), "tasks.json"))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	return newTestServerWithRepo(t, repo)
+}
+
+func newTestServerWithRepo(t *testing.T, repo todo.TaskRepo) *httptest.Server {
+	t.Helper()
+	t.Cleanup(func() { repo.Close() })
+
+	authSvc, err := auth.NewService(storage.NewMemoryUserRepo(), []byte("test-secret"), auth.WithHashCost(bcrypt.MinCost))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	ts := httptest.NewServer(NewServer(todo.NewService(repo), authSvc).Routes())
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func decodeError(t *testing.T, resp *http.Response) ErrorBody {
+	t.Helper()
+
+	var body ErrorResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatalf("expected JSON error body, got %v", err)
+	}
+	return body.Error
+}
+
+func TestErrorModel(t *testing.T) {
+	ts := newTestServer(t)
+
+	// Check validation error with field details
+	resp, err := http.Post(ts.URL+"/v1/tasks", "application/json", strings.NewReader(`{"title":""}`))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected status 400, got %d", resp.StatusCode)
+	}
+	body := decodeError(t, resp)
+	if body.Code != string(todo.CodeValidation) {
+		t.Fatalf("expected code %s, got %s", todo.CodeValidation, body.Code)
+	}
+	if len(body.Details) != 1 || body.Details[0].Field != "title" {
+		t.Fatalf("expected one title detail, got %v", body.Details)
+	}
+
+	// Check unknown fields are rejected as validation errors
+	resp, err = http.Post(ts.URL+"/v1/tasks", "application/json", strings.NewReader(`{"title":"x","color":"red"}`))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	defer resp.Body.Close()
+
+	if body := decodeError(t, resp); body.Code != string(todo.CodeValidation) {
+		t.Fatalf("expected code %s, got %s", todo.CodeValidation, body.Code)
+	}
+
+	// Check not found
+	resp, err = http.Get(ts.URL + "/v1/tasks/42")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected status 404, got %d", resp.StatusCode)
+	}
+	if body := decodeError(t, resp); body.Code != string(todo.CodeNotFound) {
+		t.Fatalf("expected code %s, got %s", todo.CodeNotFound, body.Code)
+	}
+}
+
+func TestListTasksQuery(t *testing.T) {
+	ts := newTestServer(t)
+
+	for _, title := range []string{"alpha", "beta", "gamma"} {
+		resp, err := http.Post(ts.URL+"/v1/tasks", "application/json", strings.NewReader(`{"title":"`+title+`"}`))
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		resp.Body.Close()
+	}
+
+	resp, err := http.Get(ts.URL + "/v1/tasks?sort=created_at&order=asc&limit=2&offset=1")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	defer resp.Body.Close()
+
+	var list TaskListResponse
+	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if list.Total != 3 || list.Limit != 2 || list.Offset != 1 {
+		t.Fatalf("unexpected envelope %+v", list)
+	}
+	if len(list.Items) != 2 || list.Items[0].Title != "beta" {
+		t.Fatalf("unexpected items %+v", list.Items)
+	}
+
+	// Check invalid parameters
+	resp, err = http.Get(ts.URL + "/v1/tasks?limit=abc")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected status 400, got %d", resp.StatusCode)
+	}
+	if body := decodeError(t, resp); len(body.Details) != 1 || body.Details[0].Field != "limit" {
+		t.Fatalf("expected limit detail, got %v", body.Details)
+	}
+}
+
+func TestPatchNullClearsFields(t *testing.T) {
+	ts := newTestServer(t)
+
+	resp, err := http.Post(ts.URL+"/v1/tasks", "application/json",
+		strings.NewReader(`{"title":"pay rent","category":"home","due_date":"2026-02-01T00:00:00Z"}`))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	resp.Body.Close()
+
+	patch := func(body string) TaskResponse {
+		t.Helper()
+
+		req, _ := http.NewRequest(http.MethodPatch, ts.URL+"/v1/tasks/1", strings.NewReader(body))
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", resp.StatusCode)
+		}
+		var task TaskResponse
+		if err := json.NewDecoder(resp.Body).Decode(&task); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		return task
+	}
+
+	// Check omitted fields are unchanged
+	task := patch(`{"is_done":true}`)
+	if task.Category == nil || task.DueDate == nil {
+		t.Fatalf("omitted fields were cleared: %+v", task)
+	}
+
+	// Check null clears only the given field
+	task = patch(`{"category":null}`)
+	if task.Category != nil {
+		t.Fatalf("expected category to be cleared, got %v", *task.Category)
+	}
+	if task.DueDate == nil {
+		t.Fatalf("due date should not be cleared")
+	}
+
+	task = patch(`{"due_date":null}`)
+	if task.DueDate != nil {
+		t.Fatalf("expected due date to be cleared, got %v", task.DueDate)
+	}
+}
+
+func TestV2TasksAreScopedPerUser(t *testing.T) {
+	ts := newTestServer(t)
+
+	do := func(method, path, token, body string) *http.Response {
+		t.Helper()
+
+		req, _ := http.NewRequest(method, ts.URL+path, strings.NewReader(body))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		t.Cleanup(func() { resp.Body.Close() })
+		return resp
+	}
+
+	login := func(username string) string {
+		t.Helper()
+
+		creds := `{"username":"` + username + `","password":"password123"}`
+		if resp := do(http.MethodPost, "/v2/auth/register", "", creds); resp.StatusCode != http.StatusCreated {
+			t.Fatalf("expected status 201, got %d", resp.StatusCode)
+		}
+		var tok TokenResponse
+		json.NewDecoder(do(http.MethodPost, "/v2/auth/login", "", creds).Body).Decode(&tok)
+		return tok.AccessToken
+	}
+
+	alice := login("alice")
+	bob := login("bob")
+
+	// Check duplicate registration
+	resp := do(http.MethodPost, "/v2/auth/register", "", `{"username":"alice","password":"password123"}`)
+	if resp.StatusCode != http.StatusConflict {
+		t.Fatalf("expected status 409, got %d", resp.StatusCode)
+	}
+
+	// Check missing token
+	resp = do(http.MethodGet, "/v2/tasks", "", "")
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected status 401, got %d", resp.StatusCode)
+	}
+	if body := decodeError(t, resp); body.Code != string(todo.CodeUnauthorized) {
+		t.Fatalf("expected code %s, got %s", todo.CodeUnauthorized, body.Code)
+	}
+
+	resp = do(http.MethodPost, "/v2/tasks", alice, `{"title":"alice's task"}`)
+	var task TaskResponse
+	json.NewDecoder(resp.Body).Decode(&task)
+
+	// Check bob can neither list, read, change nor delete alice's task
+	var list TaskListResponse
+	json.NewDecoder(do(http.MethodGet, "/v2/tasks", bob, "").Body).Decode(&list)
+	if list.Total != 0 {
+		t.Fatalf("expected bob to see no tasks, got %d", list.Total)
+	}
+
+	path := "/v2/tasks/" + strconv.Itoa(task.ID)
+	for _, method := range []string{http.MethodGet, http.MethodPatch, http.MethodDelete} {
+		if resp := do(method, path, bob, `{"is_done":true}`); resp.StatusCode != http.StatusNotFound {
+			t.Fatalf("%s: expected status 404, got %d", method, resp.StatusCode)
+		}
+	}
+	if resp := do(http.MethodGet, path, alice, ""); resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", resp.StatusCode)
+	}
+
+	// Check v1 is unaffected and does not see v2 tasks
+	list = TaskListResponse{}
+	json.NewDecoder(do(http.MethodGet, "/v1/tasks", "", "").Body).Decode(&list)
+	if list.Total != 0 {
+		t.Fatalf("expected v1 to see no v2 tasks, got %d", list.Total)
+	}
+}
+
+func TestIfMatch(t *testing.T) {
+	ts := newTestServer(t)
+
+	resp, err := http.Post(ts.URL+"/v1/tasks", "application/json", strings.NewReader(`{"title":"shared"}`))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	resp.Body.Close()
+	if etag := resp.Header.Get("ETag"); etag != `"1"` {
+		t.Fatalf(`expected ETag "1" on create, got %s`, etag)
+	}
+
+	send := func(method, ifMatch, body string) *http.Response {
+		t.Helper()
+
+		req, _ := http.NewRequest(method, ts.URL+"/v1/tasks/1", strings.NewReader(body))
+		if ifMatch != "" {
+			req.Header.Set("If-Match", ifMatch)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		t.Cleanup(func() { resp.Body.Close() })
+		return resp
+	}
+
+	// Check the current version is accepted and bumped
+	resp = send(http.MethodPatch, `"1"`, `{"title":"first"}`)
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("ETag") != `"2"` {
+		t.Fatalf("expected 200 with ETag \"2\", got %d %s", resp.StatusCode, resp.Header.Get("ETag"))
+	}
+
+	// Check a stale version is rejected with 412 and the CONFLICT code
+	for _, method := range []string{http.MethodPatch, http.MethodDelete} {
+		resp = send(method, `"1"`, `{"title":"lost"}`)
+		if resp.StatusCode != http.StatusPreconditionFailed {
+			t.Fatalf("%s: expected status 412, got %d", method, resp.StatusCode)
+		}
+		if body := decodeError(t, resp); body.Code != string(todo.CodeConflict) {
+			t.Fatalf("%s: expected code %s, got %s", method, todo.CodeConflict, body.Code)
+		}
+	}
+
+	// Check malformed headers
+	resp = send(http.MethodPatch, `W/"2"`, `{"title":"weak"}`)
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected status 400, got %d", resp.StatusCode)
+	}
+
+	resp = send(http.MethodGet, "", "")
+	var task TaskResponse
+	json.NewDecoder(resp.Body).Decode(&task)
+	if task.Title != "first" || task.Version != 2 || resp.Header.Get("ETag") != `"2"` {
+		t.Fatalf("expected first at version 2, got %+v", task)
+	}
+
+	resp = send(http.MethodDelete, `"2"`, "")
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("expected status 204, got %d", resp.StatusCode)
+	}
+}
+
+// TestConcurrentRequests hammers one task from many goroutines; run it with
+// -race. Compare-and-swap increments must all land exactly once and only
+// one of several racing deletes may succeed, whatever the backend.
+func TestConcurrentRequests(t *testing.T) {
+	backends := map[string]func(t *testing.T) todo.TaskRepo{
+		"memory": func(t *testing.T) todo.TaskRepo { return storage.NewMemoryTaskRepo() },
+		"file": func(t *testing.T) todo.TaskRepo {
+			repo, err := storage.NewFileTaskRepo(filepath.Join(t.TempDir(), "tasks.json"))
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			return repo
+		},
+		"journal": func(t *testing.T) todo.TaskRepo {
+			repo, err := storage.NewFileTaskRepo(filepath.Join(t.TempDir(), "tasks.json"), storage.WithJournal(16))
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			return repo
+		},
+		"sqlite": func(t *testing.T) todo.TaskRepo {
+			db, err := storage.OpenSQLite(filepath.Join(t.TempDir(), "tasks.db"))
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			return storage.NewSQLiteTaskRepo(db)
+		},
+	}
+
+	for name, newRepo := range backends {
+		t.Run(name, func(t *testing.T) {
+			testConcurrentRequests(t, newTestServerWithRepo(t, newRepo(t)))
+		})
+	}
+}
+
+func testConcurrentRequests(t *testing.T, ts *httptest.Server) {
+	const workers = 8
+	const perWorker = 10
+
+	do := func(method, path, ifMatch, body string) (int, string, TaskResponse) {
+		req, _ := http.NewRequest(method, ts.URL+path, strings.NewReader(body))
+		if ifMatch != "" {
+			req.Header.Set("If-Match", ifMatch)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Errorf("%s %s: expected no error, got %v", method, path, err)
+			return 0, "", TaskResponse{}
+		}
+		defer resp.Body.Close()
+
+		var task TaskResponse
+		if resp.StatusCode < 300 && resp.StatusCode != http.StatusNoContent {
+			json.NewDecoder(resp.Body).Decode(&task)
+		}
+		return resp.StatusCode, resp.Header.Get("ETag"), task
+	}
+
+	if status, _, _ := do(http.MethodPost, "/v1/tasks", "", `{"title":"0"}`); status != http.StatusCreated {
+		t.Fatalf("expected status 201, got %d", status)
+	}
+	if status, _, _ := do(http.MethodPost, "/v1/tasks", "", `{"title":"doomed"}`); status != http.StatusCreated {
+		t.Fatalf("expected status 201, got %d", status)
+	}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	deletes := 0
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			// Read-increment-write with If-Match, retrying lost races
+			for i := 0; i < perWorker; {
+				_, etag, task := do(http.MethodGet, "/v1/tasks/1", "", "")
+				n, _ := strconv.Atoi(task.Title)
+
+				status, _, _ := do(http.MethodPatch, "/v1/tasks/1", etag, `{"title":"`+strconv.Itoa(n+1)+`"}`)
+				switch status {
+				case http.StatusOK:
+					i++
+				case http.StatusPreconditionFailed:
+				default:
+					t.Errorf("PATCH: expected status 200 or 412, got %d", status)
+					return
+				}
+			}
+
+			// Unconditional updates never fail on contention
+			if status, _, _ := do(http.MethodPatch, "/v1/tasks/1", "", `{"is_done":true}`); status != http.StatusOK {
+				t.Errorf("PATCH: expected status 200, got %d", status)
+			}
+
+			switch status, _, _ := do(http.MethodDelete, "/v1/tasks/2", "", ""); status {
+			case http.StatusNoContent:
+				mu.Lock()
+				deletes++
+				mu.Unlock()
+			case http.StatusNotFound:
+			default:
+				t.Errorf("DELETE: expected status 204 or 404, got %d", status)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if deletes != 1 {
+		t.Fatalf("expected exactly one successful delete, got %d", deletes)
+	}
+
+	_, _, task := do(http.MethodGet, "/v1/tasks/1", "", "")
+	if want := strconv.Itoa(workers * perWorker); task.Title != want {
+		t.Fatalf("expected counter %s, got %s", want, task.Title)
+	}
+	if want := workers*perWorker + workers + 1; task.Version != want {
+		t.Fatalf("expected version %d, got %d", want, task.Version)
+	}
+}
+
+func TestBatch(t *testing.T) {
+	ts := newTestServer(t)
+
+	post := func(body string) *http.Response {
+		t.Helper()
+
+		resp, err := http.Post(ts.URL+"/v1/tasks:batch", "application/json", strings.NewReader(body))
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		t.Cleanup(func() { resp.Body.Close() })
+		return resp
+	}
+
+	// Check every operation gets its own result
+	resp := post(`{"operations":[
+		{"op":"create","task":{"title":"a"}},
+		{"op":"create","task":{"title":"b","category":"work"}},
+		{"op":"update","id":1,"if_version":1,"task":{"is_done":true}},
+		{"op":"update","id":2,"if_version":7,"task":{"category":null}},
+		{"op":"delete","id":42}
+	]}`)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", resp.StatusCode)
+	}
+	var out BatchResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	wantStatus := []int{http.StatusCreated, http.StatusCreated, http.StatusOK, http.StatusPreconditionFailed, http.StatusNotFound}
+	for i, want := range wantStatus {
+		if out.Results[i].Status != want {
+			t.Fatalf("result %d: expected status %d, got %+v", i, want, out.Results[i])
+		}
+	}
+	if task := out.Results[2].Task; task == nil || !task.IsDone || task.Version != 2 {
+		t.Fatalf("expected task 1 done at version 2, got %+v", task)
+	}
+	if e := out.Results[4].Error; e == nil || e.Code != string(todo.CodeNotFound) {
+		t.Fatalf("expected NOT_FOUND, got %+v", e)
+	}
+
+	// Check an atomic batch is all or nothing
+	resp = post(`{"atomic":true,"operations":[
+		{"op":"create","task":{"title":"c"}},
+		{"op":"update","id":2,"task":{"title":"   "}}
+	]}`)
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected status 400, got %d", resp.StatusCode)
+	}
+	body := decodeError(t, resp)
+	if len(body.Details) != 1 || body.Details[0].Field != "operations[1].task.title" {
+		t.Fatalf("expected detail for operations[1].task.title, got %+v", body)
+	}
+
+	list, err := http.Get(ts.URL + "/v1/tasks")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	defer list.Body.Close()
+	var page TaskListResponse
+	json.NewDecoder(list.Body).Decode(&page)
+	if page.Total != 2 {
+		t.Fatalf("expected atomic batch to create nothing, got %d tasks", page.Total)
+	}
+
+	// Check malformed operations reject the whole request
+	resp = post(`{"operations":[{"op":"create"},{"op":"delete"},{"op":"rename","id":1}]}`)
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected status 400, got %d", resp.StatusCode)
+	}
+	body = decodeError(t, resp)
+	fields := make([]string, 0, len(body.Details))
+	for _, d := range body.Details {
+		fields = append(fields, d.Field)
+	}
+	if strings.Join(fields, ",") != "operations[0].task,operations[1].id,operations[2].op" {
+		t.Fatalf("unexpected details %+v", body.Details)
+	}
+}
+
+func TestSubtasks(t *testing.T) {
+	ts := newTestServer(t)
+
+	send := func(method, path, body string) *http.Response {
+		t.Helper()
+
+		req, _ := http.NewRequest(method, ts.URL+path, strings.NewReader(body))
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		t.Cleanup(func() { resp.Body.Close() })
+		return resp
+	}
+	list := func(path string) TaskListResponse {
+		t.Helper()
+
+		resp := send(http.MethodGet, path, "")
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", resp.StatusCode)
+		}
+		var page TaskListResponse
+		if err := json.NewDecoder(resp.Body).Decode(&page); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		return page
+	}
+
+	send(http.MethodPost, "/v1/tasks", `{"title":"epic"}`)
+	resp := send(http.MethodPost, "/v1/tasks", `{"title":"step","parent_id":1}`)
+	var step TaskResponse
+	json.NewDecoder(resp.Body).Decode(&step)
+	if resp.StatusCode != http.StatusCreated || step.ParentID == nil || *step.ParentID != 1 {
+		t.Fatalf("expected a subtask of 1, got %d %+v", resp.StatusCode, step)
+	}
+
+	// Check children and the parent_id filter
+	page := list("/v1/tasks/1/children")
+	if page.Total != 1 || page.Items[0].ID != step.ID {
+		t.Fatalf("expected step as the only child, got %+v", page.Items)
+	}
+	page = list("/v1/tasks?parent_id=null")
+	if page.Total != 1 || page.Items[0].ID != 1 {
+		t.Fatalf("expected epic as the only top-level task, got %+v", page.Items)
+	}
+
+	// Check invalid parents and orphaning deletes are refused
+	resp = send(http.MethodPost, "/v1/tasks", `{"title":"orphan","parent_id":99}`)
+	if resp.StatusCode != http.StatusBadRequest || decodeError(t, resp).Details[0].Field != "parent_id" {
+		t.Fatalf("expected 400 on parent_id, got %d", resp.StatusCode)
+	}
+	resp = send(http.MethodPatch, "/v1/tasks/1", `{"parent_id":2}`)
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected status 400 for a cycle, got %d", resp.StatusCode)
+	}
+	resp = send(http.MethodDelete, "/v1/tasks/1", "")
+	if resp.StatusCode != http.StatusConflict {
+		t.Fatalf("expected status 409, got %d", resp.StatusCode)
+	}
+
+	// Check null detaches the subtask
+	resp = send(http.MethodPatch, "/v1/tasks/2", `{"parent_id":null}`)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", resp.StatusCode)
+	}
+	if page := list("/v1/tasks/1/children"); page.Total != 0 {
+		t.Fatalf("expected no children, got %+v", page.Items)
+	}
+
+	for _, path := range []string{"/v1/tasks/99/children", "/v1/tasks/1/parents", "/v1/tasks/1/"} {
+		if resp := send(http.MethodGet, path, ""); resp.StatusCode != http.StatusNotFound {
+			t.Fatalf("%s: expected status 404, got %d", path, resp.StatusCode)
+		}
+	}
+}
+
+func TestRecurringTask(t *testing.T) {
+	ts := newTestServer(t)
+
+	resp, err := http.Post(ts.URL+"/v1/tasks", "application/json", strings.NewReader(`{"title":"chores","repeat":"weekly: Thu,Mon"}`))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	var task TaskResponse
+	json.NewDecoder(resp.Body).Decode(&task)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated || task.Repeat != "weekly:mon,thu" {
+		t.Fatalf("expected the normalized rule, got %d %+v", resp.StatusCode, task)
+	}
+
+	// Check an invalid rule is reported on its field
+	resp, _ = http.Post(ts.URL+"/v1/tasks", "application/json", strings.NewReader(`{"title":"chores","repeat":"sometimes"}`))
+	if body := decodeError(t, resp); resp.StatusCode != http.StatusBadRequest || body.Details[0].Field != "repeat" {
+		t.Fatalf("expected 400 on repeat, got %d %+v", resp.StatusCode, body)
+	}
+	resp.Body.Close()
+
+	// Check completing creates the next occurrence
+	req, _ := http.NewRequest(http.MethodPatch, ts.URL+"/v1/tasks/1", strings.NewReader(`{"is_done":true}`))
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	resp.Body.Close()
+
+	resp, err = http.Get(ts.URL + "/v1/tasks?is_done=false")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	var page TaskListResponse
+	json.NewDecoder(resp.Body).Decode(&page)
+	resp.Body.Close()
+	if page.Total != 1 || page.Items[0].ID == 1 || page.Items[0].Repeat != "weekly:mon,thu" || page.Items[0].DueDate == nil {
+		t.Fatalf("expected one new open occurrence, got %+v", page.Items)
+	}
+}
+
+func TestReminders(t *testing.T) {
+	ts := newTestServer(t)
+
+	resp, err := http.Post(ts.URL+"/v1/tasks", "application/json",
+		strings.NewReader(`{"title":"dentist","due_date":"2026-03-02T09:00:00Z","reminders":["due - 1d","2026-03-01T18:00:00+01:00"]}`))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	var task TaskResponse
+	json.NewDecoder(resp.Body).Decode(&task)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated || strings.Join(task.Reminders, ",") != "2026-03-01T17:00:00Z,due-1d" {
+		t.Fatalf("expected the normalized reminders, got %d %+v", resp.StatusCode, task)
+	}
+
+	// Check an invalid reminder is reported on its field
+	resp, _ = http.Post(ts.URL+"/v1/tasks", "application/json", strings.NewReader(`{"title":"x","reminders":["tomorrow"]}`))
+	if body := decodeError(t, resp); resp.StatusCode != http.StatusBadRequest || body.Details[0].Field != "reminders" {
+		t.Fatalf("expected 400 on reminders, got %d %+v", resp.StatusCode, body)
+	}
+	resp.Body.Close()
+
+	// Check null clears them
+	req, _ := http.NewRequest(http.MethodPatch, ts.URL+"/v1/tasks/1", strings.NewReader(`{"reminders":null}`))
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	task = TaskResponse{}
+	json.NewDecoder(resp.Body).Decode(&task)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || task.Reminders != nil {
+		t.Fatalf("expected the reminders to be cleared, got %d %+v", resp.StatusCode, task)
+	}
+}
+
+func TestTags(t *testing.T) {
+	ts := newTestServer(t)
+
+	send := func(method, path, body string) *http.Response {
+		t.Helper()
+
+		req, _ := http.NewRequest(method, ts.URL+path, strings.NewReader(body))
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		t.Cleanup(func() { resp.Body.Close() })
+		return resp
+	}
+	titles := func(query string) string {
+		t.Helper()
+
+		var page TaskListResponse
+		json.NewDecoder(send(http.MethodGet, "/v1/tasks?sort=created_at&order=asc&"+query, "").Body).Decode(&page)
+		var out []string
+		for _, task := range page.Items {
+			out = append(out, task.Title)
+		}
+		return strings.Join(out, ",")
+	}
+
+	send(http.MethodPost, "/v1/tasks", `{"title":"a","tags":["Work","urgent"]}`)
+	send(http.MethodPost, "/v1/tasks", `{"title":"b","tags":["work"]}`)
+	send(http.MethodPost, "/v1/tasks", `{"title":"c"}`)
+
+	// Check the filters, comma-separated and repeated
+	if got := titles("tags_all=work,urgent"); got != "a" {
+		t.Fatalf("expected a, got %q", got)
+	}
+	if got := titles("tags_any=urgent&tags_any=work"); got != "a,b" {
+		t.Fatalf("expected a,b, got %q", got)
+	}
+	if got := titles("tags_none=urgent"); got != "b,c" {
+		t.Fatalf("expected b,c, got %q", got)
+	}
+
+	// Check PATCH edits the tags
+	resp := send(http.MethodPatch, "/v1/tasks/3", `{"add_tags":["home"]}`)
+	var task TaskResponse
+	json.NewDecoder(resp.Body).Decode(&task)
+	if resp.StatusCode != http.StatusOK || len(task.Tags) != 1 || task.Tags[0] != "home" {
+		t.Fatalf("expected tag home, got %d %+v", resp.StatusCode, task)
+	}
+
+	var tags TagListResponse
+	json.NewDecoder(send(http.MethodGet, "/v1/tags", "").Body).Decode(&tags)
+	if len(tags.Items) != 3 || tags.Items[0] != (TagCountResponse{Tag: "work", Count: 2}) {
+		t.Fatalf("unexpected tags %+v", tags.Items)
+	}
+
+	// Check rename and delete report the tasks changed
+	var change TagChangeResponse
+	resp = send(http.MethodPatch, "/v1/tags/work", `{"name":"Job"}`)
+	json.NewDecoder(resp.Body).Decode(&change)
+	if resp.StatusCode != http.StatusOK || change != (TagChangeResponse{Tag: "job", Tasks: 2}) {
+		t.Fatalf("unexpected rename result %d %+v", resp.StatusCode, change)
+	}
+	resp = send(http.MethodDelete, "/v1/tags/job", "")
+	json.NewDecoder(resp.Body).Decode(&change)
+	if resp.StatusCode != http.StatusOK || change.Tasks != 2 {
+		t.Fatalf("unexpected delete result %d %+v", resp.StatusCode, change)
+	}
+	if resp := send(http.MethodDelete, "/v1/tags/job", ""); resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected status 404, got %d", resp.StatusCode)
+	}
+	if got := titles("tags_any=job,work"); got != "" {
+		t.Fatalf("expected no tasks, got %q", got)
+	}
+}
+
+func TestPriority(t *testing.T) {
+	ts := newTestServer(t)
+
+	for _, body := range []string{
+		`{"title":"later"}`,
+		`{"title":"important","priority":"high"}`,
+		`{"title":"late","due_date":"2020-01-01T00:00:00Z"}`,
+	} {
+		resp, err := http.Post(ts.URL+"/v1/tasks", "application/json", strings.NewReader(body))
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		resp.Body.Close()
+	}
+
+	// Check an invalid priority is reported on its field
+	resp, _ := http.Post(ts.URL+"/v1/tasks", "application/json", strings.NewReader(`{"title":"x","priority":"top"}`))
+	if body := decodeError(t, resp); resp.StatusCode != http.StatusBadRequest || body.Details[0].Field != "priority" {
+		t.Fatalf("expected 400 on priority, got %d %+v", resp.StatusCode, body)
+	}
+	resp.Body.Close()
+
+	list := func(query string) TaskListResponse {
+		t.Helper()
+
+		resp, err := http.Get(ts.URL + "/v1/tasks" + query)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", resp.StatusCode)
+		}
+		var page TaskListResponse
+		json.NewDecoder(resp.Body).Decode(&page)
+		return page
+	}
+
+	// Check the default order: overdue, then by priority
+	page := list("")
+	if page.Items[0].Title != "late" || page.Items[1].Title != "important" || page.Items[2].Priority != "none" {
+		t.Fatalf("unexpected default order %+v", page.Items)
+	}
+
+	page = list("?priority=high,low")
+	if page.Total != 1 || page.Items[0].Priority != "high" {
+		t.Fatalf("expected only the high priority task, got %+v", page.Items)
+	}
+
+	resp, _ = http.Get(ts.URL + "/v1/tasks?priority=top")
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected status 400, got %d", resp.StatusCode)
+	}
+	resp.Body.Close()
+}
+
+func TestTrash(t *testing.T) {
+	ts := newTestServer(t)
+
+	send := func(method, path string) *http.Response {
+		t.Helper()
+
+		req, _ := http.NewRequest(method, ts.URL+path, nil)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		t.Cleanup(func() { resp.Body.Close() })
+		return resp
+	}
+
+	for _, title := range []string{"a", "b"} {
+		resp, _ := http.Post(ts.URL+"/v1/tasks", "application/json", strings.NewReader(`{"title":"`+title+`"}`))
+		resp.Body.Close()
+	}
+	send(http.MethodDelete, "/v1/tasks/1")
+	send(http.MethodDelete, "/v1/tasks/2")
+
+	// Check deleted tasks are hidden but listed in the trash
+	if resp := send(http.MethodGet, "/v1/tasks/1"); resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected status 404, got %d", resp.StatusCode)
+	}
+	var page TaskListResponse
+	json.NewDecoder(send(http.MethodGet, "/v1/trash").Body).Decode(&page)
+	if page.Total != 2 || page.Items[0].Title != "b" || page.Items[0].DeletedAt == nil {
+		t.Fatalf("unexpected trash %+v", page)
+	}
+
+	resp := send(http.MethodPost, "/v1/trash/1/restore")
+	var task TaskResponse
+	json.NewDecoder(resp.Body).Decode(&task)
+	if resp.StatusCode != http.StatusOK || task.ID != 1 || task.DeletedAt != nil {
+		t.Fatalf("expected task 1 restored, got %d %+v", resp.StatusCode, task)
+	}
+	if resp := send(http.MethodGet, "/v1/tasks/1"); resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", resp.StatusCode)
+	}
+
+	// Check purge and its errors
+	if resp := send(http.MethodDelete, "/v1/trash/1"); resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected status 404 for a live task, got %d", resp.StatusCode)
+	}
+	if resp := send(http.MethodDelete, "/v1/trash/2"); resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("expected status 204, got %d", resp.StatusCode)
+	}
+	if resp := send(http.MethodPost, "/v1/trash/2/restore"); resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected status 404, got %d", resp.StatusCode)
+	}
+
+	send(http.MethodDelete, "/v1/tasks/1")
+	var purged PurgeResponse
+	json.NewDecoder(send(http.MethodDelete, "/v1/trash").Body).Decode(&purged)
+	if purged.Purged != 1 {
+		t.Fatalf("expected 1 task purged, got %+v", purged)
+	}
+	if resp := send(http.MethodGet, "/v1/trash/1/undo"); resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected status 404, got %d", resp.StatusCode)
+	}
+}
+
+func TestTaskHistory(t *testing.T) {
+	repo := storage.NewMemoryTaskRepo()
+	t.Cleanup(func() { repo.Close() })
+	authSvc, err := auth.NewService(storage.NewMemoryUserRepo(), []byte("test-secret"), auth.WithHashCost(bcrypt.MinCost))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	svc := todo.NewService(repo, todo.WithHistory(storage.NewMemoryHistoryRepo()))
+	ts := httptest.NewServer(NewServer(svc, authSvc).Routes())
+	t.Cleanup(ts.Close)
+
+	do := func(method, path, token, body string) *http.Response {
+		t.Helper()
+
+		req, _ := http.NewRequest(method, ts.URL+path, strings.NewReader(body))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		t.Cleanup(func() { resp.Body.Close() })
+		return resp
+	}
+
+	creds := `{"username":"alice","password":"password123"}`
+	do(http.MethodPost, "/v2/auth/register", "", creds)
+	var tok TokenResponse
+	json.NewDecoder(do(http.MethodPost, "/v2/auth/login", "", creds).Body).Decode(&tok)
+
+	do(http.MethodPost, "/v2/tasks", tok.AccessToken, `{"title":"draft","due_date":"2026-03-01T00:00:00Z"}`)
+	do(http.MethodPatch, "/v2/tasks/1", tok.AccessToken, `{"due_date":"2026-03-08T00:00:00Z"}`)
+	do(http.MethodPatch, "/v2/tasks/1", tok.AccessToken, `{"is_done":true}`)
+
+	// Check the events, their diff and actor
+	resp := do(http.MethodGet, "/v2/tasks/1/history", tok.AccessToken, "")
+	var history HistoryResponse
+	json.NewDecoder(resp.Body).Decode(&history)
+	if resp.StatusCode != http.StatusOK || len(history.Items) != 3 {
+		t.Fatalf("expected 3 events, got %d %+v", resp.StatusCode, history)
+	}
+	due := history.Items[1]
+	if due.Kind != "updated" || due.Actor != "alice" || len(due.Changes) != 1 || due.Changes[0].Field != "due_date" ||
+		*due.Changes[0].Old != "2026-03-01T00:00:00Z" || *due.Changes[0].New != "2026-03-08T00:00:00Z" {
+		t.Fatalf("expected due date change by alice, got %+v", due)
+	}
+	if history.Items[2].Kind != "completed" {
+		t.Fatalf("expected completed event, got %+v", history.Items[2])
+	}
+
+	// Check other callers cannot see it and the method is checked
+	if resp := do(http.MethodGet, "/v1/tasks/1/history", "", ""); resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected status 404, got %d", resp.StatusCode)
+	}
+	if resp := do(http.MethodPost, "/v2/tasks/1/history", tok.AccessToken, ""); resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Fatalf("expected status 405, got %d", resp.StatusCode)
+	}
+}
+
+func TestEventStream(t *testing.T) {
+	repo := storage.NewMemoryTaskRepo()
+	t.Cleanup(func() { repo.Close() })
+	broker := events.NewBroker(10)
+	svc := todo.NewService(repo, todo.WithPublisher(broker))
+	ts := httptest.NewServer(NewServer(svc, nil, WithEvents(broker)).Routes())
+	t.Cleanup(ts.Close)
+
+	open := func(lastID string) *bufio.Reader {
+		t.Helper()
+
+		req, _ := http.NewRequestWithContext(t.Context(), http.MethodGet, ts.URL+"/v1/events", nil)
+		if lastID != "" {
+			req.Header.Set("Last-Event-ID", lastID)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		t.Cleanup(func() { resp.Body.Close() })
+		if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/event-stream" {
+			t.Fatalf("expected event stream, got %d %s", resp.StatusCode, resp.Header.Get("Content-Type"))
+		}
+		return bufio.NewReader(resp.Body)
+	}
+	// next returns the lines of the next event
+	next := func(r *bufio.Reader) []string {
+		t.Helper()
+
+		var lines []string
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if line == "\n" {
+				return lines
+			}
+			lines = append(lines, strings.TrimSuffix(line, "\n"))
+		}
+	}
+
+	stream := open("")
+	resp, _ := http.Post(ts.URL+"/v1/tasks", "application/json", strings.NewReader(`{"title":"a"}`))
+	resp.Body.Close()
+
+	// Check the created event and its data
+	lines := next(stream)
+	id := broker.Epoch() + "-"
+	if len(lines) != 3 || lines[0] != "id: "+id+"1" || lines[1] != "event: created" {
+		t.Fatalf("unexpected event %q", lines)
+	}
+	var data StreamEventResponse
+	if err := json.Unmarshal([]byte(strings.TrimPrefix(lines[2], "data: ")), &data); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if data.Kind != "created" || data.TaskID != 1 || data.Task.Title != "a" {
+		t.Fatalf("unexpected data %+v", data)
+	}
+
+	req, _ := http.NewRequest(http.MethodPatch, ts.URL+"/v1/tasks/1", strings.NewReader(`{"is_done":true}`))
+	resp, _ = http.DefaultClient.Do(req)
+	resp.Body.Close()
+	if lines := next(stream); lines[1] != "event: completed" {
+		t.Fatalf("expected completed event, got %q", lines)
+	}
+
+	// Check resuming replays what was missed
+	if lines := next(open(id + "1")); lines[0] != "id: "+id+"2" {
+		t.Fatalf("expected event 2, got %q", lines)
+	}
+	if lines := next(open(id + "50")); lines[0] != "event: reset" {
+		t.Fatalf("expected reset, got %q", lines)
+	}
+
+	// Check an ID from before a restart resets instead of replaying
+	stale := open("0-1")
+	if lines := next(stale); lines[0] != "event: reset" {
+		t.Fatalf("expected reset, got %q", lines)
+	}
+	resp, _ = http.Post(ts.URL+"/v1/tasks", "application/json", strings.NewReader(`{"title":"b"}`))
+	resp.Body.Close()
+	if lines := next(stale); lines[0] != "id: "+id+"3" {
+		t.Fatalf("expected only new events after the reset, got %q", lines)
+	}
+
+	// Check a bad Last-Event-ID gets the error envelope
+	req, _ = http.NewRequest(http.MethodGet, ts.URL+"/v1/events", nil)
+	req.Header.Set("Last-Event-ID", "x")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	defer resp.Body.Close()
+	if body := decodeError(t, resp); resp.StatusCode != http.StatusBadRequest || body.Details[0].Field != "Last-Event-ID" {
+		t.Fatalf("expected 400 on Last-Event-ID, got %d %+v", resp.StatusCode, body)
+	}
+}
+
+func TestWebhooks(t *testing.T) {
+	type delivery struct {
+		header http.Header
+		raw    []byte
+		body   StreamEventResponse
+	}
+	received := make(chan delivery, 10)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		d := delivery{header: r.Header}
+		d.raw, _ = io.ReadAll(r.Body)
+		json.Unmarshal(d.raw, &d.body)
+		received <- d
+	}))
+	defer receiver.Close()
+
+	repo := storage.NewMemoryTaskRepo()
+	t.Cleanup(func() { repo.Close() })
+	hooks := storage.NewMemoryWebhookRepo()
+	dispatcher := webhook.NewDispatcher(hooks, EncodeEvent)
+	t.Cleanup(dispatcher.Close)
+	svc := todo.NewService(repo, todo.WithPublisher(dispatcher))
+	ts := httptest.NewServer(NewServer(svc, nil, WithWebhooks(webhook.NewService(hooks))).Routes())
+	t.Cleanup(ts.Close)
+
+	send := func(method, path, body string) *http.Response {
+		t.Helper()
+
+		req, _ := http.NewRequest(method, ts.URL+path, strings.NewReader(body))
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		t.Cleanup(func() { resp.Body.Close() })
+		return resp
+	}
+
+	// Check validation
+	if resp := send(http.MethodPost, "/v1/webhooks", `{"url":"nope"}`); resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected status 400, got %d", resp.StatusCode)
+	}
+
+	resp := send(http.MethodPost, "/v1/webhooks", `{"url":"`+receiver.URL+`","events":["completed"]}`)
+	var hook WebhookResponse
+	json.NewDecoder(resp.Body).Decode(&hook)
+	if resp.StatusCode != http.StatusCreated || hook.Secret == "" || len(hook.Events) != 1 {
+		t.Fatalf("expected webhook with a secret, got %d %+v", resp.StatusCode, hook)
+	}
+	var list WebhookListResponse
+	json.NewDecoder(send(http.MethodGet, "/v1/webhooks", "").Body).Decode(&list)
+	if len(list.Items) != 1 || list.Items[0].Secret != "" {
+		t.Fatalf("expected the webhook without secret, got %+v", list)
+	}
+
+	send(http.MethodPost, "/v1/tasks", `{"title":"ship it"}`)
+	send(http.MethodPatch, "/v1/tasks/1", `{"is_done":true}`)
+
+	// Check the completion is delivered, signed with the secret
+	var d delivery
+	select {
+	case d = <-received:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("expected a delivery")
+	}
+	if d.header.Get(webhook.HeaderEvent) != "completed" || d.body.Task.Title != "ship it" || !d.body.Task.IsDone {
+		t.Fatalf("unexpected delivery %v %+v", d.header, d.body)
+	}
+	if sig := d.header.Get(webhook.HeaderSignature); sig != webhook.Sign(hook.Secret, d.raw) {
+		t.Fatalf("expected signature %s, got %s", webhook.Sign(hook.Secret, d.raw), sig)
+	}
+
+	path := "/v1/webhooks/" + strconv.Itoa(hook.ID)
+	var log DeliveryListResponse
+	for deadline := time.Now().Add(time.Second); len(log.Items) == 0 && time.Now().Before(deadline); {
+		json.NewDecoder(send(http.MethodGet, path+"/deliveries", "").Body).Decode(&log)
+	}
+	if len(log.Items) != 1 || log.Items[0].StatusCode != 200 || log.Items[0].Event != "completed" {
+		t.Fatalf("expected one successful delivery, got %+v", log)
+	}
+
+	// Check update and delete
+	resp = send(http.MethodPatch, path, `{"events":[]}`)
+	json.NewDecoder(resp.Body).Decode(&hook)
+	if resp.StatusCode != http.StatusOK || len(hook.Events) != 0 {
+		t.Fatalf("expected webhook for all events, got %d %+v", resp.StatusCode, hook)
+	}
+	if resp := send(http.MethodDelete, path, ""); resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("expected status 204, got %d", resp.StatusCode)
+	}
+	if resp := send(http.MethodGet, path, ""); resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected status 404, got %d", resp.StatusCode)
+	}
+}
+
+func TestImportExport(t *testing.T) {
+	ts := newTestServer(t)
+
+	post := func(query, contentType, body string) *http.Response {
+		t.Helper()
+
+		resp, err := http.Post(ts.URL+"/v1/tasks:import"+query, contentType, strings.NewReader(body))
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		t.Cleanup(func() { resp.Body.Close() })
+		return resp
+	}
+	decode := func(resp *http.Response) ImportResponse {
+		t.Helper()
+
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", resp.StatusCode)
+		}
+		var out ImportResponse
+		if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		return out
+	}
+
+	file := "(A) 2026-02-20 Pay rent +home due:2026-03-01\nx 2026-02-21 Call Bob @phone\nFix bike due:someday\n"
+
+	// Check a dry run previews each row without writing
+	out := decode(post("?dry_run=true", "text/plain", file))
+	if !out.DryRun || out.Created != 2 || out.Failed != 1 || len(out.Results) != 3 {
+		t.Fatalf("expected 2 created and 1 failed, got %+v", out)
+	}
+	if e := out.Results[2].Error; out.Results[2].Row != 3 || e == nil || e.Details[0].Field != "due" {
+		t.Fatalf("expected an error for due in row 3, got %+v", out.Results[2])
+	}
+
+	// Check importing again skips the duplicates of the first import
+	decode(post("", "text/plain; charset=utf-8", file))
+	out = decode(post("?format=todotxt", "application/octet-stream", file))
+	if out.DryRun || out.Created != 0 || out.Skipped != 2 {
+		t.Fatalf("expected 2 skipped, got %+v", out)
+	}
+
+	// Check the export carries priority, dates and tags
+	resp, err := http.Get(ts.URL + "/v1/tasks:export?format=csv&is_done=false")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	defer resp.Body.Close()
+	raw, _ := io.ReadAll(resp.Body)
+	if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "text/csv") {
+		t.Fatalf("expected text/csv, got %q", ct)
+	}
+	lines := strings.Split(strings.TrimSpace(string(raw)), "\n")
+	if len(lines) != 2 || !strings.Contains(lines[1], ",Pay rent,,home,2026-03-01,high,false,,,2026-02-20T00:00:00Z,") {
+		t.Fatalf("expected the open task, got %q", raw)
+	}
+
+	// Check a missing format is rejected
+	resp = post("", "application/octet-stream", file)
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected status 400, got %d", resp.StatusCode)
+	}
+	if body := decodeError(t, resp); body.Details[0].Field != "format" {
+		t.Fatalf("expected detail for format, got %+v", body)
+	}
+
+	// Check a body over the limit is 413 in every format
+	for _, format := range []string{"json", "csv", "todotxt"} {
+		big := "title\n" + strings.Repeat("a very long line of nothing in particular\n", maxImportBytes/40)
+		if format == "json" {
+			big = "[" + strings.Repeat(" ", maxImportBytes) + "]"
+		}
+		resp = post("?format="+format, "", big)
+		if resp.StatusCode != http.StatusRequestEntityTooLarge {
+			t.Fatalf("%s: expected status 413, got %d", format, resp.StatusCode)
+		}
+		if body := decodeError(t, resp); body.Details[0].Field != "body" {
+			t.Fatalf("%s: expected detail for body, got %+v", format, body)
+		}
+	}
+}
+
+func TestTasksICS(t *testing.T) {
+	ts := newTestServer(t)
+
+	for _, body := range []string{
+		`{"title":"file taxes","due_date":"2026-04-15T00:00:00Z","category":"admin"}`,
+		`{"title":"water plants"}`,
+	} {
+		resp, err := http.Post(ts.URL+"/v1/tasks", "application/json", strings.NewReader(body))
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		resp.Body.Close()
+	}
+
+	get := func(path string) (*http.Response, string) {
+		t.Helper()
+
+		resp, err := http.Get(ts.URL + path)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		defer resp.Body.Close()
+		raw, _ := io.ReadAll(resp.Body)
+		return resp, string(raw)
+	}
+
+	// Check the feed is filtered like the list
+	resp, feed := get("/v1/tasks.ics?q=taxes")
+	if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "text/calendar") {
+		t.Fatalf("expected text/calendar, got %q", ct)
+	}
+	if strings.Count(feed, "BEGIN:VTODO") != 1 || !strings.Contains(feed, "DUE;VALUE=DATE:20260415\r\n") || !strings.Contains(feed, "CATEGORIES:admin\r\n") {
+		t.Fatalf("expected one VTODO due 2026-04-15, got %q", feed)
+	}
+
+	// Check the ics export is the same feed, as an attachment
+	resp, export := get("/v1/tasks:export?format=ics&q=taxes")
+	if export != feed || resp.Header.Get("Content-Disposition") != `attachment; filename="tasks.ics"` {
+		t.Fatalf("expected the feed as tasks.ics, got %q", export)
+	}
+}
+
+func TestAgenda(t *testing.T) {
+	ts := newTestServer(t)
+
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Skipf("no time zone database: %v", err)
+	}
+	today := time.Now().In(tokyo)
+	date := func(days int) string {
+		return today.AddDate(0, 0, days).Format(time.DateOnly) + "T00:00:00Z"
+	}
+	for _, body := range []string{
+		`{"title":"late","due_date":"` + date(-2) + `"}`,
+		`{"title":"now","due_date":"` + date(0) + `"}`,
+		`{"title":"soon","due_date":"` + date(3) + `"}`,
+		`{"title":"later","due_date":"` + date(10) + `"}`,
+		`{"title":"someday"}`,
+	} {
+		resp, err := http.Post(ts.URL+"/v1/tasks", "application/json", strings.NewReader(body))
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		resp.Body.Close()
+	}
+
+	titles := func(l TaskListResponse) string {
+		var out []string
+		for _, task := range l.Items {
+			out = append(out, task.Title)
+		}
+		return strings.Join(out, ",")
+	}
+
+	// Check the agenda sorts the open tasks by due day in the caller's zone
+	resp, err := http.Get(ts.URL + "/v1/agenda?tz=Asia/Tokyo")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	var agenda AgendaResponse
+	json.NewDecoder(resp.Body).Decode(&agenda)
+	resp.Body.Close()
+	if agenda.Date != today.Format(time.DateOnly) || agenda.TimeZone != "Asia/Tokyo" {
+		t.Fatalf("unexpected agenda day %s in %s", agenda.Date, agenda.TimeZone)
+	}
+	if titles(agenda.Overdue) != "late" || titles(agenda.Today) != "now" || titles(agenda.Upcoming) != "soon" || titles(agenda.NoDueDate) != "someday" {
+		t.Fatalf("unexpected agenda %+v", agenda)
+	}
+
+	// Check the list presets and the computed fields
+	resp, err = http.Get(ts.URL + "/v1/tasks?due=overdue&tz=Asia/Tokyo")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	var list TaskListResponse
+	json.NewDecoder(resp.Body).Decode(&list)
+	resp.Body.Close()
+	if len(list.Items) != 1 || !list.Items[0].IsOverdue || list.Items[0].DueIn == nil || *list.Items[0].DueIn != -2 {
+		t.Fatalf("expected the overdue task due 2 days ago, got %+v", list.Items)
+	}
+	if s := agenda.Upcoming.Items[0]; s.IsOverdue || s.DueIn == nil || *s.DueIn != 3 {
+		t.Fatalf("expected the upcoming task due in 3 days, got %+v", s)
+	}
+	if s := agenda.NoDueDate.Items[0]; s.DueIn != nil {
+		t.Fatalf("expected no due_in without a due date, got %d", *s.DueIn)
+	}
+
+	// Check offsets are accepted and unknown zones rejected
+	for tz, status := range map[string]int{"%2B09:00": http.StatusOK, "Mars/Olympus": http.StatusBadRequest, "Local": http.StatusBadRequest} {
+		resp, err := http.Get(ts.URL + "/v1/tasks?due=week&tz=" + tz)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != status {
+			t.Fatalf("%s: expected %d, got %d", tz, status, resp.StatusCode)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/Saintrad/todo-server-client/internal/todo"
+)
+
+// tagsHandler serves GET /v1/tags: every tag of the caller's tasks with the
+// number of tasks carrying it.
+func (s *Server) tagsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	tags, err := s.service(r).ListTags(r.Context())
+	if err != nil {
+		s.writeDomainError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, ToTagListResponse(tags))
+}
+
+// tagHandler serves PATCH /v1/tags/{tag}, which renames the tag on every
+// task, and DELETE /v1/tags/{tag}, which removes it from every task.
+func (s *Server) tagHandler(w http.ResponseWriter, r *http.Request) {
+	_, tag, _ := strings.Cut(r.URL.Path, "/tags/")
+	if tag == "" || strings.Contains(tag, "/") {
+		http.NotFound(w, r)
+		return
+	}
+
+	svc := s.service(r)
+
+	switch r.Method {
+	case http.MethodPatch:
+		var req RenameTagRequest
+		dec := json.NewDecoder(r.Body)
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&req); err != nil {
+			writeBadJSON(w, err)
+			return
+		}
+
+		n, err := svc.RenameTag(r.Context(), tag, req.Name)
+		if err != nil {
+			s.writeDomainError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, TagChangeResponse{Tag: todo.NormalizeTag(req.Name), Tasks: n})
+
+	case http.MethodDelete:
+		n, err := svc.DeleteTag(r.Context(), tag)
+		if err != nil {
+			s.writeDomainError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, TagChangeResponse{Tag: todo.NormalizeTag(tag), Tasks: n})
+
+	default:
+		w.Header().Set("Allow", "PATCH, DELETE")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
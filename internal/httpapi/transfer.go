@@ -0,0 +1,141 @@
+package httpapi
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/Saintrad/todo-server-client/internal/todo"
+	"github.com/Saintrad/todo-server-client/internal/transfer"
+)
+
+// maxImportBytes bounds the size of an uploaded import file.
+const maxImportBytes = 10 << 20
+
+// exportHandler serves GET /v1/tasks:export: every task matching the list
+// query parameters, as a file in ?format= (json by default). limit and
+// offset are ignored.
+func (s *Server) exportHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	format := transfer.FormatJSON
+	if raw := r.URL.Query().Get("format"); raw != "" {
+		f, err := transfer.ParseFormat(raw)
+		if err != nil {
+			s.writeDomainError(w, err)
+			return
+		}
+		format = f
+	}
+
+	s.writeExport(w, r, format, "tasks"+format.Ext())
+}
+
+// icsHandler serves GET /v1/tasks.ics: the tasks matching the list query
+// parameters as an iCalendar feed of VTODOs, for calendar clients to
+// subscribe to. It is the ics export, served inline.
+func (s *Server) icsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	s.writeExport(w, r, transfer.FormatICS, "")
+}
+
+// writeExport writes the tasks matching the list query of r in format, as
+// an attachment if filename is set.
+func (s *Server) writeExport(w http.ResponseWriter, r *http.Request, format transfer.Format, filename string) {
+	q, err := ParseListQuery(r.URL.Query())
+	if err != nil {
+		s.writeDomainError(w, err)
+		return
+	}
+
+	tasks, err := s.service(r).Export(r.Context(), q)
+	if err != nil {
+		s.writeDomainError(w, err)
+		return
+	}
+
+	// Encode fully first, so a failure can still be reported as an error
+	var buf bytes.Buffer
+	if err := transfer.Encode(&buf, format, tasks); err != nil {
+		s.writeDomainError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", format.ContentType())
+	if filename != "" {
+		w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`"`)
+	}
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(buf.Bytes())
+}
+
+// importHandler serves POST /v1/tasks:import. The body is the file, in
+// ?format= or else the format of its Content-Type. ?duplicates= is skip
+// (the default), update or create, and ?dry_run=true reports the outcome
+// without writing. Rows that cannot be imported are reported in the 200
+// response; only an unreadable file or bad parameters fail the request.
+func (s *Server) importHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	v := r.URL.Query()
+	var issues []todo.FieldIssue
+
+	format, ok := transfer.FormatOfMediaType(r.Header.Get("Content-Type"))
+	if raw := v.Get("format"); raw != "" {
+		f, err := transfer.ParseFormat(raw)
+		if err != nil {
+			issues = append(issues, transfer.ErrInvalidFormat.Details...)
+		}
+		format, ok = f, true
+	} else if !ok {
+		issues = append(issues, todo.FieldIssue{Field: "format", Issue: "is required unless the Content-Type is application/json, text/csv or text/plain"})
+	}
+
+	opts := todo.ImportOptions{Duplicates: todo.DuplicatePolicy(v.Get("duplicates"))}
+	if raw := v.Get("dry_run"); raw != "" {
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			issues = append(issues, todo.FieldIssue{Field: "dry_run", Issue: "must be true or false"})
+		}
+		opts.DryRun = b
+	}
+
+	if len(issues) > 0 {
+		s.writeDomainError(w, todo.NewValidationError(issues...))
+		return
+	}
+
+	recs, err := transfer.Decode(http.MaxBytesReader(w, r.Body, maxImportBytes), format)
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		de := todo.NewValidationError(todo.FieldIssue{Field: "body", Issue: fmt.Sprintf("must be at most %d bytes", maxImportBytes)})
+		de.Message = "import file too large"
+		writeJSON(w, http.StatusRequestEntityTooLarge, ToErrorResponse(de))
+		return
+	}
+	if err != nil {
+		s.writeDomainError(w, err)
+		return
+	}
+
+	results, err := s.service(r).Import(r.Context(), recs, opts)
+	if err != nil {
+		s.writeDomainError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, ToImportResponse(results, opts.DryRun))
+}
@@ -0,0 +1,84 @@
+package httpapi
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// trashHandler serves GET /v1/trash, the deleted tasks with the same query
+// parameters as the task list, and DELETE /v1/trash, which purges them all.
+func (s *Server) trashHandler(w http.ResponseWriter, r *http.Request) {
+	svc := s.service(r)
+
+	switch r.Method {
+	case http.MethodGet:
+		q, err := ParseListQuery(r.URL.Query())
+		if err != nil {
+			s.writeDomainError(w, err)
+			return
+		}
+
+		q.Now = nowIn(q.Location)
+
+		page, err := svc.ListTrash(r.Context(), q)
+		if err != nil {
+			s.writeDomainError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, ToTaskListResponseAt(page, q.Now))
+
+	case http.MethodDelete:
+		n, err := svc.EmptyTrash(r.Context(), time.Time{})
+		if err != nil {
+			s.writeDomainError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, PurgeResponse{Purged: n})
+
+	default:
+		w.Header().Set("Allow", "GET, DELETE")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// trashItemHandler serves DELETE /v1/trash/{id}, which purges one task, and
+// POST /v1/trash/{id}/restore.
+func (s *Server) trashItemHandler(w http.ResponseWriter, r *http.Request) {
+	_, tail, _ := strings.Cut(r.URL.Path, "/trash/")
+	tail, sub, hasSub := strings.Cut(tail, "/")
+
+	id, err := strconv.Atoi(tail)
+	if err != nil || id <= 0 || (hasSub && sub != "restore") {
+		http.NotFound(w, r)
+		return
+	}
+
+	svc := s.service(r)
+
+	switch {
+	case hasSub && r.Method == http.MethodPost:
+		task, err := svc.Restore(r.Context(), id)
+		if err != nil {
+			s.writeDomainError(w, err)
+			return
+		}
+		writeTask(w, http.StatusOK, task, nil)
+
+	case !hasSub && r.Method == http.MethodDelete:
+		if err := svc.Purge(r.Context(), id); err != nil {
+			s.writeDomainError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	case hasSub:
+		w.Header().Set("Allow", "POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+
+	default:
+		w.Header().Set("Allow", "DELETE")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,111 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// webhooksHandler serves GET /v1/webhooks, the caller's webhooks, and
+// POST /v1/webhooks, which creates one.
+func (s *Server) webhooksHandler(w http.ResponseWriter, r *http.Request) {
+	owner := ownerID(r)
+
+	switch r.Method {
+	case http.MethodGet:
+		hooks, err := s.webhooks.List(r.Context(), owner)
+		if err != nil {
+			s.writeDomainError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, ToWebhookListResponse(hooks))
+
+	case http.MethodPost:
+		var req CreateWebhookRequest
+		dec := json.NewDecoder(r.Body)
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&req); err != nil {
+			writeBadJSON(w, err)
+			return
+		}
+
+		hook, err := s.webhooks.Create(r.Context(), owner, req.ToDomain())
+		if err != nil {
+			s.writeDomainError(w, err)
+			return
+		}
+		resp := ToWebhookResponse(hook)
+		resp.Secret = hook.Secret
+		writeJSON(w, http.StatusCreated, resp)
+
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// webhookHandler serves GET, PATCH and DELETE /v1/webhooks/{id} and
+// GET /v1/webhooks/{id}/deliveries, the latest delivery attempts.
+func (s *Server) webhookHandler(w http.ResponseWriter, r *http.Request) {
+	_, tail, _ := strings.Cut(r.URL.Path, "/webhooks/")
+	tail, sub, hasSub := strings.Cut(tail, "/")
+	id, err := strconv.Atoi(tail)
+	if err != nil || id <= 0 || (hasSub && sub != "deliveries") {
+		http.NotFound(w, r)
+		return
+	}
+	owner := ownerID(r)
+
+	if hasSub {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", "GET")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		log, err := s.webhooks.Deliveries(r.Context(), owner, id)
+		if err != nil {
+			s.writeDomainError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, ToDeliveryListResponse(log))
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		hook, err := s.webhooks.Get(r.Context(), owner, id)
+		if err != nil {
+			s.writeDomainError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, ToWebhookResponse(hook))
+
+	case http.MethodPatch:
+		var req UpdateWebhookRequest
+		dec := json.NewDecoder(r.Body)
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&req); err != nil {
+			writeBadJSON(w, err)
+			return
+		}
+
+		hook, err := s.webhooks.Update(r.Context(), owner, id, req.ToDomain())
+		if err != nil {
+			s.writeDomainError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, ToWebhookResponse(hook))
+
+	case http.MethodDelete:
+		if err := s.webhooks.Delete(r.Context(), owner, id); err != nil {
+			s.writeDomainError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		w.Header().Set("Allow", "GET, PATCH, DELETE")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,113 @@
+// Package reminder fires task reminders when they come due and hands them
+// to sinks: the log, webhooks, the event stream or a local command.
+package reminder
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Saintrad/todo-server-client/internal/todo"
+)
+
+// CommandTimeout bounds one run of a CommandSink.
+const CommandTimeout = 30 * time.Second
+
+// Repo persists how far the scheduler has got, so reminders that come due
+// while the server is down fire when it starts again.
+type Repo interface {
+	// Checkpoint returns the time up to which reminders have fired, zero
+	// if the scheduler never ran.
+	Checkpoint(context.Context) (time.Time, error)
+	SetCheckpoint(context.Context, time.Time) error
+
+	// Close releases resources. Calls after Close fail; closing twice is a
+	// no-op.
+	Close() error
+}
+
+// Sink receives fired reminders, one at a time, from the scheduler
+// goroutine. A reminder may be late: r.At is when it was due.
+type Sink interface {
+	Remind(ctx context.Context, r todo.DueReminder) error
+}
+
+// SinkFunc adapts a function to a Sink.
+type SinkFunc func(ctx context.Context, r todo.DueReminder) error
+
+func (f SinkFunc) Remind(ctx context.Context, r todo.DueReminder) error {
+	return f(ctx, r)
+}
+
+// LogSink logs every reminder at info level.
+func LogSink(logger *slog.Logger) Sink {
+	return SinkFunc(func(ctx context.Context, r todo.DueReminder) error {
+		logger.InfoContext(ctx, "reminder", "task", r.Task.ID, "owner", r.Task.OwnerID,
+			"title", r.Task.Title, "reminder", r.Reminder.String(), "due", r.At)
+		return nil
+	})
+}
+
+// PublisherSink publishes every reminder to p as an EventReminder, with
+// At set to when it was due. The event is not recorded in the history.
+// The events broker and the webhook dispatcher are both publishers.
+func PublisherSink(p todo.Publisher) Sink {
+	return SinkFunc(func(ctx context.Context, r todo.DueReminder) error {
+		p.Publish(todo.Event{
+			TaskID:  r.Task.ID,
+			OwnerID: r.Task.OwnerID,
+			Kind:    todo.EventReminder,
+			At:      r.At,
+		}, r.Task)
+		return nil
+	})
+}
+
+// CommandSink runs a local command for every reminder. command is split on
+// spaces and run without a shell; the reminder is passed in the
+// environment:
+//
+//	TODO_TASK_ID, TODO_TASK_OWNER, TODO_TASK_TITLE
+//	TODO_TASK_DUE      RFC 3339, empty without a due date
+//	TODO_REMINDER      the reminder in text form, e.g. due-1h
+//	TODO_REMINDER_AT   when it was due, RFC 3339
+//
+// A run that exits non-zero or takes longer than CommandTimeout fails.
+func CommandSink(command string) Sink {
+	args := strings.Fields(command)
+	return SinkFunc(func(ctx context.Context, r todo.DueReminder) error {
+		if len(args) == 0 {
+			return nil
+		}
+		ctx, cancel := context.WithTimeout(ctx, CommandTimeout)
+		defer cancel()
+
+		var due string
+		if r.Task.DueDate != nil {
+			due = r.Task.DueDate.UTC().Format(time.RFC3339)
+		}
+		cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+		cmd.Env = append(os.Environ(),
+			"TODO_TASK_ID="+strconv.Itoa(r.Task.ID),
+			"TODO_TASK_OWNER="+strconv.Itoa(r.Task.OwnerID),
+			"TODO_TASK_TITLE="+r.Task.Title,
+			"TODO_TASK_DUE="+due,
+			"TODO_REMINDER="+r.Reminder.String(),
+			"TODO_REMINDER_AT="+r.At.UTC().Format(time.RFC3339),
+		)
+		out, err := cmd.CombinedOutput()
+		if err != nil && len(bytes.TrimSpace(out)) > 0 {
+			return fmt.Errorf("%s: %w: %s", args[0], err, bytes.TrimSpace(out))
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", args[0], err)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,158 @@
+package reminder
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/Saintrad/todo-server-client/internal/todo"
+)
+
+// DefaultInterval is how often the scheduler looks for new reminders when
+// none is known to come due sooner.
+const DefaultInterval = time.Minute
+
+// Clock is the scheduler's source of time, swapped for a fake in tests.
+type Clock interface {
+	Now() time.Time
+	After(time.Duration) <-chan time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time                         { return time.Now() }
+func (systemClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// Source lists reminders that fire after a time, soonest first;
+// todo.Service is one.
+type Source interface {
+	Reminders(ctx context.Context, after time.Time) ([]todo.DueReminder, error)
+}
+
+// Scheduler fires reminders as they come due. It keeps a checkpoint, the
+// time up to which it has fired everything, in its repo: on startup it
+// catches up on the reminders that came due since then, so none is missed
+// across restarts. A crash between firing and saving the checkpoint fires
+// those reminders again; sinks get every reminder at least once.
+type Scheduler struct {
+	src      Source
+	repo     Repo
+	sinks    []Sink
+	clock    Clock
+	interval time.Duration
+	log      *slog.Logger
+
+	last time.Time
+}
+
+type Option func(*Scheduler)
+
+// WithSink hands fired reminders to sink. It may be given more than once;
+// sinks are called in order.
+func WithSink(sink Sink) Option {
+	return func(s *Scheduler) { s.sinks = append(s.sinks, sink) }
+}
+
+func WithClock(c Clock) Option {
+	return func(s *Scheduler) { s.clock = c }
+}
+
+// WithInterval sets how long the scheduler sleeps at most, which bounds how
+// late a reminder added meanwhile fires.
+func WithInterval(d time.Duration) Option {
+	return func(s *Scheduler) {
+		if d > 0 {
+			s.interval = d
+		}
+	}
+}
+
+func WithLogger(l *slog.Logger) Option {
+	return func(s *Scheduler) { s.log = l }
+}
+
+func NewScheduler(src Source, repo Repo, opts ...Option) *Scheduler {
+	s := &Scheduler{
+		src:      src,
+		repo:     repo,
+		clock:    systemClock{},
+		interval: DefaultInterval,
+		log:      slog.Default(),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Run fires reminders until ctx is done. The first run of a new server
+// starts from now rather than firing every reminder in the past. It only
+// fails if the checkpoint cannot be read or first saved; later errors are
+// logged and retried.
+func (s *Scheduler) Run(ctx context.Context) error {
+	last, err := s.repo.Checkpoint(ctx)
+	if err != nil {
+		return err
+	}
+	if last.IsZero() {
+		last = s.clock.Now()
+		if err := s.repo.SetCheckpoint(ctx, last); err != nil {
+			return err
+		}
+	}
+	s.last = last
+
+	for {
+		wait := s.interval
+		next, err := s.fire(ctx)
+		if err != nil && ctx.Err() == nil {
+			s.log.Error("fire reminders", "err", err)
+		}
+		if !next.IsZero() {
+			wait = min(wait, next.Sub(s.clock.Now()))
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-s.clock.After(wait):
+		}
+	}
+}
+
+// fire hands the reminders due since the checkpoint to the sinks, moves
+// the checkpoint to now and returns when the next reminder is due, zero if
+// none is known.
+func (s *Scheduler) fire(ctx context.Context) (time.Time, error) {
+	now := s.clock.Now()
+	if now.Before(s.last) {
+		// The clock went back; wait for it to pass the checkpoint
+		return s.last, nil
+	}
+
+	due, err := s.src.Reminders(ctx, s.last)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	var next time.Time
+	for _, r := range due {
+		if r.At.After(now) {
+			next = r.At
+			break
+		}
+		for _, sink := range s.sinks {
+			if err := sink.Remind(ctx, r); err != nil {
+				s.log.Error("send reminder", "task", r.Task.ID, "reminder", r.Reminder.String(), "err", err)
+			}
+		}
+	}
+
+	// Move on even if the checkpoint cannot be saved, or every retry would
+	// fire the same reminders again
+	s.last = now
+	if err := s.repo.SetCheckpoint(ctx, now); err != nil {
+		return next, err
+	}
+	return next, nil
+}
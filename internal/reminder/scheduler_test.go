@@ -0,0 +1,213 @@
+package reminder
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Saintrad/todo-server-client/internal/todo"
+)
+
+// fakeClock only moves when Advance is called. Every After call is
+// announced on waiting, so a test knows when the scheduler is asleep.
+type fakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	timers  []fakeTimer
+	waiting chan time.Duration
+}
+
+type fakeTimer struct {
+	at time.Time
+	ch chan time.Time
+}
+
+func newFakeClock(now time.Time) *fakeClock {
+	return &fakeClock{now: now, waiting: make(chan time.Duration)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	ch := make(chan time.Time, 1)
+	c.timers = append(c.timers, fakeTimer{c.now.Add(d), ch})
+	c.mu.Unlock()
+
+	c.waiting <- d
+	return ch
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+	c.timers = slices.DeleteFunc(c.timers, func(t fakeTimer) bool {
+		if t.at.After(c.now) {
+			return false
+		}
+		t.ch <- c.now
+		return true
+	})
+}
+
+type fakeSource []todo.DueReminder
+
+func (s fakeSource) Reminders(ctx context.Context, after time.Time) ([]todo.DueReminder, error) {
+	var out []todo.DueReminder
+	for _, r := range s {
+		if r.At.After(after) {
+			out = append(out, r)
+		}
+	}
+	return out, nil
+}
+
+type fakeRepo struct {
+	mu         sync.Mutex
+	checkpoint time.Time
+}
+
+func (r *fakeRepo) Checkpoint(ctx context.Context) (time.Time, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.checkpoint, nil
+}
+
+func (r *fakeRepo) SetCheckpoint(ctx context.Context, t time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.checkpoint = t
+	return nil
+}
+
+func (r *fakeRepo) Close() error {
+	return nil
+}
+
+// recorder is a Sink that keeps the IDs of the tasks it was given.
+type recorder struct {
+	mu  sync.Mutex
+	ids []int
+}
+
+func (r *recorder) Remind(ctx context.Context, d todo.DueReminder) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.ids = append(r.ids, d.Task.ID)
+	return nil
+}
+
+func (r *recorder) got() []int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.ids)
+}
+
+var t0 = time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+
+func due(id int, at time.Time) todo.DueReminder {
+	return todo.DueReminder{Task: todo.Task{ID: id}, Reminder: todo.Reminder{At: &at}, At: at}
+}
+
+// start runs s until the test ends.
+func start(t *testing.T, s *Scheduler) {
+	ctx, cancel := context.WithCancel(t.Context())
+	done := make(chan error, 1)
+	go func() { done <- s.Run(ctx) }()
+	t.Cleanup(func() {
+		cancel()
+		if err := <-done; err != nil {
+			t.Errorf("Run: expected no error, got %v", err)
+		}
+	})
+}
+
+func TestSchedulerCatchesUpAndWaits(t *testing.T) {
+	clock := newFakeClock(t0.Add(2 * time.Hour))
+	repo := &fakeRepo{checkpoint: t0}
+	src := fakeSource{due(1, t0.Add(-time.Hour)), due(2, t0.Add(time.Hour)), due(3, t0.Add(3*time.Hour))}
+	var sink recorder
+	failing := SinkFunc(func(context.Context, todo.DueReminder) error { return errors.New("down") })
+
+	start(t, NewScheduler(src, repo,
+		WithClock(clock), WithInterval(10*time.Hour),
+		WithSink(failing), WithSink(&sink),
+		WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))),
+	))
+
+	// Check the reminder missed while down fires at once, despite the
+	// failing sink, and the scheduler sleeps until the next one
+	if d := <-clock.waiting; d != time.Hour {
+		t.Fatalf("expected to wait 1h, got %v", d)
+	}
+	if got := sink.got(); !slices.Equal(got, []int{2}) {
+		t.Fatalf("expected task 2 to fire, got %v", got)
+	}
+	if cp, _ := repo.Checkpoint(t.Context()); !cp.Equal(t0.Add(2 * time.Hour)) {
+		t.Fatalf("expected the checkpoint to move to now, got %v", cp)
+	}
+
+	// Check the next one fires when its time comes
+	clock.Advance(time.Hour)
+	if d := <-clock.waiting; d != 10*time.Hour {
+		t.Fatalf("expected to wait the interval, got %v", d)
+	}
+	if got := sink.got(); !slices.Equal(got, []int{2, 3}) {
+		t.Fatalf("expected tasks 2 and 3 to fire, got %v", got)
+	}
+}
+
+func TestSchedulerFirstRunSkipsThePast(t *testing.T) {
+	clock := newFakeClock(t0)
+	repo := &fakeRepo{}
+	var sink recorder
+
+	start(t, NewScheduler(fakeSource{due(1, t0.Add(-time.Minute))}, repo, WithClock(clock), WithSink(&sink)))
+
+	if d := <-clock.waiting; d != DefaultInterval {
+		t.Fatalf("expected to wait the interval, got %v", d)
+	}
+	if got := sink.got(); len(got) != 0 {
+		t.Fatalf("expected nothing to fire, got %v", got)
+	}
+	if cp, _ := repo.Checkpoint(t.Context()); !cp.Equal(t0) {
+		t.Fatalf("expected checkpoint %v, got %v", t0, cp)
+	}
+}
+
+func TestCommandSink(t *testing.T) {
+	dir := t.TempDir()
+	script := filepath.Join(dir, "notify")
+	out := filepath.Join(dir, "out")
+	if err := os.WriteFile(script, []byte("#!/bin/sh\nprintf '%s|%s|%s' \"$TODO_TASK_ID\" \"$TODO_TASK_TITLE\" \"$TODO_REMINDER\" > \"$1\"\n"), 0o755); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	// Check the reminder is passed in the environment
+	r := due(7, t0)
+	r.Task.Title = "call Bob"
+	if err := CommandSink(script+" "+out).Remind(t.Context(), r); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if b, _ := os.ReadFile(out); string(b) != "7|call Bob|2026-03-01T09:00:00Z" {
+		t.Fatalf("unexpected command output %q", b)
+	}
+
+	// Check a failing command is an error
+	if err := CommandSink("false").Remind(t.Context(), r); err == nil {
+		t.Fatalf("expected an error")
+	}
+}
@@ -0,0 +1,74 @@
+package storage
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+)
+
+// writeJSONAtomic writes v as indented JSON to path via a temp file in the
+// same directory and a rename, so readers never see a partial file.
+func writeJSONAtomic(path string, v any) error {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	b = append(b, '\n')
+
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+"-*.tmp")
+	if err != nil {
+		return err
+	}
+
+	tmpName := tmp.Name()
+	defer func() {
+		_ = tmp.Close()
+		_ = os.Remove(tmpName) // no-op if rename succeeded
+	}()
+
+	if _, err := tmp.Write(b); err != nil {
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	// Atomic replace on most OS/filesystems when same directory
+	return os.Rename(tmpName, path)
+}
+
+// readJSONFile decodes path into v. It reports found=false for a missing or
+// empty file, leaving v untouched.
+func readJSONFile(path string, v any) (found bool, err error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		// Missing file is not an error: start empty
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	// Empty file: treat as empty state
+	if len(data) == 0 {
+		return false, nil
+	}
+
+	return true, json.Unmarshal(data, v)
+}
+
+// removeStaleTemps deletes temp files left behind by a writeJSONAtomic that
+// was interrupted (crash, kill -9) before its rename.
+func removeStaleTemps(path string) error {
+	matches, err := filepath.Glob(filepath.Join(filepath.Dir(path), filepath.Base(path)+"-*.tmp"))
+	if err != nil {
+		return err
+	}
+	for _, m := range matches {
+		if err := os.Remove(m); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/Saintrad/todo-server-client/internal/todo"
+)
+
+var _ todo.HistoryRepo = (*FileHistoryRepo)(nil)
+
+// FileHistoryRepo appends events as JSON lines to a file and keeps them in
+// memory for reads. Like the task journal, a torn last line left by a crash
+// is dropped on open.
+type FileHistoryRepo struct {
+	mu     sync.Mutex
+	file   *os.File
+	size   int64
+	events []todo.Event
+	closed bool
+}
+
+// NewFileHistoryRepo loads the events in path, creating it if missing.
+func NewFileHistoryRepo(path string) (*FileHistoryRepo, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &FileHistoryRepo{file: f}
+	if err := r.load(); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to load %s: %w", path, err)
+	}
+	return r, nil
+}
+
+// load reads every complete line and positions the file after them.
+func (r *FileHistoryRepo) load() error {
+	data, err := io.ReadAll(r.file)
+	if err != nil {
+		return err
+	}
+
+	for len(data) > 0 {
+		line, rest, complete := bytes.Cut(data, []byte{'\n'})
+
+		var e todo.Event
+		err := json.Unmarshal(line, &e)
+		if err != nil || !complete {
+			if len(bytes.TrimSpace(rest)) == 0 {
+				break
+			}
+			return fmt.Errorf("event %d: %w", len(r.events)+1, err)
+		}
+
+		r.events = append(r.events, e)
+		r.size += int64(len(line)) + 1
+		data = rest
+	}
+
+	if err := r.file.Truncate(r.size); err != nil {
+		return err
+	}
+	_, err = r.file.Seek(r.size, io.SeekStart)
+	return err
+}
+
+// Append durably writes events, all in one write.
+func (r *FileHistoryRepo) Append(ctx context.Context, events ...todo.Event) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return todo.ErrRepoClosed
+	}
+
+	var buf bytes.Buffer
+	next := len(r.events) + 1
+	for i := range events {
+		events[i].ID = next + i
+		b, err := json.Marshal(events[i])
+		if err != nil {
+			return err
+		}
+		buf.Write(b)
+		buf.WriteByte('\n')
+	}
+
+	if _, err := r.file.Write(buf.Bytes()); err != nil {
+		// Cut off a partial write so the next append starts on a clean line
+		_ = r.file.Truncate(r.size)
+		_, _ = r.file.Seek(r.size, io.SeekStart)
+		return err
+	}
+	if err := r.file.Sync(); err != nil {
+		return err
+	}
+	r.size += int64(buf.Len())
+	r.events = append(r.events, events...)
+	return nil
+}
+
+func (r *FileHistoryRepo) ListByTask(ctx context.Context, taskID int) ([]todo.Event, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return nil, todo.ErrRepoClosed
+	}
+
+	return eventsOf(r.events, taskID), nil
+}
+
+// Close closes the file and rejects further calls.
+func (r *FileHistoryRepo) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return nil
+	}
+	r.closed = true
+	return r.file.Close()
+}
@@ -0,0 +1,83 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/Saintrad/todo-server-client/internal/reminder"
+	"github.com/Saintrad/todo-server-client/internal/todo"
+)
+
+var _ reminder.Repo = (*FileReminderRepo)(nil)
+
+// FileReminderRepo keeps the reminder checkpoint in a small JSON file,
+// rewritten on every change.
+type FileReminderRepo struct {
+	mu       sync.Mutex
+	filePath string
+	state    reminderState
+	closed   bool
+}
+
+type reminderState struct {
+	Checkpoint time.Time `json:"checkpoint"`
+}
+
+// NewFileReminderRepo loads the checkpoint from file if present.
+func NewFileReminderRepo(path string) (*FileReminderRepo, error) {
+	r := &FileReminderRepo{filePath: path}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	if err := removeStaleTemps(path); err != nil {
+		return nil, err
+	}
+	if _, err := readJSONFile(path, &r.state); err != nil {
+		return nil, fmt.Errorf("failed to load %s: %w", path, err)
+	}
+	return r, nil
+}
+
+func (r *FileReminderRepo) Checkpoint(ctx context.Context) (time.Time, error) {
+	if err := ctx.Err(); err != nil {
+		return time.Time{}, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return time.Time{}, todo.ErrRepoClosed
+	}
+	return r.state.Checkpoint, nil
+}
+
+func (r *FileReminderRepo) SetCheckpoint(ctx context.Context, t time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return todo.ErrRepoClosed
+	}
+	st := reminderState{Checkpoint: t.UTC()}
+	if err := writeJSONAtomic(r.filePath, st); err != nil {
+		return err
+	}
+	r.state = st
+	return nil
+}
+
+func (r *FileReminderRepo) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.closed = true
+	return nil
+}
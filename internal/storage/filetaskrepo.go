@@ -1,73 +1,133 @@
 package storage
 
 import (
-	"encoding/json"
+	"context"
 	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"sync"
 
 	"github.com/Saintrad/todo-server-client/internal/todo"
 )
 
+// fileSchema is the current layout of the tasks file; older files are
+// upgraded by upgradeFileState when loaded.
+//
+//	0: before tags
+//	1: categories copied into tags
+const fileSchema = 1
+
 type fileState struct {
+	Schema int         `json:"schema,omitempty"`
 	NextID int         `json:"next_id"`
 	Tasks  []todo.Task `json:"tasks"`
 }
 
+var _ todo.TaskRepo = (*FileTaskRepo)(nil)
+
 type FileTaskRepo struct {
 	mu       sync.Mutex
 	filePath string
 	state    fileState
+	closed   bool
+
+	// Journal mode only (see journal.go)
+	journal      journalFile
+	journalSize  int64
+	journalLen   int
+	compactEvery int
 }
 
+// FileOption configures a FileTaskRepo.
+type FileOption func(*FileTaskRepo)
+
 // NewFileTaskRepo loads state from file if present, otherwise starts empty.
-func NewFileTaskRepo(path string) (*FileTaskRepo, error) {
+func NewFileTaskRepo(path string, opts ...FileOption) (*FileTaskRepo, error) {
 	r := &FileTaskRepo{
 		filePath: path,
 		state: fileState{
+			Schema: fileSchema,
 			NextID: 1,
 			Tasks:  make([]todo.Task, 0),
 		},
 	}
+	for _, opt := range opts {
+		opt(r)
+	}
 
 	// Ensure parent dir exists (e.g., data/)
 	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
 		return nil, err
 	}
-
-	data, err := os.ReadFile(path)
-	if err != nil {
-		// Missing file is not an error: start empty
-		if errors.Is(err, os.ErrNotExist) {
-			return r, nil
-		}
+	if err := removeStaleTemps(path); err != nil {
 		return nil, err
 	}
 
-	// Empty file: treat as empty state
-	if len(data) == 0 {
-		return r, nil
-	}
-
 	var st fileState
-	if err := json.Unmarshal(data, &st); err != nil {
-		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	found, err := readJSONFile(path, &st)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load %s: %w", path, err)
+	}
+	if found {
+		// Defensive defaults
+		if st.NextID <= 0 {
+			st.NextID = computeNextID(st.Tasks)
+		}
+		if st.Tasks == nil {
+			st.Tasks = make([]todo.Task, 0)
+		}
+		for i := range st.Tasks {
+			// Files written before versions existed
+			if st.Tasks[i].Version <= 0 {
+				st.Tasks[i].Version = 1
+			}
+		}
+		r.state = st
 	}
 
-	// Defensive defaults
-	if st.NextID <= 0 {
-		st.NextID = computeNextID(st.Tasks)
+	if r.compactEvery > 0 {
+		if err := r.openJournal(); err != nil {
+			return nil, err
+		}
 	}
-	if st.Tasks == nil {
-		st.Tasks = make([]todo.Task, 0)
+
+	// Upgrade after the journal replay, which may hold old records too, and
+	// write the result at once so the upgrade runs only once.
+	if r.state.Schema < fileSchema {
+		upgradeFileState(&r.state)
+		save := r.saveLocked
+		if r.journal != nil {
+			save = r.compactLocked
+		}
+		if err := save(); err != nil {
+			if r.journal != nil {
+				r.journal.Close()
+			}
+			return nil, fmt.Errorf("upgrade %s: %w", path, err)
+		}
 	}
 
-	r.state = st
 	return r, nil
 }
 
+// upgradeFileState brings a state read from an older file to fileSchema.
+func upgradeFileState(st *fileState) {
+	if st.Schema < 1 {
+		for i, t := range st.Tasks {
+			if t.Category == nil {
+				continue
+			}
+			if tag, ok := todo.CategoryTag(*t.Category); ok && !slices.Contains(t.Tags, tag) {
+				st.Tasks[i].Tags = append(slices.Clone(t.Tags), tag)
+				slices.Sort(st.Tasks[i].Tags)
+			}
+		}
+	}
+	st.Schema = fileSchema
+}
+
 func computeNextID(tasks []todo.Task) int {
 	max := 0
 	for _, t := range tasks {
@@ -79,71 +139,76 @@ func computeNextID(tasks []todo.Task) int {
 }
 
 // Create assigns an ID, stores the task, and persists to disk.
-func (r *FileTaskRepo) Create(task todo.Task) (todo.Task, error) {
+func (r *FileTaskRepo) Create(ctx context.Context, task todo.Task) (todo.Task, error) {
+	if err := ctx.Err(); err != nil {
+		return todo.Task{}, err
+	}
+
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
+	if r.closed {
+		return todo.Task{}, todo.ErrRepoClosed
+	}
+
 	task.ID = r.state.NextID
+	task.Version = 1
 	r.state.NextID++
 
 	r.state.Tasks = append(r.state.Tasks, task)
 
-	if err := r.saveLocked(); err != nil {
+	if err := r.commitLocked(journalRecord{Op: opCreate, Task: &task}); err != nil {
+		r.state.Tasks = r.state.Tasks[:len(r.state.Tasks)-1]
+		r.state.NextID--
+
 		return todo.Task{}, err
 	}
 	return task, nil
 }
 
+// commitLocked persists a mutation already applied to r.state: as a journal
+// record in journal mode, otherwise by rewriting the whole file.
+// Call only while holding r.mu.
+func (r *FileTaskRepo) commitLocked(rec journalRecord) error {
+	if r.journal != nil {
+		return r.appendJournalLocked(rec)
+	}
+	return r.saveLocked()
+}
+
 // saveLocked persists r.state to disk atomically.
 // Call only while holding r.mu.
 func (r *FileTaskRepo) saveLocked() error {
-	b, err := json.MarshalIndent(r.state, "", "  ")
-	if err != nil {
-		return err
-	}
-	b = append(b, '\n')
+	return writeJSONAtomic(r.filePath, r.state)
+}
 
-	dir := filepath.Dir(r.filePath)
-	tmp, err := os.CreateTemp(dir, "tasks-*.tmp")
-	if err != nil {
-		return err
+func (r *FileTaskRepo) List(ctx context.Context, q todo.ListQuery) (todo.TaskPage, error) {
+	if err := ctx.Err(); err != nil {
+		return todo.TaskPage{}, err
 	}
 
-	tmpName := tmp.Name()
-	defer func() {
-		_ = tmp.Close()
-		_ = os.Remove(tmpName) // no-op if rename succeeded
-	}()
+	r.mu.Lock()
+	defer r.mu.Unlock()
 
-	if _, err := tmp.Write(b); err != nil {
-		return err
-	}
-	if err := tmp.Close(); err != nil {
-		return err
+	if r.closed {
+		return todo.TaskPage{}, todo.ErrRepoClosed
 	}
 
-	// Atomic replace on most OS/filesystems when same directory
-	if err := os.Rename(tmpName, r.filePath); err != nil {
-		return err
-	}
-	return nil
+	return q.Apply(r.state.Tasks), nil
 }
 
-func (r *FileTaskRepo) List() ([]todo.Task, error) {
+func (r *FileTaskRepo) GetByID(ctx context.Context, id int) (todo.Task, error) {
+	if err := ctx.Err(); err != nil {
+		return todo.Task{}, err
+	}
+
 	r.mu.Lock()
 	defer r.mu.Unlock()
-	list := make([]todo.Task, 0)
 
-	for _, task := range r.state.Tasks {
-
-		list = append(list, task)
+	if r.closed {
+		return todo.Task{}, todo.ErrRepoClosed
 	}
 
-	return list, nil
-}
-
-func (r *FileTaskRepo) GetByID(id int) (todo.Task, error) {
-
 	tasks := r.state.Tasks
 
 	for _, task := range tasks {
@@ -155,10 +220,18 @@ func (r *FileTaskRepo) GetByID(id int) (todo.Task, error) {
 	return todo.Task{}, todo.ErrTaskNotFound
 }
 
-func (r *FileTaskRepo) Update(t todo.Task) (todo.Task, error) {
+func (r *FileTaskRepo) Update(ctx context.Context, t todo.Task) (todo.Task, error) {
+	if err := ctx.Err(); err != nil {
+		return todo.Task{}, err
+	}
+
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
+	if r.closed {
+		return todo.Task{}, todo.ErrRepoClosed
+	}
+
 	var oldIdx int
 	var oldTask todo.Task
 	found := false
@@ -166,6 +239,10 @@ func (r *FileTaskRepo) Update(t todo.Task) (todo.Task, error) {
 	// Find the task by ID
 	for idx, task := range r.state.Tasks {
 		if task.ID == t.ID {
+			if task.Version != t.Version {
+				return todo.Task{}, todo.ErrVersionConflict
+			}
+			t.Version++
 			oldTask = task
 			oldIdx = idx
 			found = true
@@ -181,7 +258,7 @@ func (r *FileTaskRepo) Update(t todo.Task) (todo.Task, error) {
 	}
 
 	// Save the state
-	err := r.saveLocked()
+	err := r.commitLocked(journalRecord{Op: opUpdate, Task: &t})
 
 	// If save fails, revert the update
 	if err != nil {
@@ -193,7 +270,17 @@ func (r *FileTaskRepo) Update(t todo.Task) (todo.Task, error) {
 	return t, nil
 }
 
-func (r *FileTaskRepo) Delete(id int) (todo.Task, error) {
+func (r *FileTaskRepo) Delete(ctx context.Context, id int) (todo.Task, error) {
+	if err := ctx.Err(); err != nil {
+		return todo.Task{}, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return todo.Task{}, todo.ErrRepoClosed
+	}
 
 	var oldTask todo.Task
 	found := false
@@ -213,7 +300,7 @@ func (r *FileTaskRepo) Delete(id int) (todo.Task, error) {
 		return todo.Task{}, todo.ErrTaskNotFound
 	}
 
-	err := r.saveLocked()
+	err := r.commitLocked(journalRecord{Op: opDelete, ID: id})
 
 	if err != nil {
 		r.state.Tasks = append(r.state.Tasks, oldTask)
@@ -223,3 +310,60 @@ func (r *FileTaskRepo) Delete(id int) (todo.Task, error) {
 
 	return oldTask, nil
 }
+
+// Atomic runs fn on a copy of the state while holding the lock. If fn
+// succeeds its writes are persisted together, as one journal record in
+// journal mode, and the copy becomes the new state.
+func (r *FileTaskRepo) Atomic(ctx context.Context, fn func(todo.TaskStore) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return todo.ErrRepoClosed
+	}
+
+	tx := newTaskTx(r.state.Tasks, r.state.NextID)
+	if err := fn(tx); err != nil {
+		return err
+	}
+	if len(tx.log) == 0 {
+		return nil
+	}
+
+	old := r.state
+	r.state = fileState{Schema: old.Schema, NextID: tx.nextID, Tasks: tx.tasks}
+
+	var err error
+	if r.journal != nil {
+		err = r.appendJournalLocked(tx.log...)
+	} else {
+		err = r.saveLocked()
+	}
+	if err != nil {
+		r.state = old
+		return err
+	}
+	return nil
+}
+
+// Close waits for an in-flight write to finish and rejects further calls.
+// Every mutation is already on disk; in journal mode the journal is
+// compacted into the snapshot so the next start does not replay it.
+func (r *FileTaskRepo) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return nil
+	}
+	r.closed = true
+
+	if r.journal == nil {
+		return nil
+	}
+	return errors.Join(r.compactLocked(), r.journal.Close())
+}
@@ -5,10 +5,15 @@ import (
 	"errors"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/Saintrad/todo-server-client/internal/reminder"
+	"github.com/Saintrad/todo-server-client/internal/storage/storagetest"
 	"github.com/Saintrad/todo-server-client/internal/todo"
+	"github.com/Saintrad/todo-server-client/internal/webhook"
 )
 
 func strPtr(s string) *string {
@@ -27,7 +32,7 @@ func TestFileRepo_NoFile_StartsEmptyAndCreatesFile(t *testing.T) {
 	}
 
 	// Call Create twice and assert IDs are 1 and 2.
-	task1, err := repo.Create(todo.Task{
+	task1, err := repo.Create(t.Context(), todo.Task{
 		Title:     "first",
 		DueDate:   &now,
 		CreatedAt: now,
@@ -43,7 +48,7 @@ func TestFileRepo_NoFile_StartsEmptyAndCreatesFile(t *testing.T) {
 		t.Fatalf("expected ID 1, got %d", task1.ID)
 	}
 
-	task2, err := repo.Create(todo.Task{
+	task2, err := repo.Create(t.Context(), todo.Task{
 		Title:     "second",
 		DueDate:   &now,
 		CreatedAt: now,
@@ -133,7 +138,7 @@ func TestFileRepo_ExistingFile_LoadsStateAndContinuesIDs(t *testing.T) {
 		t.Fatalf("expected no error, got %v", nErr)
 	}
 
-	task, cErr := repo.Create(todo.Task{
+	task, cErr := repo.Create(t.Context(), todo.Task{
 		Title: "task",
 	})
 	if cErr != nil {
@@ -165,7 +170,7 @@ func TestUpdateTask(t *testing.T) {
 		Category: strPtr("changed"),
 	}
 	// Check updating missing tasks
-	_, err := repo.Update(inputTask)
+	_, err := repo.Update(t.Context(), inputTask)
 
 	if err == nil {
 		t.Fatalf("expected error %v, got %v", todo.ErrTaskNotFound, err)
@@ -176,13 +181,14 @@ func TestUpdateTask(t *testing.T) {
 	}
 
 	// Check updating exisiting task
-	_, cErr := repo.Create(todo.Task{})
+	created, cErr := repo.Create(t.Context(), todo.Task{})
 	if cErr != nil {
 		t.Fatalf("expected no errors, got %v", cErr)
 	}
 
-	_, err = repo.Update(inputTask)
-	task, _ := repo.GetByID(1)
+	inputTask.Version = created.Version
+	_, err = repo.Update(t.Context(), inputTask)
+	task, _ := repo.GetByID(t.Context(), 1)
 
 	if err != nil {
 		t.Fatalf("expected no errors, got %v", err)
@@ -209,7 +215,7 @@ func TestDeleteTask(t *testing.T) {
 	}
 
 	// Check delete missing task
-	_, err := repo.Delete(1)
+	_, err := repo.Delete(t.Context(), 1)
 
 	if err == nil {
 		t.Fatalf("expected error %v, got %v", todo.ErrTaskNotFound, err)
@@ -220,17 +226,17 @@ func TestDeleteTask(t *testing.T) {
 	}
 
 	// Check delete existing task
-	_, cErr := repo.Create(todo.Task{})
+	_, cErr := repo.Create(t.Context(), todo.Task{})
 	if cErr != nil {
 		t.Fatalf("expected no errors, got %v", cErr)
 	}
 
-	_, err = repo.Delete(1)
+	_, err = repo.Delete(t.Context(), 1)
 	if err != nil {
 		t.Fatalf("expected no errors, got %v", err)
 	}
 
-	_, err = repo.GetByID(1)
+	_, err = repo.GetByID(t.Context(), 1)
 
 	if err == nil {
 		t.Fatalf("expected error %v, got %v", todo.ErrTaskNotFound, err)
@@ -240,3 +246,210 @@ func TestDeleteTask(t *testing.T) {
 		t.Fatalf("expected error %v, got %v", todo.ErrTaskNotFound, err)
 	}
 }
+
+func TestFileRepoConformance(t *testing.T) {
+	storagetest.RunTaskRepoTests(t, func(t *testing.T) todo.TaskRepo {
+		repo, err := NewFileTaskRepo(filepath.Join(t.TempDir(), "tasks.json"))
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		return repo
+	})
+}
+
+func TestFileHistoryRepoConformance(t *testing.T) {
+	storagetest.RunHistoryRepoTests(t, func(t *testing.T) todo.HistoryRepo {
+		repo, err := NewFileHistoryRepo(filepath.Join(t.TempDir(), "history.jsonl"))
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		return repo
+	})
+}
+
+func TestFileWebhookRepoConformance(t *testing.T) {
+	storagetest.RunWebhookRepoTests(t, func(t *testing.T) webhook.Repo {
+		repo, err := NewFileWebhookRepo(filepath.Join(t.TempDir(), "webhooks.json"))
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		return repo
+	})
+}
+
+func TestFileReminderRepoConformance(t *testing.T) {
+	storagetest.RunReminderRepoTests(t, func(t *testing.T) reminder.Repo {
+		repo, err := NewFileReminderRepo(filepath.Join(t.TempDir(), "reminders.json"))
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		return repo
+	})
+}
+
+func TestFileReminderRepo_ReloadsCheckpoint(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "reminders.json")
+	repo, err := NewFileReminderRepo(path)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	at := time.Date(2026, 3, 1, 9, 30, 0, 0, time.UTC)
+	if err := repo.SetCheckpoint(t.Context(), at); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	repo.Close()
+
+	// Check the checkpoint survives a restart
+	reopened, err := NewFileReminderRepo(path)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if got, err := reopened.Checkpoint(t.Context()); err != nil || !got.Equal(at) {
+		t.Fatalf("expected checkpoint %v, got %v, %v", at, got, err)
+	}
+}
+
+func TestFileHistoryRepo_ReloadsAndDropsTornLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	repo, err := NewFileHistoryRepo(path)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if err := repo.Append(t.Context(), todo.Event{TaskID: 1, Kind: todo.EventCreated}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	repo.Close()
+
+	// Simulate a crash in the middle of an append
+	f, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	f.WriteString(`{"ID":2,"TaskID":1,"Ki`)
+	f.Close()
+
+	repo, err = NewFileHistoryRepo(path)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	defer repo.Close()
+	if err := repo.Append(t.Context(), todo.Event{TaskID: 1, Kind: todo.EventDeleted}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	events, _ := repo.ListByTask(t.Context(), 1)
+	if len(events) != 2 || events[1].ID != 2 || events[1].Kind != todo.EventDeleted {
+		t.Fatalf("expected created and deleted events, got %+v", events)
+	}
+}
+
+func TestFileRepo_RemovesStaleTempFiles(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "tasks.json")
+
+	// Simulate a write interrupted before its rename
+	stale := filepath.Join(dir, "tasks.json-123.tmp")
+	if err := os.WriteFile(stale, []byte("{"), 0o644); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if _, err := NewFileTaskRepo(path); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Fatalf("expected stale temp file to be removed, got %v", err)
+	}
+}
+
+func TestFileRepo_UpgradeCopiesCategoriesIntoTags(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tasks.json")
+
+	// A file written before tags: no schema field
+	os.WriteFile(path, []byte(`{"next_id":3,"tasks":[
+		{"ID":1,"Title":"a","Category":"Work Stuff","Version":1},
+		{"ID":2,"Title":"b","Version":1}
+	]}`), 0o644)
+
+	repo, err := NewFileTaskRepo(path)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	task, _ := repo.GetByID(t.Context(), 1)
+	if !slices.Equal(task.Tags, []string{"work-stuff"}) || task.Category == nil {
+		t.Fatalf("expected category kept and copied to tags, got %+v", task)
+	}
+	if task, _ := repo.GetByID(t.Context(), 2); task.Tags != nil {
+		t.Fatalf("expected no tags, got %v", task.Tags)
+	}
+
+	// Check the upgrade is saved and does not run again
+	task.Tags = nil
+	repo.Update(t.Context(), task)
+
+	reopened, err := NewFileTaskRepo(path)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if got, _ := reopened.GetByID(t.Context(), 1); got.Tags != nil {
+		t.Fatalf("expected removed tag to stay removed, got %v", got.Tags)
+	}
+	if reopened.state.Schema != fileSchema {
+		t.Fatalf("expected schema %d, got %d", fileSchema, reopened.state.Schema)
+	}
+}
+
+func TestFileRepo_UpgradeSkipsInvalidCategoryTags(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tasks.json")
+
+	os.WriteFile(path, []byte(`{"next_id":4,"tasks":[
+		{"ID":1,"Title":"a","Category":"work","Version":1},
+		{"ID":2,"Title":"b","Category":"`+strings.Repeat("x", 60)+`","Version":1},
+		{"ID":3,"Title":"c","Category":"a\tb","Version":1}
+	]}`), 0o644)
+
+	repo, err := NewFileTaskRepo(path)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	checkCategoryTagsEditable(t, repo)
+}
+
+func TestFileRepo_AtomicKeepsSchema(t *testing.T) {
+	for name, opts := range map[string][]FileOption{"snapshot": nil, "journal": {WithJournal(1)}} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "tasks.json")
+			repo, err := NewFileTaskRepo(path, opts...)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			svc := todo.NewService(repo)
+			task, err := svc.CreateTask(t.Context(), todo.CreateTaskInput{Title: "a", Category: strPtr("work"), Tags: []string{"work"}})
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			// Removing the tag goes through Atomic, then compacts in journal mode
+			if _, err := svc.UpdateTask(t.Context(), task.ID, todo.UpdateTaskInput{Tags: todo.Clear[[]string]()}); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			repo.Close()
+
+			var raw struct {
+				Schema int `json:"schema"`
+			}
+			b, _ := os.ReadFile(path)
+			if err := json.Unmarshal(b, &raw); err != nil || raw.Schema != fileSchema {
+				t.Fatalf("expected schema %d on disk, got %d (%v)", fileSchema, raw.Schema, err)
+			}
+
+			// Check reopening does not run the upgrade again
+			reopened, err := NewFileTaskRepo(path, opts...)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			defer reopened.Close()
+			if got, _ := reopened.GetByID(t.Context(), task.ID); got.Tags != nil {
+				t.Fatalf("expected removed tag to stay removed, got %v", got.Tags)
+			}
+		})
+	}
+}
@@ -0,0 +1,149 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/Saintrad/todo-server-client/internal/auth"
+	"github.com/Saintrad/todo-server-client/internal/todo"
+)
+
+type userState struct {
+	NextID int         `json:"next_id"`
+	Users  []auth.User `json:"users"`
+}
+
+var _ auth.UserRepo = (*FileUserRepo)(nil)
+
+// FileUserRepo stores v2 user accounts in a JSON file next to the tasks.
+type FileUserRepo struct {
+	mu       sync.Mutex
+	filePath string
+	state    userState
+	closed   bool
+}
+
+// NewFileUserRepo loads users from file if present, otherwise starts empty.
+func NewFileUserRepo(path string) (*FileUserRepo, error) {
+	r := &FileUserRepo{
+		filePath: path,
+		state: userState{
+			NextID: 1,
+			Users:  make([]auth.User, 0),
+		},
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	if err := removeStaleTemps(path); err != nil {
+		return nil, err
+	}
+
+	var st userState
+	found, err := readJSONFile(path, &st)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load %s: %w", path, err)
+	}
+	if !found {
+		return r, nil
+	}
+
+	if st.NextID <= 0 {
+		for _, u := range st.Users {
+			st.NextID = max(st.NextID, u.ID)
+		}
+		st.NextID++
+	}
+	if st.Users == nil {
+		st.Users = make([]auth.User, 0)
+	}
+
+	r.state = st
+	return r, nil
+}
+
+// CreateUser assigns an ID and stores the user. Usernames are unique,
+// compared case-insensitively.
+func (r *FileUserRepo) CreateUser(ctx context.Context, u auth.User) (auth.User, error) {
+	if err := ctx.Err(); err != nil {
+		return auth.User{}, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return auth.User{}, todo.ErrRepoClosed
+	}
+
+	for _, existing := range r.state.Users {
+		if strings.EqualFold(existing.Username, u.Username) {
+			return auth.User{}, auth.ErrUsernameTaken
+		}
+	}
+
+	u.ID = r.state.NextID
+	r.state.NextID++
+	r.state.Users = append(r.state.Users, u)
+
+	if err := writeJSONAtomic(r.filePath, r.state); err != nil {
+		r.state.Users = r.state.Users[:len(r.state.Users)-1]
+		r.state.NextID--
+		return auth.User{}, err
+	}
+	return u, nil
+}
+
+func (r *FileUserRepo) GetUserByID(ctx context.Context, id int) (auth.User, error) {
+	if err := ctx.Err(); err != nil {
+		return auth.User{}, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return auth.User{}, todo.ErrRepoClosed
+	}
+
+	for _, u := range r.state.Users {
+		if u.ID == id {
+			return u, nil
+		}
+	}
+	return auth.User{}, auth.ErrUserNotFound
+}
+
+func (r *FileUserRepo) GetUserByUsername(ctx context.Context, username string) (auth.User, error) {
+	if err := ctx.Err(); err != nil {
+		return auth.User{}, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return auth.User{}, todo.ErrRepoClosed
+	}
+
+	for _, u := range r.state.Users {
+		if strings.EqualFold(u.Username, username) {
+			return u, nil
+		}
+	}
+	return auth.User{}, auth.ErrUserNotFound
+}
+
+// Close waits for an in-flight write to finish and rejects further calls.
+func (r *FileUserRepo) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.closed = true
+	return nil
+}
@@ -0,0 +1,161 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/Saintrad/todo-server-client/internal/todo"
+	"github.com/Saintrad/todo-server-client/internal/webhook"
+)
+
+var _ webhook.Repo = (*FileWebhookRepo)(nil)
+
+// FileWebhookRepo stores webhooks and their delivery log in a JSON file,
+// rewritten on every change.
+type FileWebhookRepo struct {
+	mu       sync.Mutex
+	filePath string
+	state    webhookState
+	closed   bool
+}
+
+// NewFileWebhookRepo loads webhooks from file if present, otherwise starts
+// empty.
+func NewFileWebhookRepo(path string) (*FileWebhookRepo, error) {
+	r := &FileWebhookRepo{filePath: path, state: newWebhookState()}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	if err := removeStaleTemps(path); err != nil {
+		return nil, err
+	}
+
+	st := newWebhookState()
+	found, err := readJSONFile(path, &st)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load %s: %w", path, err)
+	}
+	if found {
+		r.state = st
+	}
+	return r, nil
+}
+
+// read runs fn on the state.
+func (r *FileWebhookRepo) read(ctx context.Context, fn func(*webhookState) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return todo.ErrRepoClosed
+	}
+	return fn(&r.state)
+}
+
+// write runs fn on the state and saves it, keeping the state unchanged if
+// either fails.
+func (r *FileWebhookRepo) write(ctx context.Context, fn func(*webhookState) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return todo.ErrRepoClosed
+	}
+
+	st := r.state.clone()
+	if err := fn(&st); err != nil {
+		return err
+	}
+	if err := writeJSONAtomic(r.filePath, st); err != nil {
+		return err
+	}
+	r.state = st
+	return nil
+}
+
+func (r *FileWebhookRepo) CreateWebhook(ctx context.Context, w webhook.Webhook) (webhook.Webhook, error) {
+	err := r.write(ctx, func(st *webhookState) error {
+		w = st.create(w)
+		return nil
+	})
+	if err != nil {
+		return webhook.Webhook{}, err
+	}
+	return w, nil
+}
+
+func (r *FileWebhookRepo) ListWebhooks(ctx context.Context, ownerID int) ([]webhook.Webhook, error) {
+	var out []webhook.Webhook
+	err := r.read(ctx, func(st *webhookState) error {
+		out = st.list(ownerID)
+		return nil
+	})
+	return out, err
+}
+
+func (r *FileWebhookRepo) GetWebhook(ctx context.Context, id int) (webhook.Webhook, error) {
+	var w webhook.Webhook
+	err := r.read(ctx, func(st *webhookState) (err error) {
+		w, err = st.get(id)
+		return err
+	})
+	return w, err
+}
+
+func (r *FileWebhookRepo) UpdateWebhook(ctx context.Context, w webhook.Webhook) (webhook.Webhook, error) {
+	err := r.write(ctx, func(st *webhookState) (err error) {
+		w, err = st.update(w)
+		return err
+	})
+	if err != nil {
+		return webhook.Webhook{}, err
+	}
+	return w, nil
+}
+
+func (r *FileWebhookRepo) DeleteWebhook(ctx context.Context, id int) error {
+	return r.write(ctx, func(st *webhookState) error {
+		return st.delete(id)
+	})
+}
+
+func (r *FileWebhookRepo) AppendDelivery(ctx context.Context, d webhook.Delivery) (webhook.Delivery, error) {
+	err := r.write(ctx, func(st *webhookState) (err error) {
+		d, err = st.appendDelivery(d)
+		return err
+	})
+	if err != nil {
+		return webhook.Delivery{}, err
+	}
+	return d, nil
+}
+
+func (r *FileWebhookRepo) ListDeliveries(ctx context.Context, webhookID int) ([]webhook.Delivery, error) {
+	var out []webhook.Delivery
+	err := r.read(ctx, func(st *webhookState) error {
+		out = st.listDeliveries(webhookID)
+		return nil
+	})
+	return out, err
+}
+
+// Close waits for an in-flight write to finish and rejects further calls.
+func (r *FileWebhookRepo) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.closed = true
+	return nil
+}
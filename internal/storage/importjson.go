@@ -0,0 +1,65 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+)
+
+// ImportTaskFile copies the tasks of a FileTaskRepo JSON file into an empty
+// SQLite database, keeping IDs. The journal of a store in journal mode is
+// replayed on top, leaving both files untouched. It returns the number of
+// tasks imported.
+func ImportTaskFile(ctx context.Context, jsonPath string, dst *SQLiteTaskRepo) (int, error) {
+	var st fileState
+	found, err := readJSONFile(jsonPath, &st)
+	if err != nil {
+		return 0, fmt.Errorf("read %s: %w", jsonPath, err)
+	}
+	if st.NextID <= 0 {
+		st.NextID = computeNextID(st.Tasks)
+	}
+
+	src := &FileTaskRepo{filePath: jsonPath, state: st}
+	journal, err := os.ReadFile(src.journalPath())
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+	case err != nil:
+		return 0, fmt.Errorf("read %s: %w", src.journalPath(), err)
+	default:
+		if _, _, err := src.replay(journal); err != nil {
+			return 0, fmt.Errorf("replay %s: %w", src.journalPath(), err)
+		}
+		found = true
+	}
+	if !found {
+		return 0, fmt.Errorf("read %s: no tasks file", jsonPath)
+	}
+
+	st = src.state
+	upgradeFileState(&st)
+	if err := dst.Import(ctx, st.Tasks, st.NextID); err != nil {
+		return 0, err
+	}
+	return len(st.Tasks), nil
+}
+
+// ImportUserFile copies the users of a FileUserRepo JSON file into SQLite.
+// A missing file imports nothing.
+func ImportUserFile(ctx context.Context, jsonPath string, dst *SQLiteUserRepo) (int, error) {
+	var st userState
+	found, err := readJSONFile(jsonPath, &st)
+	if err != nil {
+		return 0, fmt.Errorf("read %s: %w", jsonPath, err)
+	}
+	if !found {
+		return 0, nil
+	}
+
+	if err := dst.Import(ctx, st.Users); err != nil {
+		return 0, err
+	}
+	return len(st.Users), nil
+}
@@ -0,0 +1,241 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/Saintrad/todo-server-client/internal/todo"
+)
+
+// Journal mode
+//
+// Instead of rewriting the whole file on every mutation, FileTaskRepo can
+// append one JSON line per mutation to <path>.journal. On open the journal
+// is replayed on top of the snapshot in <path>; every compactEvery records
+// (and on Close) the snapshot is rewritten and the journal truncated.
+//
+// Replay is idempotent, so a crash between writing the snapshot and
+// truncating the journal is harmless. A crash in the middle of an append
+// leaves a last line without its newline; it is dropped on the next open.
+// The writes of one Atomic call share a single line, so they are replayed
+// all together or not at all.
+
+// DefaultCompactEvery is the journal length that triggers a compaction when
+// WithJournal is given a non-positive value.
+const DefaultCompactEvery = 1000
+
+const (
+	opCreate = "create"
+	opUpdate = "update"
+	opDelete = "delete"
+	opBatch  = "batch"
+)
+
+type journalRecord struct {
+	Op   string     `json:"op"`
+	Task *todo.Task `json:"task,omitempty"`
+	ID   int        `json:"id,omitempty"`
+	// Batch holds the records of one transaction (op "batch").
+	Batch []journalRecord `json:"batch,omitempty"`
+}
+
+// WithJournal enables journal mode, compacting after compactEvery records.
+func WithJournal(compactEvery int) FileOption {
+	return func(r *FileTaskRepo) {
+		if compactEvery <= 0 {
+			compactEvery = DefaultCompactEvery
+		}
+		r.compactEvery = compactEvery
+	}
+}
+
+// journalFile is the part of *os.File an open journal is used through.
+type journalFile interface {
+	io.WriteSeeker
+	io.Closer
+	Truncate(size int64) error
+	Sync() error
+}
+
+func (r *FileTaskRepo) journalPath() string {
+	return r.filePath + ".journal"
+}
+
+// openJournal replays the journal into r.state, compacts it if it held any
+// records, and keeps it open for appending.
+func (r *FileTaskRepo) openJournal() error {
+	f, err := os.OpenFile(r.journalPath(), os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return err
+	}
+
+	data, err := io.ReadAll(f)
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	good, n, err := r.replay(data)
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("replay %s: %w", r.journalPath(), err)
+	}
+
+	// Drop a torn last record so new appends start on a clean line
+	if good < int64(len(data)) {
+		if err := f.Truncate(good); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	if _, err := f.Seek(good, io.SeekStart); err != nil {
+		f.Close()
+		return err
+	}
+
+	r.journal = f
+	r.journalSize = good
+	r.journalLen = n
+
+	if n > 0 {
+		if err := r.compactLocked(); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	return nil
+}
+
+// replay applies every complete record in data. It returns the length of
+// the valid prefix and the number of records applied. Only the last line
+// may be damaged; damage anywhere else is an error.
+func (r *FileTaskRepo) replay(data []byte) (int64, int, error) {
+	var offset int64
+	n := 0
+
+	for len(data) > 0 {
+		line, rest, complete := bytes.Cut(data, []byte{'\n'})
+
+		var rec journalRecord
+		err := json.Unmarshal(line, &rec)
+		if err != nil || !complete {
+			if len(bytes.TrimSpace(rest)) == 0 {
+				// Truncated or torn last record
+				return offset, n, nil
+			}
+			return 0, 0, fmt.Errorf("record %d: %w", n+1, err)
+		}
+
+		if err := r.applyRecord(rec); err != nil {
+			return 0, 0, fmt.Errorf("record %d: %w", n+1, err)
+		}
+
+		n++
+		offset += int64(len(line)) + 1
+		data = rest
+	}
+	return offset, n, nil
+}
+
+// applyRecord replays one mutation. Creates and updates are upserts and
+// deletes of missing tasks are ignored, so replaying a record twice is safe.
+func (r *FileTaskRepo) applyRecord(rec journalRecord) error {
+	switch rec.Op {
+	case opCreate, opUpdate:
+		if rec.Task == nil {
+			return fmt.Errorf("%s record without task", rec.Op)
+		}
+		t := *rec.Task
+
+		replaced := false
+		for idx := range r.state.Tasks {
+			if r.state.Tasks[idx].ID == t.ID {
+				r.state.Tasks[idx] = t
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			r.state.Tasks = append(r.state.Tasks, t)
+		}
+		r.state.NextID = max(r.state.NextID, t.ID+1)
+
+	case opDelete:
+		for idx, task := range r.state.Tasks {
+			if task.ID == rec.ID {
+				r.state.Tasks = append(r.state.Tasks[:idx], r.state.Tasks[idx+1:]...)
+				break
+			}
+		}
+
+	case opBatch:
+		for _, sub := range rec.Batch {
+			if err := r.applyRecord(sub); err != nil {
+				return err
+			}
+		}
+
+	default:
+		return fmt.Errorf("unknown op %q", rec.Op)
+	}
+	return nil
+}
+
+// appendJournalLocked durably appends records as one line, so they are
+// replayed together, compacting when the journal has grown past
+// compactEvery. Call only while holding r.mu.
+func (r *FileTaskRepo) appendJournalLocked(recs ...journalRecord) error {
+	rec := recs[0]
+	if len(recs) > 1 {
+		rec = journalRecord{Op: opBatch, Batch: recs}
+	}
+
+	b, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	buf.Write(b)
+	buf.WriteByte('\n')
+
+	_, err = r.journal.Write(buf.Bytes())
+	if err == nil {
+		err = r.journal.Sync()
+	}
+	if err != nil {
+		// Cut off a partial or unsynced record so the journal ends at
+		// journalSize again and the next append starts on a clean line
+		_ = r.journal.Truncate(r.journalSize)
+		_, _ = r.journal.Seek(r.journalSize, io.SeekStart)
+		return err
+	}
+	r.journalSize += int64(buf.Len())
+	r.journalLen += len(recs)
+
+	if r.journalLen >= r.compactEvery {
+		// The records are durable; a failed compaction is retried on the
+		// next append or on Close.
+		_ = r.compactLocked()
+	}
+	return nil
+}
+
+// compactLocked writes a snapshot of r.state and empties the journal.
+// Call only while holding r.mu.
+func (r *FileTaskRepo) compactLocked() error {
+	if err := r.saveLocked(); err != nil {
+		return err
+	}
+	if err := r.journal.Truncate(0); err != nil {
+		return err
+	}
+	if _, err := r.journal.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	r.journalSize = 0
+	r.journalLen = 0
+	return nil
+}
@@ -0,0 +1,226 @@
+package storage
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Saintrad/todo-server-client/internal/storage/storagetest"
+	"github.com/Saintrad/todo-server-client/internal/todo"
+)
+
+func TestJournalRepoConformance(t *testing.T) {
+	storagetest.RunTaskRepoTests(t, func(t *testing.T) todo.TaskRepo {
+		// A small threshold so the suite also exercises compaction
+		repo, err := NewFileTaskRepo(filepath.Join(t.TempDir(), "tasks.json"), WithJournal(7))
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		return repo
+	})
+}
+
+func TestJournal_ReplaysWithoutClose(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tasks.json")
+
+	repo, err := NewFileTaskRepo(path, WithJournal(100))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	repo.Create(t.Context(), todo.Task{Title: "first"})
+	second, _ := repo.Create(t.Context(), todo.Task{Title: "second"})
+	repo.Create(t.Context(), todo.Task{Title: "third"})
+	second.Title = "second, edited"
+	repo.Update(t.Context(), second)
+	repo.Delete(t.Context(), 1)
+
+	// Mutations go to the journal, not the snapshot
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("expected no snapshot before compaction, got %v", err)
+	}
+
+	// Simulate a crash: reopen without Close
+	reopened, err := NewFileTaskRepo(path, WithJournal(100))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	defer reopened.Close()
+
+	page, _ := reopened.List(t.Context(), todo.ListQuery{Sort: todo.SortCreatedAt, Order: todo.OrderAsc})
+	if page.Total != 2 {
+		t.Fatalf("expected 2 tasks after replay, got %+v", page.Items)
+	}
+	if got, _ := reopened.GetByID(t.Context(), 2); got.Title != "second, edited" {
+		t.Fatalf("expected update to be replayed, got %q", got.Title)
+	}
+	if created, _ := reopened.Create(t.Context(), todo.Task{Title: "fourth"}); created.ID != 4 {
+		t.Fatalf("expected next ID 4, got %d", created.ID)
+	}
+
+	// Replay compacts into the snapshot
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("expected snapshot after replay, got %v", err)
+	}
+}
+
+func TestJournal_ToleratesTruncatedLastRecord(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tasks.json")
+
+	repo, _ := NewFileTaskRepo(path, WithJournal(100))
+	repo.Create(t.Context(), todo.Task{Title: "kept"})
+
+	// Simulate a crash in the middle of an append
+	f, _ := os.OpenFile(path+".journal", os.O_APPEND|os.O_WRONLY, 0o644)
+	f.WriteString(`{"op":"create","task":{"ID":2,"Tit`)
+	f.Close()
+
+	reopened, err := NewFileTaskRepo(path, WithJournal(100))
+	if err != nil {
+		t.Fatalf("expected torn record to be tolerated, got %v", err)
+	}
+
+	page, _ := reopened.List(t.Context(), todo.ListQuery{})
+	if page.Total != 1 || page.Items[0].Title != "kept" {
+		t.Fatalf("expected only the complete record, got %+v", page.Items)
+	}
+
+	// New appends must not be glued to the torn record
+	reopened.Create(t.Context(), todo.Task{Title: "after crash"})
+	reopened2, err := NewFileTaskRepo(path, WithJournal(100))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if page, _ := reopened2.List(t.Context(), todo.ListQuery{}); page.Total != 2 {
+		t.Fatalf("expected 2 tasks, got %+v", page.Items)
+	}
+}
+
+func TestJournal_RejectsCorruptionInTheMiddle(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tasks.json")
+
+	journal := strings.Join([]string{
+		`{"op":"create","task":{"ID":1,"Title":"a"}}`,
+		`garbage`,
+		`{"op":"create","task":{"ID":2,"Title":"b"}}`,
+	}, "\n") + "\n"
+	os.WriteFile(path+".journal", []byte(journal), 0o644)
+
+	if _, err := NewFileTaskRepo(path, WithJournal(100)); err == nil {
+		t.Fatalf("expected corrupt journal to be rejected")
+	}
+}
+
+func TestJournal_CompactsAfterThreshold(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tasks.json")
+
+	repo, _ := NewFileTaskRepo(path, WithJournal(3))
+	for i := 0; i < 4; i++ {
+		repo.Create(t.Context(), todo.Task{Title: "task"})
+	}
+
+	// Three records were compacted, one is left in the journal
+	data, _ := os.ReadFile(path + ".journal")
+	if n := strings.Count(string(data), "\n"); n != 1 {
+		t.Fatalf("expected 1 journal record after compaction, got %d", n)
+	}
+
+	var st fileState
+	readJSONFile(path, &st)
+	if len(st.Tasks) != 3 || st.NextID != 4 {
+		t.Fatalf("expected snapshot with 3 tasks, got %+v", st)
+	}
+
+	// Close folds the rest into the snapshot
+	repo.Close()
+	data, _ = os.ReadFile(path + ".journal")
+	if len(data) != 0 {
+		t.Fatalf("expected empty journal after Close, got %q", data)
+	}
+}
+
+func TestJournal_AtomicWritesReplayTogether(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tasks.json")
+
+	repo, _ := NewFileTaskRepo(path, WithJournal(100))
+	repo.Create(t.Context(), todo.Task{Title: "kept"})
+	err := repo.Atomic(t.Context(), func(tx todo.TaskStore) error {
+		tx.Create(t.Context(), todo.Task{Title: "a"})
+		tx.Create(t.Context(), todo.Task{Title: "b"})
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	// The transaction is a single journal record
+	data, _ := os.ReadFile(path + ".journal")
+	if n := strings.Count(string(data), "\n"); n != 2 {
+		t.Fatalf("expected 2 journal records, got %d", n)
+	}
+
+	// Tear the batch: neither of its writes may survive
+	os.WriteFile(path+".journal", data[:len(data)-10], 0o644)
+
+	reopened, err := NewFileTaskRepo(path, WithJournal(100))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	page, _ := reopened.List(t.Context(), todo.ListQuery{})
+	if page.Total != 1 || page.Items[0].Title != "kept" {
+		t.Fatalf("expected only the first record, got %+v", page.Items)
+	}
+}
+
+// failingSync is a journal file whose Sync fails while fail is set.
+type failingSync struct {
+	journalFile
+	fail bool
+}
+
+func (f *failingSync) Sync() error {
+	if f.fail {
+		return errors.New("sync failed")
+	}
+	return f.journalFile.Sync()
+}
+
+func TestJournal_FailedSyncIsCutOff(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tasks.json")
+
+	repo, err := NewFileTaskRepo(path, WithJournal(100))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	file := &failingSync{journalFile: repo.journal}
+	repo.journal = file
+
+	repo.Create(t.Context(), todo.Task{Title: "first"})
+	file.fail = true
+	if _, err := repo.Create(t.Context(), todo.Task{Title: "lost"}); err == nil {
+		t.Fatalf("expected the failed sync to fail the create")
+	}
+	file.fail = false
+	repo.Create(t.Context(), todo.Task{Title: "second"})
+
+	// Check the unsynced record is gone and the journal matches its size
+	data, _ := os.ReadFile(path + ".journal")
+	if strings.Contains(string(data), "lost") || strings.Count(string(data), "\n") != 2 {
+		t.Fatalf("expected the first and second records only, got %q", data)
+	}
+	if int64(len(data)) != repo.journalSize {
+		t.Fatalf("expected journal size %d, got %d", len(data), repo.journalSize)
+	}
+
+	// Check a replay sees what the repo saw
+	reopened, err := NewFileTaskRepo(path, WithJournal(100))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	defer reopened.Close()
+	page, _ := reopened.List(t.Context(), todo.ListQuery{Sort: todo.SortCreatedAt, Order: todo.OrderAsc})
+	if page.Total != 2 || page.Items[1].Title != "second" || page.Items[1].ID != 2 {
+		t.Fatalf("expected first and second, got %+v", page.Items)
+	}
+}
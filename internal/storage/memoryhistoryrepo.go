@@ -0,0 +1,74 @@
+package storage
+
+import (
+	"context"
+	"sync"
+
+	"github.com/Saintrad/todo-server-client/internal/todo"
+)
+
+var _ todo.HistoryRepo = (*MemoryHistoryRepo)(nil)
+
+type MemoryHistoryRepo struct {
+	mu     sync.Mutex
+	events []todo.Event
+	closed bool
+}
+
+func NewMemoryHistoryRepo() *MemoryHistoryRepo {
+	return &MemoryHistoryRepo{}
+}
+
+func (r *MemoryHistoryRepo) Append(ctx context.Context, events ...todo.Event) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return todo.ErrRepoClosed
+	}
+
+	for _, e := range events {
+		e.ID = len(r.events) + 1
+		r.events = append(r.events, e)
+	}
+	return nil
+}
+
+func (r *MemoryHistoryRepo) ListByTask(ctx context.Context, taskID int) ([]todo.Event, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return nil, todo.ErrRepoClosed
+	}
+
+	return eventsOf(r.events, taskID), nil
+}
+
+// Close rejects further calls; the events are discarded.
+func (r *MemoryHistoryRepo) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.closed = true
+	return nil
+}
+
+// eventsOf returns the events of taskID, in order.
+func eventsOf(events []todo.Event, taskID int) []todo.Event {
+	out := make([]todo.Event, 0)
+	for _, e := range events {
+		if e.TaskID == taskID {
+			out = append(out, e)
+		}
+	}
+	return out
+}
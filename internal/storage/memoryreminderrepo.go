@@ -0,0 +1,59 @@
+package storage
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Saintrad/todo-server-client/internal/reminder"
+	"github.com/Saintrad/todo-server-client/internal/todo"
+)
+
+var _ reminder.Repo = (*MemoryReminderRepo)(nil)
+
+// MemoryReminderRepo keeps the reminder checkpoint in memory; like the
+// memory task repo it starts over on restart.
+type MemoryReminderRepo struct {
+	mu         sync.Mutex
+	checkpoint time.Time
+	closed     bool
+}
+
+func NewMemoryReminderRepo() *MemoryReminderRepo {
+	return &MemoryReminderRepo{}
+}
+
+func (r *MemoryReminderRepo) Checkpoint(ctx context.Context) (time.Time, error) {
+	if err := ctx.Err(); err != nil {
+		return time.Time{}, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return time.Time{}, todo.ErrRepoClosed
+	}
+	return r.checkpoint, nil
+}
+
+func (r *MemoryReminderRepo) SetCheckpoint(ctx context.Context, t time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return todo.ErrRepoClosed
+	}
+	r.checkpoint = t
+	return nil
+}
+
+func (r *MemoryReminderRepo) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.closed = true
+	return nil
+}
@@ -1,15 +1,21 @@
 package storage
 
 import (
+	"context"
 	"sync"
 
 	"github.com/Saintrad/todo-server-client/internal/todo"
 )
 
+var _ todo.TaskRepo = (*MemoryTaskRepo)(nil)
+
+// MemoryTaskRepo keeps tasks in process memory only; everything is lost on
+// exit. Useful for tests and throwaway servers.
 type MemoryTaskRepo struct {
 	mu     sync.Mutex
 	tasks  []todo.Task
 	nextID int
+	closed bool
 }
 
 func NewMemoryTaskRepo() *MemoryTaskRepo {
@@ -19,13 +25,21 @@ func NewMemoryTaskRepo() *MemoryTaskRepo {
 	}
 }
 
-func (r *MemoryTaskRepo) Create(t todo.Task) (todo.Task, error) {
+func (r *MemoryTaskRepo) Create(ctx context.Context, t todo.Task) (todo.Task, error) {
+	if err := ctx.Err(); err != nil {
+		return todo.Task{}, err
+	}
 
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
+	if r.closed {
+		return todo.Task{}, todo.ErrRepoClosed
+	}
+
 	// Assign ID
 	t.ID = r.nextID
+	t.Version = 1
 	r.nextID++
 
 	// Save task
@@ -33,3 +47,121 @@ func (r *MemoryTaskRepo) Create(t todo.Task) (todo.Task, error) {
 
 	return t, nil
 }
+
+func (r *MemoryTaskRepo) List(ctx context.Context, q todo.ListQuery) (todo.TaskPage, error) {
+	if err := ctx.Err(); err != nil {
+		return todo.TaskPage{}, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return todo.TaskPage{}, todo.ErrRepoClosed
+	}
+
+	return q.Apply(r.tasks), nil
+}
+
+func (r *MemoryTaskRepo) GetByID(ctx context.Context, id int) (todo.Task, error) {
+	if err := ctx.Err(); err != nil {
+		return todo.Task{}, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return todo.Task{}, todo.ErrRepoClosed
+	}
+
+	for _, task := range r.tasks {
+		if task.ID == id {
+			return task, nil
+		}
+	}
+
+	return todo.Task{}, todo.ErrTaskNotFound
+}
+
+func (r *MemoryTaskRepo) Update(ctx context.Context, t todo.Task) (todo.Task, error) {
+	if err := ctx.Err(); err != nil {
+		return todo.Task{}, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return todo.Task{}, todo.ErrRepoClosed
+	}
+
+	for idx, task := range r.tasks {
+		if task.ID == t.ID {
+			if task.Version != t.Version {
+				return todo.Task{}, todo.ErrVersionConflict
+			}
+			t.Version++
+			r.tasks[idx] = t
+
+			return t, nil
+		}
+	}
+
+	return todo.Task{}, todo.ErrTaskNotFound
+}
+
+func (r *MemoryTaskRepo) Delete(ctx context.Context, id int) (todo.Task, error) {
+	if err := ctx.Err(); err != nil {
+		return todo.Task{}, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return todo.Task{}, todo.ErrRepoClosed
+	}
+
+	for idx, task := range r.tasks {
+		if task.ID == id {
+			r.tasks = append(r.tasks[:idx], r.tasks[idx+1:]...)
+
+			return task, nil
+		}
+	}
+
+	return todo.Task{}, todo.ErrTaskNotFound
+}
+
+// Atomic runs fn on a copy of the tasks while holding the lock, and keeps
+// the copy only if fn succeeds.
+func (r *MemoryTaskRepo) Atomic(ctx context.Context, fn func(todo.TaskStore) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return todo.ErrRepoClosed
+	}
+
+	tx := newTaskTx(r.tasks, r.nextID)
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	r.tasks, r.nextID = tx.tasks, tx.nextID
+	return nil
+}
+
+// Close rejects further calls; the data is discarded.
+func (r *MemoryTaskRepo) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.closed = true
+	return nil
+}
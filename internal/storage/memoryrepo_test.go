@@ -4,14 +4,17 @@ import (
 	"testing"
 	"time"
 
+	"github.com/Saintrad/todo-server-client/internal/reminder"
+	"github.com/Saintrad/todo-server-client/internal/storage/storagetest"
 	"github.com/Saintrad/todo-server-client/internal/todo"
+	"github.com/Saintrad/todo-server-client/internal/webhook"
 )
 
 func TestMemoryRepo(t *testing.T) {
 	repo := NewMemoryTaskRepo()
 
 	now := time.Now()
-	task1, err := repo.Create(todo.Task{
+	task1, err := repo.Create(t.Context(), todo.Task{
 		Title:     "first",
 		DueDate:   &now,
 		CreatedAt: now,
@@ -27,7 +30,7 @@ func TestMemoryRepo(t *testing.T) {
 		t.Fatalf("expected ID 1, got %d", task1.ID)
 	}
 
-	task2, err := repo.Create(todo.Task{
+	task2, err := repo.Create(t.Context(), todo.Task{
 		Title:     "second",
 		DueDate:   &now,
 		CreatedAt: now,
@@ -43,3 +46,27 @@ func TestMemoryRepo(t *testing.T) {
 		t.Fatalf("expected ID 2, got %d", task2.ID)
 	}
 }
+
+func TestMemoryRepoConformance(t *testing.T) {
+	storagetest.RunTaskRepoTests(t, func(t *testing.T) todo.TaskRepo {
+		return NewMemoryTaskRepo()
+	})
+}
+
+func TestMemoryHistoryRepoConformance(t *testing.T) {
+	storagetest.RunHistoryRepoTests(t, func(t *testing.T) todo.HistoryRepo {
+		return NewMemoryHistoryRepo()
+	})
+}
+
+func TestMemoryWebhookRepoConformance(t *testing.T) {
+	storagetest.RunWebhookRepoTests(t, func(t *testing.T) webhook.Repo {
+		return NewMemoryWebhookRepo()
+	})
+}
+
+func TestMemoryReminderRepoConformance(t *testing.T) {
+	storagetest.RunReminderRepoTests(t, func(t *testing.T) reminder.Repo {
+		return NewMemoryReminderRepo()
+	})
+}
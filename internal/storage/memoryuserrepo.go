@@ -0,0 +1,100 @@
+package storage
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/Saintrad/todo-server-client/internal/auth"
+	"github.com/Saintrad/todo-server-client/internal/todo"
+)
+
+var _ auth.UserRepo = (*MemoryUserRepo)(nil)
+
+type MemoryUserRepo struct {
+	mu     sync.Mutex
+	users  []auth.User
+	nextID int
+	closed bool
+}
+
+func NewMemoryUserRepo() *MemoryUserRepo {
+	return &MemoryUserRepo{
+		users:  make([]auth.User, 0),
+		nextID: 1,
+	}
+}
+
+func (r *MemoryUserRepo) CreateUser(ctx context.Context, u auth.User) (auth.User, error) {
+	if err := ctx.Err(); err != nil {
+		return auth.User{}, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return auth.User{}, todo.ErrRepoClosed
+	}
+
+	for _, existing := range r.users {
+		if strings.EqualFold(existing.Username, u.Username) {
+			return auth.User{}, auth.ErrUsernameTaken
+		}
+	}
+
+	u.ID = r.nextID
+	r.nextID++
+	r.users = append(r.users, u)
+
+	return u, nil
+}
+
+func (r *MemoryUserRepo) GetUserByID(ctx context.Context, id int) (auth.User, error) {
+	if err := ctx.Err(); err != nil {
+		return auth.User{}, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return auth.User{}, todo.ErrRepoClosed
+	}
+
+	for _, u := range r.users {
+		if u.ID == id {
+			return u, nil
+		}
+	}
+	return auth.User{}, auth.ErrUserNotFound
+}
+
+func (r *MemoryUserRepo) GetUserByUsername(ctx context.Context, username string) (auth.User, error) {
+	if err := ctx.Err(); err != nil {
+		return auth.User{}, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return auth.User{}, todo.ErrRepoClosed
+	}
+
+	for _, u := range r.users {
+		if strings.EqualFold(u.Username, username) {
+			return u, nil
+		}
+	}
+	return auth.User{}, auth.ErrUserNotFound
+}
+
+// Close rejects further calls; the data is discarded.
+func (r *MemoryUserRepo) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.closed = true
+	return nil
+}
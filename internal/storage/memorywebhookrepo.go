@@ -0,0 +1,211 @@
+package storage
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"github.com/Saintrad/todo-server-client/internal/todo"
+	"github.com/Saintrad/todo-server-client/internal/webhook"
+)
+
+// webhookState holds webhooks and their delivery log for the memory and
+// file repos.
+type webhookState struct {
+	NextID         int                `json:"next_id"`
+	Webhooks       []webhook.Webhook  `json:"webhooks"`
+	NextDeliveryID int                `json:"next_delivery_id"`
+	Deliveries     []webhook.Delivery `json:"deliveries"`
+}
+
+func newWebhookState() webhookState {
+	return webhookState{
+		NextID:         1,
+		Webhooks:       make([]webhook.Webhook, 0),
+		NextDeliveryID: 1,
+		Deliveries:     make([]webhook.Delivery, 0),
+	}
+}
+
+func (st webhookState) clone() webhookState {
+	st.Webhooks = slices.Clone(st.Webhooks)
+	st.Deliveries = slices.Clone(st.Deliveries)
+	return st
+}
+
+func (st *webhookState) create(w webhook.Webhook) webhook.Webhook {
+	w.ID = st.NextID
+	st.NextID++
+	st.Webhooks = append(st.Webhooks, w)
+	return w
+}
+
+func (st *webhookState) list(ownerID int) []webhook.Webhook {
+	out := make([]webhook.Webhook, 0)
+	for _, w := range st.Webhooks {
+		if w.OwnerID == ownerID {
+			out = append(out, w)
+		}
+	}
+	return out
+}
+
+func (st *webhookState) index(id int) int {
+	return slices.IndexFunc(st.Webhooks, func(w webhook.Webhook) bool { return w.ID == id })
+}
+
+func (st *webhookState) get(id int) (webhook.Webhook, error) {
+	i := st.index(id)
+	if i < 0 {
+		return webhook.Webhook{}, webhook.ErrWebhookNotFound
+	}
+	return st.Webhooks[i], nil
+}
+
+func (st *webhookState) update(w webhook.Webhook) (webhook.Webhook, error) {
+	i := st.index(w.ID)
+	if i < 0 {
+		return webhook.Webhook{}, webhook.ErrWebhookNotFound
+	}
+	st.Webhooks[i] = w
+	return w, nil
+}
+
+func (st *webhookState) delete(id int) error {
+	i := st.index(id)
+	if i < 0 {
+		return webhook.ErrWebhookNotFound
+	}
+	st.Webhooks = slices.Delete(st.Webhooks, i, i+1)
+	st.Deliveries = slices.DeleteFunc(st.Deliveries, func(d webhook.Delivery) bool { return d.WebhookID == id })
+	return nil
+}
+
+func (st *webhookState) appendDelivery(d webhook.Delivery) (webhook.Delivery, error) {
+	if st.index(d.WebhookID) < 0 {
+		return webhook.Delivery{}, webhook.ErrWebhookNotFound
+	}
+	d.ID = st.NextDeliveryID
+	st.NextDeliveryID++
+	st.Deliveries = append(st.Deliveries, d)
+
+	// Drop the oldest attempts beyond the limit
+	n := 0
+	for i := len(st.Deliveries) - 1; i >= 0; i-- {
+		if st.Deliveries[i].WebhookID != d.WebhookID {
+			continue
+		}
+		if n++; n > webhook.MaxDeliveryLog {
+			st.Deliveries = slices.Delete(st.Deliveries, i, i+1)
+		}
+	}
+	return d, nil
+}
+
+func (st *webhookState) listDeliveries(webhookID int) []webhook.Delivery {
+	out := make([]webhook.Delivery, 0)
+	for i := len(st.Deliveries) - 1; i >= 0; i-- {
+		if st.Deliveries[i].WebhookID == webhookID {
+			out = append(out, st.Deliveries[i])
+		}
+	}
+	return out
+}
+
+var _ webhook.Repo = (*MemoryWebhookRepo)(nil)
+
+type MemoryWebhookRepo struct {
+	mu     sync.Mutex
+	state  webhookState
+	closed bool
+}
+
+func NewMemoryWebhookRepo() *MemoryWebhookRepo {
+	return &MemoryWebhookRepo{state: newWebhookState()}
+}
+
+// lock locks r and checks that ctx is live and r open; call unlock when
+// it returns nil.
+func (r *MemoryWebhookRepo) lock(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	if r.closed {
+		r.mu.Unlock()
+		return todo.ErrRepoClosed
+	}
+	return nil
+}
+
+func (r *MemoryWebhookRepo) CreateWebhook(ctx context.Context, w webhook.Webhook) (webhook.Webhook, error) {
+	if err := r.lock(ctx); err != nil {
+		return webhook.Webhook{}, err
+	}
+	defer r.mu.Unlock()
+
+	return r.state.create(w), nil
+}
+
+func (r *MemoryWebhookRepo) ListWebhooks(ctx context.Context, ownerID int) ([]webhook.Webhook, error) {
+	if err := r.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer r.mu.Unlock()
+
+	return r.state.list(ownerID), nil
+}
+
+func (r *MemoryWebhookRepo) GetWebhook(ctx context.Context, id int) (webhook.Webhook, error) {
+	if err := r.lock(ctx); err != nil {
+		return webhook.Webhook{}, err
+	}
+	defer r.mu.Unlock()
+
+	return r.state.get(id)
+}
+
+func (r *MemoryWebhookRepo) UpdateWebhook(ctx context.Context, w webhook.Webhook) (webhook.Webhook, error) {
+	if err := r.lock(ctx); err != nil {
+		return webhook.Webhook{}, err
+	}
+	defer r.mu.Unlock()
+
+	return r.state.update(w)
+}
+
+func (r *MemoryWebhookRepo) DeleteWebhook(ctx context.Context, id int) error {
+	if err := r.lock(ctx); err != nil {
+		return err
+	}
+	defer r.mu.Unlock()
+
+	return r.state.delete(id)
+}
+
+func (r *MemoryWebhookRepo) AppendDelivery(ctx context.Context, d webhook.Delivery) (webhook.Delivery, error) {
+	if err := r.lock(ctx); err != nil {
+		return webhook.Delivery{}, err
+	}
+	defer r.mu.Unlock()
+
+	return r.state.appendDelivery(d)
+}
+
+func (r *MemoryWebhookRepo) ListDeliveries(ctx context.Context, webhookID int) ([]webhook.Delivery, error) {
+	if err := r.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer r.mu.Unlock()
+
+	return r.state.listDeliveries(webhookID), nil
+}
+
+// Close rejects further calls; the data is discarded.
+func (r *MemoryWebhookRepo) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.closed = true
+	return nil
+}
@@ -0,0 +1,263 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	_ "modernc.org/sqlite"
+
+	"github.com/Saintrad/todo-server-client/internal/todo"
+)
+
+// sqliteTimeLayout is fixed-width so stored timestamps sort as text.
+const sqliteTimeLayout = "2006-01-02T15:04:05.000000000Z"
+
+// sqliteMigrations are applied in order; PRAGMA user_version records how
+// many have run. Never edit a shipped migration, append a new one.
+var sqliteMigrations = []string{
+	// 1: tasks and users
+	`CREATE TABLE tasks (
+		id         INTEGER PRIMARY KEY AUTOINCREMENT,
+		owner_id   INTEGER NOT NULL DEFAULT 0,
+		title      TEXT    NOT NULL,
+		category   TEXT,
+		due_date   TEXT,
+		is_done    INTEGER NOT NULL DEFAULT 0,
+		created_at TEXT    NOT NULL,
+		updated_at TEXT    NOT NULL
+	);
+	CREATE INDEX tasks_owner_done     ON tasks(owner_id, is_done);
+	CREATE INDEX tasks_owner_due      ON tasks(owner_id, due_date);
+	CREATE INDEX tasks_owner_category ON tasks(owner_id, category);
+
+	CREATE TABLE users (
+		id            INTEGER PRIMARY KEY AUTOINCREMENT,
+		username      TEXT    NOT NULL UNIQUE COLLATE NOCASE,
+		password_hash BLOB    NOT NULL,
+		created_at    TEXT    NOT NULL
+	);`,
+
+	// 2: optimistic concurrency
+	`ALTER TABLE tasks ADD COLUMN version INTEGER NOT NULL DEFAULT 1;`,
+
+	// 3: subtasks. The service keeps parents valid; there is no foreign key
+	// so that all backends accept the same writes.
+	`ALTER TABLE tasks ADD COLUMN parent_id INTEGER;
+	CREATE INDEX tasks_parent ON tasks(parent_id);`,
+
+	// 4: recurring tasks, stored in their text form
+	`ALTER TABLE tasks ADD COLUMN recurrence TEXT;`,
+
+	// 5: tags, starting with each task's category (see sqliteBackfills)
+	`CREATE TABLE task_tags (
+		task_id INTEGER NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+		tag     TEXT    NOT NULL,
+		PRIMARY KEY (task_id, tag)
+	) WITHOUT ROWID;
+	CREATE INDEX task_tags_tag ON task_tags(tag);`,
+
+	// 6: priorities (todo.Priority values, 0 for none)
+	`ALTER TABLE tasks ADD COLUMN priority INTEGER NOT NULL DEFAULT 0;`,
+
+	// 7: trash; deleted tasks keep their row until purged
+	`ALTER TABLE tasks ADD COLUMN deleted_at TEXT;
+	CREATE INDEX tasks_deleted ON tasks(deleted_at);`,
+
+	// 8: task history; no foreign key, events outlive purged tasks
+	`CREATE TABLE task_events (
+		id       INTEGER PRIMARY KEY,
+		task_id  INTEGER NOT NULL,
+		owner_id INTEGER NOT NULL,
+		kind     TEXT    NOT NULL,
+		actor    TEXT    NOT NULL DEFAULT '',
+		at       TEXT    NOT NULL,
+		changes  TEXT    NOT NULL DEFAULT '[]'
+	);
+	CREATE INDEX task_events_task ON task_events(task_id, id);`,
+
+	// 9: webhooks and their delivery log; events is a comma list of kinds
+	`CREATE TABLE webhooks (
+		id         INTEGER PRIMARY KEY AUTOINCREMENT,
+		owner_id   INTEGER NOT NULL,
+		url        TEXT    NOT NULL,
+		events     TEXT    NOT NULL DEFAULT '',
+		secret     TEXT    NOT NULL,
+		created_at TEXT    NOT NULL,
+		updated_at TEXT    NOT NULL
+	);
+	CREATE INDEX webhooks_owner ON webhooks(owner_id);
+
+	CREATE TABLE webhook_deliveries (
+		id          INTEGER PRIMARY KEY AUTOINCREMENT,
+		webhook_id  INTEGER NOT NULL REFERENCES webhooks(id) ON DELETE CASCADE,
+		key         TEXT    NOT NULL,
+		event       TEXT    NOT NULL,
+		task_id     INTEGER NOT NULL,
+		attempt     INTEGER NOT NULL,
+		status_code INTEGER NOT NULL,
+		error       TEXT    NOT NULL DEFAULT '',
+		duration_ns INTEGER NOT NULL,
+		at          TEXT    NOT NULL
+	);
+	CREATE INDEX webhook_deliveries_webhook ON webhook_deliveries(webhook_id, id);`,
+
+	// 10: reminders, a comma list of their text forms, and how far the
+	// reminder scheduler has got
+	`ALTER TABLE tasks ADD COLUMN reminders TEXT;
+
+	CREATE TABLE reminder_state (
+		id         INTEGER PRIMARY KEY CHECK (id = 1),
+		checkpoint TEXT NOT NULL
+	);`,
+}
+
+// sqliteBackfills run after the migration with the same number, in its
+// transaction, for data changes that must match Go code exactly.
+var sqliteBackfills = map[int]func(*sql.Tx) error{
+	5: backfillCategoryTags,
+}
+
+// backfillCategoryTags tags every task with its category, as
+// todo.CategoryTag turns it into a tag.
+func backfillCategoryTags(tx *sql.Tx) error {
+	rows, err := tx.Query(`SELECT id, category FROM tasks WHERE category IS NOT NULL`)
+	if err != nil {
+		return err
+	}
+	tags := map[int]string{}
+	for rows.Next() {
+		var id int
+		var category string
+		if err := rows.Scan(&id, &category); err != nil {
+			rows.Close()
+			return err
+		}
+		if tag, ok := todo.CategoryTag(category); ok {
+			tags[id] = tag
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for id, tag := range tags {
+		if _, err := tx.Exec(`INSERT OR IGNORE INTO task_tags (task_id, tag) VALUES (?, ?)`, id, tag); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// OpenSQLite opens (creating if needed) the database at path and brings its
+// schema up to date.
+func OpenSQLite(path string) (*sql.DB, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+
+	// WAL lets readers run alongside the writer; immediate transactions take
+	// the write lock up front so concurrent read-then-write transactions
+	// wait on busy_timeout instead of failing.
+	dsn := "file:" + path +
+		"?_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)&_pragma=foreign_keys(1)&_txlock=immediate"
+
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, err
+	}
+	if err := migrateSQLite(db); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("migrate %s: %w", path, err)
+	}
+	return db, nil
+}
+
+func migrateSQLite(db *sql.DB) error {
+	var version int
+	if err := db.QueryRow(`PRAGMA user_version`).Scan(&version); err != nil {
+		return err
+	}
+	if version > len(sqliteMigrations) {
+		return fmt.Errorf("database schema version %d is newer than this server (%d)", version, len(sqliteMigrations))
+	}
+
+	for i := version; i < len(sqliteMigrations); i++ {
+		tx, err := db.Begin()
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(sqliteMigrations[i]); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migration %d: %w", i+1, err)
+		}
+		if backfill := sqliteBackfills[i+1]; backfill != nil {
+			if err := backfill(tx); err != nil {
+				tx.Rollback()
+				return fmt.Errorf("migration %d: %w", i+1, err)
+			}
+		}
+		// PRAGMA does not take bind parameters
+		if _, err := tx.Exec(fmt.Sprintf(`PRAGMA user_version = %d`, i+1)); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func formatSQLiteTime(t time.Time) string {
+	return t.UTC().Format(sqliteTimeLayout)
+}
+
+func parseSQLiteTime(s string) (time.Time, error) {
+	return time.Parse(sqliteTimeLayout, s)
+}
+
+// nullTime converts an optional time to a nullable column value.
+func nullTime(t *time.Time) sql.NullString {
+	if t == nil {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: formatSQLiteTime(*t), Valid: true}
+}
+
+func nullString(s *string) sql.NullString {
+	if s == nil {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: *s, Valid: true}
+}
+
+func nullRecurrence(r *todo.Recurrence) sql.NullString {
+	if r == nil {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: r.String(), Valid: true}
+}
+
+// nullReminders stores reminders as a comma list of their text forms.
+func nullReminders(rs []todo.Reminder) sql.NullString {
+	if len(rs) == 0 {
+		return sql.NullString{}
+	}
+	parts := make([]string, len(rs))
+	for i, r := range rs {
+		parts[i] = r.String()
+	}
+	return sql.NullString{String: strings.Join(parts, ","), Valid: true}
+}
+
+func nullInt(n *int) sql.NullInt64 {
+	if n == nil {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{Int64: int64(*n), Valid: true}
+}
@@ -0,0 +1,88 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/Saintrad/todo-server-client/internal/todo"
+)
+
+var _ todo.HistoryRepo = (*SQLiteHistoryRepo)(nil)
+
+// SQLiteHistoryRepo stores events in the database shared with
+// SQLiteTaskRepo. Closing it does not close the database; the task repo
+// owns it.
+type SQLiteHistoryRepo struct {
+	db     *sql.DB
+	closed atomic.Bool
+}
+
+func NewSQLiteHistoryRepo(db *sql.DB) *SQLiteHistoryRepo {
+	return &SQLiteHistoryRepo{db: db}
+}
+
+func (r *SQLiteHistoryRepo) Append(ctx context.Context, events ...todo.Event) error {
+	if r.closed.Load() {
+		return todo.ErrRepoClosed
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, e := range events {
+		changes, err := json.Marshal(e.Changes)
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx,
+			`INSERT INTO task_events (task_id, owner_id, kind, actor, at, changes) VALUES (?, ?, ?, ?, ?, ?)`,
+			e.TaskID, e.OwnerID, string(e.Kind), e.Actor, formatSQLiteTime(e.At), string(changes),
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (r *SQLiteHistoryRepo) ListByTask(ctx context.Context, taskID int) ([]todo.Event, error) {
+	if r.closed.Load() {
+		return nil, todo.ErrRepoClosed
+	}
+
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT id, task_id, owner_id, kind, actor, at, changes FROM task_events WHERE task_id = ? ORDER BY id`, taskID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]todo.Event, 0)
+	for rows.Next() {
+		var e todo.Event
+		var kind, at, changes string
+		if err := rows.Scan(&e.ID, &e.TaskID, &e.OwnerID, &kind, &e.Actor, &at, &changes); err != nil {
+			return nil, err
+		}
+		e.Kind = todo.EventKind(kind)
+		if e.At, err = parseSQLiteTime(at); err != nil {
+			return nil, fmt.Errorf("event %d: %w", e.ID, err)
+		}
+		if err := json.Unmarshal([]byte(changes), &e.Changes); err != nil {
+			return nil, fmt.Errorf("event %d: %w", e.ID, err)
+		}
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
+
+func (r *SQLiteHistoryRepo) Close() error {
+	r.closed.Store(true)
+	return nil
+}
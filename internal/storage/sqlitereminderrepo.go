@@ -0,0 +1,60 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"github.com/Saintrad/todo-server-client/internal/reminder"
+	"github.com/Saintrad/todo-server-client/internal/todo"
+)
+
+var _ reminder.Repo = (*SQLiteReminderRepo)(nil)
+
+// SQLiteReminderRepo keeps the reminder checkpoint in the database shared
+// with SQLiteTaskRepo. Closing it does not close the database; the task
+// repo owns it.
+type SQLiteReminderRepo struct {
+	db     *sql.DB
+	closed atomic.Bool
+}
+
+func NewSQLiteReminderRepo(db *sql.DB) *SQLiteReminderRepo {
+	return &SQLiteReminderRepo{db: db}
+}
+
+func (r *SQLiteReminderRepo) Checkpoint(ctx context.Context) (time.Time, error) {
+	if r.closed.Load() {
+		return time.Time{}, todo.ErrRepoClosed
+	}
+
+	var s string
+	err := r.db.QueryRowContext(ctx, `SELECT checkpoint FROM reminder_state WHERE id = 1`).Scan(&s)
+	if errors.Is(err, sql.ErrNoRows) {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+	return parseSQLiteTime(s)
+}
+
+func (r *SQLiteReminderRepo) SetCheckpoint(ctx context.Context, t time.Time) error {
+	if r.closed.Load() {
+		return todo.ErrRepoClosed
+	}
+
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO reminder_state (id, checkpoint) VALUES (1, ?)
+		 ON CONFLICT (id) DO UPDATE SET checkpoint = excluded.checkpoint`,
+		formatSQLiteTime(t),
+	)
+	return err
+}
+
+func (r *SQLiteReminderRepo) Close() error {
+	r.closed.Store(true)
+	return nil
+}
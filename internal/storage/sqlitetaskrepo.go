@@ -0,0 +1,508 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/Saintrad/todo-server-client/internal/todo"
+)
+
+var _ todo.TaskRepo = (*SQLiteTaskRepo)(nil)
+
+// SQLiteTaskRepo stores tasks in an SQLite database opened with OpenSQLite.
+// It owns the connection: Close closes the database for every repo that
+// shares it.
+type SQLiteTaskRepo struct {
+	db     *sql.DB
+	closed atomic.Bool
+}
+
+func NewSQLiteTaskRepo(db *sql.DB) *SQLiteTaskRepo {
+	return &SQLiteTaskRepo{db: db}
+}
+
+// sqlQueryer is satisfied by both *sql.DB and *sql.Tx.
+type sqlQueryer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+const taskColumns = `id, owner_id, title, category, due_date, is_done, created_at, updated_at, version, parent_id, recurrence, priority, deleted_at, reminders`
+
+// taskSelect is taskColumns plus the task's tags, comma-separated.
+const taskSelect = taskColumns + `, (SELECT group_concat(tag, ',') FROM task_tags WHERE task_id = tasks.id)`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanTask(row rowScanner) (todo.Task, error) {
+	var (
+		t                    todo.Task
+		category, due        sql.NullString
+		deletedAt            sql.NullString
+		createdAt, updatedAt string
+		parentID             sql.NullInt64
+		recurrence, tags     sql.NullString
+		reminders            sql.NullString
+	)
+	if err := row.Scan(&t.ID, &t.OwnerID, &t.Title, &category, &due, &t.IsDone, &createdAt, &updatedAt, &t.Version, &parentID, &recurrence, &t.Priority, &deletedAt, &reminders, &tags); err != nil {
+		return todo.Task{}, err
+	}
+
+	if category.Valid {
+		t.Category = &category.String
+	}
+	if parentID.Valid {
+		p := int(parentID.Int64)
+		t.ParentID = &p
+	}
+	if tags.Valid {
+		t.Tags = strings.Split(tags.String, ",")
+		slices.Sort(t.Tags)
+	}
+	if recurrence.Valid {
+		r, err := todo.ParseRecurrence(recurrence.String)
+		if err != nil {
+			return todo.Task{}, err
+		}
+		t.Recurrence = &r
+	}
+	if reminders.Valid {
+		for _, s := range strings.Split(reminders.String, ",") {
+			r, err := todo.ParseReminder(s)
+			if err != nil {
+				return todo.Task{}, err
+			}
+			t.Reminders = append(t.Reminders, r)
+		}
+	}
+	if due.Valid {
+		d, err := parseSQLiteTime(due.String)
+		if err != nil {
+			return todo.Task{}, err
+		}
+		t.DueDate = &d
+	}
+	if deletedAt.Valid {
+		d, err := parseSQLiteTime(deletedAt.String)
+		if err != nil {
+			return todo.Task{}, err
+		}
+		t.DeletedAt = &d
+	}
+
+	var err error
+	if t.CreatedAt, err = parseSQLiteTime(createdAt); err != nil {
+		return todo.Task{}, err
+	}
+	if t.UpdatedAt, err = parseSQLiteTime(updatedAt); err != nil {
+		return todo.Task{}, err
+	}
+	return t, nil
+}
+
+func (r *SQLiteTaskRepo) Create(ctx context.Context, t todo.Task) (todo.Task, error) {
+	return r.inTx(ctx, func(tx *sql.Tx) (todo.Task, error) {
+		return sqliteCreateTask(ctx, tx, t)
+	})
+}
+
+// inTx runs a write that takes several statements in a transaction of its
+// own.
+func (r *SQLiteTaskRepo) inTx(ctx context.Context, fn func(*sql.Tx) (todo.Task, error)) (todo.Task, error) {
+	if r.closed.Load() {
+		return todo.Task{}, todo.ErrRepoClosed
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return todo.Task{}, err
+	}
+	defer tx.Rollback()
+
+	t, err := fn(tx)
+	if err != nil {
+		return todo.Task{}, err
+	}
+	return t, tx.Commit()
+}
+
+func sqliteCreateTask(ctx context.Context, q sqlQueryer, t todo.Task) (todo.Task, error) {
+	res, err := q.ExecContext(ctx,
+		`INSERT INTO tasks (owner_id, title, category, due_date, is_done, created_at, updated_at, version, parent_id, recurrence, priority, deleted_at, reminders)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, 1, ?, ?, ?, ?, ?)`,
+		t.OwnerID, t.Title, nullString(t.Category), nullTime(t.DueDate), t.IsDone,
+		formatSQLiteTime(t.CreatedAt), formatSQLiteTime(t.UpdatedAt), nullInt(t.ParentID), nullRecurrence(t.Recurrence), t.Priority,
+		nullTime(t.DeletedAt), nullReminders(t.Reminders),
+	)
+	if err != nil {
+		return todo.Task{}, err
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return todo.Task{}, err
+	}
+	t.ID = int(id)
+	t.Version = 1
+	return t, sqliteSetTags(ctx, q, t.ID, t.Tags)
+}
+
+// sqliteSetTags replaces the tags of a task.
+func sqliteSetTags(ctx context.Context, q sqlQueryer, id int, tags []string) error {
+	if _, err := q.ExecContext(ctx, `DELETE FROM task_tags WHERE task_id = ?`, id); err != nil {
+		return err
+	}
+	for _, tag := range tags {
+		if _, err := q.ExecContext(ctx, `INSERT OR IGNORE INTO task_tags (task_id, tag) VALUES (?, ?)`, id, tag); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *SQLiteTaskRepo) List(ctx context.Context, q todo.ListQuery) (todo.TaskPage, error) {
+	if r.closed.Load() {
+		return todo.TaskPage{}, todo.ErrRepoClosed
+	}
+	return sqliteListTasks(ctx, r.db, q)
+}
+
+// sqliteListTasks translates q into SQL. It must agree with
+// todo.ListQuery.Apply, which the conformance suite checks.
+func sqliteListTasks(ctx context.Context, db sqlQueryer, q todo.ListQuery) (todo.TaskPage, error) {
+	var where []string
+	var args []any
+	if !q.AllOwners {
+		where = append(where, "owner_id = ?")
+		args = append(args, q.OwnerID)
+	}
+	if q.Trashed {
+		where = append(where, "deleted_at IS NOT NULL")
+	} else {
+		where = append(where, "deleted_at IS NULL")
+	}
+	if !q.DeletedBefore.IsZero() {
+		where = append(where, "deleted_at < ?")
+		args = append(args, formatSQLiteTime(q.DeletedBefore))
+	}
+
+	if q.IsDone != nil {
+		where = append(where, "is_done = ?")
+		args = append(args, *q.IsDone)
+	}
+	if q.HasReminders {
+		where = append(where, "reminders IS NOT NULL")
+	}
+	if q.NoDueDate {
+		where = append(where, "due_date IS NULL")
+	}
+	for _, b := range []struct {
+		day time.Time
+		op  string
+	}{{q.DueFrom, ">="}, {q.DueUntil, "<"}} {
+		if b.day.IsZero() {
+			continue
+		}
+		expr, exprArgs := sqliteDueCompare(b.op, b.day, q.Location)
+		where = append(where, expr)
+		args = append(args, exprArgs...)
+	}
+	if q.ParentID.Set {
+		if q.ParentID.Value == nil {
+			where = append(where, "parent_id IS NULL")
+		} else {
+			where = append(where, "parent_id = ?")
+			args = append(args, *q.ParentID.Value)
+		}
+	}
+	if len(q.Priorities) > 0 {
+		where = append(where, `priority IN (`+placeholders(len(q.Priorities))+`)`)
+		for _, p := range q.Priorities {
+			args = append(args, p)
+		}
+	}
+	if len(q.TagsAny) > 0 {
+		where = append(where, `id IN (SELECT task_id FROM task_tags WHERE tag IN (`+placeholders(len(q.TagsAny))+`))`)
+		args = appendStrings(args, q.TagsAny)
+	}
+	for _, tag := range q.TagsAll {
+		where = append(where, `id IN (SELECT task_id FROM task_tags WHERE tag = ?)`)
+		args = append(args, tag)
+	}
+	if len(q.TagsNone) > 0 {
+		where = append(where, `id NOT IN (SELECT task_id FROM task_tags WHERE tag IN (`+placeholders(len(q.TagsNone))+`))`)
+		args = appendStrings(args, q.TagsNone)
+	}
+	if q.Search != "" {
+		where = append(where, `(instr(lower(title), lower(?)) > 0 OR instr(lower(coalesce(category, '')), lower(?)) > 0)`)
+		args = append(args, q.Search, q.Search)
+	}
+	cond := strings.Join(where, " AND ")
+
+	page := todo.TaskPage{Limit: q.Limit, Offset: q.Offset}
+	if err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM tasks WHERE `+cond, args...).Scan(&page.Total); err != nil {
+		return todo.TaskPage{}, err
+	}
+
+	dir, rdir := "DESC", "ASC"
+	if q.Order == todo.OrderAsc {
+		dir, rdir = "ASC", "DESC"
+	}
+	var (
+		order     string
+		orderArgs []any
+	)
+	switch q.Sort {
+	case todo.SortSmart:
+		// See todo.ListQuery.Less and todo.Task.IsOverdue
+		loc := q.Location
+		if loc == nil {
+			loc = time.UTC
+		}
+		overdue, overdueArgs := sqliteDueCompare("<", todo.CalendarDay(q.Now.In(loc)), loc)
+		order = "is_done " + dir +
+			", (is_done = 0 AND due_date IS NOT NULL AND " + overdue + ") " + rdir +
+			", priority " + rdir +
+			", due_date IS NULL, due_date " + dir +
+			", created_at " + dir
+		orderArgs = append(orderArgs, overdueArgs...)
+	case todo.SortDueDate:
+		// Tasks without a due date sort last in both directions
+		order = "due_date IS NULL, due_date " + dir
+	case todo.SortDeletedAt:
+		order = "deleted_at IS NULL, deleted_at " + dir
+	case todo.SortUpdatedAt:
+		order = "updated_at " + dir
+	default:
+		order = "created_at " + dir
+	}
+	order += ", id " + dir
+
+	limit := -1
+	if q.Limit > 0 {
+		limit = q.Limit
+	}
+
+	rows, err := db.QueryContext(ctx,
+		`SELECT `+taskSelect+` FROM tasks WHERE `+cond+` ORDER BY `+order+` LIMIT ? OFFSET ?`,
+		append(append(args, orderArgs...), limit, max(q.Offset, 0))...,
+	)
+	if err != nil {
+		return todo.TaskPage{}, err
+	}
+	defer rows.Close()
+
+	page.Items = make([]todo.Task, 0)
+	for rows.Next() {
+		t, err := scanTask(rows)
+		if err != nil {
+			return todo.TaskPage{}, err
+		}
+		page.Items = append(page.Items, t)
+	}
+	return page, rows.Err()
+}
+
+// sqliteDueCompare compares the due day of a task with day, a
+// todo.CalendarDay, like todo.DueDay: date-only due dates, stored at
+// midnight UTC, compare as dates, due times against midnight of day in loc.
+func sqliteDueCompare(op string, day time.Time, loc *time.Location) (string, []any) {
+	expr := `(CASE WHEN substr(due_date, 11) = 'T00:00:00.000000000Z' THEN due_date ` + op + ` ? ELSE due_date ` + op + ` ? END)`
+	return expr, []any{formatSQLiteTime(day), formatSQLiteTime(todo.MidnightIn(day, loc))}
+}
+
+// placeholders returns n comma-separated "?".
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
+
+func appendStrings(args []any, values []string) []any {
+	for _, v := range values {
+		args = append(args, v)
+	}
+	return args
+}
+
+func (r *SQLiteTaskRepo) GetByID(ctx context.Context, id int) (todo.Task, error) {
+	if r.closed.Load() {
+		return todo.Task{}, todo.ErrRepoClosed
+	}
+	return sqliteGetTask(ctx, r.db, id)
+}
+
+func sqliteGetTask(ctx context.Context, q sqlQueryer, id int) (todo.Task, error) {
+	t, err := scanTask(q.QueryRowContext(ctx, `SELECT `+taskSelect+` FROM tasks WHERE id = ?`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return todo.Task{}, todo.ErrTaskNotFound
+	}
+	return t, err
+}
+
+func (r *SQLiteTaskRepo) Update(ctx context.Context, t todo.Task) (todo.Task, error) {
+	return r.inTx(ctx, func(tx *sql.Tx) (todo.Task, error) {
+		return sqliteUpdateTask(ctx, tx, t)
+	})
+}
+
+func sqliteUpdateTask(ctx context.Context, q sqlQueryer, t todo.Task) (todo.Task, error) {
+	res, err := q.ExecContext(ctx,
+		`UPDATE tasks SET owner_id = ?, title = ?, category = ?, due_date = ?, is_done = ?, created_at = ?, updated_at = ?,
+		 parent_id = ?, recurrence = ?, priority = ?, deleted_at = ?, reminders = ?, version = version + 1
+		 WHERE id = ? AND version = ?`,
+		t.OwnerID, t.Title, nullString(t.Category), nullTime(t.DueDate), t.IsDone,
+		formatSQLiteTime(t.CreatedAt), formatSQLiteTime(t.UpdatedAt), nullInt(t.ParentID), nullRecurrence(t.Recurrence), t.Priority,
+		nullTime(t.DeletedAt), nullReminders(t.Reminders),
+		t.ID, t.Version,
+	)
+	if err != nil {
+		return todo.Task{}, err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return todo.Task{}, err
+	}
+	if n == 0 {
+		// Either the task is gone or its version moved on
+		var exists bool
+		if err := q.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM tasks WHERE id = ?)`, t.ID).Scan(&exists); err != nil {
+			return todo.Task{}, err
+		}
+		if exists {
+			return todo.Task{}, todo.ErrVersionConflict
+		}
+		return todo.Task{}, todo.ErrTaskNotFound
+	}
+	t.Version++
+	return t, sqliteSetTags(ctx, q, t.ID, t.Tags)
+}
+
+// Delete reads and removes the task in one transaction so the returned
+// task is exactly the one deleted. Its tags go with it (ON DELETE CASCADE).
+func (r *SQLiteTaskRepo) Delete(ctx context.Context, id int) (todo.Task, error) {
+	return r.inTx(ctx, func(tx *sql.Tx) (todo.Task, error) {
+		return sqliteDeleteTask(ctx, tx, id)
+	})
+}
+
+func sqliteDeleteTask(ctx context.Context, q sqlQueryer, id int) (todo.Task, error) {
+	t, err := sqliteGetTask(ctx, q, id)
+	if err != nil {
+		return todo.Task{}, err
+	}
+	if _, err := q.ExecContext(ctx, `DELETE FROM tasks WHERE id = ?`, id); err != nil {
+		return todo.Task{}, err
+	}
+	return t, nil
+}
+
+// Atomic runs fn inside one SQLite transaction. Transactions take the write
+// lock when they begin (see OpenSQLite), so concurrent calls run one after
+// the other.
+func (r *SQLiteTaskRepo) Atomic(ctx context.Context, fn func(todo.TaskStore) error) error {
+	if r.closed.Load() {
+		return todo.ErrRepoClosed
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(sqliteTaskTx{tx}); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// sqliteTaskTx is the TaskStore handed to Atomic.
+type sqliteTaskTx struct {
+	tx *sql.Tx
+}
+
+func (s sqliteTaskTx) Create(ctx context.Context, t todo.Task) (todo.Task, error) {
+	return sqliteCreateTask(ctx, s.tx, t)
+}
+
+func (s sqliteTaskTx) List(ctx context.Context, q todo.ListQuery) (todo.TaskPage, error) {
+	return sqliteListTasks(ctx, s.tx, q)
+}
+
+func (s sqliteTaskTx) GetByID(ctx context.Context, id int) (todo.Task, error) {
+	return sqliteGetTask(ctx, s.tx, id)
+}
+
+func (s sqliteTaskTx) Update(ctx context.Context, t todo.Task) (todo.Task, error) {
+	return sqliteUpdateTask(ctx, s.tx, t)
+}
+
+func (s sqliteTaskTx) Delete(ctx context.Context, id int) (todo.Task, error) {
+	return sqliteDeleteTask(ctx, s.tx, id)
+}
+
+// Import copies tasks into an empty database keeping their IDs, so links and
+// scripts that use them keep working. nextID continues the ID sequence.
+func (r *SQLiteTaskRepo) Import(ctx context.Context, tasks []todo.Task, nextID int) error {
+	if r.closed.Load() {
+		return todo.ErrRepoClosed
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var n int
+	if err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM tasks`).Scan(&n); err != nil {
+		return err
+	}
+	if n > 0 {
+		return fmt.Errorf("import: database already holds %d tasks", n)
+	}
+
+	for _, t := range tasks {
+		_, err := tx.ExecContext(ctx,
+			`INSERT INTO tasks (`+taskColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			t.ID, t.OwnerID, t.Title, nullString(t.Category), nullTime(t.DueDate), t.IsDone,
+			formatSQLiteTime(t.CreatedAt), formatSQLiteTime(t.UpdatedAt), max(t.Version, 1), nullInt(t.ParentID),
+			nullRecurrence(t.Recurrence), t.Priority, nullTime(t.DeletedAt), nullReminders(t.Reminders),
+		)
+		if err != nil {
+			return fmt.Errorf("import task %d: %w", t.ID, err)
+		}
+		if err := sqliteSetTags(ctx, tx, t.ID, t.Tags); err != nil {
+			return fmt.Errorf("import task %d: %w", t.ID, err)
+		}
+	}
+
+	// AUTOINCREMENT continues after the largest value in sqlite_sequence
+	if nextID > 1 {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM sqlite_sequence WHERE name = 'tasks'`); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, `INSERT INTO sqlite_sequence (name, seq) VALUES ('tasks', ?)`, nextID-1); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// Close closes the shared database connection.
+func (r *SQLiteTaskRepo) Close() error {
+	if r.closed.Swap(true) {
+		return nil
+	}
+	return r.db.Close()
+}
@@ -0,0 +1,269 @@
+package storage
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Saintrad/todo-server-client/internal/auth"
+	"github.com/Saintrad/todo-server-client/internal/reminder"
+	"github.com/Saintrad/todo-server-client/internal/storage/storagetest"
+	"github.com/Saintrad/todo-server-client/internal/todo"
+	"github.com/Saintrad/todo-server-client/internal/webhook"
+)
+
+func openTestSQLite(t *testing.T) *sql.DB {
+	t.Helper()
+
+	db, err := OpenSQLite(filepath.Join(t.TempDir(), "tasks.db"))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestSQLiteRepoConformance(t *testing.T) {
+	storagetest.RunTaskRepoTests(t, func(t *testing.T) todo.TaskRepo {
+		return NewSQLiteTaskRepo(openTestSQLite(t))
+	})
+}
+
+func TestSQLiteHistoryRepoConformance(t *testing.T) {
+	storagetest.RunHistoryRepoTests(t, func(t *testing.T) todo.HistoryRepo {
+		return NewSQLiteHistoryRepo(openTestSQLite(t))
+	})
+}
+
+func TestSQLiteWebhookRepoConformance(t *testing.T) {
+	storagetest.RunWebhookRepoTests(t, func(t *testing.T) webhook.Repo {
+		return NewSQLiteWebhookRepo(openTestSQLite(t))
+	})
+}
+
+func TestSQLiteReminderRepoConformance(t *testing.T) {
+	storagetest.RunReminderRepoTests(t, func(t *testing.T) reminder.Repo {
+		return NewSQLiteReminderRepo(openTestSQLite(t))
+	})
+}
+
+func TestSQLiteMigrationsAreIdempotent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tasks.db")
+
+	for i := 0; i < 2; i++ {
+		db, err := OpenSQLite(path)
+		if err != nil {
+			t.Fatalf("open %d: expected no error, got %v", i, err)
+		}
+
+		var version int
+		db.QueryRow(`PRAGMA user_version`).Scan(&version)
+		if version != len(sqliteMigrations) {
+			t.Fatalf("expected schema version %d, got %d", len(sqliteMigrations), version)
+		}
+		db.Close()
+	}
+}
+
+func TestSQLiteMigrationCopiesCategoriesIntoTags(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tasks.db")
+
+	// A database from before tags, at schema version 4
+	old, err := sql.Open("sqlite", "file:"+path)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	for _, m := range sqliteMigrations[:4] {
+		if _, err := old.Exec(m); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+	}
+	now := formatSQLiteTime(time.Now())
+	old.Exec(`PRAGMA user_version = 4`)
+	old.Exec(`INSERT INTO tasks (title, category, created_at, updated_at) VALUES ('a', ' Work Stuff ', ?, ?), ('b', '', ?, ?)`, now, now, now, now)
+	// Categories where SQL's trim and lower differ from todo.NormalizeTag
+	categories := []string{"\t+Étude/Été\n", "ÀB, c", " + "}
+	for _, c := range categories {
+		old.Exec(`INSERT INTO tasks (title, category, created_at, updated_at) VALUES ('c', ?, ?, ?)`, c, now, now)
+	}
+	old.Close()
+
+	db, err := OpenSQLite(path)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	defer db.Close()
+	repo := NewSQLiteTaskRepo(db)
+
+	task, _ := repo.GetByID(t.Context(), 1)
+	if !slices.Equal(task.Tags, []string{"work-stuff"}) || task.Category == nil {
+		t.Fatalf("expected category kept and copied to tags, got %+v", task)
+	}
+	if task, _ := repo.GetByID(t.Context(), 2); task.Tags != nil {
+		t.Fatalf("expected no tags, got %v", task.Tags)
+	}
+
+	// Check the tags match todo.CategoryTag
+	for i, c := range categories {
+		var want []string
+		if tag, ok := todo.CategoryTag(c); ok {
+			want = []string{tag}
+		}
+		if task, _ := repo.GetByID(t.Context(), 3+i); !slices.Equal(task.Tags, want) {
+			t.Fatalf("%q: expected tags %q, got %q", c, want, task.Tags)
+		}
+	}
+}
+
+func TestSQLiteMigrationSkipsInvalidCategoryTags(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tasks.db")
+
+	old, err := sql.Open("sqlite", "file:"+path)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	for _, m := range sqliteMigrations[:4] {
+		if _, err := old.Exec(m); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+	}
+	now := formatSQLiteTime(time.Now())
+	old.Exec(`PRAGMA user_version = 4`)
+	for _, c := range []string{"work", strings.Repeat("x", 60), "a\tb"} {
+		old.Exec(`INSERT INTO tasks (title, category, created_at, updated_at) VALUES ('t', ?, ?, ?)`, c, now, now)
+	}
+	old.Close()
+
+	db, err := OpenSQLite(path)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	defer db.Close()
+	checkCategoryTagsEditable(t, NewSQLiteTaskRepo(db))
+}
+
+// checkCategoryTagsEditable checks the tags of tasks 1 to 3, migrated from
+// the categories "work", 60 characters and "a\tb", can still be edited.
+func checkCategoryTagsEditable(t *testing.T, repo todo.TaskRepo) {
+	t.Helper()
+
+	for id := 2; id <= 3; id++ {
+		task, _ := repo.GetByID(t.Context(), id)
+		if task.Tags != nil || task.Category == nil {
+			t.Fatalf("expected category kept without a tag, got %+v", task)
+		}
+	}
+
+	svc := todo.NewService(repo)
+	for id := 2; id <= 3; id++ {
+		if _, err := svc.UpdateTask(t.Context(), id, todo.UpdateTaskInput{AddTags: []string{"work"}}); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+	}
+	if n, err := svc.RenameTag(t.Context(), "work", "job"); err != nil || n != 3 {
+		t.Fatalf("expected 3 tasks renamed, got %d, %v", n, err)
+	}
+}
+
+func TestImportTaskFile(t *testing.T) {
+	dir := t.TempDir()
+	jsonPath := filepath.Join(dir, "tasks.json")
+	now := time.Now()
+
+	src, err := NewFileTaskRepo(jsonPath)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	for _, title := range []string{"first", "second", "third"} {
+		src.Create(t.Context(), todo.Task{Title: title, Category: strPtr("work"), CreatedAt: now, UpdatedAt: now})
+	}
+	src.Delete(t.Context(), 2)
+
+	users, err := NewFileUserRepo(filepath.Join(dir, "users.json"))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	users.CreateUser(t.Context(), auth.User{Username: "alice", PasswordHash: []byte("hash"), CreatedAt: now})
+
+	db := openTestSQLite(t)
+	dst := NewSQLiteTaskRepo(db)
+
+	n, err := ImportTaskFile(t.Context(), jsonPath, dst)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if n != 2 {
+		t.Fatalf("expected 2 tasks imported, got %d", n)
+	}
+
+	// Check IDs are kept and the sequence continues after the old next ID
+	task, err := dst.GetByID(t.Context(), 3)
+	if err != nil || task.Title != "third" || task.Category == nil || *task.Category != "work" {
+		t.Fatalf("expected task 3 to be imported, got %+v, %v", task, err)
+	}
+	created, _ := dst.Create(t.Context(), todo.Task{Title: "fourth", CreatedAt: now, UpdatedAt: now})
+	if created.ID != 4 {
+		t.Fatalf("expected next ID 4, got %d", created.ID)
+	}
+
+	// Check importing twice is refused
+	if _, err := ImportTaskFile(t.Context(), jsonPath, dst); err == nil {
+		t.Fatalf("expected second import to fail")
+	}
+
+	dstUsers := NewSQLiteUserRepo(db)
+	if n, err := ImportUserFile(t.Context(), filepath.Join(dir, "users.json"), dstUsers); err != nil || n != 1 {
+		t.Fatalf("expected 1 user imported, got %d, %v", n, err)
+	}
+	if u, err := dstUsers.GetUserByUsername(t.Context(), "ALICE"); err != nil || u.ID != 1 {
+		t.Fatalf("expected alice to be imported, got %+v, %v", u, err)
+	}
+	if _, err := dstUsers.CreateUser(t.Context(), auth.User{Username: "Alice", PasswordHash: []byte("x"), CreatedAt: now}); err != auth.ErrUsernameTaken {
+		t.Fatalf("expected %v, got %v", auth.ErrUsernameTaken, err)
+	}
+}
+
+func TestImportTaskFileReplaysJournal(t *testing.T) {
+	jsonPath := filepath.Join(t.TempDir(), "tasks.json")
+	now := time.Now()
+
+	// A crash leaves the mutations since the last compaction in the journal
+	src, err := NewFileTaskRepo(jsonPath, WithJournal(100))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	for _, title := range []string{"first", "second", "third"} {
+		src.Create(t.Context(), todo.Task{Title: title, CreatedAt: now, UpdatedAt: now})
+	}
+	src.Delete(t.Context(), 1)
+	journal, err := os.ReadFile(jsonPath + ".journal")
+	if err != nil || len(journal) == 0 {
+		t.Fatalf("expected a journal, got %d bytes, %v", len(journal), err)
+	}
+
+	dst := NewSQLiteTaskRepo(openTestSQLite(t))
+	n, err := ImportTaskFile(t.Context(), jsonPath, dst)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if n != 2 {
+		t.Fatalf("expected 2 tasks imported, got %d", n)
+	}
+	if task, err := dst.GetByID(t.Context(), 3); err != nil || task.Title != "third" {
+		t.Fatalf("expected task 3 to be imported, got %+v, %v", task, err)
+	}
+	if _, err := dst.GetByID(t.Context(), 1); !errors.Is(err, todo.ErrTaskNotFound) {
+		t.Fatalf("expected error %v, got %v", todo.ErrTaskNotFound, err)
+	}
+
+	// Check the source is left as it was
+	if after, _ := os.ReadFile(jsonPath + ".journal"); !bytes.Equal(after, journal) {
+		t.Fatalf("expected the journal untouched")
+	}
+}
@@ -0,0 +1,113 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+
+	"github.com/Saintrad/todo-server-client/internal/auth"
+	"github.com/Saintrad/todo-server-client/internal/todo"
+)
+
+var _ auth.UserRepo = (*SQLiteUserRepo)(nil)
+
+// SQLiteUserRepo stores users in the database shared with SQLiteTaskRepo.
+// Closing it does not close the database; the task repo owns it.
+type SQLiteUserRepo struct {
+	db     *sql.DB
+	closed atomic.Bool
+}
+
+func NewSQLiteUserRepo(db *sql.DB) *SQLiteUserRepo {
+	return &SQLiteUserRepo{db: db}
+}
+
+func (r *SQLiteUserRepo) CreateUser(ctx context.Context, u auth.User) (auth.User, error) {
+	if r.closed.Load() {
+		return auth.User{}, todo.ErrRepoClosed
+	}
+
+	res, err := r.db.ExecContext(ctx,
+		`INSERT INTO users (username, password_hash, created_at) VALUES (?, ?, ?)`,
+		u.Username, u.PasswordHash, formatSQLiteTime(u.CreatedAt),
+	)
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE constraint failed") {
+			return auth.User{}, auth.ErrUsernameTaken
+		}
+		return auth.User{}, err
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return auth.User{}, err
+	}
+	u.ID = int(id)
+	return u, nil
+}
+
+func (r *SQLiteUserRepo) GetUserByID(ctx context.Context, id int) (auth.User, error) {
+	if r.closed.Load() {
+		return auth.User{}, todo.ErrRepoClosed
+	}
+	return r.getUser(ctx, `id = ?`, id)
+}
+
+func (r *SQLiteUserRepo) GetUserByUsername(ctx context.Context, username string) (auth.User, error) {
+	if r.closed.Load() {
+		return auth.User{}, todo.ErrRepoClosed
+	}
+	// The username column is COLLATE NOCASE
+	return r.getUser(ctx, `username = ?`, username)
+}
+
+func (r *SQLiteUserRepo) getUser(ctx context.Context, cond string, arg any) (auth.User, error) {
+	var u auth.User
+	var createdAt string
+
+	err := r.db.QueryRowContext(ctx, `SELECT id, username, password_hash, created_at FROM users WHERE `+cond, arg).
+		Scan(&u.ID, &u.Username, &u.PasswordHash, &createdAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return auth.User{}, auth.ErrUserNotFound
+	}
+	if err != nil {
+		return auth.User{}, err
+	}
+
+	if u.CreatedAt, err = parseSQLiteTime(createdAt); err != nil {
+		return auth.User{}, fmt.Errorf("user %d: %w", u.ID, err)
+	}
+	return u, nil
+}
+
+// Import copies users into an empty users table keeping their IDs.
+func (r *SQLiteUserRepo) Import(ctx context.Context, users []auth.User) error {
+	if r.closed.Load() {
+		return todo.ErrRepoClosed
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, u := range users {
+		_, err := tx.ExecContext(ctx,
+			`INSERT INTO users (id, username, password_hash, created_at) VALUES (?, ?, ?, ?)`,
+			u.ID, u.Username, u.PasswordHash, formatSQLiteTime(u.CreatedAt),
+		)
+		if err != nil {
+			return fmt.Errorf("import user %d: %w", u.ID, err)
+		}
+	}
+	return tx.Commit()
+}
+
+func (r *SQLiteUserRepo) Close() error {
+	r.closed.Store(true)
+	return nil
+}
@@ -0,0 +1,223 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/Saintrad/todo-server-client/internal/todo"
+	"github.com/Saintrad/todo-server-client/internal/webhook"
+)
+
+var _ webhook.Repo = (*SQLiteWebhookRepo)(nil)
+
+// SQLiteWebhookRepo stores webhooks in the database shared with
+// SQLiteTaskRepo. Closing it does not close the database; the task repo
+// owns it.
+type SQLiteWebhookRepo struct {
+	db     *sql.DB
+	closed atomic.Bool
+}
+
+func NewSQLiteWebhookRepo(db *sql.DB) *SQLiteWebhookRepo {
+	return &SQLiteWebhookRepo{db: db}
+}
+
+const webhookColumns = `id, owner_id, url, events, secret, created_at, updated_at`
+
+func (r *SQLiteWebhookRepo) CreateWebhook(ctx context.Context, w webhook.Webhook) (webhook.Webhook, error) {
+	if r.closed.Load() {
+		return webhook.Webhook{}, todo.ErrRepoClosed
+	}
+
+	res, err := r.db.ExecContext(ctx,
+		`INSERT INTO webhooks (owner_id, url, events, secret, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?)`,
+		w.OwnerID, w.URL, joinKinds(w.Events), w.Secret, formatSQLiteTime(w.CreatedAt), formatSQLiteTime(w.UpdatedAt),
+	)
+	if err != nil {
+		return webhook.Webhook{}, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return webhook.Webhook{}, err
+	}
+	w.ID = int(id)
+	return w, nil
+}
+
+func (r *SQLiteWebhookRepo) ListWebhooks(ctx context.Context, ownerID int) ([]webhook.Webhook, error) {
+	if r.closed.Load() {
+		return nil, todo.ErrRepoClosed
+	}
+
+	rows, err := r.db.QueryContext(ctx, `SELECT `+webhookColumns+` FROM webhooks WHERE owner_id = ? ORDER BY id`, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]webhook.Webhook, 0)
+	for rows.Next() {
+		w, err := scanWebhook(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, w)
+	}
+	return out, rows.Err()
+}
+
+func (r *SQLiteWebhookRepo) GetWebhook(ctx context.Context, id int) (webhook.Webhook, error) {
+	if r.closed.Load() {
+		return webhook.Webhook{}, todo.ErrRepoClosed
+	}
+
+	w, err := scanWebhook(r.db.QueryRowContext(ctx, `SELECT `+webhookColumns+` FROM webhooks WHERE id = ?`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return webhook.Webhook{}, webhook.ErrWebhookNotFound
+	}
+	return w, err
+}
+
+func (r *SQLiteWebhookRepo) UpdateWebhook(ctx context.Context, w webhook.Webhook) (webhook.Webhook, error) {
+	if r.closed.Load() {
+		return webhook.Webhook{}, todo.ErrRepoClosed
+	}
+
+	res, err := r.db.ExecContext(ctx,
+		`UPDATE webhooks SET url = ?, events = ?, secret = ?, updated_at = ? WHERE id = ?`,
+		w.URL, joinKinds(w.Events), w.Secret, formatSQLiteTime(w.UpdatedAt), w.ID,
+	)
+	if err != nil {
+		return webhook.Webhook{}, err
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		return webhook.Webhook{}, errors.Join(err, webhook.ErrWebhookNotFound)
+	}
+	return w, nil
+}
+
+// DeleteWebhook removes the webhook; its deliveries go by ON DELETE CASCADE.
+func (r *SQLiteWebhookRepo) DeleteWebhook(ctx context.Context, id int) error {
+	if r.closed.Load() {
+		return todo.ErrRepoClosed
+	}
+
+	res, err := r.db.ExecContext(ctx, `DELETE FROM webhooks WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		return errors.Join(err, webhook.ErrWebhookNotFound)
+	}
+	return nil
+}
+
+func (r *SQLiteWebhookRepo) AppendDelivery(ctx context.Context, d webhook.Delivery) (webhook.Delivery, error) {
+	if r.closed.Load() {
+		return webhook.Delivery{}, todo.ErrRepoClosed
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return webhook.Delivery{}, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx,
+		`INSERT INTO webhook_deliveries (webhook_id, key, event, task_id, attempt, status_code, error, duration_ns, at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		d.WebhookID, d.Key, string(d.Event), d.TaskID, d.Attempt, d.StatusCode, d.Error, int64(d.Duration), formatSQLiteTime(d.At),
+	)
+	if err != nil {
+		if strings.Contains(err.Error(), "FOREIGN KEY constraint failed") {
+			return webhook.Delivery{}, webhook.ErrWebhookNotFound
+		}
+		return webhook.Delivery{}, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return webhook.Delivery{}, err
+	}
+	d.ID = int(id)
+
+	_, err = tx.ExecContext(ctx,
+		`DELETE FROM webhook_deliveries WHERE webhook_id = ? AND id <= (
+			SELECT id FROM webhook_deliveries WHERE webhook_id = ? ORDER BY id DESC LIMIT 1 OFFSET ?)`,
+		d.WebhookID, d.WebhookID, webhook.MaxDeliveryLog,
+	)
+	if err != nil {
+		return webhook.Delivery{}, err
+	}
+	return d, tx.Commit()
+}
+
+func (r *SQLiteWebhookRepo) ListDeliveries(ctx context.Context, webhookID int) ([]webhook.Delivery, error) {
+	if r.closed.Load() {
+		return nil, todo.ErrRepoClosed
+	}
+
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT id, webhook_id, key, event, task_id, attempt, status_code, error, duration_ns, at
+		 FROM webhook_deliveries WHERE webhook_id = ? ORDER BY id DESC`, webhookID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]webhook.Delivery, 0)
+	for rows.Next() {
+		var d webhook.Delivery
+		var event, at string
+		var duration int64
+		if err := rows.Scan(&d.ID, &d.WebhookID, &d.Key, &event, &d.TaskID, &d.Attempt, &d.StatusCode, &d.Error, &duration, &at); err != nil {
+			return nil, err
+		}
+		d.Event = todo.EventKind(event)
+		d.Duration = time.Duration(duration)
+		if d.At, err = parseSQLiteTime(at); err != nil {
+			return nil, fmt.Errorf("delivery %d: %w", d.ID, err)
+		}
+		out = append(out, d)
+	}
+	return out, rows.Err()
+}
+
+func (r *SQLiteWebhookRepo) Close() error {
+	r.closed.Store(true)
+	return nil
+}
+
+func scanWebhook(row rowScanner) (webhook.Webhook, error) {
+	var w webhook.Webhook
+	var events, createdAt, updatedAt string
+	if err := row.Scan(&w.ID, &w.OwnerID, &w.URL, &events, &w.Secret, &createdAt, &updatedAt); err != nil {
+		return webhook.Webhook{}, err
+	}
+	for _, k := range strings.Split(events, ",") {
+		if k != "" {
+			w.Events = append(w.Events, todo.EventKind(k))
+		}
+	}
+
+	var err error
+	if w.CreatedAt, err = parseSQLiteTime(createdAt); err != nil {
+		return webhook.Webhook{}, fmt.Errorf("webhook %d: %w", w.ID, err)
+	}
+	if w.UpdatedAt, err = parseSQLiteTime(updatedAt); err != nil {
+		return webhook.Webhook{}, fmt.Errorf("webhook %d: %w", w.ID, err)
+	}
+	return w, nil
+}
+
+func joinKinds(kinds []todo.EventKind) string {
+	names := make([]string, len(kinds))
+	for i, k := range kinds {
+		names[i] = string(k)
+	}
+	return strings.Join(names, ",")
+}
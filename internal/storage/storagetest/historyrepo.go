@@ -0,0 +1,85 @@
+package storagetest
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Saintrad/todo-server-client/internal/todo"
+)
+
+// HistoryRepoFactory returns a new, empty repo. It is called once per
+// subtest.
+type HistoryRepoFactory func(t *testing.T) todo.HistoryRepo
+
+// RunHistoryRepoTests runs the HistoryRepo conformance suite against
+// newRepo.
+func RunHistoryRepoTests(t *testing.T, newRepo HistoryRepoFactory) {
+	t.Run("AppendAndList", func(t *testing.T) { testHistoryAppendAndList(t, newRepo(t)) })
+	t.Run("Close", func(t *testing.T) { testHistoryClose(t, newRepo(t)) })
+}
+
+func testHistoryAppendAndList(t *testing.T, repo todo.HistoryRepo) {
+	ctx := t.Context()
+
+	created := todo.Event{
+		TaskID: 1, OwnerID: 7, Kind: todo.EventCreated, Actor: "alice", At: baseTime,
+		Changes: []todo.Change{{Field: "title", New: strPtr("Write tests")}},
+	}
+	other := todo.Event{TaskID: 2, OwnerID: 7, Kind: todo.EventCreated, At: baseTime}
+	if err := repo.Append(ctx, created, other); err != nil {
+		t.Fatalf("Append: expected no error, got %v", err)
+	}
+	completed := todo.Event{
+		TaskID: 1, OwnerID: 7, Kind: todo.EventCompleted, At: baseTime.Add(time.Hour),
+		Changes: []todo.Change{{Field: "is_done", Old: strPtr("false"), New: strPtr("true")}},
+	}
+	if err := repo.Append(ctx, completed); err != nil {
+		t.Fatalf("Append: expected no error, got %v", err)
+	}
+
+	events, err := repo.ListByTask(ctx, 1)
+	if err != nil {
+		t.Fatalf("ListByTask: expected no error, got %v", err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(events))
+	}
+
+	// Check order, IDs and fields survive the round trip
+	first, second := events[0], events[1]
+	if first.ID <= 0 || second.ID <= first.ID {
+		t.Fatalf("expected increasing IDs, got %d and %d", first.ID, second.ID)
+	}
+	if first.Kind != todo.EventCreated || first.OwnerID != 7 || first.Actor != "alice" || !first.At.Equal(baseTime) {
+		t.Fatalf("expected created event by alice, got %+v", first)
+	}
+	if len(first.Changes) != 1 || first.Changes[0].Field != "title" || first.Changes[0].Old != nil || *first.Changes[0].New != "Write tests" {
+		t.Fatalf("expected title change, got %+v", first.Changes)
+	}
+	if second.Kind != todo.EventCompleted || *second.Changes[0].Old != "false" {
+		t.Fatalf("expected completed event, got %+v", second)
+	}
+
+	// Check unknown tasks have no events
+	events, err = repo.ListByTask(ctx, 99)
+	if err != nil || len(events) != 0 {
+		t.Fatalf("expected no events, got %v, %v", events, err)
+	}
+}
+
+func testHistoryClose(t *testing.T, repo todo.HistoryRepo) {
+	if err := repo.Close(); err != nil {
+		t.Fatalf("Close: expected no error, got %v", err)
+	}
+	if err := repo.Close(); err != nil {
+		t.Fatalf("second Close: expected no error, got %v", err)
+	}
+
+	if err := repo.Append(t.Context(), todo.Event{TaskID: 1}); !errors.Is(err, todo.ErrRepoClosed) {
+		t.Fatalf("expected error %v, got %v", todo.ErrRepoClosed, err)
+	}
+	if _, err := repo.ListByTask(t.Context(), 1); !errors.Is(err, todo.ErrRepoClosed) {
+		t.Fatalf("expected error %v, got %v", todo.ErrRepoClosed, err)
+	}
+}
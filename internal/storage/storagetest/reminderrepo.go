@@ -0,0 +1,55 @@
+package storagetest
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Saintrad/todo-server-client/internal/reminder"
+	"github.com/Saintrad/todo-server-client/internal/todo"
+)
+
+// ReminderRepoFactory returns a new, empty repo. It is called once per
+// subtest.
+type ReminderRepoFactory func(t *testing.T) reminder.Repo
+
+// RunReminderRepoTests runs the reminder.Repo conformance suite against
+// newRepo.
+func RunReminderRepoTests(t *testing.T, newRepo ReminderRepoFactory) {
+	t.Run("Checkpoint", func(t *testing.T) { testReminderCheckpoint(t, newRepo(t)) })
+	t.Run("Close", func(t *testing.T) { testReminderClose(t, newRepo(t)) })
+}
+
+func testReminderCheckpoint(t *testing.T, repo reminder.Repo) {
+	ctx := t.Context()
+
+	// Check a new repo has no checkpoint
+	got, err := repo.Checkpoint(ctx)
+	if err != nil || !got.IsZero() {
+		t.Fatalf("Checkpoint: expected zero time, got %v, %v", got, err)
+	}
+
+	for _, at := range []time.Time{baseTime, baseTime.Add(90 * time.Second)} {
+		if err := repo.SetCheckpoint(ctx, at); err != nil {
+			t.Fatalf("SetCheckpoint: expected no error, got %v", err)
+		}
+		if got, err := repo.Checkpoint(ctx); err != nil || !got.Equal(at) {
+			t.Fatalf("Checkpoint: expected %v, got %v, %v", at, got, err)
+		}
+	}
+}
+
+func testReminderClose(t *testing.T, repo reminder.Repo) {
+	if err := repo.Close(); err != nil {
+		t.Fatalf("Close: expected no error, got %v", err)
+	}
+	if err := repo.Close(); err != nil {
+		t.Fatalf("second Close: expected no error, got %v", err)
+	}
+	if _, err := repo.Checkpoint(t.Context()); !errors.Is(err, todo.ErrRepoClosed) {
+		t.Fatalf("Checkpoint after Close: expected %v, got %v", todo.ErrRepoClosed, err)
+	}
+	if err := repo.SetCheckpoint(t.Context(), baseTime); !errors.Is(err, todo.ErrRepoClosed) {
+		t.Fatalf("SetCheckpoint after Close: expected %v, got %v", todo.ErrRepoClosed, err)
+	}
+}
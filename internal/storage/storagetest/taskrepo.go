@@ -0,0 +1,797 @@
+// Package storagetest holds a conformance suite that every todo.TaskRepo
+// implementation runs against itself, so all backends honour the same
+// contract.
+package storagetest
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Saintrad/todo-server-client/internal/todo"
+)
+
+// TaskRepoFactory returns a new, empty repo. It is called once per subtest.
+type TaskRepoFactory func(t *testing.T) todo.TaskRepo
+
+// RunTaskRepoTests runs the TaskRepo conformance suite against newRepo.
+func RunTaskRepoTests(t *testing.T, newRepo TaskRepoFactory) {
+	t.Run("CreateAssignsIncreasingIDs", func(t *testing.T) { testCreateAssignsIDs(t, newRepo(t)) })
+	t.Run("CreateIgnoresGivenID", func(t *testing.T) { testCreateIgnoresGivenID(t, newRepo(t)) })
+	t.Run("GetByID", func(t *testing.T) { testGetByID(t, newRepo(t)) })
+	t.Run("NotFound", func(t *testing.T) { testNotFound(t, newRepo(t)) })
+	t.Run("Update", func(t *testing.T) { testUpdate(t, newRepo(t)) })
+	t.Run("UpdateChecksVersion", func(t *testing.T) { testUpdateChecksVersion(t, newRepo(t)) })
+	t.Run("Delete", func(t *testing.T) { testDelete(t, newRepo(t)) })
+	t.Run("List", func(t *testing.T) { testList(t, newRepo(t)) })
+	t.Run("ListByParent", func(t *testing.T) { testListByParent(t, newRepo(t)) })
+	t.Run("ListByTags", func(t *testing.T) { testListByTags(t, newRepo(t)) })
+	t.Run("ListByPriority", func(t *testing.T) { testListByPriority(t, newRepo(t)) })
+	t.Run("ListTrash", func(t *testing.T) { testListTrash(t, newRepo(t)) })
+	t.Run("ListWithReminders", func(t *testing.T) { testListWithReminders(t, newRepo(t)) })
+	t.Run("ListByDueDay", func(t *testing.T) { testListByDueDay(t, newRepo(t)) })
+	t.Run("AtomicCommits", func(t *testing.T) { testAtomicCommits(t, newRepo(t)) })
+	t.Run("AtomicRollsBack", func(t *testing.T) { testAtomicRollsBack(t, newRepo(t)) })
+	t.Run("AtomicIsSerializable", func(t *testing.T) { testAtomicIsSerializable(t, newRepo(t)) })
+	t.Run("CanceledContext", func(t *testing.T) { testCanceledContext(t, newRepo(t)) })
+	t.Run("ConcurrentAccess", func(t *testing.T) { testConcurrentAccess(t, newRepo(t)) })
+	t.Run("Close", func(t *testing.T) { testClose(t, newRepo(t)) })
+}
+
+var baseTime = time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+func mustCreate(t *testing.T, repo todo.TaskRepo, task todo.Task) todo.Task {
+	t.Helper()
+
+	if task.CreatedAt.IsZero() {
+		task.CreatedAt = baseTime
+		task.UpdatedAt = baseTime
+	}
+	created, err := repo.Create(t.Context(), task)
+	if err != nil {
+		t.Fatalf("Create: expected no error, got %v", err)
+	}
+	return created
+}
+
+func strPtr(s string) *string {
+	return &s
+}
+
+func testCreateAssignsIDs(t *testing.T, repo todo.TaskRepo) {
+	first := mustCreate(t, repo, todo.Task{Title: "first"})
+	second := mustCreate(t, repo, todo.Task{Title: "second"})
+
+	if first.ID <= 0 {
+		t.Fatalf("expected positive ID, got %d", first.ID)
+	}
+	if second.ID <= first.ID {
+		t.Fatalf("expected increasing IDs, got %d then %d", first.ID, second.ID)
+	}
+
+	// IDs are not reused after a delete
+	if _, err := repo.Delete(t.Context(), second.ID); err != nil {
+		t.Fatalf("Delete: expected no error, got %v", err)
+	}
+	third := mustCreate(t, repo, todo.Task{Title: "third"})
+	if third.ID <= second.ID {
+		t.Fatalf("expected ID greater than %d, got %d", second.ID, third.ID)
+	}
+}
+
+func testCreateIgnoresGivenID(t *testing.T, repo todo.TaskRepo) {
+	created := mustCreate(t, repo, todo.Task{ID: 99, Title: "task"})
+
+	if created.ID == 99 {
+		t.Fatalf("expected repo to assign the ID, got caller's ID")
+	}
+}
+
+func testGetByID(t *testing.T, repo todo.TaskRepo) {
+	due := baseTime.Add(24 * time.Hour)
+	rule, _ := todo.ParseRecurrence("weekly:mon,thu")
+	remindAt := baseTime.Add(time.Hour)
+	created := mustCreate(t, repo, todo.Task{
+		OwnerID:    7,
+		Title:      "round trip",
+		Category:   strPtr("work"),
+		Tags:       []string{"urgent", "work"},
+		DueDate:    &due,
+		IsDone:     true,
+		Priority:   todo.PriorityHigh,
+		Recurrence: &rule,
+		Reminders:  []todo.Reminder{{At: &remindAt}, {Offset: -90 * time.Minute}},
+	})
+
+	got, err := repo.GetByID(t.Context(), created.ID)
+	if err != nil {
+		t.Fatalf("GetByID: expected no error, got %v", err)
+	}
+
+	if got.ID != created.ID || got.OwnerID != 7 || got.Title != "round trip" || !got.IsDone {
+		t.Fatalf("unexpected task %+v", got)
+	}
+	if got.Category == nil || *got.Category != "work" {
+		t.Fatalf("expected category work, got %v", got.Category)
+	}
+	if got.DueDate == nil || !got.DueDate.Equal(due) {
+		t.Fatalf("expected due date %v, got %v", due, got.DueDate)
+	}
+	if !got.CreatedAt.Equal(baseTime) || !got.UpdatedAt.Equal(baseTime) {
+		t.Fatalf("timestamps were not preserved: %+v", got)
+	}
+	if got.Recurrence == nil || got.Recurrence.String() != "weekly:mon,thu" {
+		t.Fatalf("expected recurrence weekly:mon,thu, got %v", got.Recurrence)
+	}
+	if !slices.Equal(got.Tags, []string{"urgent", "work"}) {
+		t.Fatalf("expected tags [urgent work], got %v", got.Tags)
+	}
+	if got.Priority != todo.PriorityHigh {
+		t.Fatalf("expected priority high, got %v", got.Priority)
+	}
+	if len(got.Reminders) != 2 || !got.Reminders[0].At.Equal(remindAt) || got.Reminders[1].Offset != -90*time.Minute {
+		t.Fatalf("expected reminders %v and due-1h30m, got %v", remindAt, got.Reminders)
+	}
+}
+
+func testNotFound(t *testing.T, repo todo.TaskRepo) {
+	if _, err := repo.GetByID(t.Context(), 1); !errors.Is(err, todo.ErrTaskNotFound) {
+		t.Fatalf("GetByID: expected %v, got %v", todo.ErrTaskNotFound, err)
+	}
+	if _, err := repo.Update(t.Context(), todo.Task{ID: 1, Title: "x"}); !errors.Is(err, todo.ErrTaskNotFound) {
+		t.Fatalf("Update: expected %v, got %v", todo.ErrTaskNotFound, err)
+	}
+	if _, err := repo.Delete(t.Context(), 1); !errors.Is(err, todo.ErrTaskNotFound) {
+		t.Fatalf("Delete: expected %v, got %v", todo.ErrTaskNotFound, err)
+	}
+}
+
+func testUpdate(t *testing.T, repo todo.TaskRepo) {
+	created := mustCreate(t, repo, todo.Task{Title: "before", Category: strPtr("old")})
+	other := mustCreate(t, repo, todo.Task{Title: "untouched"})
+
+	created.Title = "after"
+	created.Category = nil
+	created.IsDone = true
+	created.UpdatedAt = baseTime.Add(time.Hour)
+
+	updated, err := repo.Update(t.Context(), created)
+	if err != nil {
+		t.Fatalf("Update: expected no error, got %v", err)
+	}
+	if updated.Title != "after" {
+		t.Fatalf("expected returned task to be updated, got %+v", updated)
+	}
+
+	got, _ := repo.GetByID(t.Context(), created.ID)
+	if got.Title != "after" || got.Category != nil || !got.IsDone || !got.UpdatedAt.Equal(created.UpdatedAt) {
+		t.Fatalf("update was not stored: %+v", got)
+	}
+
+	got, _ = repo.GetByID(t.Context(), other.ID)
+	if got.Title != "untouched" {
+		t.Fatalf("update changed another task: %+v", got)
+	}
+}
+
+func testUpdateChecksVersion(t *testing.T, repo todo.TaskRepo) {
+	created := mustCreate(t, repo, todo.Task{Title: "v1"})
+	if created.Version != 1 {
+		t.Fatalf("expected version 1 after create, got %d", created.Version)
+	}
+
+	first := created
+	first.Title = "v2"
+	updated, err := repo.Update(t.Context(), first)
+	if err != nil {
+		t.Fatalf("Update: expected no error, got %v", err)
+	}
+	if updated.Version != 2 {
+		t.Fatalf("expected version 2 after update, got %d", updated.Version)
+	}
+
+	// A writer still holding version 1 must not overwrite version 2
+	stale := created
+	stale.Title = "lost update"
+	if _, err := repo.Update(t.Context(), stale); !errors.Is(err, todo.ErrVersionConflict) {
+		t.Fatalf("Update: expected %v, got %v", todo.ErrVersionConflict, err)
+	}
+
+	got, _ := repo.GetByID(t.Context(), created.ID)
+	if got.Title != "v2" || got.Version != 2 {
+		t.Fatalf("expected v2 at version 2 to be kept, got %+v", got)
+	}
+}
+
+func testDelete(t *testing.T, repo todo.TaskRepo) {
+	created := mustCreate(t, repo, todo.Task{Title: "doomed"})
+	kept := mustCreate(t, repo, todo.Task{Title: "kept"})
+
+	deleted, err := repo.Delete(t.Context(), created.ID)
+	if err != nil {
+		t.Fatalf("Delete: expected no error, got %v", err)
+	}
+	if deleted.ID != created.ID || deleted.Title != "doomed" {
+		t.Fatalf("expected deleted task to be returned, got %+v", deleted)
+	}
+
+	if _, err := repo.GetByID(t.Context(), created.ID); !errors.Is(err, todo.ErrTaskNotFound) {
+		t.Fatalf("expected %v after delete, got %v", todo.ErrTaskNotFound, err)
+	}
+	if _, err := repo.Delete(t.Context(), created.ID); !errors.Is(err, todo.ErrTaskNotFound) {
+		t.Fatalf("expected second delete to fail with %v, got %v", todo.ErrTaskNotFound, err)
+	}
+	if _, err := repo.GetByID(t.Context(), kept.ID); err != nil {
+		t.Fatalf("delete removed another task: %v", err)
+	}
+}
+
+func testList(t *testing.T, repo todo.TaskRepo) {
+	for i, title := range []string{"alpha", "beta", "gamma", "delta"} {
+		mustCreate(t, repo, todo.Task{
+			Title:     title,
+			IsDone:    i%2 == 1,
+			CreatedAt: baseTime.Add(time.Duration(i) * time.Hour),
+			UpdatedAt: baseTime,
+		})
+	}
+	mustCreate(t, repo, todo.Task{OwnerID: 3, Title: "someone else's alpha"})
+
+	q := todo.ListQuery{Sort: todo.SortCreatedAt, Order: todo.OrderAsc}
+
+	page, err := repo.List(t.Context(), q)
+	if err != nil {
+		t.Fatalf("List: expected no error, got %v", err)
+	}
+	if page.Total != 4 || len(page.Items) != 4 || page.Items[0].Title != "alpha" {
+		t.Fatalf("unexpected page %+v", page)
+	}
+
+	// Owner scoping
+	scoped := q
+	scoped.OwnerID = 3
+	page, _ = repo.List(t.Context(), scoped)
+	if page.Total != 1 || page.Items[0].OwnerID != 3 {
+		t.Fatalf("expected only owner 3's task, got %+v", page.Items)
+	}
+
+	// Filters
+	done := true
+	filtered := q
+	filtered.IsDone = &done
+	page, _ = repo.List(t.Context(), filtered)
+	if page.Total != 2 || page.Items[0].Title != "beta" || page.Items[1].Title != "delta" {
+		t.Fatalf("unexpected is_done page %+v", page.Items)
+	}
+
+	filtered = q
+	filtered.Search = "ALP"
+	page, _ = repo.List(t.Context(), filtered)
+	if page.Total != 1 || page.Items[0].Title != "alpha" {
+		t.Fatalf("unexpected search page %+v", page.Items)
+	}
+
+	// Sorting and paging
+	paged := q
+	paged.Order = todo.OrderDesc
+	paged.Limit = 2
+	paged.Offset = 1
+	page, _ = repo.List(t.Context(), paged)
+	if page.Total != 4 || len(page.Items) != 2 || page.Items[0].Title != "gamma" || page.Items[1].Title != "beta" {
+		t.Fatalf("unexpected paged result %+v", page)
+	}
+}
+
+func testListByParent(t *testing.T, repo todo.TaskRepo) {
+	root := mustCreate(t, repo, todo.Task{Title: "root", CreatedAt: baseTime, UpdatedAt: baseTime})
+	first := mustCreate(t, repo, todo.Task{Title: "first", ParentID: &root.ID, CreatedAt: baseTime.Add(time.Hour), UpdatedAt: baseTime})
+	second := mustCreate(t, repo, todo.Task{Title: "second", ParentID: &root.ID, CreatedAt: baseTime.Add(2 * time.Hour), UpdatedAt: baseTime})
+	mustCreate(t, repo, todo.Task{Title: "grandchild", ParentID: &first.ID})
+	mustCreate(t, repo, todo.Task{Title: "other root"})
+
+	// Check the parent is stored
+	got, _ := repo.GetByID(t.Context(), first.ID)
+	if got.ParentID == nil || *got.ParentID != root.ID {
+		t.Fatalf("expected parent %d, got %v", root.ID, got.ParentID)
+	}
+
+	q := todo.ListQuery{ParentID: todo.Some(root.ID), Sort: todo.SortCreatedAt, Order: todo.OrderAsc}
+	page, err := repo.List(t.Context(), q)
+	if err != nil {
+		t.Fatalf("List: expected no error, got %v", err)
+	}
+	if page.Total != 2 || page.Items[0].ID != first.ID || page.Items[1].ID != second.ID {
+		t.Fatalf("expected the two children of root, got %+v", page.Items)
+	}
+
+	q.ParentID = todo.Clear[int]()
+	page, _ = repo.List(t.Context(), q)
+	if page.Total != 2 || page.Items[0].ID != root.ID {
+		t.Fatalf("expected the two top-level tasks, got %+v", page.Items)
+	}
+
+	// Moving a task to the top level
+	second.ParentID = nil
+	if _, err := repo.Update(t.Context(), second); err != nil {
+		t.Fatalf("Update: expected no error, got %v", err)
+	}
+	page, _ = repo.List(t.Context(), todo.ListQuery{ParentID: todo.Some(root.ID)})
+	if page.Total != 1 || page.Items[0].ID != first.ID {
+		t.Fatalf("expected only first under root, got %+v", page.Items)
+	}
+}
+
+func testListByTags(t *testing.T, repo todo.TaskRepo) {
+	mustCreate(t, repo, todo.Task{Title: "a", Tags: []string{"urgent", "work"}, CreatedAt: baseTime, UpdatedAt: baseTime})
+	b := mustCreate(t, repo, todo.Task{Title: "b", Tags: []string{"home"}, CreatedAt: baseTime.Add(time.Hour), UpdatedAt: baseTime})
+	mustCreate(t, repo, todo.Task{Title: "c", Tags: []string{"work"}, CreatedAt: baseTime.Add(2 * time.Hour), UpdatedAt: baseTime})
+	mustCreate(t, repo, todo.Task{Title: "d", CreatedAt: baseTime.Add(3 * time.Hour), UpdatedAt: baseTime})
+	mustCreate(t, repo, todo.Task{OwnerID: 3, Title: "e", Tags: []string{"work"}})
+
+	titles := func(q todo.ListQuery) string {
+		t.Helper()
+
+		q.Sort, q.Order = todo.SortCreatedAt, todo.OrderAsc
+		page, err := repo.List(t.Context(), q)
+		if err != nil {
+			t.Fatalf("List: expected no error, got %v", err)
+		}
+		var out []string
+		for _, task := range page.Items {
+			out = append(out, task.Title)
+		}
+		return strings.Join(out, ",")
+	}
+
+	cases := []struct {
+		q    todo.ListQuery
+		want string
+	}{
+		{todo.ListQuery{TagsAny: []string{"home", "urgent"}}, "a,b"},
+		{todo.ListQuery{TagsAll: []string{"work", "urgent"}}, "a"},
+		{todo.ListQuery{TagsNone: []string{"work"}}, "b,d"},
+		{todo.ListQuery{TagsAny: []string{"work"}, TagsNone: []string{"urgent"}}, "c"},
+		{todo.ListQuery{TagsAll: []string{"missing"}}, ""},
+	}
+	for _, tc := range cases {
+		if got := titles(tc.q); got != tc.want {
+			t.Fatalf("%+v: expected %q, got %q", tc.q, tc.want, got)
+		}
+	}
+
+	// Updates replace the tags
+	b.Tags = []string{"work"}
+	if _, err := repo.Update(t.Context(), b); err != nil {
+		t.Fatalf("Update: expected no error, got %v", err)
+	}
+	if got := titles(todo.ListQuery{TagsAll: []string{"work"}}); got != "a,b,c" {
+		t.Fatalf("expected a,b,c, got %q", got)
+	}
+	if got := titles(todo.ListQuery{TagsAll: []string{"home"}}); got != "" {
+		t.Fatalf("expected no home tasks, got %q", got)
+	}
+}
+
+func testListByPriority(t *testing.T, repo todo.TaskRepo) {
+	now := baseTime.Add(10 * 24 * time.Hour)
+	day := func(n int) *time.Time {
+		d := now.AddDate(0, 0, n).Truncate(24 * time.Hour)
+		return &d
+	}
+
+	for _, task := range []todo.Task{
+		{Title: "done", IsDone: true, Priority: todo.PriorityHigh, DueDate: day(-3)},
+		{Title: "someday"},
+		{Title: "low later", Priority: todo.PriorityLow, DueDate: day(2)},
+		{Title: "high", Priority: todo.PriorityHigh},
+		{Title: "overdue", DueDate: day(-1)},
+		{Title: "low soon", Priority: todo.PriorityLow, DueDate: day(1)},
+		{Title: "due today", Priority: todo.PriorityHigh, DueDate: day(0)},
+	} {
+		task.CreatedAt, task.UpdatedAt = baseTime, baseTime
+		mustCreate(t, repo, task)
+	}
+
+	titles := func(q todo.ListQuery) string {
+		t.Helper()
+
+		q.Now = now
+		page, err := repo.List(t.Context(), q)
+		if err != nil {
+			t.Fatalf("List: expected no error, got %v", err)
+		}
+		var out []string
+		for _, task := range page.Items {
+			out = append(out, task.Title)
+		}
+		return strings.Join(out, ",")
+	}
+
+	// Smart order: open, overdue, priority, due date. Reversed, tasks
+	// without a due date still come last among equals.
+	want := "overdue,due today,high,low soon,low later,someday,done"
+	if got := titles(todo.ListQuery{Sort: todo.SortSmart, Order: todo.OrderAsc}); got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+	want = "done,someday,low later,low soon,due today,high,overdue"
+	if got := titles(todo.ListQuery{Sort: todo.SortSmart, Order: todo.OrderDesc}); got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+
+	// Priority filter
+	q := todo.ListQuery{Sort: todo.SortCreatedAt, Order: todo.OrderAsc, Priorities: []todo.Priority{todo.PriorityLow, todo.PriorityNone}}
+	if got := titles(q); got != "someday,low later,overdue,low soon" {
+		t.Fatalf("unexpected priority filter result %q", got)
+	}
+}
+
+func testListTrash(t *testing.T, repo todo.TaskRepo) {
+	at := func(h int) *time.Time {
+		d := baseTime.Add(time.Duration(h) * time.Hour)
+		return &d
+	}
+	mustCreate(t, repo, todo.Task{Title: "live", CreatedAt: baseTime, UpdatedAt: baseTime})
+	mustCreate(t, repo, todo.Task{Title: "old", DeletedAt: at(1), CreatedAt: baseTime, UpdatedAt: baseTime})
+	recent := mustCreate(t, repo, todo.Task{Title: "recent", DeletedAt: at(5), CreatedAt: baseTime, UpdatedAt: baseTime})
+	mustCreate(t, repo, todo.Task{OwnerID: 3, Title: "other", DeletedAt: at(2), CreatedAt: baseTime, UpdatedAt: baseTime})
+
+	titles := func(q todo.ListQuery) string {
+		t.Helper()
+
+		page, err := repo.List(t.Context(), q)
+		if err != nil {
+			t.Fatalf("List: expected no error, got %v", err)
+		}
+		var out []string
+		for _, task := range page.Items {
+			out = append(out, task.Title)
+		}
+		return strings.Join(out, ",")
+	}
+
+	cases := []struct {
+		q    todo.ListQuery
+		want string
+	}{
+		{todo.ListQuery{}, "live"},
+		{todo.ListQuery{Trashed: true, Sort: todo.SortDeletedAt, Order: todo.OrderDesc}, "recent,old"},
+		{todo.ListQuery{Trashed: true, DeletedBefore: *at(3)}, "old"},
+		{todo.ListQuery{Trashed: true, AllOwners: true, DeletedBefore: *at(3), Sort: todo.SortDeletedAt, Order: todo.OrderAsc}, "old,other"},
+	}
+	for _, tc := range cases {
+		if got := titles(tc.q); got != tc.want {
+			t.Fatalf("%+v: expected %q, got %q", tc.q, tc.want, got)
+		}
+	}
+
+	// The deletion time round-trips and can be cleared
+	got, err := repo.GetByID(t.Context(), recent.ID)
+	if err != nil || got.DeletedAt == nil || !got.DeletedAt.Equal(*at(5)) {
+		t.Fatalf("expected deleted_at %v, got %+v, %v", at(5), got, err)
+	}
+	got.DeletedAt = nil
+	if _, err := repo.Update(t.Context(), got); err != nil {
+		t.Fatalf("Update: expected no error, got %v", err)
+	}
+	if got := titles(todo.ListQuery{Sort: todo.SortCreatedAt, Order: todo.OrderAsc}); got != "live,recent" {
+		t.Fatalf("expected live,recent, got %q", got)
+	}
+}
+
+func testListWithReminders(t *testing.T, repo todo.TaskRepo) {
+	mustCreate(t, repo, todo.Task{Title: "plain"})
+	reminded := mustCreate(t, repo, todo.Task{Title: "reminded", Reminders: []todo.Reminder{{}}})
+	mustCreate(t, repo, todo.Task{OwnerID: 3, Title: "other", Reminders: []todo.Reminder{{Offset: time.Hour}}})
+
+	page, err := repo.List(t.Context(), todo.ListQuery{HasReminders: true, AllOwners: true, Sort: todo.SortCreatedAt, Order: todo.OrderAsc})
+	if err != nil || len(page.Items) != 2 || page.Items[0].Title != "reminded" || page.Items[1].Title != "other" {
+		t.Fatalf("expected reminded and other, got %+v, %v", page.Items, err)
+	}
+
+	// Check clearing the reminders drops the task from the filter
+	reminded.Reminders = nil
+	if _, err := repo.Update(t.Context(), reminded); err != nil {
+		t.Fatalf("Update: expected no error, got %v", err)
+	}
+	page, err = repo.List(t.Context(), todo.ListQuery{HasReminders: true})
+	if err != nil || len(page.Items) != 0 {
+		t.Fatalf("expected no tasks with reminders, got %+v, %v", page.Items, err)
+	}
+}
+
+func testListByDueDay(t *testing.T, repo todo.TaskRepo) {
+	day := func(d int) time.Time { return time.Date(2026, 3, d, 0, 0, 0, 0, time.UTC) }
+	at := func(d, h int) *time.Time {
+		due := day(d).Add(time.Duration(h) * time.Hour)
+		return &due
+	}
+	for _, task := range []todo.Task{
+		{Title: "dated-yesterday", DueDate: at(8, 0)},
+		{Title: "dated-today", DueDate: at(9, 0)},
+		{Title: "timed-early", DueDate: at(9, 3)}, // 22:00 on the 8th at UTC-5
+		{Title: "timed-late", DueDate: at(10, 1)}, // 20:00 on the 9th at UTC-5
+		{Title: "dated-later", DueDate: at(15, 0)},
+		{Title: "undated"},
+	} {
+		mustCreate(t, repo, task)
+	}
+
+	est := time.FixedZone("EST", -5*60*60)
+	titles := func(q todo.ListQuery) string {
+		t.Helper()
+		if q.Sort == "" {
+			q.Sort, q.Order = todo.SortCreatedAt, todo.OrderAsc
+		}
+		page, err := repo.List(t.Context(), q)
+		if err != nil {
+			t.Fatalf("List: expected no error, got %v", err)
+		}
+		var out []string
+		for _, task := range page.Items {
+			out = append(out, task.Title)
+		}
+		return strings.Join(out, ",")
+	}
+
+	// Check due times fall on their day in the zone, dates on their date
+	cases := []struct {
+		q    todo.ListQuery
+		want string
+	}{
+		{todo.ListQuery{Location: est, DueUntil: day(9)}, "dated-yesterday,timed-early"},
+		{todo.ListQuery{Location: est, DueFrom: day(9), DueUntil: day(10)}, "dated-today,timed-late"},
+		{todo.ListQuery{Location: est, DueFrom: day(10), DueUntil: day(16)}, "dated-later"},
+		{todo.ListQuery{DueFrom: day(9), DueUntil: day(10)}, "dated-today,timed-early"},
+		{todo.ListQuery{NoDueDate: true}, "undated"},
+	}
+	for _, c := range cases {
+		if got := titles(c.q); got != c.want {
+			t.Fatalf("%+v: expected %s, got %s", c.q, c.want, got)
+		}
+	}
+
+	// Check the smart sort judges overdue tasks in the zone: at 21:00 on
+	// the 9th at UTC-5, the date due that day is not overdue yet
+	now := at(10, 2)
+	cases = []struct {
+		q    todo.ListQuery
+		want string
+	}{
+		{todo.ListQuery{Location: est}, "dated-yesterday,timed-early,dated-today,timed-late,dated-later,undated"},
+		{todo.ListQuery{}, "dated-yesterday,dated-today,timed-early,timed-late,dated-later,undated"},
+	}
+	for _, c := range cases {
+		c.q.Sort, c.q.Order, c.q.Now = todo.SortSmart, todo.OrderAsc, *now
+		if got := titles(c.q); got != c.want {
+			t.Fatalf("%+v: expected %s, got %s", c.q, c.want, got)
+		}
+	}
+}
+
+func testAtomicCommits(t *testing.T, repo todo.TaskRepo) {
+	existing := mustCreate(t, repo, todo.Task{Title: "existing"})
+	doomed := mustCreate(t, repo, todo.Task{Title: "doomed"})
+
+	var created todo.Task
+	err := repo.Atomic(t.Context(), func(tx todo.TaskStore) error {
+		var err error
+		if created, err = tx.Create(t.Context(), todo.Task{Title: "new", CreatedAt: baseTime}); err != nil {
+			return err
+		}
+
+		// The transaction sees its own writes
+		if _, err := tx.GetByID(t.Context(), created.ID); err != nil {
+			return err
+		}
+
+		task, err := tx.GetByID(t.Context(), existing.ID)
+		if err != nil {
+			return err
+		}
+		task.Title = "changed"
+		if _, err := tx.Update(t.Context(), task); err != nil {
+			return err
+		}
+
+		_, err = tx.Delete(t.Context(), doomed.ID)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("Atomic: expected no error, got %v", err)
+	}
+
+	if got, err := repo.GetByID(t.Context(), created.ID); err != nil || got.Title != "new" {
+		t.Fatalf("expected created task, got %+v, %v", got, err)
+	}
+	if got, _ := repo.GetByID(t.Context(), existing.ID); got.Title != "changed" || got.Version != 2 {
+		t.Fatalf("expected update to be committed, got %+v", got)
+	}
+	if _, err := repo.GetByID(t.Context(), doomed.ID); !errors.Is(err, todo.ErrTaskNotFound) {
+		t.Fatalf("expected delete to be committed, got %v", err)
+	}
+}
+
+func testAtomicRollsBack(t *testing.T, repo todo.TaskRepo) {
+	existing := mustCreate(t, repo, todo.Task{Title: "existing"})
+	errAbort := errors.New("abort")
+
+	err := repo.Atomic(t.Context(), func(tx todo.TaskStore) error {
+		if _, err := tx.Create(t.Context(), todo.Task{Title: "discarded", CreatedAt: baseTime}); err != nil {
+			return err
+		}
+
+		task, _ := tx.GetByID(t.Context(), existing.ID)
+		task.Title = "discarded"
+		if _, err := tx.Update(t.Context(), task); err != nil {
+			return err
+		}
+		return errAbort
+	})
+	if !errors.Is(err, errAbort) {
+		t.Fatalf("Atomic: expected %v, got %v", errAbort, err)
+	}
+
+	page, _ := repo.List(t.Context(), todo.ListQuery{})
+	if page.Total != 1 || page.Items[0].Title != "existing" || page.Items[0].Version != 1 {
+		t.Fatalf("expected no writes after rollback, got %+v", page.Items)
+	}
+}
+
+// testAtomicIsSerializable runs read-increment-write transactions from many
+// goroutines. Without isolation some increments would be lost.
+func testAtomicIsSerializable(t *testing.T, repo todo.TaskRepo) {
+	const workers = 8
+	const perWorker = 10
+
+	counter := mustCreate(t, repo, todo.Task{Title: "0"})
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < perWorker; i++ {
+				err := repo.Atomic(t.Context(), func(tx todo.TaskStore) error {
+					task, err := tx.GetByID(t.Context(), counter.ID)
+					if err != nil {
+						return err
+					}
+					n, _ := strconv.Atoi(task.Title)
+					task.Title = strconv.Itoa(n + 1)
+					_, err = tx.Update(t.Context(), task)
+					return err
+				})
+				if err != nil {
+					t.Errorf("Atomic: expected no error, got %v", err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	got, _ := repo.GetByID(t.Context(), counter.ID)
+	if want := strconv.Itoa(workers * perWorker); got.Title != want {
+		t.Fatalf("expected counter %s, got %s", want, got.Title)
+	}
+	if got.Version != workers*perWorker+1 {
+		t.Fatalf("expected version %d, got %d", workers*perWorker+1, got.Version)
+	}
+}
+
+func testCanceledContext(t *testing.T, repo todo.TaskRepo) {
+	created := mustCreate(t, repo, todo.Task{Title: "existing"})
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+
+	if _, err := repo.Create(ctx, todo.Task{Title: "canceled", CreatedAt: baseTime}); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Create: expected %v, got %v", context.Canceled, err)
+	}
+	if _, err := repo.List(ctx, todo.ListQuery{}); !errors.Is(err, context.Canceled) {
+		t.Fatalf("List: expected %v, got %v", context.Canceled, err)
+	}
+	if _, err := repo.GetByID(ctx, created.ID); !errors.Is(err, context.Canceled) {
+		t.Fatalf("GetByID: expected %v, got %v", context.Canceled, err)
+	}
+	if _, err := repo.Update(ctx, created); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Update: expected %v, got %v", context.Canceled, err)
+	}
+	if _, err := repo.Delete(ctx, created.ID); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Delete: expected %v, got %v", context.Canceled, err)
+	}
+	if err := repo.Atomic(ctx, func(todo.TaskStore) error { return nil }); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Atomic: expected %v, got %v", context.Canceled, err)
+	}
+
+	// Nothing was changed
+	page, _ := repo.List(t.Context(), todo.ListQuery{})
+	if page.Total != 1 || page.Items[0].Title != "existing" {
+		t.Fatalf("expected canceled calls to change nothing, got %+v", page.Items)
+	}
+}
+
+func testConcurrentAccess(t *testing.T, repo todo.TaskRepo) {
+	const workers = 8
+	const perWorker = 10
+
+	var wg sync.WaitGroup
+	ids := make(chan int, workers*perWorker)
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < perWorker; i++ {
+				task, err := repo.Create(t.Context(), todo.Task{Title: "concurrent", CreatedAt: baseTime})
+				if err != nil {
+					t.Errorf("Create: expected no error, got %v", err)
+					return
+				}
+				ids <- task.ID
+
+				task.IsDone = true
+				if _, err := repo.Update(t.Context(), task); err != nil {
+					t.Errorf("Update: expected no error, got %v", err)
+				}
+				if _, err := repo.GetByID(t.Context(), task.ID); err != nil {
+					t.Errorf("GetByID: expected no error, got %v", err)
+				}
+				if _, err := repo.List(t.Context(), todo.ListQuery{}); err != nil {
+					t.Errorf("List: expected no error, got %v", err)
+				}
+				if i%2 == 0 {
+					if _, err := repo.Delete(t.Context(), task.ID); err != nil {
+						t.Errorf("Delete: expected no error, got %v", err)
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(ids)
+
+	seen := make(map[int]bool)
+	for id := range ids {
+		if seen[id] {
+			t.Fatalf("ID %d assigned twice", id)
+		}
+		seen[id] = true
+	}
+
+	page, err := repo.List(t.Context(), todo.ListQuery{})
+	if err != nil {
+		t.Fatalf("List: expected no error, got %v", err)
+	}
+	if want := workers * perWorker / 2; page.Total != want {
+		t.Fatalf("expected %d tasks left, got %d", want, page.Total)
+	}
+}
+
+func testClose(t *testing.T, repo todo.TaskRepo) {
+	created := mustCreate(t, repo, todo.Task{Title: "before close"})
+
+	if err := repo.Close(); err != nil {
+		t.Fatalf("Close: expected no error, got %v", err)
+	}
+	if err := repo.Close(); err != nil {
+		t.Fatalf("second Close: expected no error, got %v", err)
+	}
+
+	if _, err := repo.Create(t.Context(), todo.Task{Title: "after close"}); !errors.Is(err, todo.ErrRepoClosed) {
+		t.Fatalf("Create: expected %v, got %v", todo.ErrRepoClosed, err)
+	}
+	if _, err := repo.GetByID(t.Context(), created.ID); !errors.Is(err, todo.ErrRepoClosed) {
+		t.Fatalf("GetByID: expected %v, got %v", todo.ErrRepoClosed, err)
+	}
+	if _, err := repo.List(t.Context(), todo.ListQuery{}); !errors.Is(err, todo.ErrRepoClosed) {
+		t.Fatalf("List: expected %v, got %v", todo.ErrRepoClosed, err)
+	}
+	if err := repo.Atomic(t.Context(), func(todo.TaskStore) error { return nil }); !errors.Is(err, todo.ErrRepoClosed) {
+		t.Fatalf("Atomic: expected %v, got %v", todo.ErrRepoClosed, err)
+	}
+}
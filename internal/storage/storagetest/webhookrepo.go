@@ -0,0 +1,122 @@
+package storagetest
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Saintrad/todo-server-client/internal/todo"
+	"github.com/Saintrad/todo-server-client/internal/webhook"
+)
+
+// WebhookRepoFactory returns a new, empty repo. It is called once per
+// subtest.
+type WebhookRepoFactory func(t *testing.T) webhook.Repo
+
+// RunWebhookRepoTests runs the webhook.Repo conformance suite against
+// newRepo.
+func RunWebhookRepoTests(t *testing.T, newRepo WebhookRepoFactory) {
+	t.Run("CRUD", func(t *testing.T) { testWebhookCRUD(t, newRepo(t)) })
+	t.Run("Deliveries", func(t *testing.T) { testWebhookDeliveries(t, newRepo(t)) })
+	t.Run("Close", func(t *testing.T) { testWebhookClose(t, newRepo(t)) })
+}
+
+func testWebhookCRUD(t *testing.T, repo webhook.Repo) {
+	ctx := t.Context()
+
+	w, err := repo.CreateWebhook(ctx, webhook.Webhook{
+		OwnerID: 1, URL: "https://example.com/a", Secret: "s",
+		Events: []todo.EventKind{todo.EventCreated, todo.EventCompleted}, CreatedAt: baseTime, UpdatedAt: baseTime,
+	})
+	if err != nil || w.ID <= 0 {
+		t.Fatalf("CreateWebhook: expected an ID, got %+v, %v", w, err)
+	}
+	other, _ := repo.CreateWebhook(ctx, webhook.Webhook{OwnerID: 2, URL: "https://example.com/b", Secret: "s", CreatedAt: baseTime, UpdatedAt: baseTime})
+	if other.ID == w.ID {
+		t.Fatalf("expected distinct IDs, got %d twice", w.ID)
+	}
+
+	got, err := repo.GetWebhook(ctx, w.ID)
+	if err != nil || got.URL != w.URL || len(got.Events) != 2 || got.Events[1] != todo.EventCompleted || !got.CreatedAt.Equal(baseTime) {
+		t.Fatalf("GetWebhook: expected %+v, got %+v, %v", w, got, err)
+	}
+
+	// Check listing is per owner
+	list, err := repo.ListWebhooks(ctx, 1)
+	if err != nil || len(list) != 1 || list[0].ID != w.ID {
+		t.Fatalf("ListWebhooks: expected webhook %d, got %+v, %v", w.ID, list, err)
+	}
+
+	w.URL = "https://example.com/c"
+	w.Events = nil
+	if _, err := repo.UpdateWebhook(ctx, w); err != nil {
+		t.Fatalf("UpdateWebhook: expected no error, got %v", err)
+	}
+	if got, _ := repo.GetWebhook(ctx, w.ID); got.URL != w.URL || len(got.Events) != 0 {
+		t.Fatalf("expected updated webhook, got %+v", got)
+	}
+
+	if err := repo.DeleteWebhook(ctx, w.ID); err != nil {
+		t.Fatalf("DeleteWebhook: expected no error, got %v", err)
+	}
+
+	// Check missing webhooks
+	if _, err := repo.GetWebhook(ctx, w.ID); !errors.Is(err, webhook.ErrWebhookNotFound) {
+		t.Fatalf("expected error %v, got %v", webhook.ErrWebhookNotFound, err)
+	}
+	if _, err := repo.UpdateWebhook(ctx, w); !errors.Is(err, webhook.ErrWebhookNotFound) {
+		t.Fatalf("expected error %v, got %v", webhook.ErrWebhookNotFound, err)
+	}
+	if err := repo.DeleteWebhook(ctx, w.ID); !errors.Is(err, webhook.ErrWebhookNotFound) {
+		t.Fatalf("expected error %v, got %v", webhook.ErrWebhookNotFound, err)
+	}
+}
+
+func testWebhookDeliveries(t *testing.T, repo webhook.Repo) {
+	ctx := t.Context()
+	w, _ := repo.CreateWebhook(ctx, webhook.Webhook{OwnerID: 1, URL: "https://example.com", Secret: "s", CreatedAt: baseTime, UpdatedAt: baseTime})
+
+	for i := 1; i <= webhook.MaxDeliveryLog+2; i++ {
+		_, err := repo.AppendDelivery(ctx, webhook.Delivery{
+			WebhookID: w.ID, Key: "k", Event: todo.EventCreated, TaskID: i, Attempt: 1,
+			StatusCode: 500, Error: "Internal Server Error", Duration: time.Millisecond, At: baseTime,
+		})
+		if err != nil {
+			t.Fatalf("AppendDelivery: expected no error, got %v", err)
+		}
+	}
+
+	// Check the log is capped, newest first
+	log, err := repo.ListDeliveries(ctx, w.ID)
+	if err != nil || len(log) != webhook.MaxDeliveryLog {
+		t.Fatalf("expected %d deliveries, got %d, %v", webhook.MaxDeliveryLog, len(log), err)
+	}
+	d := log[0]
+	if d.TaskID != webhook.MaxDeliveryLog+2 || d.Key != "k" || d.StatusCode != 500 || d.Duration != time.Millisecond || !d.At.Equal(baseTime) {
+		t.Fatalf("unexpected newest delivery %+v", d)
+	}
+	if last := log[len(log)-1]; last.TaskID != 3 {
+		t.Fatalf("expected oldest kept delivery for task 3, got %+v", last)
+	}
+
+	// Check deleting the webhook removes its log and rejects new entries
+	repo.DeleteWebhook(ctx, w.ID)
+	if log, _ := repo.ListDeliveries(ctx, w.ID); len(log) != 0 {
+		t.Fatalf("expected no deliveries, got %d", len(log))
+	}
+	if _, err := repo.AppendDelivery(ctx, webhook.Delivery{WebhookID: w.ID, At: baseTime}); !errors.Is(err, webhook.ErrWebhookNotFound) {
+		t.Fatalf("expected error %v, got %v", webhook.ErrWebhookNotFound, err)
+	}
+}
+
+func testWebhookClose(t *testing.T, repo webhook.Repo) {
+	if err := repo.Close(); err != nil {
+		t.Fatalf("Close: expected no error, got %v", err)
+	}
+	if err := repo.Close(); err != nil {
+		t.Fatalf("second Close: expected no error, got %v", err)
+	}
+	if _, err := repo.ListWebhooks(t.Context(), 1); !errors.Is(err, todo.ErrRepoClosed) {
+		t.Fatalf("expected error %v, got %v", todo.ErrRepoClosed, err)
+	}
+}
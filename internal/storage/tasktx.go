@@ -0,0 +1,94 @@
+package storage
+
+import (
+	"context"
+	"slices"
+
+	"github.com/Saintrad/todo-server-client/internal/todo"
+)
+
+// taskTx is the TaskStore that MemoryTaskRepo and FileTaskRepo hand to
+// Atomic. It edits a private copy of the tasks, which the repo installs only
+// if the transaction succeeds, and records each write for the journal. It
+// does no locking: the repo holds its mutex for the whole transaction.
+type taskTx struct {
+	tasks  []todo.Task
+	nextID int
+	log    []journalRecord
+}
+
+var _ todo.TaskStore = (*taskTx)(nil)
+
+func newTaskTx(tasks []todo.Task, nextID int) *taskTx {
+	return &taskTx{tasks: slices.Clone(tasks), nextID: nextID}
+}
+
+func (tx *taskTx) Create(ctx context.Context, t todo.Task) (todo.Task, error) {
+	if err := ctx.Err(); err != nil {
+		return todo.Task{}, err
+	}
+
+	t.ID = tx.nextID
+	t.Version = 1
+	tx.nextID++
+	tx.tasks = append(tx.tasks, t)
+
+	tx.log = append(tx.log, journalRecord{Op: opCreate, Task: &t})
+	return t, nil
+}
+
+func (tx *taskTx) List(ctx context.Context, q todo.ListQuery) (todo.TaskPage, error) {
+	if err := ctx.Err(); err != nil {
+		return todo.TaskPage{}, err
+	}
+	return q.Apply(tx.tasks), nil
+}
+
+func (tx *taskTx) GetByID(ctx context.Context, id int) (todo.Task, error) {
+	if err := ctx.Err(); err != nil {
+		return todo.Task{}, err
+	}
+
+	for _, task := range tx.tasks {
+		if task.ID == id {
+			return task, nil
+		}
+	}
+	return todo.Task{}, todo.ErrTaskNotFound
+}
+
+func (tx *taskTx) Update(ctx context.Context, t todo.Task) (todo.Task, error) {
+	if err := ctx.Err(); err != nil {
+		return todo.Task{}, err
+	}
+
+	for idx, task := range tx.tasks {
+		if task.ID == t.ID {
+			if task.Version != t.Version {
+				return todo.Task{}, todo.ErrVersionConflict
+			}
+			t.Version++
+			tx.tasks[idx] = t
+
+			tx.log = append(tx.log, journalRecord{Op: opUpdate, Task: &t})
+			return t, nil
+		}
+	}
+	return todo.Task{}, todo.ErrTaskNotFound
+}
+
+func (tx *taskTx) Delete(ctx context.Context, id int) (todo.Task, error) {
+	if err := ctx.Err(); err != nil {
+		return todo.Task{}, err
+	}
+
+	for idx, task := range tx.tasks {
+		if task.ID == id {
+			tx.tasks = slices.Delete(tx.tasks, idx, idx+1)
+
+			tx.log = append(tx.log, journalRecord{Op: opDelete, ID: id})
+			return task, nil
+		}
+	}
+	return todo.Task{}, todo.ErrTaskNotFound
+}
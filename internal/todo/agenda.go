@@ -0,0 +1,60 @@
+package todo
+
+import (
+	"context"
+	"time"
+)
+
+// Agenda is the open tasks of an owner sorted into what is overdue, due
+// today, due in the rest of the week and not due at all.
+type Agenda struct {
+	// Day is today in the caller's zone, as a CalendarDay.
+	Day       time.Time
+	Overdue   TaskPage
+	Today     TaskPage
+	Upcoming  TaskPage
+	NoDueDate TaskPage
+}
+
+// Agenda lists the open tasks matching q by their due day, judged in
+// q.Location. The other filters and the pagination of q apply to every
+// section; q.Due and q.IsDone must not be set. Sections are sorted by due
+// date, soonest first, unless q says otherwise.
+func (s Service) Agenda(ctx context.Context, q ListQuery) (Agenda, error) {
+	var issues []FieldIssue
+	if q.Due != "" {
+		issues = append(issues, FieldIssue{Field: "due", Issue: "is not allowed on the agenda"})
+	}
+	if q.IsDone != nil {
+		issues = append(issues, FieldIssue{Field: "is_done", Issue: "is not allowed on the agenda"})
+	}
+	if len(issues) > 0 {
+		return Agenda{}, NewValidationError(issues...)
+	}
+
+	if q.Sort == "" {
+		q.Sort = SortDueDate
+		if q.Order == "" {
+			q.Order = OrderAsc
+		}
+	}
+	if q.Now.IsZero() {
+		q.Now = time.Now()
+	}
+	open := false
+	q.IsDone = &open
+
+	a := Agenda{Day: CalendarDay(q.Now.In(q.location()))}
+	for _, section := range []struct {
+		due DueFilter
+		dst *TaskPage
+	}{{DueOverdue, &a.Overdue}, {DueToday, &a.Today}, {DueUpcoming, &a.Upcoming}, {DueNone, &a.NoDueDate}} {
+		q.Due = section.due
+		page, err := s.ListTask(ctx, q)
+		if err != nil {
+			return Agenda{}, err
+		}
+		*section.dst = page
+	}
+	return a, nil
+}
@@ -0,0 +1,72 @@
+package todo
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAgenda(t *testing.T) {
+	s := NewService(NewFakeRepo())
+	ctx := t.Context()
+	est := time.FixedZone("EST", -5*60*60)
+	now := time.Date(2026, 3, 10, 2, 0, 0, 0, time.UTC)
+	day := func(d int) *time.Time {
+		due := time.Date(2026, 3, d, 0, 0, 0, 0, time.UTC)
+		return &due
+	}
+
+	for _, in := range []CreateTaskInput{
+		{Title: "late", DueDate: day(2)},
+		{Title: "today", DueDate: day(9)},
+		{Title: "soon", DueDate: day(12)},
+		{Title: "sooner", DueDate: day(11)},
+		{Title: "later", DueDate: day(20)},
+		{Title: "someday"},
+		{Title: "finished", DueDate: day(1)},
+	} {
+		created, err := s.CreateTask(ctx, in)
+		if err != nil {
+			t.Fatalf("expected no errors, got %v", err)
+		}
+		if created.Title == "finished" {
+			done := true
+			s.UpdateTask(ctx, created.ID, UpdateTaskInput{IsDone: &done})
+		}
+	}
+
+	a, err := s.Agenda(ctx, ListQuery{Now: now, Location: est})
+	if err != nil {
+		t.Fatalf("expected no errors, got %v", err)
+	}
+	titles := func(p TaskPage) string {
+		var out []string
+		for _, task := range p.Items {
+			out = append(out, task.Title)
+		}
+		return strings.Join(out, ",")
+	}
+
+	// Check the sections hold open tasks only, soonest first
+	if !a.Day.Equal(*day(9)) {
+		t.Fatalf("expected the 9th as today, got %v", a.Day)
+	}
+	for name, c := range map[string]struct {
+		page TaskPage
+		want string
+	}{
+		"overdue":     {a.Overdue, "late"},
+		"today":       {a.Today, "today"},
+		"upcoming":    {a.Upcoming, "sooner,soon"},
+		"no due date": {a.NoDueDate, "someday"},
+	} {
+		if got := titles(c.page); got != c.want {
+			t.Fatalf("%s: expected %s, got %v", name, c.want, got)
+		}
+	}
+
+	// Check presets cannot be mixed in
+	if _, err := s.Agenda(ctx, ListQuery{Due: DueToday}); CodeOf(err) != CodeValidation {
+		t.Fatalf("expected code %s, got %v", CodeValidation, err)
+	}
+}
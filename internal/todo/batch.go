@@ -0,0 +1,102 @@
+package todo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// MaxBatchOps is the largest number of operations accepted in one batch.
+const MaxBatchOps = 1000
+
+type BatchOpKind string
+
+const (
+	BatchCreate BatchOpKind = "create"
+	BatchUpdate BatchOpKind = "update"
+	BatchDelete BatchOpKind = "delete"
+)
+
+// BatchOp is one operation of a batch. ID is required for update and
+// delete; IfVersion optionally guards them like If-Match.
+type BatchOp struct {
+	Kind      BatchOpKind
+	ID        int
+	IfVersion *int
+	Create    CreateTaskInput
+	Update    UpdateTaskInput
+}
+
+// BatchResult is the outcome of one operation: the created or updated task,
+// the deleted task, or the domain error that made it fail.
+type BatchResult struct {
+	Task Task
+	Err  error
+}
+
+// BatchError reports the operation that aborted an atomic batch.
+type BatchError struct {
+	Index int
+	Err   error
+}
+
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("operation %d: %v", e.Index, e.Err)
+}
+
+func (e *BatchError) Unwrap() error {
+	return e.Err
+}
+
+// Batch applies ops in order within a single repo transaction, so the whole
+// batch costs one storage commit.
+//
+// If atomic is true the first failing operation aborts the batch: nothing is
+// written and the error is a *BatchError. Otherwise every operation succeeds
+// or fails on its own and failures are reported in its BatchResult; only
+// unexpected (non-domain) errors abort the batch.
+func (s Service) Batch(ctx context.Context, ops []BatchOp, atomic bool) ([]BatchResult, error) {
+	if len(ops) == 0 {
+		return nil, NewValidationError(FieldIssue{Field: "operations", Issue: "must not be empty"})
+	}
+	if len(ops) > MaxBatchOps {
+		return nil, NewValidationError(FieldIssue{Field: "operations", Issue: fmt.Sprintf("must have at most %d entries", MaxBatchOps)})
+	}
+
+	results := make([]BatchResult, len(ops))
+	err := s.atomic(ctx, func(tx TaskStore) error {
+		for i, op := range ops {
+			task, err := s.applyIn(ctx, tx, op)
+
+			var de *Error
+			switch {
+			case err == nil:
+				results[i].Task = task
+			case atomic || !errors.As(err, &de):
+				return &BatchError{Index: i, Err: err}
+			default:
+				results[i].Err = err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+func (s Service) applyIn(ctx context.Context, tx TaskStore, op BatchOp) (Task, error) {
+	switch op.Kind {
+	case BatchCreate:
+		return s.createIn(ctx, tx, op.Create)
+	case BatchUpdate:
+		in := op.Update
+		in.IfVersion = op.IfVersion
+		return s.updateIn(ctx, tx, op.ID, in)
+	case BatchDelete:
+		return s.deleteIn(ctx, tx, op.ID, op.IfVersion)
+	default:
+		return Task{}, NewValidationError(FieldIssue{Field: "op", Issue: "must be create, update or delete"})
+	}
+}
@@ -0,0 +1,16 @@
+package todo_test
+
+import (
+	"testing"
+
+	"github.com/Saintrad/todo-server-client/internal/storage/storagetest"
+	"github.com/Saintrad/todo-server-client/internal/todo"
+)
+
+// The service tests' fake repo must honour the same contract as the real
+// backends, or the service tests prove nothing.
+func TestFakeRepoConformance(t *testing.T) {
+	storagetest.RunTaskRepoTests(t, func(t *testing.T) todo.TaskRepo {
+		return todo.NewFakeRepo()
+	})
+}
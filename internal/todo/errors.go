@@ -1,6 +1,137 @@
 package todo
 
-import "errors"
+import (
+	"errors"
+	"strings"
+)
 
-var ErrTaskNotFound = errors.New("task not found")
-var ErrEmptyTitle = errors.New("title is required")
\ No newline at end of file
+// ErrorCode classifies domain errors. Transport layers map codes to their own
+// status codes (see API.md §3.1).
+type ErrorCode string
+
+const (
+	CodeValidation   ErrorCode = "VALIDATION_ERROR"
+	CodeNotFound     ErrorCode = "NOT_FOUND"
+	CodeUnauthorized ErrorCode = "UNAUTHORIZED"
+	CodeForbidden    ErrorCode = "FORBIDDEN"
+	CodeConflict     ErrorCode = "CONFLICT"
+	CodeInternal     ErrorCode = "INTERNAL"
+)
+
+// FieldIssue describes what is wrong with a single input field.
+type FieldIssue struct {
+	Field string
+	Issue string
+}
+
+// Error is the domain error type. Callers inspect it with errors.As and
+// branch on Code; Err holds an optional underlying cause that must not be
+// exposed to clients.
+type Error struct {
+	Code    ErrorCode
+	Message string
+	Details []FieldIssue
+	Err     error
+}
+
+// Error is the message followed by the details it does not already say;
+// NewValidationError takes the message from the first issue.
+func (e *Error) Error() string {
+	var b strings.Builder
+	b.WriteString(e.Message)
+	sep := ": "
+	for _, d := range e.Details {
+		if issue := d.Field + " " + d.Issue; issue != e.Message {
+			b.WriteString(sep + issue)
+			sep = "; "
+		}
+	}
+	if e.Err != nil {
+		b.WriteString(": " + e.Err.Error())
+	}
+	return b.String()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is makes errors.Is match sentinels by code and message, or by field issue,
+// so a validation error carrying several issues still matches ErrEmptyTitle.
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	if !ok || t.Code != e.Code {
+		return false
+	}
+	if t.Message == e.Message {
+		return true
+	}
+	for _, want := range t.Details {
+		for _, got := range e.Details {
+			if want == got {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// NewValidationError builds a VALIDATION_ERROR from one or more field issues.
+// The message is taken from the first issue.
+func NewValidationError(issues ...FieldIssue) *Error {
+	msg := "invalid input"
+	if len(issues) > 0 {
+		msg = issues[0].Field + " " + issues[0].Issue
+	}
+	return &Error{Code: CodeValidation, Message: msg, Details: issues}
+}
+
+func NewNotFoundError(msg string) *Error {
+	return &Error{Code: CodeNotFound, Message: msg}
+}
+
+func NewConflictError(msg string) *Error {
+	return &Error{Code: CodeConflict, Message: msg}
+}
+
+// NewInternalError wraps an unexpected failure (I/O, encoding, ...).
+func NewInternalError(err error) *Error {
+	return &Error{Code: CodeInternal, Message: "internal error", Err: err}
+}
+
+// CodeOf returns the ErrorCode carried by err, or CodeInternal if err is not
+// a domain error.
+func CodeOf(err error) ErrorCode {
+	var de *Error
+	if errors.As(err, &de) {
+		return de.Code
+	}
+	return CodeInternal
+}
+
+var ErrTaskNotFound = NewNotFoundError("task not found")
+var ErrRepoClosed = errors.New("repository is closed")
+
+// ErrVersionConflict reports that a task changed since the caller read it.
+var ErrVersionConflict = NewConflictError("task was modified concurrently")
+
+// Subtask errors; see subtask.go.
+var ErrParentNotFound = NewValidationError(FieldIssue{Field: "parent_id", Issue: "must be an existing task"})
+var ErrParentCycle = NewValidationError(FieldIssue{Field: "parent_id", Issue: "must not be the task itself or one of its subtasks"})
+var ErrHasSubtasks = NewConflictError("task has subtasks")
+var ErrOpenSubtasks = NewConflictError("task has open subtasks")
+
+var ErrInvalidTag = NewValidationError(FieldIssue{Field: "tags", Issue: "must be 1 to 50 characters without spaces"})
+var ErrTagNotFound = NewNotFoundError("tag not found")
+
+var ErrInvalidRepeat = NewValidationError(FieldIssue{Field: "repeat", Issue: "must be daily, weekly[:mon,thu], monthly[:15] or after:N"})
+
+var ErrInvalidPriority = NewValidationError(FieldIssue{Field: "priority", Issue: "must be none, low, medium or high"})
+
+var ErrNotInTrash = NewNotFoundError("task not in trash")
+
+var ErrEmptyTitle = &Error{
+	Code:    CodeValidation,
+	Message: "title is required",
+	Details: []FieldIssue{{Field: "title", Issue: "must not be empty"}},
+}
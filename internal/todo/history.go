@@ -0,0 +1,245 @@
+package todo
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type EventKind string
+
+const (
+	EventCreated   EventKind = "created"
+	EventUpdated   EventKind = "updated"
+	EventCompleted EventKind = "completed"
+	EventDeleted   EventKind = "deleted"
+	EventRestored  EventKind = "restored"
+	EventPurged    EventKind = "purged"
+	// EventReminder is published, not recorded, when a reminder fires.
+	EventReminder EventKind = "reminder"
+)
+
+// Event is one change to a task. Events are immutable once recorded.
+type Event struct {
+	ID      int
+	TaskID  int
+	OwnerID int
+	Kind    EventKind
+	// Actor is the username of the v2 user who made the change, empty for
+	// v1 requests and server jobs.
+	Actor   string
+	At      time.Time
+	Changes []Change
+}
+
+// Change is the old and new value of one task field in its text form; nil
+// means unset.
+type Change struct {
+	Field string
+	Old   *string
+	New   *string
+}
+
+// WithHistory records an Event for every task write in h.
+func WithHistory(h HistoryRepo) ServiceOption {
+	return func(s *Service) { s.history = h }
+}
+
+// Publisher is told about every task write once it is committed, with the
+// task as it was written. Publish must not block.
+type Publisher interface {
+	Publish(e Event, t Task)
+}
+
+// WithPublisher sends every Event to p, in addition to the history. It may
+// be given more than once.
+func WithPublisher(p Publisher) ServiceOption {
+	return func(s *Service) { s.publishers = append(s.publishers, p) }
+}
+
+// As returns a copy of s that records actor as the author of its changes.
+func (s Service) As(actor string) Service {
+	s.actor = actor
+	return s
+}
+
+// History returns the events of a task of the owner, oldest first. It
+// works for tasks in the trash and purged tasks too.
+func (s Service) History(ctx context.Context, id int) ([]Event, error) {
+	if s.history == nil {
+		if _, err := s.GetByID(ctx, id); err != nil {
+			return nil, err
+		}
+		return []Event{}, nil
+	}
+
+	events, err := s.history.ListByTask(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(events) == 0 || events[0].OwnerID != s.owner {
+		return nil, ErrTaskNotFound
+	}
+	return events, nil
+}
+
+// atomic runs fn in a repo transaction and records the history of its
+// writes once the transaction has committed.
+func (s Service) atomic(ctx context.Context, fn func(TaskStore) error) error {
+	rec := &recorder{}
+	err := s.repo.Atomic(ctx, func(tx TaskStore) error {
+		*rec = recorder{TaskStore: tx}
+		return fn(rec)
+	})
+	if err != nil {
+		return err
+	}
+	s.record(ctx, rec)
+	return nil
+}
+
+// record stores and publishes the events of rec. The writes they describe
+// are already committed, so a failure is logged rather than returned.
+func (s Service) record(ctx context.Context, rec *recorder) {
+	if len(rec.events) == 0 {
+		return
+	}
+	now := time.Now()
+	for i := range rec.events {
+		rec.events[i].Actor = s.actor
+		rec.events[i].At = now
+	}
+
+	if s.history != nil {
+		if err := s.history.Append(ctx, rec.events...); err != nil {
+			slog.Error("record task history", "task", rec.events[0].TaskID, "err", err)
+		}
+	}
+	for _, p := range s.publishers {
+		for i, e := range rec.events {
+			p.Publish(e, rec.tasks[i])
+		}
+	}
+}
+
+// recorder is a TaskStore that describes every write as an Event. tasks
+// holds the task written by each event.
+type recorder struct {
+	TaskStore
+	events []Event
+	tasks  []Task
+}
+
+func (r *recorder) add(e Event, t Task) {
+	r.events = append(r.events, e)
+	r.tasks = append(r.tasks, t)
+}
+
+func (r *recorder) Create(ctx context.Context, t Task) (Task, error) {
+	created, err := r.TaskStore.Create(ctx, t)
+	if err != nil {
+		return Task{}, err
+	}
+	r.add(Event{
+		TaskID:  created.ID,
+		OwnerID: created.OwnerID,
+		Kind:    EventCreated,
+		Changes: diffTasks(Task{}, created),
+	}, created)
+	return created, nil
+}
+
+func (r *recorder) Update(ctx context.Context, t Task) (Task, error) {
+	old, err := r.TaskStore.GetByID(ctx, t.ID)
+	if err != nil {
+		return Task{}, err
+	}
+	updated, err := r.TaskStore.Update(ctx, t)
+	if err != nil {
+		return Task{}, err
+	}
+
+	kind := EventUpdated
+	switch {
+	case old.DeletedAt == nil && updated.DeletedAt != nil:
+		kind = EventDeleted
+	case old.DeletedAt != nil && updated.DeletedAt == nil:
+		kind = EventRestored
+	case !old.IsDone && updated.IsDone:
+		kind = EventCompleted
+	}
+	changes := diffTasks(old, updated)
+	if kind != EventUpdated || len(changes) > 0 {
+		r.add(Event{TaskID: t.ID, OwnerID: updated.OwnerID, Kind: kind, Changes: changes}, updated)
+	}
+	return updated, nil
+}
+
+func (r *recorder) Delete(ctx context.Context, id int) (Task, error) {
+	deleted, err := r.TaskStore.Delete(ctx, id)
+	if err != nil {
+		return Task{}, err
+	}
+	r.add(Event{TaskID: id, OwnerID: deleted.OwnerID, Kind: EventPurged}, deleted)
+	return deleted, nil
+}
+
+// diffTasks lists the user-visible fields that differ between a and b.
+func diffTasks(a, b Task) []Change {
+	var changes []Change
+	add := func(field string, x, y *string) {
+		if (x == nil) != (y == nil) || (x != nil && *x != *y) {
+			changes = append(changes, Change{Field: field, Old: x, New: y})
+		}
+	}
+
+	add("title", textOf(a.Title), textOf(b.Title))
+	add("category", a.Category, b.Category)
+	add("tags", textOf(strings.Join(a.Tags, ",")), textOf(strings.Join(b.Tags, ",")))
+	add("due_date", timeText(a.DueDate), timeText(b.DueDate))
+	add("is_done", textOf(strconv.FormatBool(a.IsDone)), textOf(strconv.FormatBool(b.IsDone)))
+	add("priority", priorityText(a.Priority), priorityText(b.Priority))
+	add("parent_id", intText(a.ParentID), intText(b.ParentID))
+	add("repeat", recurrenceText(a.Recurrence), recurrenceText(b.Recurrence))
+	add("reminders", remindersText(a.Reminders), remindersText(b.Reminders))
+	add("deleted_at", timeText(a.DeletedAt), timeText(b.DeletedAt))
+	return changes
+}
+
+// textOf returns s, or nil for an empty string.
+func textOf(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+func priorityText(p Priority) *string {
+	if p == PriorityNone {
+		return nil
+	}
+	return textOf(p.String())
+}
+
+func timeText(t *time.Time) *string {
+	if t == nil {
+		return nil
+	}
+	return textOf(t.UTC().Format(time.RFC3339))
+}
+
+func intText(n *int) *string {
+	if n == nil {
+		return nil
+	}
+	return textOf(strconv.Itoa(*n))
+}
+
+func recurrenceText(r *Recurrence) *string {
+	if r == nil {
+		return nil
+	}
+	return textOf(r.String())
+}
@@ -0,0 +1,294 @@
+package todo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// MaxImportRecords is the largest number of records accepted in one import.
+const MaxImportRecords = 10000
+
+// DuplicatePolicy says what Import does with a record whose title matches a
+// live task of the owner, ignoring case and surrounding spaces.
+type DuplicatePolicy string
+
+const (
+	DuplicateSkip   DuplicatePolicy = "skip"   // keep the existing task
+	DuplicateUpdate DuplicatePolicy = "update" // overwrite it with the record
+	DuplicateCreate DuplicatePolicy = "create" // import the record as another task
+)
+
+// ImportRecord is one task read from an import file. Row is its position in
+// the file, for the report; Err is set when the row could not be read, and
+// Import then reports it as failed.
+//
+// Ref is the task's ID in the file, if it has one, and ParentRef makes the
+// task a subtask of the record with that Ref. Task.ParentID is ignored.
+type ImportRecord struct {
+	Row       int
+	Ref       int
+	ParentRef int
+	Task      CreateTaskInput
+	IsDone    bool
+	// CreatedAt keeps the creation time from the file; nil means now.
+	CreatedAt *time.Time
+	Err       error
+}
+
+type ImportOptions struct {
+	Duplicates DuplicatePolicy
+	// DryRun reports what the import would do without writing anything.
+	DryRun bool
+}
+
+type ImportOutcome string
+
+const (
+	ImportCreated ImportOutcome = "created"
+	ImportUpdated ImportOutcome = "updated"
+	ImportSkipped ImportOutcome = "skipped"
+	ImportFailed  ImportOutcome = "failed"
+)
+
+// ImportResult is the outcome of one record: the task it created or
+// updated, the existing task it was skipped for, or the error that made it
+// fail.
+type ImportResult struct {
+	Row     int
+	Outcome ImportOutcome
+	Task    Task
+	Err     error
+}
+
+// Export returns every task of the owner matching q, in q's order. The
+// pagination of q is ignored.
+func (s Service) Export(ctx context.Context, q ListQuery) ([]Task, error) {
+	q, err := q.Normalize()
+	if err != nil {
+		return nil, err
+	}
+	q.OwnerID = s.owner
+	q.AllOwners = false
+	q.Limit, q.Offset = 0, 0
+
+	page, err := s.repo.List(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	return page.Items, nil
+}
+
+// errDryRun rolls back the transaction of a dry run.
+var errDryRun = errors.New("dry run")
+
+// Import creates tasks from recs within a single repo transaction, returning
+// one result per record. Like a non-atomic Batch, every record succeeds or
+// fails on its own and only unexpected (non-domain) errors abort the import.
+// A subtask is imported after its parent record and fails if the parent did.
+//
+// In a dry run the transaction is rolled back, so the IDs of created tasks
+// are only provisional.
+func (s Service) Import(ctx context.Context, recs []ImportRecord, opts ImportOptions) ([]ImportResult, error) {
+	if len(recs) == 0 {
+		return nil, NewValidationError(FieldIssue{Field: "records", Issue: "must not be empty"})
+	}
+	if len(recs) > MaxImportRecords {
+		return nil, NewValidationError(FieldIssue{Field: "records", Issue: fmt.Sprintf("must have at most %d entries", MaxImportRecords)})
+	}
+	switch opts.Duplicates {
+	case "":
+		opts.Duplicates = DuplicateSkip
+	case DuplicateSkip, DuplicateUpdate, DuplicateCreate:
+	default:
+		return nil, NewValidationError(FieldIssue{Field: "duplicates", Issue: "must be skip, update or create"})
+	}
+
+	var results []ImportResult
+	err := s.atomic(ctx, func(tx TaskStore) error {
+		var err error
+		if results, err = s.importIn(ctx, tx, recs, opts.Duplicates); err != nil {
+			return err
+		}
+		if opts.DryRun {
+			return errDryRun
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errDryRun) {
+		return nil, err
+	}
+	return results, nil
+}
+
+func (s Service) importIn(ctx context.Context, tx TaskStore, recs []ImportRecord, dup DuplicatePolicy) ([]ImportResult, error) {
+	page, err := tx.List(ctx, ListQuery{OwnerID: s.owner, Sort: SortCreatedAt, Order: OrderAsc})
+	if err != nil {
+		return nil, err
+	}
+	// The oldest task wins when titles already repeat
+	existing := map[string]Task{}
+	for _, t := range page.Items {
+		if _, ok := existing[titleKey(t.Title)]; !ok {
+			existing[titleKey(t.Title)] = t
+		}
+	}
+
+	refs := map[int]int{}
+	for i, rec := range recs {
+		if _, ok := refs[rec.Ref]; rec.Ref != 0 && !ok {
+			refs[rec.Ref] = i
+		}
+	}
+
+	const (
+		pending = iota
+		running
+		finished
+	)
+	state := make([]int, len(recs))
+	results := make([]ImportResult, len(recs))
+
+	var importRec func(i int) error
+	importRec = func(i int) error {
+		if state[i] == finished {
+			return nil
+		}
+		state[i] = running
+		defer func() { state[i] = finished }()
+
+		rec := recs[i]
+		in := rec.Task
+		in.ParentID = nil
+
+		switch {
+		case rec.Err != nil:
+		case rec.Ref != 0 && refs[rec.Ref] != i:
+			rec.Err = NewValidationError(FieldIssue{Field: "id", Issue: "must be unique in the file"})
+		case rec.ParentRef != 0:
+			j, ok := refs[rec.ParentRef]
+			if !ok {
+				rec.Err = NewValidationError(FieldIssue{Field: "parent_id", Issue: "must be the id of a task in the file"})
+				break
+			}
+			if state[j] == running {
+				rec.Err = ErrParentCycle
+				break
+			}
+			if err := importRec(j); err != nil {
+				return err
+			}
+			if results[j].Outcome == ImportFailed {
+				rec.Err = NewValidationError(FieldIssue{Field: "parent_id", Issue: fmt.Sprintf("parent in row %d was not imported", recs[j].Row)})
+				break
+			}
+			in.ParentID = &results[j].Task.ID
+		}
+
+		results[i].Row = rec.Row
+		if rec.Err == nil {
+			results[i].Task, results[i].Outcome, rec.Err = s.importOne(ctx, tx, rec, in, existing, dup)
+		}
+		if rec.Err != nil {
+			var de *Error
+			if !errors.As(rec.Err, &de) {
+				return rec.Err
+			}
+			results[i] = ImportResult{Row: rec.Row, Outcome: ImportFailed, Err: rec.Err}
+		}
+		return nil
+	}
+
+	for i := range recs {
+		if err := importRec(i); err != nil {
+			return nil, err
+		}
+	}
+	return results, nil
+}
+
+// importOne imports rec as in, the input with the parent resolved. existing
+// maps title keys to the tasks duplicates are matched against; it is kept
+// up to date with the tasks imported so far.
+func (s Service) importOne(ctx context.Context, tx TaskStore, rec ImportRecord, in CreateTaskInput, existing map[string]Task, dup DuplicatePolicy) (Task, ImportOutcome, error) {
+	key := titleKey(in.Title)
+
+	if old, ok := existing[key]; ok && dup != DuplicateCreate {
+		if dup == DuplicateSkip {
+			return old, ImportSkipped, nil
+		}
+
+		priority := PriorityNone.String()
+		if in.Priority != nil {
+			priority = *in.Priority
+		}
+		// Only JSON files carry reminders; the other formats keep them
+		var reminders Optional[[]string]
+		if len(in.Reminders) > 0 {
+			reminders = Some(in.Reminders)
+		}
+		// A record without a parent keeps the task where it is
+		var parent Optional[int]
+		if in.ParentID != nil {
+			parent = Some(*in.ParentID)
+		}
+		task, err := s.updateIn(ctx, tx, old.ID, UpdateTaskInput{
+			Title:     &in.Title,
+			Category:  optional(in.Category),
+			Tags:      Some(in.Tags),
+			DueDate:   optional(in.DueDate),
+			ParentID:  parent,
+			Repeat:    optional(in.Repeat),
+			Priority:  &priority,
+			Reminders: reminders,
+		})
+		if err != nil {
+			return Task{}, "", err
+		}
+		// Stored as is, as for new tasks below
+		if task.IsDone != rec.IsDone {
+			task.IsDone = rec.IsDone
+			if task, err = tx.Update(ctx, task); err != nil {
+				return Task{}, "", err
+			}
+		}
+		existing[key] = task
+		return task, ImportUpdated, nil
+	}
+
+	task, err := s.createIn(ctx, tx, in)
+	if err != nil {
+		return Task{}, "", err
+	}
+	// Stored as is: completing through updateIn would start the next
+	// occurrence of a recurring task that is already done in the file.
+	if rec.IsDone || rec.CreatedAt != nil {
+		task.IsDone = rec.IsDone
+		if rec.CreatedAt != nil {
+			task.CreatedAt = *rec.CreatedAt
+		}
+		if task, err = tx.Update(ctx, task); err != nil {
+			return Task{}, "", err
+		}
+	}
+	if _, ok := existing[key]; !ok {
+		existing[key] = task
+	}
+	return task, ImportCreated, nil
+}
+
+// titleKey is the form of a title duplicates are matched by.
+func titleKey(title string) string {
+	return strings.ToLower(strings.TrimSpace(title))
+}
+
+// optional returns an Optional that sets the field to *v, or clears it if v
+// is nil.
+func optional[T any](v *T) Optional[T] {
+	if v == nil {
+		return Clear[T]()
+	}
+	return Some(*v)
+}
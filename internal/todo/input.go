@@ -2,16 +2,55 @@ package todo
 
 import "time"
 
-type CreateTaskInput struct{
-	Title string
+type CreateTaskInput struct {
+	Title    string
 	Category *string
-	DueDate *time.Time
+	Tags     []string
+	DueDate  *time.Time
+	ParentID *int
+	// Repeat is a recurrence rule in the form read by ParseRecurrence.
+	Repeat *string
+	// Priority is a name read by ParsePriority; nil means PriorityNone.
+	Priority *string
+	// Reminders are in the form read by ParseReminder.
+	Reminders []string
 }
 
-
 type UpdateTaskInput struct {
-	Title *string
-	Category *string
-	DueDate *time.Time
-	IsDone *bool
-}
\ No newline at end of file
+	Title    *string
+	Category Optional[string]
+	// Tags replaces all tags; AddTags and RemoveTags are applied after it.
+	Tags       Optional[[]string]
+	AddTags    []string
+	RemoveTags []string
+	DueDate    Optional[time.Time]
+	IsDone     *bool
+	// ParentID moves the task under another parent; clearing it makes the
+	// task top-level.
+	ParentID Optional[int]
+	Repeat   Optional[string]
+	Priority *string
+	// Reminders replaces all reminders.
+	Reminders Optional[[]string]
+
+	// IfVersion, when set, makes the update fail with ErrVersionConflict
+	// unless the task is still at that version.
+	IfVersion *int
+}
+
+// Optional is a tri-state field for partial updates of nullable values:
+// not Set leaves the current value unchanged, Set with a nil Value clears it.
+type Optional[T any] struct {
+	Set   bool
+	Value *T
+}
+
+// Some returns an Optional that sets the field to v.
+func Some[T any](v T) Optional[T] {
+	return Optional[T]{Set: true, Value: &v}
+}
+
+// Clear returns an Optional that clears the field.
+func Clear[T any]() Optional[T] {
+	return Optional[T]{Set: true}
+}
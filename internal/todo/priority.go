@@ -0,0 +1,46 @@
+package todo
+
+import "strings"
+
+// Priority ranks how important a task is. The zero value is PriorityNone;
+// higher values are more important.
+type Priority int
+
+const (
+	PriorityNone Priority = iota
+	PriorityLow
+	PriorityMedium
+	PriorityHigh
+)
+
+var priorityNames = []string{"none", "low", "medium", "high"}
+
+// ParsePriority parses a priority name, ignoring case and spaces.
+func ParsePriority(s string) (Priority, error) {
+	for i, name := range priorityNames {
+		if strings.EqualFold(strings.TrimSpace(s), name) {
+			return Priority(i), nil
+		}
+	}
+	return 0, ErrInvalidPriority
+}
+
+func (p Priority) String() string {
+	if p < 0 || int(p) >= len(priorityNames) {
+		return "none"
+	}
+	return priorityNames[p]
+}
+
+func (p Priority) MarshalText() ([]byte, error) {
+	return []byte(p.String()), nil
+}
+
+func (p *Priority) UnmarshalText(b []byte) error {
+	parsed, err := ParsePriority(string(b))
+	if err != nil {
+		return err
+	}
+	*p = parsed
+	return nil
+}
@@ -0,0 +1,347 @@
+package todo
+
+import (
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+type SortField string
+
+const (
+	SortCreatedAt SortField = "created_at"
+	SortDueDate   SortField = "due_date"
+	SortUpdatedAt SortField = "updated_at"
+	// SortSmart, the default, puts what to do next first: open tasks, then
+	// overdue ones, then by priority, then by due date.
+	SortSmart SortField = "smart"
+	// SortDeletedAt is the default for the trash.
+	SortDeletedAt SortField = "deleted_at"
+)
+
+type SortOrder string
+
+const (
+	OrderAsc  SortOrder = "asc"
+	OrderDesc SortOrder = "desc"
+)
+
+const (
+	DefaultListLimit = 50
+	MaxListLimit     = 200
+)
+
+// DueFilter is a preset over due days, judged in ListQuery.Location.
+type DueFilter string
+
+const (
+	// DueOverdue selects open tasks due before today.
+	DueOverdue DueFilter = "overdue"
+	DueToday   DueFilter = "today"
+	// DueUpcoming selects tasks due in the WeekDays-1 days after today.
+	DueUpcoming DueFilter = "upcoming"
+	// DueWeek selects tasks due in the WeekDays days from today on: today
+	// and upcoming together.
+	DueWeek DueFilter = "week"
+	DueNone DueFilter = "none"
+)
+
+// WeekDays is the length of the DueWeek window, today included.
+const WeekDays = 7
+
+// ListQuery filters, orders and pages TaskRepo.List (API.md A1.2).
+// Zero values mean "no filter"; a zero Limit means "no limit" at the repo
+// level, the service applies DefaultListLimit before calling the repo.
+// OwnerID always applies: 0 selects the v1 tasks. ParentID, when Set,
+// selects the subtasks of one task, or the top-level tasks if Value is nil.
+// The tag filters select tasks with any, all or none of their tags.
+// Priorities selects tasks with any of the given priorities. Now is the time
+// SortSmart judges overdue tasks against, in Location; Normalize sets it.
+//
+// Trashed lists the trash instead of the live tasks, optionally only tasks
+// deleted before DeletedBefore. AllOwners ignores OwnerID, for maintenance
+// across users; the service never sets it on behalf of a caller.
+// HasReminders selects tasks with at least one reminder, for the scheduler.
+//
+// Due is a preset that Normalize turns into DueFrom and DueUntil, or
+// NoDueDate, using Now and Location (UTC when nil). DueFrom and DueUntil
+// bound the due day (see DueDay) as calendar dates at midnight UTC; DueFrom
+// is inclusive, DueUntil exclusive and either may be zero.
+type ListQuery struct {
+	OwnerID    int
+	IsDone     *bool
+	Search     string
+	ParentID   Optional[int]
+	TagsAny    []string
+	TagsAll    []string
+	TagsNone   []string
+	Priorities []Priority
+	Sort       SortField
+	Order      SortOrder
+	Limit      int
+	Offset     int
+	Now        time.Time
+
+	Trashed       bool
+	DeletedBefore time.Time
+	AllOwners     bool
+	HasReminders  bool
+
+	Due       DueFilter
+	Location  *time.Location
+	DueFrom   time.Time
+	DueUntil  time.Time
+	NoDueDate bool
+}
+
+// TaskPage is one page of a list result. Total counts all matching tasks,
+// not just the ones in Items.
+type TaskPage struct {
+	Items  []Task
+	Total  int
+	Limit  int
+	Offset int
+}
+
+// Normalize validates q and fills in defaults.
+func (q ListQuery) Normalize() (ListQuery, error) {
+	var issues []FieldIssue
+
+	switch q.Sort {
+	case "":
+		q.Sort = SortSmart
+		if q.Trashed {
+			q.Sort = SortDeletedAt
+		}
+	case SortSmart, SortCreatedAt, SortDueDate, SortUpdatedAt, SortDeletedAt:
+	default:
+		issues = append(issues, FieldIssue{Field: "sort", Issue: "must be one of smart, created_at, due_date, updated_at, deleted_at"})
+	}
+
+	switch q.Order {
+	case "":
+		// Most pressing first, otherwise newest first
+		q.Order = OrderDesc
+		if q.Sort == SortSmart {
+			q.Order = OrderAsc
+		}
+	case OrderAsc, OrderDesc:
+	default:
+		issues = append(issues, FieldIssue{Field: "order", Issue: "must be asc or desc"})
+	}
+
+	switch {
+	case q.Limit == 0:
+		q.Limit = DefaultListLimit
+	case q.Limit < 0 || q.Limit > MaxListLimit:
+		issues = append(issues, FieldIssue{Field: "limit", Issue: "must be between 1 and 200"})
+	}
+
+	if q.Offset < 0 {
+		issues = append(issues, FieldIssue{Field: "offset", Issue: "must not be negative"})
+	}
+
+	q.TagsAny = normalizeTagFilter(q.TagsAny)
+	q.TagsAll = normalizeTagFilter(q.TagsAll)
+	q.TagsNone = normalizeTagFilter(q.TagsNone)
+	if q.Now.IsZero() {
+		q.Now = time.Now()
+	}
+
+	today := CalendarDay(q.Now.In(q.location()))
+	switch q.Due {
+	case "":
+	case DueOverdue:
+		q.DueUntil = today
+		if q.IsDone == nil {
+			open := false
+			q.IsDone = &open
+		}
+	case DueToday:
+		q.DueFrom, q.DueUntil = today, today.AddDate(0, 0, 1)
+	case DueUpcoming:
+		q.DueFrom, q.DueUntil = today.AddDate(0, 0, 1), today.AddDate(0, 0, WeekDays)
+	case DueWeek:
+		q.DueFrom, q.DueUntil = today, today.AddDate(0, 0, WeekDays)
+	case DueNone:
+		q.NoDueDate = true
+	default:
+		issues = append(issues, FieldIssue{Field: "due", Issue: "must be one of overdue, today, upcoming, week, none"})
+	}
+
+	if len(issues) > 0 {
+		return ListQuery{}, NewValidationError(issues...)
+	}
+	return q, nil
+}
+
+// Matches reports whether t passes the query filters.
+func (q ListQuery) Matches(t Task) bool {
+	if !q.AllOwners && t.OwnerID != q.OwnerID {
+		return false
+	}
+	if (t.DeletedAt != nil) != q.Trashed {
+		return false
+	}
+	if !q.DeletedBefore.IsZero() && (t.DeletedAt == nil || !t.DeletedAt.Before(q.DeletedBefore)) {
+		return false
+	}
+	if q.IsDone != nil && t.IsDone != *q.IsDone {
+		return false
+	}
+	if q.HasReminders && len(t.Reminders) == 0 {
+		return false
+	}
+	if q.NoDueDate && t.DueDate != nil {
+		return false
+	}
+	if !q.DueFrom.IsZero() || !q.DueUntil.IsZero() {
+		if t.DueDate == nil {
+			return false
+		}
+		day := DueDay(*t.DueDate, q.Location)
+		if !q.DueFrom.IsZero() && day.Before(q.DueFrom) {
+			return false
+		}
+		if !q.DueUntil.IsZero() && !day.Before(q.DueUntil) {
+			return false
+		}
+	}
+	if q.ParentID.Set {
+		switch {
+		case q.ParentID.Value == nil:
+			if t.ParentID != nil {
+				return false
+			}
+		case t.ParentID == nil || *t.ParentID != *q.ParentID.Value:
+			return false
+		}
+	}
+	if len(q.Priorities) > 0 && !slices.Contains(q.Priorities, t.Priority) {
+		return false
+	}
+	if len(q.TagsAny) > 0 && !slices.ContainsFunc(q.TagsAny, func(tag string) bool { return slices.Contains(t.Tags, tag) }) {
+		return false
+	}
+	for _, tag := range q.TagsAll {
+		if !slices.Contains(t.Tags, tag) {
+			return false
+		}
+	}
+	for _, tag := range q.TagsNone {
+		if slices.Contains(t.Tags, tag) {
+			return false
+		}
+	}
+	if q.Search != "" {
+		needle := strings.ToLower(q.Search)
+		inTitle := strings.Contains(strings.ToLower(t.Title), needle)
+		inCategory := t.Category != nil && strings.Contains(strings.ToLower(*t.Category), needle)
+		if !inTitle && !inCategory {
+			return false
+		}
+	}
+	return true
+}
+
+func (q ListQuery) location() *time.Location {
+	if q.Location == nil {
+		return time.UTC
+	}
+	return q.Location
+}
+
+// Less orders two tasks by the query sort field. Tasks without a due date
+// (or deletion time) sort last regardless of order; ties are broken by ID.
+func (q ListQuery) Less(a, b Task) bool {
+	var cmp int
+	switch q.Sort {
+	case SortSmart:
+		cmp = boolCompare(a.IsDone, b.IsDone)
+		if cmp == 0 {
+			now := q.Now.In(q.location())
+			cmp = boolCompare(b.IsOverdue(now), a.IsOverdue(now))
+		}
+		if cmp == 0 {
+			cmp = int(b.Priority - a.Priority)
+		}
+		if cmp == 0 {
+			switch {
+			case a.DueDate == nil && b.DueDate == nil:
+			case a.DueDate == nil:
+				return false
+			case b.DueDate == nil:
+				return true
+			default:
+				cmp = a.DueDate.Compare(*b.DueDate)
+			}
+		}
+		if cmp == 0 {
+			cmp = a.CreatedAt.Compare(b.CreatedAt)
+		}
+	case SortDueDate, SortDeletedAt:
+		x, y := a.DueDate, b.DueDate
+		if q.Sort == SortDeletedAt {
+			x, y = a.DeletedAt, b.DeletedAt
+		}
+		switch {
+		case x == nil && y == nil:
+		case x == nil:
+			return false
+		case y == nil:
+			return true
+		default:
+			cmp = x.Compare(*y)
+		}
+	case SortUpdatedAt:
+		cmp = a.UpdatedAt.Compare(b.UpdatedAt)
+	default:
+		cmp = a.CreatedAt.Compare(b.CreatedAt)
+	}
+	if cmp == 0 {
+		cmp = a.ID - b.ID
+	}
+	if q.Order == OrderDesc {
+		return cmp > 0
+	}
+	return cmp < 0
+}
+
+// boolCompare orders false before true.
+func boolCompare(a, b bool) int {
+	switch {
+	case a == b:
+		return 0
+	case a:
+		return 1
+	default:
+		return -1
+	}
+}
+
+// Apply filters, sorts and pages tasks in memory. It is shared by the
+// in-process repos; tasks is not modified.
+func (q ListQuery) Apply(tasks []Task) TaskPage {
+	matched := make([]Task, 0)
+	for _, t := range tasks {
+		if q.Matches(t) {
+			matched = append(matched, t)
+		}
+	}
+
+	sort.SliceStable(matched, func(i, j int) bool {
+		return q.Less(matched[i], matched[j])
+	})
+
+	page := TaskPage{Total: len(matched), Limit: q.Limit, Offset: q.Offset}
+
+	start := min(max(q.Offset, 0), len(matched))
+	end := len(matched)
+	if q.Limit > 0 {
+		end = min(start+q.Limit, len(matched))
+	}
+	page.Items = matched[start:end]
+
+	return page
+}
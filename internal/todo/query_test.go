@@ -0,0 +1,171 @@
+package todo
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestListQueryNormalize(t *testing.T) {
+	q, err := ListQuery{}.Normalize()
+	if err != nil {
+		t.Fatalf("expected no errors, got %v", err)
+	}
+
+	// Check defaults
+	if q.Sort != SortSmart || q.Order != OrderAsc || q.Limit != DefaultListLimit || q.Offset != 0 || q.Now.IsZero() {
+		t.Fatalf("unexpected defaults %+v", q)
+	}
+
+	// Check other sorts keep newest first
+	q, _ = ListQuery{Sort: SortCreatedAt}.Normalize()
+	if q.Order != OrderDesc {
+		t.Fatalf("expected order desc, got %s", q.Order)
+	}
+
+	// Check invalid values are reported per field
+	_, err = ListQuery{Sort: "title", Order: "up", Limit: MaxListLimit + 1, Offset: -1}.Normalize()
+
+	var de *Error
+	if !errors.As(err, &de) {
+		t.Fatalf("expected *Error, got %v", err)
+	}
+	if len(de.Details) != 4 {
+		t.Fatalf("expected 4 field issues, got %v", de.Details)
+	}
+}
+
+func TestListQueryApply(t *testing.T) {
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	due := base.Add(48 * time.Hour)
+
+	tasks := []Task{
+		{ID: 1, Title: "Buy milk", Category: strPtr("errands"), CreatedAt: base},
+		{ID: 2, Title: "Write report", IsDone: true, DueDate: &due, CreatedAt: base.Add(time.Hour)},
+		{ID: 3, Title: "Call plumber", Category: strPtr("home"), CreatedAt: base.Add(2 * time.Hour)},
+	}
+
+	// Check default order is newest first
+	page := ListQuery{Sort: SortCreatedAt, Order: OrderDesc}.Apply(tasks)
+	if page.Total != 3 || page.Items[0].ID != 3 || page.Items[2].ID != 1 {
+		t.Fatalf("unexpected page %+v", page)
+	}
+
+	// Check is_done filter
+	done := true
+	page = ListQuery{IsDone: &done}.Apply(tasks)
+	if page.Total != 1 || page.Items[0].ID != 2 {
+		t.Fatalf("expected only task 2, got %+v", page.Items)
+	}
+
+	// Check search matches category case-insensitively
+	page = ListQuery{Search: "HOME"}.Apply(tasks)
+	if page.Total != 1 || page.Items[0].ID != 3 {
+		t.Fatalf("expected only task 3, got %+v", page.Items)
+	}
+
+	// Check tasks without due date sort last in both orders
+	for _, o := range []SortOrder{OrderAsc, OrderDesc} {
+		page = ListQuery{Sort: SortDueDate, Order: o}.Apply(tasks)
+		if page.Items[0].ID != 2 {
+			t.Fatalf("expected task with due date first for %s, got %d", o, page.Items[0].ID)
+		}
+	}
+
+	// Check pagination keeps total
+	page = ListQuery{Sort: SortCreatedAt, Order: OrderAsc, Limit: 1, Offset: 1}.Apply(tasks)
+	if page.Total != 3 || len(page.Items) != 1 || page.Items[0].ID != 2 {
+		t.Fatalf("unexpected page %+v", page)
+	}
+
+	// Check offset past the end
+	page = ListQuery{Limit: 10, Offset: 10}.Apply(tasks)
+	if page.Total != 3 || len(page.Items) != 0 {
+		t.Fatalf("unexpected page %+v", page)
+	}
+}
+
+func TestListQueryDuePresets(t *testing.T) {
+	// 21:00 on March 9th at UTC-5, already the 10th in UTC
+	est := time.FixedZone("EST", -5*60*60)
+	now := time.Date(2026, 3, 10, 2, 0, 0, 0, time.UTC)
+	day := func(d int) time.Time { return time.Date(2026, 3, d, 0, 0, 0, 0, time.UTC) }
+
+	cases := []struct {
+		due         DueFilter
+		from, until time.Time
+	}{
+		{DueOverdue, time.Time{}, day(9)},
+		{DueToday, day(9), day(10)},
+		{DueUpcoming, day(10), day(16)},
+		{DueWeek, day(9), day(16)},
+	}
+	for _, c := range cases {
+		q, err := ListQuery{Due: c.due, Now: now, Location: est}.Normalize()
+		if err != nil {
+			t.Fatalf("%s: expected no errors, got %v", c.due, err)
+		}
+		if !q.DueFrom.Equal(c.from) || !q.DueUntil.Equal(c.until) {
+			t.Fatalf("%s: expected [%v, %v), got [%v, %v)", c.due, c.from, c.until, q.DueFrom, q.DueUntil)
+		}
+	}
+
+	// Check overdue is about open tasks and the days follow UTC by default
+	q, _ := ListQuery{Due: DueOverdue, Now: now}.Normalize()
+	if q.IsDone == nil || *q.IsDone || !q.DueUntil.Equal(day(10)) {
+		t.Fatalf("unexpected overdue query %+v", q)
+	}
+	if q, _ := (ListQuery{Due: DueNone}).Normalize(); !q.NoDueDate {
+		t.Fatalf("expected none to select tasks without a due date")
+	}
+	if _, err := (ListQuery{Due: "soon"}).Normalize(); err == nil {
+		t.Fatalf("expected an error for an unknown preset")
+	}
+}
+
+func TestTaskDueIn(t *testing.T) {
+	est := time.FixedZone("EST", -5*60*60)
+	now := time.Date(2026, 3, 10, 2, 0, 0, 0, time.UTC).In(est)
+	cases := map[time.Time]int{
+		time.Date(2026, 3, 9, 0, 0, 0, 0, time.UTC):  0,  // a date keeps its date
+		time.Date(2026, 3, 10, 4, 0, 0, 0, time.UTC): 0,  // 23:00 on the 9th
+		time.Date(2026, 3, 9, 4, 0, 0, 0, time.UTC):  -1, // 23:00 on the 8th
+		time.Date(2026, 3, 12, 0, 0, 0, 0, time.UTC): 3,
+	}
+	for due, want := range cases {
+		if got, ok := (Task{DueDate: &due}).DueIn(now); !ok || got != want {
+			t.Fatalf("%v: expected %d, got %d", due, want, got)
+		}
+	}
+	if _, ok := (Task{}).DueIn(now); ok {
+		t.Fatalf("expected no days without a due date")
+	}
+}
+
+func TestTaskIsOverdue(t *testing.T) {
+	est := time.FixedZone("EST", -5*60*60)
+	now := time.Date(2026, 3, 10, 2, 0, 0, 0, time.UTC)
+	due := time.Date(2026, 3, 9, 0, 0, 0, 0, time.UTC)
+	task := Task{DueDate: &due}
+
+	// Check a date due on the 9th is overdue at 02:00 UTC on the 10th but
+	// not at 21:00 on the 9th at UTC-5
+	if !task.IsOverdue(now) {
+		t.Fatalf("expected overdue in UTC")
+	}
+	if task.IsOverdue(now.In(est)) {
+		t.Fatalf("expected not overdue at UTC-5")
+	}
+
+	// Check the smart sort agrees with the zone of the query
+	later := due.AddDate(0, 0, 3)
+	other := Task{DueDate: &later, Priority: PriorityHigh}
+	q := ListQuery{Sort: SortSmart, Order: OrderAsc, Now: now}
+	if !q.Less(task, other) {
+		t.Fatalf("expected the overdue task first in UTC")
+	}
+	q.Location = est
+	if q.Less(task, other) {
+		t.Fatalf("expected the high priority task first at UTC-5")
+	}
+}
@@ -0,0 +1,177 @@
+package todo
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MaxRepeatAfterDays bounds the N of an "after:N" rule.
+const MaxRepeatAfterDays = 3650
+
+type RepeatKind string
+
+const (
+	RepeatDaily   RepeatKind = "daily"
+	RepeatWeekly  RepeatKind = "weekly"
+	RepeatMonthly RepeatKind = "monthly"
+	// RepeatAfter schedules the next occurrence N days after completion
+	// rather than on a fixed calendar.
+	RepeatAfter RepeatKind = "after"
+)
+
+// Recurrence is a repeat rule. Its text form, used on the wire and in
+// storage, is one of:
+//
+//	daily
+//	weekly            same weekday as the due date
+//	weekly:mon,thu
+//	monthly           same day of month as the due date
+//	monthly:15        clamped to the last day of shorter months
+//	after:N           N days after the task is completed
+type Recurrence struct {
+	Kind     RepeatKind
+	Weekdays []time.Weekday // weekly, sorted; empty means the due date's weekday
+	Day      int            // monthly; 0 means the due date's day
+	Days     int            // after
+}
+
+var weekdayNames = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// ParseRecurrence parses the text form of a rule, ignoring case and spaces.
+func ParseRecurrence(s string) (Recurrence, error) {
+	kind, arg, hasArg := strings.Cut(strings.ToLower(strings.ReplaceAll(s, " ", "")), ":")
+	r := Recurrence{Kind: RepeatKind(kind)}
+
+	switch {
+	case r.Kind == RepeatDaily && !hasArg:
+	case r.Kind == RepeatWeekly && !hasArg:
+	case r.Kind == RepeatWeekly:
+		for _, name := range strings.Split(arg, ",") {
+			// "mon", "mond" and "monday" all name Monday
+			if len(name) < 3 {
+				return Recurrence{}, ErrInvalidRepeat
+			}
+			d, ok := weekdayNames[name[:3]]
+			if !ok || !strings.HasPrefix(strings.ToLower(d.String()), name) {
+				return Recurrence{}, ErrInvalidRepeat
+			}
+			if !slices.Contains(r.Weekdays, d) {
+				r.Weekdays = append(r.Weekdays, d)
+			}
+		}
+		slices.Sort(r.Weekdays)
+	case r.Kind == RepeatMonthly && !hasArg:
+	case r.Kind == RepeatMonthly:
+		day, err := strconv.Atoi(arg)
+		if err != nil || day < 1 || day > 31 {
+			return Recurrence{}, ErrInvalidRepeat
+		}
+		r.Day = day
+	case r.Kind == RepeatAfter && hasArg:
+		days, err := strconv.Atoi(arg)
+		if err != nil || days < 1 || days > MaxRepeatAfterDays {
+			return Recurrence{}, ErrInvalidRepeat
+		}
+		r.Days = days
+	default:
+		return Recurrence{}, ErrInvalidRepeat
+	}
+	return r, nil
+}
+
+func (r Recurrence) String() string {
+	switch {
+	case r.Kind == RepeatWeekly && len(r.Weekdays) > 0:
+		names := make([]string, len(r.Weekdays))
+		for i, d := range r.Weekdays {
+			names[i] = strings.ToLower(d.String()[:3])
+		}
+		return "weekly:" + strings.Join(names, ",")
+	case r.Kind == RepeatMonthly && r.Day > 0:
+		return "monthly:" + strconv.Itoa(r.Day)
+	case r.Kind == RepeatAfter:
+		return "after:" + strconv.Itoa(r.Days)
+	default:
+		return string(r.Kind)
+	}
+}
+
+func (r Recurrence) MarshalText() ([]byte, error) {
+	return []byte(r.String()), nil
+}
+
+func (r *Recurrence) UnmarshalText(b []byte) error {
+	parsed, err := ParseRecurrence(string(b))
+	if err != nil {
+		return err
+	}
+	*r = parsed
+	return nil
+}
+
+// Next returns the due date of the occurrence after one due at due (nil if
+// it had none) and completed at now. Calendar rules move forward from the
+// due date to the first slot after today, so a late completion does not
+// leave the next occurrence overdue. The time of day is kept.
+func (r Recurrence) Next(due *time.Time, now time.Time) time.Time {
+	// Without a due date, count from today at midnight UTC, like the
+	// date-only due dates the clients send.
+	anchor := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	if due != nil {
+		anchor = *due
+	}
+	now = now.In(anchor.Location())
+	today := time.Date(now.Year(), now.Month(), now.Day(), anchor.Hour(), anchor.Minute(), anchor.Second(), anchor.Nanosecond(), anchor.Location())
+
+	if r.Kind == RepeatAfter {
+		return today.AddDate(0, 0, r.Days)
+	}
+
+	day := r.Day
+	if day == 0 {
+		day = anchor.Day()
+	}
+	next := anchor
+	for {
+		next = r.step(next, day)
+		if next.After(today) {
+			return next
+		}
+	}
+}
+
+// step returns the first calendar slot after t.
+func (r Recurrence) step(t time.Time, monthDay int) time.Time {
+	switch r.Kind {
+	case RepeatWeekly:
+		if len(r.Weekdays) == 0 {
+			return t.AddDate(0, 0, 7)
+		}
+		for i := 1; ; i++ {
+			if d := t.AddDate(0, 0, i); slices.Contains(r.Weekdays, d.Weekday()) {
+				return d
+			}
+		}
+	case RepeatMonthly:
+		y, m := t.Year(), t.Month()
+		if t.Day() >= clampDay(y, m, monthDay) {
+			m++
+		}
+		// time.Date normalizes month 13 into the next year
+		first := time.Date(y, m, 1, 0, 0, 0, 0, t.Location())
+		y, m = first.Year(), first.Month()
+		return time.Date(y, m, clampDay(y, m, monthDay), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+	default:
+		return t.AddDate(0, 0, 1)
+	}
+}
+
+// clampDay limits day to the length of the month.
+func clampDay(y int, m time.Month, day int) int {
+	return min(day, time.Date(y, m+1, 0, 0, 0, 0, 0, time.UTC).Day())
+}
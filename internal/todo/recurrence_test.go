@@ -0,0 +1,85 @@
+package todo
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseRecurrence(t *testing.T) {
+	cases := map[string]string{
+		"daily":                 "daily",
+		"Weekly":                "weekly",
+		"weekly: Thu, monday":   "weekly:mon,thu",
+		"weekly:sun,sunday,sat": "weekly:sun,sat",
+		"monthly":               "monthly",
+		"monthly:31":            "monthly:31",
+		"after:3":               "after:3",
+	}
+	for in, want := range cases {
+		r, err := ParseRecurrence(in)
+		if err != nil {
+			t.Fatalf("%q: expected no errors, got %v", in, err)
+		}
+		if r.String() != want {
+			t.Fatalf("%q: expected %s, got %s", in, want, r.String())
+		}
+	}
+
+	// Check invalid rules are reported on the repeat field
+	for _, in := range []string{"", "hourly", "daily:2", "weekly:", "weekly:mo", "weekly:monx", "monthly:0", "monthly:32", "after", "after:0", "after:x"} {
+		if _, err := ParseRecurrence(in); !errors.Is(err, ErrInvalidRepeat) {
+			t.Fatalf("%q: expected error %v, got %v", in, ErrInvalidRepeat, err)
+		}
+	}
+}
+
+func TestRecurrenceNext(t *testing.T) {
+	date := func(y int, m time.Month, d int) time.Time {
+		return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	}
+	// 2026-03-02 is a Monday
+	monday := date(2026, 3, 2)
+
+	cases := []struct {
+		rule string
+		due  time.Time
+		now  time.Time
+		want time.Time
+	}{
+		{"daily", monday, monday.Add(9 * time.Hour), date(2026, 3, 3)},
+		{"weekly", monday, monday, date(2026, 3, 9)},
+		{"weekly:mon,thu", monday, monday, date(2026, 3, 5)},
+		{"weekly:mon,thu", date(2026, 3, 5), date(2026, 3, 5), date(2026, 3, 9)},
+		{"monthly:15", monday, monday, date(2026, 3, 15)},
+		{"monthly:31", date(2026, 1, 31), date(2026, 1, 31), date(2026, 2, 28)},
+		{"monthly", date(2026, 1, 31), date(2026, 2, 1), date(2026, 2, 28)},
+		{"monthly", date(2026, 12, 10), date(2026, 12, 10), date(2027, 1, 10)},
+		{"after:3", monday, date(2026, 3, 4), date(2026, 3, 7)},
+
+		// Done early: the next slot after the due date
+		{"daily", date(2026, 3, 10), monday, date(2026, 3, 11)},
+		// Done late: skip the slots that are already past
+		{"weekly:mon,thu", monday, date(2026, 3, 20), date(2026, 3, 23)},
+	}
+	for _, tc := range cases {
+		r, _ := ParseRecurrence(tc.rule)
+		due := tc.due
+		if got := r.Next(&due, tc.now); !got.Equal(tc.want) {
+			t.Fatalf("%s due %s done %s: expected %s, got %s", tc.rule, tc.due.Format(time.DateOnly),
+				tc.now.Format(time.DateOnly), tc.want.Format(time.DateOnly), got.Format(time.DateOnly))
+		}
+	}
+
+	// Check a task without due date counts from the completion day
+	r, _ := ParseRecurrence("daily")
+	if got := r.Next(nil, monday.Add(15*time.Hour)); !got.Equal(date(2026, 3, 3)) {
+		t.Fatalf("expected 2026-03-03, got %s", got)
+	}
+
+	// Check the time of day is kept
+	due := monday.Add(18 * time.Hour)
+	if got := r.Next(&due, monday); !got.Equal(due.AddDate(0, 0, 1)) {
+		t.Fatalf("expected %s, got %s", due.AddDate(0, 0, 1), got)
+	}
+}
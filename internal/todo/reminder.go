@@ -0,0 +1,204 @@
+package todo
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// MaxReminders bounds the reminders of one task.
+	MaxReminders = 10
+	// MaxReminderOffset bounds how far from the due date a relative
+	// reminder may be, either way.
+	MaxReminderOffset = 365 * 24 * time.Hour
+)
+
+var ErrInvalidReminder = NewValidationError(FieldIssue{Field: "reminders", Issue: "must be RFC 3339 times or due[+-]offset like due-1h30m or due-2d"})
+
+var ErrTooManyReminders = NewValidationError(FieldIssue{Field: "reminders", Issue: "must be at most 10"})
+
+// Reminder is a time to be reminded of a task. Its text form, used on the
+// wire and in storage, is one of:
+//
+//	2026-03-01T09:00:00Z   an absolute time (RFC 3339)
+//	due                    the due date itself
+//	due-1h30m              before the due date, in days (d), hours and minutes
+//	due+2d                 after it
+//
+// Relative reminders count from the due date, which is midnight UTC for
+// the date-only due dates the clients send, and do not fire while the task
+// has none.
+type Reminder struct {
+	// At is set for absolute reminders.
+	At *time.Time
+	// Offset is added to the due date of relative reminders; negative is
+	// before it.
+	Offset time.Duration
+}
+
+// ParseReminder parses the text form of a reminder, ignoring case and
+// spaces in the relative form.
+func ParseReminder(s string) (Reminder, error) {
+	s = strings.TrimSpace(s)
+	if at, err := time.Parse(time.RFC3339, s); err == nil {
+		at = at.UTC()
+		return Reminder{At: &at}, nil
+	}
+
+	s = strings.ToLower(strings.ReplaceAll(s, " ", ""))
+	rest, ok := strings.CutPrefix(s, "due")
+	if !ok {
+		return Reminder{}, ErrInvalidReminder
+	}
+	if rest == "" {
+		return Reminder{}, nil
+	}
+
+	sign := time.Duration(1)
+	switch rest[0] {
+	case '-':
+		sign = -1
+	case '+':
+	default:
+		return Reminder{}, ErrInvalidReminder
+	}
+	d, err := parseOffset(rest[1:])
+	if err != nil || d > MaxReminderOffset {
+		return Reminder{}, ErrInvalidReminder
+	}
+	return Reminder{Offset: sign * d}, nil
+}
+
+// parseOffset reads a duration in whole minutes such as "2d", "1h30m" or
+// "90m"; a day is always 24 hours.
+func parseOffset(s string) (time.Duration, error) {
+	var days time.Duration
+	if before, after, ok := strings.Cut(s, "d"); ok {
+		n, err := strconv.Atoi(before)
+		if err != nil || n < 0 {
+			return 0, ErrInvalidReminder
+		}
+		days, s = time.Duration(n)*24*time.Hour, after
+		if s == "" {
+			return days, nil
+		}
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 || d%time.Minute != 0 {
+		return 0, ErrInvalidReminder
+	}
+	return days + d, nil
+}
+
+// String returns the text form of r, the inverse of ParseReminder.
+func (r Reminder) String() string {
+	if r.At != nil {
+		return r.At.UTC().Format(time.RFC3339)
+	}
+	if r.Offset == 0 {
+		return "due"
+	}
+
+	sign, d := "+", r.Offset
+	if d < 0 {
+		sign, d = "-", -d
+	}
+	var b strings.Builder
+	b.WriteString("due" + sign)
+	for _, u := range []struct {
+		suffix string
+		unit   time.Duration
+	}{{"d", 24 * time.Hour}, {"h", time.Hour}, {"m", time.Minute}} {
+		if n := d / u.unit; n > 0 {
+			b.WriteString(strconv.FormatInt(int64(n), 10) + u.suffix)
+			d -= n * u.unit
+		}
+	}
+	return b.String()
+}
+
+// Time returns when r fires for a task due at due, false if it is
+// relative and the task has no due date.
+func (r Reminder) Time(due *time.Time) (time.Time, bool) {
+	if r.At != nil {
+		return *r.At, true
+	}
+	if due == nil {
+		return time.Time{}, false
+	}
+	return due.Add(r.Offset), true
+}
+
+// parseReminders parses the text forms of a task's reminders, dropping
+// duplicates and ordering absolute ones first.
+func parseReminders(in []string) ([]Reminder, error) {
+	var out []Reminder
+	for _, s := range in {
+		r, err := ParseReminder(s)
+		if err != nil {
+			return nil, err
+		}
+		if !slices.ContainsFunc(out, func(o Reminder) bool { return o.String() == r.String() }) {
+			out = append(out, r)
+		}
+	}
+	if len(out) > MaxReminders {
+		return nil, ErrTooManyReminders
+	}
+	slices.SortFunc(out, func(a, b Reminder) int {
+		switch {
+		case a.At != nil && b.At != nil:
+			return a.At.Compare(*b.At)
+		case a.At != nil:
+			return -1
+		case b.At != nil:
+			return 1
+		default:
+			return cmp.Compare(a.Offset, b.Offset)
+		}
+	})
+	return out, nil
+}
+
+func remindersText(rs []Reminder) *string {
+	parts := make([]string, len(rs))
+	for i, r := range rs {
+		parts[i] = r.String()
+	}
+	return textOf(strings.Join(parts, ","))
+}
+
+// DueReminder is a reminder of a task together with the time it fires.
+type DueReminder struct {
+	Task     Task
+	Reminder Reminder
+	At       time.Time
+}
+
+// Reminders returns the reminders of open, live tasks of all owners that
+// fire after after, soonest first. It is meant for the reminder scheduler;
+// like PurgeExpired it ignores the owner of s.
+func (s Service) Reminders(ctx context.Context, after time.Time) ([]DueReminder, error) {
+	open := false
+	page, err := s.repo.List(ctx, ListQuery{AllOwners: true, IsDone: &open, HasReminders: true})
+	if err != nil {
+		return nil, err
+	}
+
+	var out []DueReminder
+	for _, t := range page.Items {
+		for _, r := range t.Reminders {
+			if at, ok := r.Time(t.DueDate); ok && at.After(after) {
+				out = append(out, DueReminder{Task: t, Reminder: r, At: at})
+			}
+		}
+	}
+	slices.SortStableFunc(out, func(a, b DueReminder) int {
+		return cmp.Or(a.At.Compare(b.At), cmp.Compare(a.Task.ID, b.Task.ID))
+	})
+	return out, nil
+}
@@ -0,0 +1,109 @@
+package todo
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestParseReminder(t *testing.T) {
+	cases := map[string]string{
+		"2026-03-01T10:00:00+01:00": "2026-03-01T09:00:00Z",
+		"due":                       "due",
+		"Due - 1h30m":               "due-1h30m",
+		"due-90m":                   "due-1h30m",
+		"due+2d":                    "due+2d",
+		"due-1d12h":                 "due-1d12h",
+		"due-0m":                    "due",
+	}
+	for in, want := range cases {
+		r, err := ParseReminder(in)
+		if err != nil {
+			t.Fatalf("%q: expected no errors, got %v", in, err)
+		}
+		if r.String() != want {
+			t.Fatalf("%q: expected %s, got %s", in, want, r.String())
+		}
+	}
+
+	// Check invalid reminders are reported on the reminders field
+	for _, in := range []string{"", "soon", "due1h", "due-", "due-1x", "due-30s", "due--1h", "due-366d", "2026-03-01"} {
+		if _, err := ParseReminder(in); !errors.Is(err, ErrInvalidReminder) {
+			t.Fatalf("%q: expected error %v, got %v", in, ErrInvalidReminder, err)
+		}
+	}
+}
+
+func TestReminderTime(t *testing.T) {
+	due := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+	before, _ := ParseReminder("due-1h")
+
+	// Check relative reminders count from the due date and need one
+	if at, ok := before.Time(&due); !ok || !at.Equal(due.Add(-time.Hour)) {
+		t.Fatalf("expected %v, got %v, %v", due.Add(-time.Hour), at, ok)
+	}
+	if _, ok := before.Time(nil); ok {
+		t.Fatalf("expected no time without a due date")
+	}
+}
+
+func TestTaskReminders(t *testing.T) {
+	r := NewFakeRepo()
+	s := NewService(r)
+	ctx := t.Context()
+
+	// Check reminders are deduplicated and ordered, absolute ones first
+	due := time.Date(2030, 1, 10, 0, 0, 0, 0, time.UTC)
+	repeat := "weekly"
+	created, err := s.CreateTask(ctx, CreateTaskInput{
+		Title: "review", DueDate: &due, Repeat: &repeat,
+		Reminders: []string{"due", "due-1d", "2030-01-08T09:00:00Z", "due-24h"},
+	})
+	if err != nil {
+		t.Fatalf("expected no errors, got %v", err)
+	}
+	if got := *remindersText(created.Reminders); got != "2030-01-08T09:00:00Z,due-1d,due" {
+		t.Fatalf("unexpected reminders %s", got)
+	}
+
+	// Check the scheduler source lists open tasks of every owner in order
+	other, _ := s.ForOwner(2).CreateTask(ctx, CreateTaskInput{Title: "other", Reminders: []string{"2030-01-09T12:00:00Z"}})
+	s.CreateTask(ctx, CreateTaskInput{Title: "undated", Reminders: []string{"due"}})
+	due2, err := s.Reminders(ctx, time.Date(2030, 1, 8, 9, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("expected no errors, got %v", err)
+	}
+	if len(due2) != 3 || due2[0].Task.ID != created.ID || due2[1].Task.ID != other.ID || !due2[2].At.Equal(due) {
+		t.Fatalf("unexpected due reminders %+v", due2)
+	}
+
+	// Check the next occurrence keeps only the relative reminders
+	done := true
+	if _, err := s.UpdateTask(ctx, created.ID, UpdateTaskInput{IsDone: &done}); err != nil {
+		t.Fatalf("expected no errors, got %v", err)
+	}
+	next := r.tasks[len(r.tasks)-1]
+	if got := remindersText(next.Reminders); got == nil || *got != "due-1d,due" {
+		t.Fatalf("expected relative reminders on the next occurrence, got %v", next.Reminders)
+	}
+	if due3, _ := s.Reminders(ctx, time.Time{}); len(due3) != 3 || due3[0].Task.ID == created.ID {
+		t.Fatalf("expected the done task to be left out, got %+v", due3)
+	}
+
+	// Check reminders can be replaced, cleared and must be valid
+	cleared, err := s.UpdateTask(ctx, next.ID, UpdateTaskInput{Reminders: Clear[[]string]()})
+	if err != nil || cleared.Reminders != nil {
+		t.Fatalf("expected the reminders to be cleared, got %+v, %v", cleared, err)
+	}
+	if _, err := s.UpdateTask(ctx, next.ID, UpdateTaskInput{Reminders: Some([]string{"later"})}); !errors.Is(err, ErrInvalidReminder) {
+		t.Fatalf("expected error %v, got %v", ErrInvalidReminder, err)
+	}
+	many := make([]string, MaxReminders+1)
+	for i := range many {
+		many[i] = "due-" + strconv.Itoa(i+1) + "m"
+	}
+	if _, err := s.UpdateTask(ctx, next.ID, UpdateTaskInput{Reminders: Some(many)}); !errors.Is(err, ErrTooManyReminders) {
+		t.Fatalf("expected error %v, got %v", ErrTooManyReminders, err)
+	}
+}
@@ -1,9 +1,48 @@
 package todo
 
+import "context"
+
+// TaskStore reads and writes tasks. Every method takes the caller's context;
+// implementations return ctx.Err() once it is done.
+//
+// Create sets Version to 1. Update is a compare-and-swap: it fails with
+// ErrVersionConflict unless the stored task still has the given Version, and
+// stores and returns the task with Version incremented.
+type TaskStore interface {
+	Create(context.Context, Task) (Task, error)
+	List(context.Context, ListQuery) (TaskPage, error)
+	GetByID(context.Context, int) (Task, error)
+	Update(context.Context, Task) (Task, error)
+	Delete(context.Context, int) (Task, error)
+}
+
+// TaskRepo is a TaskStore whose single calls are atomic, plus Atomic for
+// multi-step operations.
 type TaskRepo interface {
-	Create(Task) (Task, error)
-	List() ([]Task, error)
-	GetByID(int) (Task, error)
-	Update(Task) (Task, error)
-	Delete(int) (Task, error)
+	TaskStore
+
+	// Atomic runs fn as one transaction. The TaskStore passed to fn sees
+	// fn's own writes; other callers see all of them or none, and nothing
+	// else writes between fn's reads and its writes. If fn returns an error
+	// its writes are discarded and Atomic returns that error. The store must
+	// not be used after fn returns.
+	Atomic(ctx context.Context, fn func(TaskStore) error) error
+
+	// Close flushes pending writes and releases resources. Calls after Close
+	// fail; closing twice is a no-op.
+	Close() error
+}
+
+// HistoryRepo stores task events. Events are never changed or removed, not
+// even when their task is purged.
+type HistoryRepo interface {
+	// Append stores events in order, each with the next ID.
+	Append(ctx context.Context, events ...Event) error
+
+	// ListByTask returns the events of a task, oldest first.
+	ListByTask(ctx context.Context, taskID int) ([]Event, error)
+
+	// Close releases resources. Calls after Close fail; closing twice is a
+	// no-op.
+	Close() error
 }
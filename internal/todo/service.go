@@ -1,86 +1,362 @@
 package todo
 
 import (
+	"context"
+	"slices"
+	"strings"
 	"time"
 )
 
+// MaxTitleLength is the longest title accepted, in characters.
+const MaxTitleLength = 200
+
 func strPtr(s string) *string {
 	return &s
 }
 
 type Service struct {
-	repo TaskRepo
+	repo       TaskRepo
+	owner      int
+	onComplete CompletePolicy
+	onDelete   DeletePolicy
+	history    HistoryRepo
+	publishers []Publisher
+	// actor is recorded as the author of history events; see As.
+	actor string
+}
+
+func NewService(r TaskRepo, opts ...ServiceOption) Service {
+	s := Service{repo: r, onComplete: CompleteIndependent, onDelete: DeleteRestrict}
+	for _, opt := range opts {
+		opt(&s)
+	}
+	return s
+}
+
+// ForOwner returns a copy of s scoped to one v2 user: it only sees and
+// creates tasks with that OwnerID. The unscoped service serves v1.
+func (s Service) ForOwner(userID int) Service {
+	s.owner = userID
+	return s
 }
 
-func NewService(r TaskRepo) Service {
-	return Service{repo: r}
+func (s Service) CreateTask(ctx context.Context, i CreateTaskInput) (Task, error) {
+	if i.ParentID == nil {
+		rec := &recorder{TaskStore: s.repo}
+		created, err := s.createIn(ctx, rec, i)
+		s.record(ctx, rec)
+		return created, err
+	}
+
+	// Check the parent and insert in one transaction, so the parent cannot be
+	// deleted in between and leave an orphan.
+	var created Task
+	err := s.atomic(ctx, func(tx TaskStore) error {
+		var err error
+		created, err = s.createIn(ctx, tx, i)
+		return err
+	})
+	if err != nil {
+		return Task{}, err
+	}
+	return created, nil
 }
 
-func (s Service) CreateTask(i CreateTaskInput) (Task, error) {
+func (s Service) createIn(ctx context.Context, store TaskStore, i CreateTaskInput) (Task, error) {
 
-	// Check title not to be empty
-	if i.Title == "" {
-		return Task{}, ErrEmptyTitle
+	if err := validateTitle(i.Title); err != nil {
+		return Task{}, err
+	}
+
+	tags, err := normalizeTags(i.Tags)
+	if err != nil {
+		return Task{}, err
+	}
+
+	var rule *Recurrence
+	if i.Repeat != nil {
+		r, err := ParseRecurrence(*i.Repeat)
+		if err != nil {
+			return Task{}, err
+		}
+		rule = &r
+	}
+
+	var priority Priority
+	if i.Priority != nil {
+		if priority, err = ParsePriority(*i.Priority); err != nil {
+			return Task{}, err
+		}
+	}
+
+	reminders, err := parseReminders(i.Reminders)
+	if err != nil {
+		return Task{}, err
+	}
+
+	if i.ParentID != nil {
+		if err := s.checkParent(ctx, store, 0, *i.ParentID); err != nil {
+			return Task{}, err
+		}
 	}
 
 	//Create a new task and initialize the attributes
 	newTask := Task{
-		Title:     i.Title,
-		Category:  i.Category,
-		DueDate:   i.DueDate,
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
-		IsDone:    false,
+		OwnerID:    s.owner,
+		Title:      i.Title,
+		Category:   i.Category,
+		Tags:       tags,
+		DueDate:    i.DueDate,
+		ParentID:   i.ParentID,
+		Recurrence: rule,
+		Priority:   priority,
+		Reminders:  reminders,
+		CreatedAt:  time.Now(),
+		UpdatedAt:  time.Now(),
+		IsDone:     false,
 	}
 
-	return s.repo.Create(newTask)
+	return store.Create(ctx, newTask)
+}
+
+func (s Service) ListTask(ctx context.Context, q ListQuery) (TaskPage, error) {
+
+	q, err := q.Normalize()
+	if err != nil {
+		return TaskPage{}, err
+	}
+	q.OwnerID = s.owner
+	q.AllOwners = false
+
+	return s.repo.List(ctx, q)
+}
+
+func (s Service) GetByID(ctx context.Context, id int) (Task, error) {
+	return s.getOwned(ctx, s.repo, id)
 }
 
-func (s Service) ListTask() ([]Task, error) {
+// getOwned reads a task through store, hiding tasks of other owners.
+func (s Service) getOwned(ctx context.Context, store TaskStore, id int) (Task, error) {
 
-	return s.repo.List()
+	task, err := store.GetByID(ctx, id)
+	if err != nil {
+		return Task{}, err
+	}
+
+	// Tasks of other owners are reported as missing, not forbidden, so their
+	// IDs do not leak. Trashed tasks only exist for the trash methods.
+	if task.OwnerID != s.owner || task.DeletedAt != nil {
+		return Task{}, ErrTaskNotFound
+	}
+
+	return task, nil
 }
 
-func (s Service) GetByID(id int) (Task, error) {
+// UpdateTask reads, checks and writes the task in one repo transaction, so
+// concurrent updates are applied one after the other and none is lost.
+// Completing a recurring task also creates its next occurrence.
+func (s Service) UpdateTask(ctx context.Context, id int, i UpdateTaskInput) (Task, error) {
 
-	return s.repo.GetByID(id)
+	var updated Task
+	err := s.atomic(ctx, func(tx TaskStore) error {
+		var err error
+		updated, err = s.updateIn(ctx, tx, id, i)
+		return err
+	})
+	if err != nil {
+		return Task{}, err
+	}
+	return updated, nil
 }
 
-func (s Service) UpdateTask(id int, i UpdateTaskInput) (Task, error) {
+// updateIn applies an update through tx, which must be transactional for
+// the version check to hold.
+func (s Service) updateIn(ctx context.Context, tx TaskStore, id int, i UpdateTaskInput) (Task, error) {
 
-	task, err := s.repo.GetByID(id)
+	if i.Title != nil {
+		if err := validateTitle(*i.Title); err != nil {
+			return Task{}, err
+		}
+	}
+
+	var rule *Recurrence
+	if i.Repeat.Value != nil {
+		r, err := ParseRecurrence(*i.Repeat.Value)
+		if err != nil {
+			return Task{}, err
+		}
+		rule = &r
+	}
+
+	var priority Priority
+	if i.Priority != nil {
+		p, err := ParsePriority(*i.Priority)
+		if err != nil {
+			return Task{}, err
+		}
+		priority = p
+	}
+
+	var reminders []Reminder
+	if i.Reminders.Value != nil {
+		rs, err := parseReminders(*i.Reminders.Value)
+		if err != nil {
+			return Task{}, err
+		}
+		reminders = rs
+	}
+
+	task, err := s.getOwned(ctx, tx, id)
 
 	if err != nil {
 		return Task{}, err
 	}
 
+	if i.IfVersion != nil && task.Version != *i.IfVersion {
+		return Task{}, ErrVersionConflict
+	}
+
 	if i.Title != nil {
 		task.Title = *i.Title
 	}
-	if i.DueDate != nil {
-		task.DueDate = i.DueDate
+	if i.DueDate.Set {
+		task.DueDate = i.DueDate.Value
+	}
+	if i.Category.Set {
+		task.Category = i.Category.Value
+	}
+
+	if i.Tags.Set || len(i.AddTags) > 0 || len(i.RemoveTags) > 0 {
+		tags := task.Tags
+		if i.Tags.Set {
+			tags = nil
+			if i.Tags.Value != nil {
+				tags = *i.Tags.Value
+			}
+		}
+		tags = append(slices.Clone(tags), i.AddTags...)
+		tags = slices.DeleteFunc(tags, func(tag string) bool {
+			return slices.ContainsFunc(i.RemoveTags, func(r string) bool { return NormalizeTag(r) == NormalizeTag(tag) })
+		})
+		if task.Tags, err = normalizeTags(tags); err != nil {
+			return Task{}, err
+		}
+	}
+
+	if i.ParentID.Set {
+		if i.ParentID.Value != nil {
+			if err := s.checkParent(ctx, tx, task.ID, *i.ParentID.Value); err != nil {
+				return Task{}, err
+			}
+		}
+		task.ParentID = i.ParentID.Value
+	}
+
+	if i.Repeat.Set {
+		task.Recurrence = rule
+	}
+	if i.Priority != nil {
+		task.Priority = priority
 	}
-	if i.Category != nil {
-		task.Category = i.Category
+	if i.Reminders.Set {
+		task.Reminders = reminders
 	}
 
+	completing := i.IsDone != nil && *i.IsDone && !task.IsDone
 	if i.IsDone != nil {
+		if completing {
+			if err := s.completeSubtasks(ctx, tx, task.ID); err != nil {
+				return Task{}, err
+			}
+		}
 		task.IsDone = *i.IsDone
 	}
 
 	task.UpdatedAt = time.Now()
 
-	return s.repo.Update(task)
+	if completing && task.Recurrence != nil {
+		return s.repeatIn(ctx, tx, task)
+	}
+
+	return tx.Update(ctx, task)
+}
+
+// repeatIn stores done, a recurring task just completed, and creates its
+// next occurrence. The rule moves to the new task, so reopening and
+// completing done again does not create a second one. Relative reminders
+// carry over; absolute ones are behind the new task already.
+func (s Service) repeatIn(ctx context.Context, tx TaskStore, done Task) (Task, error) {
+	rule := *done.Recurrence
+	if rule.Kind == RepeatMonthly && rule.Day == 0 && done.DueDate != nil {
+		// Pin the day, or a task due on the 31st would drift to the 28th
+		// after February
+		rule.Day = done.DueDate.Day()
+	}
+
+	next := done
+	due := rule.Next(done.DueDate, done.UpdatedAt)
+	next.DueDate = &due
+	next.Recurrence = &rule
+	next.Reminders = slices.DeleteFunc(slices.Clone(done.Reminders), func(r Reminder) bool { return r.At != nil })
+	next.IsDone = false
+	next.CreatedAt = done.UpdatedAt
+
+	done.Recurrence = nil
+	updated, err := tx.Update(ctx, done)
+	if err != nil {
+		return Task{}, err
+	}
+
+	if _, err := tx.Create(ctx, next); err != nil {
+		return Task{}, err
+	}
+	return updated, nil
+}
+
+// Delete moves a task to the trash, and its subtasks if the delete policy
+// cascades. A non-nil ifVersion makes it fail with
+// ErrVersionConflict unless the task is still at that version.
+func (s Service) Delete(ctx context.Context, id int, ifVersion *int) (Task, error) {
+
+	var deleted Task
+	err := s.atomic(ctx, func(tx TaskStore) error {
+		var err error
+		deleted, err = s.deleteIn(ctx, tx, id, ifVersion)
+		return err
+	})
+	if err != nil {
+		return Task{}, err
+	}
+	return deleted, nil
 }
 
-func (s Service) Delete(id int) (Task, error) {
+func (s Service) deleteIn(ctx context.Context, tx TaskStore, id int, ifVersion *int) (Task, error) {
 
-	_, err := s.repo.GetByID(id)
+	task, err := s.getOwned(ctx, tx, id)
 
 	if err != nil {
+		return Task{}, err
+	}
 
+	if ifVersion != nil && task.Version != *ifVersion {
+		return Task{}, ErrVersionConflict
+	}
+
+	now := time.Now()
+	if err := s.deleteSubtasks(ctx, tx, id, now); err != nil {
 		return Task{}, err
 	}
 
-	return s.repo.Delete(id)
+	return trashIn(ctx, tx, task, now)
+}
+
+// validateTitle checks the title invariants from API.md §2.1.
+func validateTitle(title string) error {
+	if strings.TrimSpace(title) == "" {
+		return ErrEmptyTitle
+	}
+	if len([]rune(title)) > MaxTitleLength {
+		return NewValidationError(FieldIssue{Field: "title", Issue: "must be at most 200 characters"})
+	}
+	return nil
 }
@@ -1,13 +1,19 @@
 package todo
 
 import (
+	"context"
 	"errors"
+	"slices"
+	"sync"
 	"testing"
+	"time"
 )
 
 type fakeRepo struct {
+	mu     sync.Mutex
 	tasks  []Task
 	nextID int
+	closed bool
 }
 
 // fakeRepo constructor
@@ -17,8 +23,19 @@ func NewFakeRepo() *fakeRepo {
 		nextID: 1,
 	}
 }
-func (r *fakeRepo) Create(t Task) (Task, error) {
+func (r *fakeRepo) Create(ctx context.Context, t Task) (Task, error) {
+	if err := ctx.Err(); err != nil {
+		return Task{}, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return Task{}, ErrRepoClosed
+	}
 	t.ID = r.nextID
+	t.Version = 1
 	r.nextID++
 
 	r.tasks = append(r.tasks, t)
@@ -26,13 +43,32 @@ func (r *fakeRepo) Create(t Task) (Task, error) {
 	return t, nil
 }
 
-func (r *fakeRepo) List() ([]Task, error) {
-	tasks := r.tasks
+func (r *fakeRepo) List(ctx context.Context, q ListQuery) (TaskPage, error) {
+	if err := ctx.Err(); err != nil {
+		return TaskPage{}, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
 
-	return tasks, nil
+	if r.closed {
+		return TaskPage{}, ErrRepoClosed
+	}
+
+	return q.Apply(r.tasks), nil
 }
 
-func (r *fakeRepo) GetByID(id int) (Task, error) {
+func (r *fakeRepo) GetByID(ctx context.Context, id int) (Task, error) {
+	if err := ctx.Err(); err != nil {
+		return Task{}, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return Task{}, ErrRepoClosed
+	}
 
 	for _, task := range r.tasks {
 		if task.ID == id {
@@ -43,10 +79,24 @@ func (r *fakeRepo) GetByID(id int) (Task, error) {
 	return Task{}, ErrTaskNotFound
 }
 
-func (r *fakeRepo) Update(t Task) (Task, error) {
+func (r *fakeRepo) Update(ctx context.Context, t Task) (Task, error) {
+	if err := ctx.Err(); err != nil {
+		return Task{}, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return Task{}, ErrRepoClosed
+	}
 
 	for idx, task := range r.tasks {
 		if task.ID == t.ID {
+			if task.Version != t.Version {
+				return Task{}, ErrVersionConflict
+			}
+			t.Version++
 			r.tasks[idx] = t
 
 			return t, nil
@@ -56,7 +106,17 @@ func (r *fakeRepo) Update(t Task) (Task, error) {
 	return Task{}, ErrTaskNotFound
 }
 
-func (r *fakeRepo) Delete(id int) (Task, error) {
+func (r *fakeRepo) Delete(ctx context.Context, id int) (Task, error) {
+	if err := ctx.Err(); err != nil {
+		return Task{}, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return Task{}, ErrRepoClosed
+	}
 
 	for idx, task := range r.tasks {
 		if task.ID == id {
@@ -69,6 +129,36 @@ func (r *fakeRepo) Delete(id int) (Task, error) {
 	return Task{}, ErrTaskNotFound
 }
 
+// Atomic runs fn against a copy of the tasks and keeps the copy only if fn
+// succeeds. Holding r.mu throughout serializes it with every other call.
+func (r *fakeRepo) Atomic(ctx context.Context, fn func(TaskStore) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return ErrRepoClosed
+	}
+
+	tx := &fakeRepo{tasks: slices.Clone(r.tasks), nextID: r.nextID}
+	if err := fn(tx); err != nil {
+		return err
+	}
+	r.tasks, r.nextID = tx.tasks, tx.nextID
+	return nil
+}
+
+func (r *fakeRepo) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.closed = true
+	return nil
+}
+
 func TestCreateTaskEmptyTitle(t *testing.T) {
 	input := CreateTaskInput{
 		Title:    "",
@@ -79,7 +169,7 @@ func TestCreateTaskEmptyTitle(t *testing.T) {
 	r := NewFakeRepo()
 	taskService := NewService(r)
 
-	_, err := taskService.CreateTask(input)
+	_, err := taskService.CreateTask(t.Context(), input)
 
 	if !errors.Is(err, ErrEmptyTitle) {
 		t.Fatalf("expected error %v, got %v", ErrEmptyTitle, err)
@@ -98,7 +188,7 @@ func TestCreateTaskOkTask(t *testing.T) {
 	taskService := NewService(r)
 
 	currID := r.nextID
-	createdTask, err := taskService.CreateTask(input)
+	createdTask, err := taskService.CreateTask(t.Context(), input)
 
 	if err != nil {
 		t.Fatalf("expected no errors, got %v", err)
@@ -137,14 +227,14 @@ func TestListTask(t *testing.T) {
 	r := NewFakeRepo()
 	taskService := NewService(r)
 
-	tasks, err := taskService.ListTask()
+	page, err := taskService.ListTask(t.Context(), ListQuery{})
 
 	if err != nil {
 		t.Fatalf("expected no errors, got %v", err)
 	}
 
 	// Check if function works with no tasks
-	if len(tasks) != len(r.tasks) {
+	if len(page.Items) != len(r.tasks) {
 		t.Fatalf("the size of listed tasks doesn't match the size of tasks")
 	}
 
@@ -152,14 +242,14 @@ func TestListTask(t *testing.T) {
 		Title: "first",
 	})
 
-	tasks, err = taskService.ListTask()
+	page, err = taskService.ListTask(t.Context(), ListQuery{})
 
 	if err != nil {
 		t.Fatalf("expected no errors, got %v", err)
 	}
 
 	// Check if function works with tasks
-	if len(tasks) != len(r.tasks) {
+	if len(page.Items) != len(r.tasks) {
 		t.Fatalf("the size of listed tasks doesn't match the size of tasks")
 	}
 }
@@ -167,12 +257,12 @@ func TestListTask(t *testing.T) {
 func TestGetByID(t *testing.T) {
 	r := NewFakeRepo()
 	taskService := NewService(r)
-	r.Create(Task{
+	r.Create(t.Context(), Task{
 		Title: "first",
 	})
 
 	// Check missing ID
-	_, err := taskService.GetByID(2)
+	_, err := taskService.GetByID(t.Context(), 2)
 
 	if err == nil {
 		t.Fatalf("expected %v, got %v", ErrTaskNotFound, err)
@@ -183,7 +273,7 @@ func TestGetByID(t *testing.T) {
 	}
 
 	// Check existing ID
-	task, err := taskService.GetByID(1)
+	task, err := taskService.GetByID(t.Context(), 1)
 
 	if err != nil {
 		t.Fatalf("expected no errors, got %v", err)
@@ -205,7 +295,7 @@ func TestUpdateTask(t *testing.T) {
 	done := true
 
 	// Check missing task
-	_, err := s.UpdateTask(1, UpdateTaskInput{IsDone: &done})
+	_, err := s.UpdateTask(t.Context(), 1, UpdateTaskInput{IsDone: &done})
 
 	if err == nil {
 		t.Fatalf("expected %v, got %v", ErrTaskNotFound, err)
@@ -216,20 +306,19 @@ func TestUpdateTask(t *testing.T) {
 	}
 
 	// Check existing task
-	r.Create(Task{})
+	r.Create(t.Context(), Task{})
 
-	
 	input := UpdateTaskInput{
 		Title:    strPtr("changed"),
 		IsDone:   &done,
-		Category: strPtr("changed"),
+		Category: Some("changed"),
 	}
-	_, err = s.UpdateTask(1, input)
+	_, err = s.UpdateTask(t.Context(), 1, input)
 	if err != nil {
 		t.Fatalf("expected no errors, got %v", err)
 	}
 
-	task, _ := s.GetByID(1)
+	task, _ := s.GetByID(t.Context(), 1)
 
 	if !task.IsDone {
 		t.Fatalf("task done status was not updated")
@@ -239,10 +328,26 @@ func TestUpdateTask(t *testing.T) {
 		t.Fatalf("task title was not updated")
 	}
 
-	if task.Category != input.Category {
+	if task.Category == nil || *task.Category != "changed" {
 		t.Fatalf("task category was not updated")
 	}
 
+	// Check omitted fields stay unchanged and null clears
+	_, err = s.UpdateTask(t.Context(), 1, UpdateTaskInput{Category: Clear[string]()})
+	if err != nil {
+		t.Fatalf("expected no errors, got %v", err)
+	}
+
+	task, _ = s.GetByID(t.Context(), 1)
+
+	if task.Category != nil {
+		t.Fatalf("task category was not cleared")
+	}
+
+	if task.Title != "changed" || !task.IsDone {
+		t.Fatalf("omitted fields were changed")
+	}
+
 }
 
 func TestDeleteTask(t *testing.T) {
@@ -251,7 +356,7 @@ func TestDeleteTask(t *testing.T) {
 	s := NewService(r)
 
 	// Check delete missing task
-	_, err := s.Delete(1)
+	_, err := s.Delete(t.Context(), 1, nil)
 
 	if err == nil {
 		t.Fatalf("expected error %v, got %v", ErrTaskNotFound, err)
@@ -262,18 +367,18 @@ func TestDeleteTask(t *testing.T) {
 	}
 
 	//Check delete existing task
-	r.Create(Task{
+	r.Create(t.Context(), Task{
 		Title: "test",
 	})
 
-	_, err = s.Delete(1)
+	_, err = s.Delete(t.Context(), 1, nil)
 
 	if err != nil {
 		t.Fatalf("expected no errors, got %v", err)
 	}
 
 	// Check if the task is deleted
-	_, err = r.GetByID(1)
+	_, err = s.GetByID(t.Context(), 1)
 
 	if err == nil {
 		t.Fatalf("expected error %v, got %v", ErrTaskNotFound, err)
@@ -283,4 +388,747 @@ func TestDeleteTask(t *testing.T) {
 		t.Fatalf("expected error %v, got %v", ErrTaskNotFound, err)
 	}
 
+	// Check the task was kept in the trash
+	if trashed, err := r.GetByID(t.Context(), 1); err != nil || trashed.DeletedAt == nil {
+		t.Fatalf("expected the task in the trash, got %+v, %v", trashed, err)
+	}
+}
+
+func TestUpdateAndDeleteIfVersion(t *testing.T) {
+	r := NewFakeRepo()
+	s := NewService(r)
+
+	created, _ := s.CreateTask(t.Context(), CreateTaskInput{Title: "shared"})
+	stale := created.Version
+
+	// Check the matching version is applied and bumped
+	title := "first edit"
+	updated, err := s.UpdateTask(t.Context(), created.ID, UpdateTaskInput{Title: &title, IfVersion: &stale})
+	if err != nil {
+		t.Fatalf("expected no errors, got %v", err)
+	}
+	if updated.Version != stale+1 {
+		t.Fatalf("expected version %d, got %d", stale+1, updated.Version)
+	}
+
+	// Check a stale version is rejected for update and delete
+	title = "second edit"
+	_, err = s.UpdateTask(t.Context(), created.ID, UpdateTaskInput{Title: &title, IfVersion: &stale})
+	if !errors.Is(err, ErrVersionConflict) {
+		t.Fatalf("expected error %v, got %v", ErrVersionConflict, err)
+	}
+	if CodeOf(err) != CodeConflict {
+		t.Fatalf("expected code %s, got %s", CodeConflict, CodeOf(err))
+	}
+
+	_, err = s.Delete(t.Context(), created.ID, &stale)
+	if !errors.Is(err, ErrVersionConflict) {
+		t.Fatalf("expected error %v, got %v", ErrVersionConflict, err)
+	}
+
+	task, _ := s.GetByID(t.Context(), created.ID)
+	if task.Title != "first edit" {
+		t.Fatalf("stale write was applied: %+v", task)
+	}
+
+	// Check the current version deletes
+	if _, err := s.Delete(t.Context(), created.ID, &updated.Version); err != nil {
+		t.Fatalf("expected no errors, got %v", err)
+	}
+}
+
+func TestBatch(t *testing.T) {
+	r := NewFakeRepo()
+	s := NewService(r)
+
+	existing, _ := s.CreateTask(t.Context(), CreateTaskInput{Title: "existing"})
+	done := true
+	ops := []BatchOp{
+		{Kind: BatchCreate, Create: CreateTaskInput{Title: "new"}},
+		{Kind: BatchUpdate, ID: existing.ID, Update: UpdateTaskInput{IsDone: &done}},
+		{Kind: BatchDelete, ID: 99},
+	}
+
+	// Check an atomic batch writes nothing if one operation fails
+	_, err := s.Batch(t.Context(), ops, true)
+	var be *BatchError
+	if !errors.As(err, &be) || be.Index != 2 {
+		t.Fatalf("expected batch error at index 2, got %v", err)
+	}
+	if !errors.Is(err, ErrTaskNotFound) {
+		t.Fatalf("expected error %v, got %v", ErrTaskNotFound, err)
+	}
+	if len(r.tasks) != 1 || r.tasks[0].IsDone {
+		t.Fatalf("expected no writes, got %+v", r.tasks)
+	}
+
+	// Check a non-atomic batch applies what it can
+	results, err := s.Batch(t.Context(), ops, false)
+	if err != nil {
+		t.Fatalf("expected no errors, got %v", err)
+	}
+	if results[0].Err != nil || results[0].Task.Title != "new" {
+		t.Fatalf("unexpected create result %+v", results[0])
+	}
+	if results[1].Err != nil || !results[1].Task.IsDone {
+		t.Fatalf("unexpected update result %+v", results[1])
+	}
+	if !errors.Is(results[2].Err, ErrTaskNotFound) {
+		t.Fatalf("expected error %v, got %v", ErrTaskNotFound, results[2].Err)
+	}
+	if len(r.tasks) != 2 {
+		t.Fatalf("expected 2 tasks, got %d", len(r.tasks))
+	}
+
+	// Check an empty batch is rejected
+	if _, err := s.Batch(t.Context(), nil, false); CodeOf(err) != CodeValidation {
+		t.Fatalf("expected code %s, got %v", CodeValidation, err)
+	}
+}
+
+func TestSubtaskParent(t *testing.T) {
+	r := NewFakeRepo()
+	s := NewService(r)
+
+	epic, _ := s.CreateTask(t.Context(), CreateTaskInput{Title: "epic"})
+	step, err := s.CreateTask(t.Context(), CreateTaskInput{Title: "step", ParentID: &epic.ID})
+	if err != nil {
+		t.Fatalf("expected no errors, got %v", err)
+	}
+	item, _ := s.CreateTask(t.Context(), CreateTaskInput{Title: "item", ParentID: &step.ID})
+
+	// Check a missing parent and another owner's task are rejected
+	missing := 99
+	if _, err := s.CreateTask(t.Context(), CreateTaskInput{Title: "orphan", ParentID: &missing}); !errors.Is(err, ErrParentNotFound) {
+		t.Fatalf("expected error %v, got %v", ErrParentNotFound, err)
+	}
+	if _, err := s.ForOwner(5).CreateTask(t.Context(), CreateTaskInput{Title: "foreign", ParentID: &epic.ID}); !errors.Is(err, ErrParentNotFound) {
+		t.Fatalf("expected error %v, got %v", ErrParentNotFound, err)
+	}
+
+	// Check cycles are rejected, including a task parenting itself
+	for _, parent := range []int{epic.ID, item.ID} {
+		_, err := s.UpdateTask(t.Context(), epic.ID, UpdateTaskInput{ParentID: Some(parent)})
+		if !errors.Is(err, ErrParentCycle) {
+			t.Fatalf("parent %d: expected error %v, got %v", parent, ErrParentCycle, err)
+		}
+	}
+
+	// Check a task can be moved and made top-level
+	moved, err := s.UpdateTask(t.Context(), item.ID, UpdateTaskInput{ParentID: Some(epic.ID)})
+	if err != nil || moved.ParentID == nil || *moved.ParentID != epic.ID {
+		t.Fatalf("expected item under epic, got %+v, %v", moved, err)
+	}
+	moved, err = s.UpdateTask(t.Context(), item.ID, UpdateTaskInput{ParentID: Clear[int]()})
+	if err != nil || moved.ParentID != nil {
+		t.Fatalf("expected item at the top level, got %+v, %v", moved, err)
+	}
+
+	page, err := s.ListChildren(t.Context(), epic.ID, ListQuery{})
+	if err != nil || page.Total != 1 || page.Items[0].ID != step.ID {
+		t.Fatalf("expected step as the only child, got %+v, %v", page.Items, err)
+	}
+	if _, err := s.ListChildren(t.Context(), 99, ListQuery{}); !errors.Is(err, ErrTaskNotFound) {
+		t.Fatalf("expected error %v, got %v", ErrTaskNotFound, err)
+	}
+}
+
+// newTree creates epic > step > item and returns their IDs.
+func newTree(t *testing.T, s Service) (epic, step, item int) {
+	t.Helper()
+
+	e, _ := s.CreateTask(t.Context(), CreateTaskInput{Title: "epic"})
+	st, _ := s.CreateTask(t.Context(), CreateTaskInput{Title: "step", ParentID: &e.ID})
+	it, err := s.CreateTask(t.Context(), CreateTaskInput{Title: "item", ParentID: &st.ID})
+	if err != nil {
+		t.Fatalf("expected no errors, got %v", err)
+	}
+	return e.ID, st.ID, it.ID
+}
+
+func TestSubtaskCompletePolicy(t *testing.T) {
+	done := true
+
+	// Check the default leaves subtasks alone
+	s := NewService(NewFakeRepo())
+	epic, _, item := newTree(t, s)
+	if _, err := s.UpdateTask(t.Context(), epic, UpdateTaskInput{IsDone: &done}); err != nil {
+		t.Fatalf("expected no errors, got %v", err)
+	}
+	if task, _ := s.GetByID(t.Context(), item); task.IsDone {
+		t.Fatalf("expected item to stay open")
+	}
+
+	// Check cascade completes every level
+	s = NewService(NewFakeRepo(), WithCompletePolicy(CompleteCascade))
+	epic, step, item := newTree(t, s)
+	if _, err := s.UpdateTask(t.Context(), epic, UpdateTaskInput{IsDone: &done}); err != nil {
+		t.Fatalf("expected no errors, got %v", err)
+	}
+	for _, id := range []int{step, item} {
+		if task, _ := s.GetByID(t.Context(), id); !task.IsDone {
+			t.Fatalf("expected task %d to be done", id)
+		}
+	}
+
+	// Check require refuses while a subtask is open, even a grandchild
+	s = NewService(NewFakeRepo(), WithCompletePolicy(CompleteRequire))
+	epic, step, item = newTree(t, s)
+	if _, err := s.UpdateTask(t.Context(), step, UpdateTaskInput{IsDone: &done}); !errors.Is(err, ErrOpenSubtasks) {
+		t.Fatalf("expected error %v, got %v", ErrOpenSubtasks, err)
+	}
+	s.UpdateTask(t.Context(), item, UpdateTaskInput{IsDone: &done})
+	if _, err := s.UpdateTask(t.Context(), epic, UpdateTaskInput{IsDone: &done}); !errors.Is(err, ErrOpenSubtasks) {
+		t.Fatalf("expected error %v, got %v", ErrOpenSubtasks, err)
+	}
+	s.UpdateTask(t.Context(), step, UpdateTaskInput{IsDone: &done})
+	if _, err := s.UpdateTask(t.Context(), epic, UpdateTaskInput{IsDone: &done}); err != nil {
+		t.Fatalf("expected no errors, got %v", err)
+	}
+}
+
+func TestSubtaskDeletePolicy(t *testing.T) {
+	// Check the default refuses to orphan subtasks
+	r := NewFakeRepo()
+	s := NewService(r)
+	epic, step, item := newTree(t, s)
+	if _, err := s.Delete(t.Context(), epic, nil); !errors.Is(err, ErrHasSubtasks) {
+		t.Fatalf("expected error %v, got %v", ErrHasSubtasks, err)
+	}
+	if _, err := s.Delete(t.Context(), item, nil); err != nil {
+		t.Fatalf("expected no errors, got %v", err)
+	}
+	if page, _ := s.ListTask(t.Context(), ListQuery{}); page.Total != 2 {
+		t.Fatalf("expected 2 tasks, got %d", page.Total)
+	}
+
+	// Check cascade removes the whole subtree and nothing else
+	r = NewFakeRepo()
+	s = NewService(r, WithDeletePolicy(DeleteCascade))
+	epic, step, _ = newTree(t, s)
+	s.CreateTask(t.Context(), CreateTaskInput{Title: "unrelated"})
+	deleted, err := s.Delete(t.Context(), epic, nil)
+	if err != nil || deleted.ID != epic {
+		t.Fatalf("expected epic to be deleted, got %+v, %v", deleted, err)
+	}
+	if page, _ := s.ListTask(t.Context(), ListQuery{}); page.Total != 1 || page.Items[0].Title != "unrelated" {
+		t.Fatalf("expected only the unrelated task, got %+v", page.Items)
+	}
+	if _, err := s.GetByID(t.Context(), step); !errors.Is(err, ErrTaskNotFound) {
+		t.Fatalf("expected error %v, got %v", ErrTaskNotFound, err)
+	}
+}
+
+func TestRecurringTask(t *testing.T) {
+	r := NewFakeRepo()
+	s := NewService(r)
+
+	due := time.Date(2030, 1, 31, 0, 0, 0, 0, time.UTC)
+	repeat := "monthly"
+	created, err := s.CreateTask(t.Context(), CreateTaskInput{Title: "pay rent", DueDate: &due, Repeat: &repeat})
+	if err != nil {
+		t.Fatalf("expected no errors, got %v", err)
+	}
+	if created.Recurrence == nil || created.Recurrence.Kind != RepeatMonthly {
+		t.Fatalf("expected a monthly rule, got %v", created.Recurrence)
+	}
+
+	// Check completing creates the next occurrence and moves the rule to it
+	done := true
+	completed, err := s.UpdateTask(t.Context(), created.ID, UpdateTaskInput{IsDone: &done})
+	if err != nil {
+		t.Fatalf("expected no errors, got %v", err)
+	}
+	if !completed.IsDone || completed.Recurrence != nil {
+		t.Fatalf("expected a done task without rule, got %+v", completed)
+	}
+	if len(r.tasks) != 2 {
+		t.Fatalf("expected 2 tasks, got %d", len(r.tasks))
+	}
+	next := r.tasks[1]
+	if next.Title != "pay rent" || next.IsDone || next.DueDate == nil || next.Recurrence == nil {
+		t.Fatalf("unexpected next occurrence %+v", next)
+	}
+	if want := time.Date(2030, 2, 28, 0, 0, 0, 0, time.UTC); !next.DueDate.Equal(want) {
+		t.Fatalf("expected due %v, got %v", want, next.DueDate)
+	}
+	if next.Recurrence.String() != "monthly:31" {
+		t.Fatalf("expected the day to be pinned, got %s", next.Recurrence)
+	}
+
+	// Check reopening and completing again does not repeat twice
+	undone := false
+	s.UpdateTask(t.Context(), created.ID, UpdateTaskInput{IsDone: &undone})
+	s.UpdateTask(t.Context(), created.ID, UpdateTaskInput{IsDone: &done})
+	if len(r.tasks) != 2 {
+		t.Fatalf("expected 2 tasks, got %d", len(r.tasks))
+	}
+
+	// Check the rule can be cleared and must be valid
+	cleared, err := s.UpdateTask(t.Context(), next.ID, UpdateTaskInput{Repeat: Clear[string]()})
+	if err != nil || cleared.Recurrence != nil {
+		t.Fatalf("expected the rule to be cleared, got %+v, %v", cleared, err)
+	}
+	bad := "fortnightly"
+	if _, err := s.UpdateTask(t.Context(), next.ID, UpdateTaskInput{Repeat: Some(bad)}); !errors.Is(err, ErrInvalidRepeat) {
+		t.Fatalf("expected error %v, got %v", ErrInvalidRepeat, err)
+	}
+}
+
+func TestTags(t *testing.T) {
+	s := NewService(NewFakeRepo())
+
+	// Check tags are normalized, deduplicated and sorted
+	a, err := s.CreateTask(t.Context(), CreateTaskInput{Title: "a", Tags: []string{" Work", "+urgent", "work", "waiting on review"}})
+	if err != nil {
+		t.Fatalf("expected no errors, got %v", err)
+	}
+	if !slices.Equal(a.Tags, []string{"urgent", "waiting-on-review", "work"}) {
+		t.Fatalf("unexpected tags %v", a.Tags)
+	}
+	s.CreateTask(t.Context(), CreateTaskInput{Title: "b", Tags: []string{"work"}})
+	s.ForOwner(5).CreateTask(t.Context(), CreateTaskInput{Title: "c", Tags: []string{"work"}})
+
+	if _, err := s.CreateTask(t.Context(), CreateTaskInput{Title: "bad", Tags: []string{"+"}}); !errors.Is(err, ErrInvalidTag) {
+		t.Fatalf("expected error %v, got %v", ErrInvalidTag, err)
+	}
+
+	// Check add and remove edit the current set
+	a, err = s.UpdateTask(t.Context(), a.ID, UpdateTaskInput{AddTags: []string{"Home"}, RemoveTags: []string{"URGENT", "missing"}})
+	if err != nil || !slices.Equal(a.Tags, []string{"home", "waiting-on-review", "work"}) {
+		t.Fatalf("unexpected tags %v, %v", a.Tags, err)
+	}
+
+	// Check filters are normalized too
+	page, _ := s.ListTask(t.Context(), ListQuery{TagsAll: []string{"WORK"}, TagsNone: []string{"+home"}})
+	if page.Total != 1 || page.Items[0].Title != "b" {
+		t.Fatalf("expected only b, got %+v", page.Items)
+	}
+
+	tags, err := s.ListTags(t.Context())
+	if err != nil {
+		t.Fatalf("expected no errors, got %v", err)
+	}
+	want := []TagCount{{"work", 2}, {"home", 1}, {"waiting-on-review", 1}}
+	if !slices.Equal(tags, want) {
+		t.Fatalf("expected %v, got %v", want, tags)
+	}
+
+	// Check rename merges into an existing tag, only for this owner
+	n, err := s.RenameTag(t.Context(), "home", "Work")
+	if err != nil || n != 1 {
+		t.Fatalf("expected 1 task renamed, got %d, %v", n, err)
+	}
+	a, _ = s.GetByID(t.Context(), a.ID)
+	if !slices.Equal(a.Tags, []string{"waiting-on-review", "work"}) {
+		t.Fatalf("unexpected tags %v", a.Tags)
+	}
+
+	n, err = s.DeleteTag(t.Context(), "work")
+	if err != nil || n != 2 {
+		t.Fatalf("expected 2 tasks untagged, got %d, %v", n, err)
+	}
+	if tags, _ := s.ForOwner(5).ListTags(t.Context()); len(tags) != 1 {
+		t.Fatalf("expected other owner's tag to stay, got %v", tags)
+	}
+	if _, err := s.DeleteTag(t.Context(), "work"); !errors.Is(err, ErrTagNotFound) {
+		t.Fatalf("expected error %v, got %v", ErrTagNotFound, err)
+	}
+	if _, err := s.RenameTag(t.Context(), "waiting-on-review", " "); CodeOf(err) != CodeValidation {
+		t.Fatalf("expected code %s, got %v", CodeValidation, err)
+	}
+}
+
+func TestPriority(t *testing.T) {
+	s := NewService(NewFakeRepo())
+
+	// Check names are case-insensitive and the default is none
+	high, err := s.CreateTask(t.Context(), CreateTaskInput{Title: "a", Priority: strPtr("High")})
+	if err != nil || high.Priority != PriorityHigh {
+		t.Fatalf("expected priority high, got %v, %v", high.Priority, err)
+	}
+	plain, _ := s.CreateTask(t.Context(), CreateTaskInput{Title: "b"})
+	if plain.Priority != PriorityNone {
+		t.Fatalf("expected priority none, got %v", plain.Priority)
+	}
+
+	if _, err := s.CreateTask(t.Context(), CreateTaskInput{Title: "c", Priority: strPtr("asap")}); !errors.Is(err, ErrInvalidPriority) {
+		t.Fatalf("expected error %v, got %v", ErrInvalidPriority, err)
+	}
+	if _, err := s.UpdateTask(t.Context(), plain.ID, UpdateTaskInput{Priority: strPtr("2")}); !errors.Is(err, ErrInvalidPriority) {
+		t.Fatalf("expected error %v, got %v", ErrInvalidPriority, err)
+	}
+
+	// Check the default order puts the more important task first
+	if _, err := s.UpdateTask(t.Context(), high.ID, UpdateTaskInput{Priority: strPtr("low")}); err != nil {
+		t.Fatalf("expected no errors, got %v", err)
+	}
+	page, _ := s.ListTask(t.Context(), ListQuery{})
+	if page.Items[0].ID != high.ID || page.Items[0].Priority != PriorityLow {
+		t.Fatalf("expected the low priority task first, got %+v", page.Items)
+	}
+}
+
+func TestTrash(t *testing.T) {
+	s := NewService(NewFakeRepo(), WithDeletePolicy(DeleteCascade))
+	epic, step, item := newTree(t, s)
+
+	// Check delete moves the subtree to the trash
+	if _, err := s.Delete(t.Context(), item, nil); err != nil {
+		t.Fatalf("expected no errors, got %v", err)
+	}
+	if _, err := s.Delete(t.Context(), epic, nil); err != nil {
+		t.Fatalf("expected no errors, got %v", err)
+	}
+	if page, _ := s.ListTask(t.Context(), ListQuery{}); page.Total != 0 {
+		t.Fatalf("expected no live tasks, got %+v", page.Items)
+	}
+	trash, err := s.ListTrash(t.Context(), ListQuery{})
+	if err != nil || trash.Total != 3 {
+		t.Fatalf("expected 3 tasks in the trash, got %+v, %v", trash.Items, err)
+	}
+	if _, err := s.UpdateTask(t.Context(), step, UpdateTaskInput{Title: strPtr("x")}); !errors.Is(err, ErrTaskNotFound) {
+		t.Fatalf("expected error %v, got %v", ErrTaskNotFound, err)
+	}
+
+	// Check restore brings back what was deleted together, not item
+	restored, err := s.Restore(t.Context(), epic)
+	if err != nil || restored.DeletedAt != nil {
+		t.Fatalf("expected epic restored, got %+v, %v", restored, err)
+	}
+	if _, err := s.GetByID(t.Context(), step); err != nil {
+		t.Fatalf("expected step restored, got %v", err)
+	}
+	if _, err := s.Restore(t.Context(), epic); !errors.Is(err, ErrNotInTrash) {
+		t.Fatalf("expected error %v, got %v", ErrNotInTrash, err)
+	}
+
+	// Check purge takes the trashed subtasks along
+	if _, err := s.Delete(t.Context(), step, nil); err != nil {
+		t.Fatalf("expected no errors, got %v", err)
+	}
+	if err := s.Purge(t.Context(), step); err != nil {
+		t.Fatalf("expected no errors, got %v", err)
+	}
+	if _, err := s.Restore(t.Context(), step); !errors.Is(err, ErrNotInTrash) {
+		t.Fatalf("expected error %v, got %v", ErrNotInTrash, err)
+	}
+	if trash, _ := s.ListTrash(t.Context(), ListQuery{}); trash.Total != 0 {
+		t.Fatalf("expected purge to take item too, got %+v", trash.Items)
+	}
+
+	// Check a subtask whose parent is not live comes back top-level
+	lone, _ := s.CreateTask(t.Context(), CreateTaskInput{Title: "lone", ParentID: &epic})
+	s.Delete(t.Context(), lone.ID, nil)
+	if _, err := s.Delete(t.Context(), epic, nil); err != nil {
+		t.Fatalf("expected no errors, got %v", err)
+	}
+	if restored, err := s.Restore(t.Context(), lone.ID); err != nil || restored.ParentID != nil {
+		t.Fatalf("expected lone restored top-level, got %+v, %v", restored, err)
+	}
+
+	// Check emptying is limited by time and owner
+	other := s.ForOwner(4)
+	o, _ := other.CreateTask(t.Context(), CreateTaskInput{Title: "other"})
+	other.Delete(t.Context(), o.ID, nil)
+	s.Delete(t.Context(), lone.ID, nil)
+
+	if n, err := s.EmptyTrash(t.Context(), time.Now().Add(-time.Hour)); err != nil || n != 0 {
+		t.Fatalf("expected nothing purged, got %d, %v", n, err)
+	}
+	if n, err := s.EmptyTrash(t.Context(), time.Time{}); err != nil || n != 2 {
+		t.Fatalf("expected 2 tasks purged, got %d, %v", n, err)
+	}
+	if n, err := s.PurgeExpired(t.Context(), time.Now().Add(time.Second)); err != nil || n != 1 {
+		t.Fatalf("expected the other owner's task purged, got %d, %v", n, err)
+	}
+}
+
+func TestPurgeExpiredTakesSubtasks(t *testing.T) {
+	repo := NewFakeRepo()
+	s := NewService(repo)
+	now := time.Now()
+	old, recent := now.Add(-48*time.Hour), now.Add(-time.Hour)
+
+	// A parent that expired above a subtask trashed later, and a live
+	// subtask left below it
+	parent, _ := repo.Create(t.Context(), Task{OwnerID: 1, Title: "parent", DeletedAt: &old, CreatedAt: old, UpdatedAt: old})
+	child, _ := repo.Create(t.Context(), Task{OwnerID: 1, Title: "child", ParentID: &parent.ID, DeletedAt: &recent, CreatedAt: old, UpdatedAt: recent})
+	grandchild, _ := repo.Create(t.Context(), Task{OwnerID: 1, Title: "grandchild", ParentID: &child.ID, DeletedAt: &recent, CreatedAt: old, UpdatedAt: recent})
+	live, _ := repo.Create(t.Context(), Task{OwnerID: 1, Title: "live", ParentID: &parent.ID, CreatedAt: old, UpdatedAt: old})
+
+	// Check the trashed subtree goes with the parent
+	if n, err := s.PurgeExpired(t.Context(), now.Add(-24*time.Hour)); err != nil || n != 3 {
+		t.Fatalf("expected 3 tasks purged, got %d, %v", n, err)
+	}
+	for _, id := range []int{parent.ID, child.ID, grandchild.ID} {
+		if _, err := repo.GetByID(t.Context(), id); !errors.Is(err, ErrTaskNotFound) {
+			t.Fatalf("expected task %d purged, got %v", id, err)
+		}
+	}
+
+	// Check the live subtask becomes top-level
+	if task, err := repo.GetByID(t.Context(), live.ID); err != nil || task.ParentID != nil {
+		t.Fatalf("expected live task top-level, got %+v, %v", task, err)
+	}
+}
+
+type fakeHistory struct {
+	mu     sync.Mutex
+	events []Event
+}
+
+func (h *fakeHistory) Append(ctx context.Context, events ...Event) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, e := range events {
+		e.ID = len(h.events) + 1
+		h.events = append(h.events, e)
+	}
+	return nil
+}
+
+func (h *fakeHistory) ListByTask(ctx context.Context, taskID int) ([]Event, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var out []Event
+	for _, e := range h.events {
+		if e.TaskID == taskID {
+			out = append(out, e)
+		}
+	}
+	return out, nil
+}
+
+func (h *fakeHistory) Close() error { return nil }
+
+func TestHistory(t *testing.T) {
+	s := NewService(NewFakeRepo(), WithHistory(&fakeHistory{})).ForOwner(1).As("alice")
+
+	task, _ := s.CreateTask(t.Context(), CreateTaskInput{Title: "draft", Priority: strPtr("high")})
+	s.UpdateTask(t.Context(), task.ID, UpdateTaskInput{Title: strPtr("final")})
+	// No change, no event
+	s.UpdateTask(t.Context(), task.ID, UpdateTaskInput{Title: strPtr("final")})
+	done := true
+	s.UpdateTask(t.Context(), task.ID, UpdateTaskInput{IsDone: &done})
+	s.Delete(t.Context(), task.ID, nil)
+	s.Restore(t.Context(), task.ID)
+
+	events, err := s.History(t.Context(), task.ID)
+	if err != nil {
+		t.Fatalf("expected no errors, got %v", err)
+	}
+	var kinds []EventKind
+	for _, e := range events {
+		kinds = append(kinds, e.Kind)
+	}
+	want := []EventKind{EventCreated, EventUpdated, EventCompleted, EventDeleted, EventRestored}
+	if !slices.Equal(kinds, want) {
+		t.Fatalf("expected %v, got %v", want, kinds)
+	}
+
+	// Check the diff and the actor
+	if e := events[0]; e.Actor != "alice" || e.At.IsZero() || len(e.Changes) != 2 {
+		t.Fatalf("expected title and priority set by alice, got %+v", e)
+	}
+	c := events[1].Changes
+	if len(c) != 1 || c[0].Field != "title" || *c[0].Old != "draft" || *c[0].New != "final" {
+		t.Fatalf("expected title change, got %+v", c)
+	}
+
+	// Check purged tasks keep their history and other owners see none
+	s.Delete(t.Context(), task.ID, nil)
+	s.Purge(t.Context(), task.ID)
+	events, _ = s.History(t.Context(), task.ID)
+	if last := events[len(events)-1]; last.Kind != EventPurged {
+		t.Fatalf("expected purged event, got %+v", last)
+	}
+	if _, err := s.ForOwner(2).History(t.Context(), task.ID); !errors.Is(err, ErrTaskNotFound) {
+		t.Fatalf("expected error %v, got %v", ErrTaskNotFound, err)
+	}
+}
+
+type fakePublisher struct {
+	events []Event
+	tasks  []Task
+}
+
+func (p *fakePublisher) Publish(e Event, t Task) {
+	p.events = append(p.events, e)
+	p.tasks = append(p.tasks, t)
+}
+
+func TestPublisher(t *testing.T) {
+	pub := &fakePublisher{}
+	s := NewService(NewFakeRepo(), WithPublisher(pub))
+
+	task, _ := s.CreateTask(t.Context(), CreateTaskInput{Title: "water plants", Repeat: strPtr("daily")})
+	done := true
+	s.UpdateTask(t.Context(), task.ID, UpdateTaskInput{IsDone: &done})
+	// Fails validation: nothing is published
+	s.UpdateTask(t.Context(), task.ID, UpdateTaskInput{Title: strPtr("")})
+
+	// Check completing a recurring task publishes the next occurrence too
+	if len(pub.events) != 3 || pub.events[1].Kind != EventCompleted || pub.events[2].Kind != EventCreated {
+		t.Fatalf("expected created, completed and created, got %+v", pub.events)
+	}
+	if !pub.tasks[1].IsDone || pub.tasks[2].ID == task.ID || pub.tasks[2].Title != "water plants" {
+		t.Fatalf("expected the written tasks, got %+v", pub.tasks)
+	}
+}
+
+func TestImport(t *testing.T) {
+	r := NewFakeRepo()
+	s := NewService(r)
+
+	existing, _ := s.CreateTask(t.Context(), CreateTaskInput{Title: "Pay rent"})
+	created := time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)
+	recs := []ImportRecord{
+		{Row: 1, Ref: 2, ParentRef: 1, Task: CreateTaskInput{Title: "buy paint"}},
+		{Row: 2, Ref: 1, Task: CreateTaskInput{Title: "paint fence", Priority: strPtr("high")}, CreatedAt: &created},
+		{Row: 3, Task: CreateTaskInput{Title: " pay RENT ", Category: strPtr("home")}, IsDone: true},
+		{Row: 4, Task: CreateTaskInput{Title: "bad", Priority: strPtr("urgent")}},
+		{Row: 5, ParentRef: 9, Task: CreateTaskInput{Title: "orphan"}},
+		{Row: 6, Err: NewValidationError(FieldIssue{Field: "due", Issue: "must be a date"})},
+	}
+
+	// Check a dry run reports the outcome without writing
+	results, err := s.Import(t.Context(), recs, ImportOptions{DryRun: true})
+	if err != nil {
+		t.Fatalf("expected no errors, got %v", err)
+	}
+	want := []ImportOutcome{ImportCreated, ImportCreated, ImportSkipped, ImportFailed, ImportFailed, ImportFailed}
+	for i, res := range results {
+		if res.Outcome != want[i] || res.Row != recs[i].Row {
+			t.Fatalf("expected row %d %s, got %+v", recs[i].Row, want[i], res)
+		}
+	}
+	if len(r.tasks) != 1 {
+		t.Fatalf("expected no writes, got %+v", r.tasks)
+	}
+
+	// Check the subtask is created under its parent, with the file's dates
+	results, err = s.Import(t.Context(), recs, ImportOptions{Duplicates: DuplicateUpdate})
+	if err != nil {
+		t.Fatalf("expected no errors, got %v", err)
+	}
+	parent := results[1].Task
+	if results[0].Task.ParentID == nil || *results[0].Task.ParentID != parent.ID {
+		t.Fatalf("expected parent %d, got %+v", parent.ID, results[0].Task)
+	}
+	if !parent.CreatedAt.Equal(created) || parent.Priority != PriorityHigh {
+		t.Fatalf("expected the file's fields, got %+v", parent)
+	}
+
+	// Check a duplicate title updates the existing task
+	if results[2].Outcome != ImportUpdated || results[2].Task.ID != existing.ID || !results[2].Task.IsDone {
+		t.Fatalf("expected task %d updated and done, got %+v", existing.ID, results[2])
+	}
+	if !errors.Is(results[3].Err, ErrInvalidPriority) || CodeOf(results[4].Err) != CodeValidation {
+		t.Fatalf("expected validation errors, got %v and %v", results[3].Err, results[4].Err)
+	}
+	if len(r.tasks) != 3 {
+		t.Fatalf("expected 3 tasks, got %d", len(r.tasks))
+	}
+
+	// Check an unknown duplicate policy is rejected
+	if _, err := s.Import(t.Context(), recs, ImportOptions{Duplicates: "merge"}); CodeOf(err) != CodeValidation {
+		t.Fatalf("expected code %s, got %v", CodeValidation, err)
+	}
+}
+
+func TestImportUpdateKeepsParentAndRecurrence(t *testing.T) {
+	r := NewFakeRepo()
+	s := NewService(r)
+
+	parent, _ := s.CreateTask(t.Context(), CreateTaskInput{Title: "garden"})
+	task, _ := s.CreateTask(t.Context(), CreateTaskInput{Title: "water plants", ParentID: &parent.ID})
+	recs := []ImportRecord{{Row: 1, Task: CreateTaskInput{Title: "water plants", Repeat: strPtr("daily")}, IsDone: true}}
+
+	results, err := s.Import(t.Context(), recs, ImportOptions{Duplicates: DuplicateUpdate})
+	if err != nil {
+		t.Fatalf("expected no errors, got %v", err)
+	}
+
+	// Check the task is done and keeps its parent
+	got := results[0].Task
+	if results[0].Outcome != ImportUpdated || got.ID != task.ID || !got.IsDone {
+		t.Fatalf("expected task %d updated and done, got %+v", task.ID, results[0])
+	}
+	if got.ParentID == nil || *got.ParentID != parent.ID {
+		t.Fatalf("expected parent %d kept, got %v", parent.ID, got.ParentID)
+	}
+
+	// Check no next occurrence is started
+	if len(r.tasks) != 2 {
+		t.Fatalf("expected 2 tasks, got %+v", r.tasks)
+	}
+}
+
+func TestCreateTaskInvalidTitle(t *testing.T) {
+	r := NewFakeRepo()
+	s := NewService(r)
+
+	// Check whitespace-only title
+	_, err := s.CreateTask(t.Context(), CreateTaskInput{Title: "   "})
+	if !errors.Is(err, ErrEmptyTitle) {
+		t.Fatalf("expected error %v, got %v", ErrEmptyTitle, err)
+	}
+
+	// Check too long title
+	long := make([]rune, MaxTitleLength+1)
+	for i := range long {
+		long[i] = 'a'
+	}
+	_, err = s.CreateTask(t.Context(), CreateTaskInput{Title: string(long)})
+
+	var de *Error
+	if !errors.As(err, &de) {
+		t.Fatalf("expected *Error, got %T", err)
+	}
+	if de.Code != CodeValidation {
+		t.Fatalf("expected code %s, got %s", CodeValidation, de.Code)
+	}
+	if len(de.Details) != 1 || de.Details[0].Field != "title" {
+		t.Fatalf("expected one title issue, got %v", de.Details)
+	}
+
+	if len(r.tasks) != 0 {
+		t.Fatalf("expected no tasks to be created")
+	}
+}
+
+func TestErrorIsMatchesFieldIssue(t *testing.T) {
+	err := NewValidationError(
+		FieldIssue{Field: "due_date", Issue: "must be in the future"},
+		FieldIssue{Field: "title", Issue: "must not be empty"},
+	)
+
+	if !errors.Is(err, ErrEmptyTitle) {
+		t.Fatalf("expected validation error to match %v", ErrEmptyTitle)
+	}
+	if errors.Is(err, ErrTaskNotFound) {
+		t.Fatalf("validation error must not match %v", ErrTaskNotFound)
+	}
+	if CodeOf(err) != CodeValidation {
+		t.Fatalf("expected code %s, got %s", CodeValidation, CodeOf(err))
+	}
+}
+
+func TestErrorStringDoesNotRepeatMessage(t *testing.T) {
+	cases := map[string]error{
+		"title must not be empty": NewValidationError(FieldIssue{Field: "title", Issue: "must not be empty"}),
+		"title must not be empty: due_date must be a date": NewValidationError(
+			FieldIssue{Field: "title", Issue: "must not be empty"},
+			FieldIssue{Field: "due_date", Issue: "must be a date"},
+		),
+		"invalid JSON body: body must be an object": &Error{Code: CodeValidation, Message: "invalid JSON body", Details: []FieldIssue{{Field: "body", Issue: "must be an object"}}},
+	}
+	for want, err := range cases {
+		if got := err.Error(); got != want {
+			t.Fatalf("expected %q, got %q", want, got)
+		}
+	}
 }
@@ -0,0 +1,166 @@
+package todo
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// CompletePolicy decides what marking a task done does to its subtasks.
+type CompletePolicy string
+
+const (
+	// CompleteIndependent leaves subtasks as they are.
+	CompleteIndependent CompletePolicy = "independent"
+	// CompleteCascade marks every open subtask done as well.
+	CompleteCascade CompletePolicy = "cascade"
+	// CompleteRequire refuses with ErrOpenSubtasks while a subtask is open.
+	CompleteRequire CompletePolicy = "require"
+)
+
+// DeletePolicy decides what deleting a task does to its subtasks.
+type DeletePolicy string
+
+const (
+	// DeleteRestrict refuses with ErrHasSubtasks while the task has subtasks.
+	DeleteRestrict DeletePolicy = "restrict"
+	// DeleteCascade moves the subtasks, and theirs, to the trash with the
+	// task.
+	DeleteCascade DeletePolicy = "cascade"
+)
+
+// ServiceOption configures a Service.
+type ServiceOption func(*Service)
+
+// WithCompletePolicy sets the completion rule; the default is
+// CompleteIndependent.
+func WithCompletePolicy(p CompletePolicy) ServiceOption {
+	return func(s *Service) { s.onComplete = p }
+}
+
+// WithDeletePolicy sets the deletion rule; the default is DeleteRestrict,
+// so a task is never left pointing at a deleted parent.
+func WithDeletePolicy(p DeletePolicy) ServiceOption {
+	return func(s *Service) { s.onDelete = p }
+}
+
+// ListChildren lists the direct subtasks of a task.
+func (s Service) ListChildren(ctx context.Context, id int, q ListQuery) (TaskPage, error) {
+	if _, err := s.GetByID(ctx, id); err != nil {
+		return TaskPage{}, err
+	}
+	q.ParentID = Some(id)
+	return s.ListTask(ctx, q)
+}
+
+// checkParent validates parentID as the parent of task id, which is 0 for a
+// task being created. The parent must be a task of the same owner, and must
+// not be the task itself or one of its descendants.
+func (s Service) checkParent(ctx context.Context, tx TaskStore, id, parentID int) error {
+	parent, err := s.getOwned(ctx, tx, parentID)
+	if errors.Is(err, ErrTaskNotFound) {
+		return ErrParentNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if id == 0 {
+		return nil
+	}
+
+	// Walk up from the new parent; reaching id would close a loop
+	seen := map[int]bool{}
+	for t := parent; ; {
+		if t.ID == id || seen[t.ID] {
+			return ErrParentCycle
+		}
+		seen[t.ID] = true
+		if t.ParentID == nil {
+			return nil
+		}
+		if t, err = tx.GetByID(ctx, *t.ParentID); err != nil {
+			return err
+		}
+	}
+}
+
+// children returns the direct subtasks of id, oldest first: the live ones,
+// or those in the trash if trashed is true.
+func (s Service) children(ctx context.Context, tx TaskStore, id int, trashed bool) ([]Task, error) {
+	page, err := tx.List(ctx, ListQuery{OwnerID: s.owner, ParentID: Some(id), Trashed: trashed, Sort: SortCreatedAt, Order: OrderAsc})
+	if err != nil {
+		return nil, err
+	}
+	return page.Items, nil
+}
+
+// descendants returns all subtasks below id, every parent before its
+// children. trashed is passed to children.
+func (s Service) descendants(ctx context.Context, tx TaskStore, id int, trashed bool) ([]Task, error) {
+	var out []Task
+	for next := 0; ; next++ {
+		kids, err := s.children(ctx, tx, id, trashed)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, kids...)
+		if next == len(out) {
+			return out, nil
+		}
+		id = out[next].ID
+	}
+}
+
+// completeSubtasks applies the completion policy before task id is marked
+// done.
+func (s Service) completeSubtasks(ctx context.Context, tx TaskStore, id int) error {
+	if s.onComplete != CompleteCascade && s.onComplete != CompleteRequire {
+		return nil
+	}
+
+	subtasks, err := s.descendants(ctx, tx, id, false)
+	if err != nil {
+		return err
+	}
+	for _, t := range subtasks {
+		if t.IsDone {
+			continue
+		}
+		if s.onComplete == CompleteRequire {
+			return ErrOpenSubtasks
+		}
+		t.IsDone = true
+		t.UpdatedAt = time.Now()
+		if _, err := tx.Update(ctx, t); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteSubtasks applies the deletion policy before task id is moved to
+// the trash at now.
+func (s Service) deleteSubtasks(ctx context.Context, tx TaskStore, id int, now time.Time) error {
+	if s.onDelete != DeleteCascade {
+		kids, err := s.children(ctx, tx, id, false)
+		if err != nil {
+			return err
+		}
+		if len(kids) > 0 {
+			return ErrHasSubtasks
+		}
+		return nil
+	}
+
+	subtasks, err := s.descendants(ctx, tx, id, false)
+	if err != nil {
+		return err
+	}
+	// The shared deletion time lets Restore bring them back together
+	for _, t := range subtasks {
+		if _, err := trashIn(ctx, tx, t, now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
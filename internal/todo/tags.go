@@ -0,0 +1,167 @@
+package todo
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	MaxTags      = 20
+	MaxTagLength = 50
+)
+
+// TagCount is the number of tasks carrying a tag.
+type TagCount struct {
+	Tag   string
+	Count int
+}
+
+// NormalizeTag returns the canonical form of a tag: lower case, without
+// leading "+", with spaces, commas and slashes turned into dashes so tags
+// fit in URLs and comma-separated filters.
+func NormalizeTag(tag string) string {
+	tag = strings.TrimLeft(strings.TrimSpace(tag), "+")
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', ',', '/':
+			return '-'
+		}
+		return unicode.ToLower(r)
+	}, tag)
+}
+
+// CategoryTag returns the tag a category is copied into when a store moves
+// to tags, or false if it does not make a valid tag. The category itself is
+// kept either way.
+func CategoryTag(category string) (string, bool) {
+	tag := NormalizeTag(category)
+	return tag, validateTag(tag) == nil
+}
+
+// normalizeTags returns tags normalized, without duplicates and sorted,
+// or nil if there are none.
+func normalizeTags(tags []string) ([]string, error) {
+	var out []string
+	for _, tag := range tags {
+		tag = NormalizeTag(tag)
+		if err := validateTag(tag); err != nil {
+			return nil, err
+		}
+		if !slices.Contains(out, tag) {
+			out = append(out, tag)
+		}
+	}
+	if len(out) > MaxTags {
+		return nil, NewValidationError(FieldIssue{Field: "tags", Issue: "must have at most 20 entries"})
+	}
+	slices.Sort(out)
+	return out, nil
+}
+
+func validateTag(tag string) error {
+	if tag == "" || utf8.RuneCountInString(tag) > MaxTagLength || strings.IndexFunc(tag, unicode.IsSpace) >= 0 {
+		return ErrInvalidTag
+	}
+	return nil
+}
+
+// normalizeTagFilter normalizes the tags of a list filter, dropping empty
+// ones.
+func normalizeTagFilter(tags []string) []string {
+	var out []string
+	for _, tag := range tags {
+		if tag = NormalizeTag(tag); tag != "" {
+			out = append(out, tag)
+		}
+	}
+	return out
+}
+
+// ListTags counts the tags over all tasks of the owner, most used first.
+func (s Service) ListTags(ctx context.Context) ([]TagCount, error) {
+	page, err := s.repo.List(ctx, ListQuery{OwnerID: s.owner})
+	if err != nil {
+		return nil, err
+	}
+
+	counts := map[string]int{}
+	for _, t := range page.Items {
+		for _, tag := range t.Tags {
+			counts[tag]++
+		}
+	}
+
+	out := make([]TagCount, 0, len(counts))
+	for tag, n := range counts {
+		out = append(out, TagCount{Tag: tag, Count: n})
+	}
+	slices.SortFunc(out, func(a, b TagCount) int {
+		if a.Count != b.Count {
+			return b.Count - a.Count
+		}
+		return strings.Compare(a.Tag, b.Tag)
+	})
+	return out, nil
+}
+
+// RenameTag replaces tag from with to on every task of the owner, merging
+// it into to where a task already has both. It returns the number of tasks
+// changed.
+func (s Service) RenameTag(ctx context.Context, from, to string) (int, error) {
+	to = NormalizeTag(to)
+	if err := validateTag(to); err != nil {
+		return 0, NewValidationError(FieldIssue{Field: "name", Issue: ErrInvalidTag.Details[0].Issue})
+	}
+	return s.retag(ctx, from, func(tags []string) []string {
+		return append(tags, to)
+	})
+}
+
+// DeleteTag removes tag from every task of the owner and returns the number
+// of tasks changed.
+func (s Service) DeleteTag(ctx context.Context, tag string) (int, error) {
+	return s.retag(ctx, tag, func(tags []string) []string {
+		return tags
+	})
+}
+
+// retag rewrites the tags of every task carrying tag in one transaction:
+// edit gets the task's other tags and returns the new set.
+func (s Service) retag(ctx context.Context, tag string, edit func([]string) []string) (int, error) {
+	tag = NormalizeTag(tag)
+	if tag == "" {
+		return 0, ErrTagNotFound
+	}
+
+	n := 0
+	err := s.atomic(ctx, func(tx TaskStore) error {
+		page, err := tx.List(ctx, ListQuery{OwnerID: s.owner, TagsAll: []string{tag}})
+		if err != nil {
+			return err
+		}
+		if page.Total == 0 {
+			return ErrTagNotFound
+		}
+
+		for _, t := range page.Items {
+			others := slices.DeleteFunc(slices.Clone(t.Tags), func(x string) bool { return x == tag })
+			if t.Tags, err = normalizeTags(edit(others)); err != nil {
+				return err
+			}
+			t.UpdatedAt = time.Now()
+			if _, err := tx.Update(ctx, t); err != nil {
+				return err
+			}
+			n++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return n, nil
+}
@@ -4,12 +4,80 @@ import (
 	"time"
 )
 
-type Task struct{
+type Task struct {
 	ID int
-	Title string
+	// OwnerID is the v2 user owning the task; 0 marks v1 (single-user) tasks.
+	OwnerID  int
+	Title    string
 	Category *string
-	DueDate *time.Time
+	// Tags are normalized (see NormalizeTag), unique and sorted.
+	Tags      []string
+	DueDate   *time.Time
 	CreatedAt time.Time
 	UpdatedAt time.Time
-	IsDone bool
+	IsDone    bool
+	Priority  Priority
+	// ParentID is the task this one is a subtask of, nil for top-level tasks.
+	ParentID *int
+	// Recurrence, if set, makes completing the task create its next
+	// occurrence; see Service.UpdateTask.
+	Recurrence *Recurrence
+	// Reminders are fired by the server's reminder scheduler while the
+	// task is open; see Reminder.
+	Reminders []Reminder
+	// DeletedAt is set while the task is in the trash; see Service.Delete.
+	DeletedAt *time.Time
+	// Version starts at 1 and is incremented by the repo on every update.
+	Version int
+}
+
+// IsOverdue reports whether t is open and its due day, judged in now's
+// location (see DueDay), is before the day of now.
+func (t Task) IsOverdue(now time.Time) bool {
+	days, ok := t.DueIn(now)
+	return !t.IsDone && ok && days < 0
+}
+
+// StartOfDay returns midnight UTC of the day t falls on.
+func StartOfDay(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// CalendarDay returns the calendar date of t in its own location, as
+// midnight UTC, so dates from different zones compare and subtract.
+func CalendarDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// DueDay returns the day a task due at due is due in loc (UTC when nil),
+// as a CalendarDay. A date-only due date, stored as midnight UTC, keeps
+// its date in every zone; a due time falls on its local date in loc.
+func DueDay(due time.Time, loc *time.Location) time.Time {
+	if due.Equal(StartOfDay(due)) {
+		return due.UTC()
+	}
+	if loc == nil {
+		loc = time.UTC
+	}
+	return CalendarDay(due.In(loc))
+}
+
+// MidnightIn returns the instant day, a CalendarDay, starts in loc.
+func MidnightIn(day time.Time, loc *time.Location) time.Time {
+	if loc == nil {
+		loc = time.UTC
+	}
+	return time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, loc)
+}
+
+// DueIn returns how many days t's due day is from the day of now, both in
+// now's location: 0 for today, negative once it passed. It is false when t
+// has no due date.
+func (t Task) DueIn(now time.Time) (int, bool) {
+	if t.DueDate == nil {
+		return 0, false
+	}
+	due := DueDay(*t.DueDate, now.Location())
+	return int(due.Sub(CalendarDay(now)) / (24 * time.Hour)), true
 }
@@ -0,0 +1,181 @@
+package todo
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+)
+
+// ListTrash lists the owner's deleted tasks, most recently deleted first
+// unless q sorts otherwise.
+func (s Service) ListTrash(ctx context.Context, q ListQuery) (TaskPage, error) {
+	q.Trashed = true
+	return s.ListTask(ctx, q)
+}
+
+// Restore takes a task out of the trash, with the subtasks that were
+// deleted together with it. A task whose parent is no longer live becomes
+// top-level.
+func (s Service) Restore(ctx context.Context, id int) (Task, error) {
+	var restored Task
+	err := s.atomic(ctx, func(tx TaskStore) error {
+		task, err := s.getTrashed(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+
+		if task.ParentID != nil {
+			if _, err := s.getOwned(ctx, tx, *task.ParentID); errors.Is(err, ErrTaskNotFound) {
+				task.ParentID = nil
+			} else if err != nil {
+				return err
+			}
+		}
+
+		subtasks, err := s.descendants(ctx, tx, id, true)
+		if err != nil {
+			return err
+		}
+
+		deletedAt := *task.DeletedAt
+		if restored, err = restoreIn(ctx, tx, task); err != nil {
+			return err
+		}
+
+		// Walk parents before children, skipping subtrees deleted on
+		// their own
+		live := map[int]bool{id: true}
+		for _, t := range subtasks {
+			if !live[*t.ParentID] || !t.DeletedAt.Equal(deletedAt) {
+				continue
+			}
+			if _, err := restoreIn(ctx, tx, t); err != nil {
+				return err
+			}
+			live[t.ID] = true
+		}
+		return nil
+	})
+	if err != nil {
+		return Task{}, err
+	}
+	return restored, nil
+}
+
+// Purge permanently deletes a task in the trash and the subtasks in the
+// trash below it.
+func (s Service) Purge(ctx context.Context, id int) error {
+	return s.atomic(ctx, func(tx TaskStore) error {
+		if _, err := s.getTrashed(ctx, tx, id); err != nil {
+			return err
+		}
+
+		subtasks, err := s.descendants(ctx, tx, id, true)
+		if err != nil {
+			return err
+		}
+		for _, t := range subtasks {
+			if _, err := tx.Delete(ctx, t.ID); err != nil {
+				return err
+			}
+		}
+		_, err = tx.Delete(ctx, id)
+		return err
+	})
+}
+
+// EmptyTrash permanently deletes the owner's tasks deleted before before,
+// or all of them if before is zero, with the trashed subtasks below them,
+// and returns how many there were.
+func (s Service) EmptyTrash(ctx context.Context, before time.Time) (int, error) {
+	return s.purgeIn(ctx, ListQuery{OwnerID: s.owner, Trashed: true, DeletedBefore: before})
+}
+
+// PurgeExpired is EmptyTrash for every owner, for the automatic purge.
+func (s Service) PurgeExpired(ctx context.Context, before time.Time) (int, error) {
+	return s.purgeIn(ctx, ListQuery{AllOwners: true, Trashed: true, DeletedBefore: before})
+}
+
+// purgeIn permanently deletes the tasks matching q with the trashed tasks
+// below them, whenever those were deleted, so no subtask is left with a
+// parent that is gone. Live tasks below them become top-level.
+func (s Service) purgeIn(ctx context.Context, q ListQuery) (int, error) {
+	n := 0
+	err := s.atomic(ctx, func(tx TaskStore) error {
+		page, err := tx.List(ctx, q)
+		if err != nil {
+			return err
+		}
+
+		purged := make(map[int]bool)
+		var doomed []Task
+		for next := page.Items; len(next) > 0; {
+			t := next[0]
+			next = next[1:]
+			if purged[t.ID] {
+				continue
+			}
+			purged[t.ID] = true
+			doomed = append(doomed, t)
+
+			kids, err := tx.List(ctx, ListQuery{AllOwners: true, ParentID: Some(t.ID), Trashed: true})
+			if err != nil {
+				return err
+			}
+			next = append(next, kids.Items...)
+
+			live, err := tx.List(ctx, ListQuery{AllOwners: true, ParentID: Some(t.ID)})
+			if err != nil {
+				return err
+			}
+			for _, kid := range live.Items {
+				kid.ParentID = nil
+				kid.UpdatedAt = time.Now()
+				if _, err := tx.Update(ctx, kid); err != nil {
+					return err
+				}
+			}
+		}
+
+		// Children before their parents, as Purge does
+		for _, t := range slices.Backward(doomed) {
+			if _, err := tx.Delete(ctx, t.ID); err != nil {
+				return err
+			}
+		}
+		n = len(doomed)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// getTrashed is getOwned for tasks in the trash.
+func (s Service) getTrashed(ctx context.Context, store TaskStore, id int) (Task, error) {
+	task, err := store.GetByID(ctx, id)
+	if errors.Is(err, ErrTaskNotFound) {
+		return Task{}, ErrNotInTrash
+	}
+	if err != nil {
+		return Task{}, err
+	}
+	if task.OwnerID != s.owner || task.DeletedAt == nil {
+		return Task{}, ErrNotInTrash
+	}
+	return task, nil
+}
+
+func trashIn(ctx context.Context, tx TaskStore, t Task, now time.Time) (Task, error) {
+	t.DeletedAt = &now
+	t.UpdatedAt = now
+	return tx.Update(ctx, t)
+}
+
+func restoreIn(ctx context.Context, tx TaskStore, t Task) (Task, error) {
+	t.DeletedAt = nil
+	t.UpdatedAt = time.Now()
+	return tx.Update(ctx, t)
+}
@@ -0,0 +1,170 @@
+package transfer
+
+import (
+	"encoding/csv"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/Saintrad/todo-server-client/internal/todo"
+)
+
+// csvColumns is the header of exported CSV files. Imports match columns by
+// name, ignoring case and order; only title is required.
+var csvColumns = []string{"id", "title", "category", "tags", "due_date", "priority", "is_done", "repeat", "parent_id", "created_at", "updated_at"}
+
+func encodeCSV(w io.Writer, tasks []todo.Task) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvColumns); err != nil {
+		return err
+	}
+
+	for _, t := range tasks {
+		var category, due, repeat, parent string
+		if t.Category != nil {
+			category = *t.Category
+		}
+		if t.DueDate != nil {
+			due = formatDate(*t.DueDate)
+		}
+		if t.Recurrence != nil {
+			repeat = t.Recurrence.String()
+		}
+		if t.ParentID != nil {
+			parent = strconv.Itoa(*t.ParentID)
+		}
+
+		if err := cw.Write([]string{
+			strconv.Itoa(t.ID),
+			t.Title,
+			category,
+			strings.Join(t.Tags, " "),
+			due,
+			t.Priority.String(),
+			strconv.FormatBool(t.IsDone),
+			repeat,
+			parent,
+			t.CreatedAt.UTC().Format(time.RFC3339),
+			t.UpdatedAt.UTC().Format(time.RFC3339),
+		}); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// csvError reports a CSV syntax error as a bad file and any other error as
+// a failed read.
+func csvError(err error) error {
+	var pe *csv.ParseError
+	if errors.As(err, &pe) {
+		return badFile(err.Error())
+	}
+	return badRead(err)
+}
+
+// decodeCSV reads a CSV file with a header row. Row is the line a record
+// starts on, so the first task is row 2.
+func decodeCSV(r io.Reader) ([]todo.ImportRecord, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.LazyQuotes = true
+
+	header, err := cr.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, badFile("must have a header row")
+	}
+	if err != nil {
+		return nil, csvError(err)
+	}
+	cols := map[string]int{}
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if _, dup := cols[name]; !dup {
+			cols[name] = i
+		}
+	}
+	if _, ok := cols["title"]; !ok {
+		return nil, badFile("header must have a title column")
+	}
+
+	var recs []todo.ImportRecord
+	for {
+		fields, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			return recs, nil
+		}
+		if err != nil {
+			return nil, csvError(err)
+		}
+		line, _ := cr.FieldPos(0)
+
+		get := func(col string) string {
+			if i, ok := cols[col]; ok && i < len(fields) {
+				return strings.TrimSpace(fields[i])
+			}
+			return ""
+		}
+		recs = append(recs, csvRecord(line, get))
+	}
+}
+
+func csvRecord(row int, get func(col string) string) todo.ImportRecord {
+	rec := todo.ImportRecord{Row: row}
+	var issues []todo.FieldIssue
+
+	rec.Task.Title = get("title")
+	if s := get("category"); s != "" {
+		rec.Task.Category = &s
+	}
+	rec.Task.Tags = strings.FieldsFunc(get("tags"), func(r rune) bool {
+		return r == ',' || unicode.IsSpace(r)
+	})
+	if s := get("due_date"); s != "" {
+		if due, ok := parseDate(s); ok {
+			rec.Task.DueDate = &due
+		} else {
+			issues = append(issues, todo.FieldIssue{Field: "due_date", Issue: "must be a date (YYYY-MM-DD) or RFC 3339 time"})
+		}
+	}
+	if s := get("priority"); s != "" {
+		rec.Task.Priority = &s
+	}
+	if s := get("is_done"); s != "" {
+		done, err := strconv.ParseBool(s)
+		if err != nil {
+			issues = append(issues, todo.FieldIssue{Field: "is_done", Issue: "must be true or false"})
+		}
+		rec.IsDone = done
+	}
+	if s := get("repeat"); s != "" {
+		rec.Task.Repeat = &s
+	}
+	for _, f := range []struct {
+		col string
+		dst *int
+	}{{"id", &rec.Ref}, {"parent_id", &rec.ParentRef}} {
+		if s := get(f.col); s != "" {
+			n, err := strconv.Atoi(s)
+			if err != nil || n <= 0 {
+				issues = append(issues, todo.FieldIssue{Field: f.col, Issue: "must be a positive integer"})
+			}
+			*f.dst = n
+		}
+	}
+	if s := get("created_at"); s != "" {
+		if created, ok := parseDate(s); ok {
+			rec.CreatedAt = &created
+		} else {
+			issues = append(issues, todo.FieldIssue{Field: "created_at", Issue: "must be a date (YYYY-MM-DD) or RFC 3339 time"})
+		}
+	}
+
+	rec.Err = rowError(issues)
+	return rec
+}
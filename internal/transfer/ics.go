@@ -0,0 +1,104 @@
+package transfer
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Saintrad/todo-server-client/internal/todo"
+)
+
+// icsPriorities maps priorities to RFC 5545 PRIORITY values, where 1 is the
+// highest; PriorityNone is left out.
+var icsPriorities = map[todo.Priority]int{
+	todo.PriorityHigh:   1,
+	todo.PriorityMedium: 5,
+	todo.PriorityLow:    9,
+}
+
+// encodeICS writes tasks as an iCalendar (RFC 5545) calendar of VTODO
+// components. A task's UID is derived from its ID, so calendar clients
+// recognize it across refreshes, and DTSTAMP is its UpdatedAt, so the
+// output only changes when the tasks do.
+func encodeICS(w io.Writer, tasks []todo.Task) error {
+	bw := bufio.NewWriter(w)
+	line := func(name, value string) {
+		writeFolded(bw, name+":"+value)
+	}
+
+	line("BEGIN", "VCALENDAR")
+	line("VERSION", "2.0")
+	line("PRODID", "-//todo-server-client//Tasks//EN")
+	line("CALSCALE", "GREGORIAN")
+	for _, t := range tasks {
+		line("BEGIN", "VTODO")
+		line("UID", icsUID(t.ID))
+		line("DTSTAMP", icsTime(t.UpdatedAt))
+		line("CREATED", icsTime(t.CreatedAt))
+		line("LAST-MODIFIED", icsTime(t.UpdatedAt))
+		line("SUMMARY", icsText(t.Title))
+		if t.DueDate != nil {
+			// Date-only due dates are all-day
+			if due := t.DueDate.UTC(); due.Equal(todo.StartOfDay(due)) {
+				line("DUE;VALUE=DATE", due.Format("20060102"))
+			} else {
+				line("DUE", icsTime(due))
+			}
+		}
+		if t.IsDone {
+			line("STATUS", "COMPLETED")
+			line("COMPLETED", icsTime(t.UpdatedAt))
+		} else {
+			line("STATUS", "NEEDS-ACTION")
+		}
+		if p, ok := icsPriorities[t.Priority]; ok {
+			line("PRIORITY", strconv.Itoa(p))
+		}
+		if t.Category != nil && *t.Category != "" {
+			line("CATEGORIES", icsText(*t.Category))
+		}
+		if t.ParentID != nil {
+			line("RELATED-TO;RELTYPE=PARENT", icsUID(*t.ParentID))
+		}
+		line("END", "VTODO")
+	}
+	line("END", "VCALENDAR")
+	return bw.Flush()
+}
+
+func icsUID(id int) string {
+	return "task-" + strconv.Itoa(id) + "@todo-server-client"
+}
+
+func icsTime(t time.Time) string {
+	return t.UTC().Format("20060102T150405Z")
+}
+
+var icsEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`, "\r", `\n`)
+
+// icsText escapes a TEXT value.
+func icsText(s string) string {
+	return icsEscaper.Replace(s)
+}
+
+// writeFolded writes a content line, folded into lines of at most 75
+// octets without splitting UTF-8 characters, each ended by CRLF.
+func writeFolded(w *bufio.Writer, s string) {
+	limit := 75
+	for len(s) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		w.WriteString(s[:cut])
+		w.WriteString("\r\n ")
+		s = s[cut:]
+		// The leading space of continuation lines counts too
+		limit = 74
+	}
+	w.WriteString(s)
+	w.WriteString("\r\n")
+}
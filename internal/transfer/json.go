@@ -0,0 +1,129 @@
+package transfer
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/Saintrad/todo-server-client/internal/todo"
+)
+
+// jsonTask is a task in the JSON format: the fields of the API's task
+// objects that carry over to another server, so list responses can be
+// imported too.
+type jsonTask struct {
+	ID        int      `json:"id,omitempty"`
+	Title     string   `json:"title"`
+	Category  *string  `json:"category,omitempty"`
+	Tags      []string `json:"tags,omitempty"`
+	DueDate   *string  `json:"due_date,omitempty"`
+	IsDone    bool     `json:"is_done"`
+	ParentID  *int     `json:"parent_id,omitempty"`
+	Repeat    string   `json:"repeat,omitempty"`
+	Priority  string   `json:"priority,omitempty"`
+	Reminders []string `json:"reminders,omitempty"`
+	CreatedAt *string  `json:"created_at,omitempty"`
+	UpdatedAt *string  `json:"updated_at,omitempty"`
+}
+
+func encodeJSON(w io.Writer, tasks []todo.Task) error {
+	out := make([]jsonTask, 0, len(tasks))
+	for _, t := range tasks {
+		jt := jsonTask{
+			ID:        t.ID,
+			Title:     t.Title,
+			Category:  t.Category,
+			Tags:      t.Tags,
+			IsDone:    t.IsDone,
+			ParentID:  t.ParentID,
+			Priority:  t.Priority.String(),
+			CreatedAt: timeString(t.CreatedAt),
+			UpdatedAt: timeString(t.UpdatedAt),
+		}
+		if t.DueDate != nil {
+			jt.DueDate = timeString(*t.DueDate)
+		}
+		if t.Recurrence != nil {
+			jt.Repeat = t.Recurrence.String()
+		}
+		for _, r := range t.Reminders {
+			jt.Reminders = append(jt.Reminders, r.String())
+		}
+		out = append(out, jt)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
+
+func timeString(t time.Time) *string {
+	s := t.Format(time.RFC3339Nano)
+	return &s
+}
+
+// decodeJSON reads an array of tasks, or a list response with the tasks in
+// "items". Row is the position of a task in the array, from 1.
+func decodeJSON(r io.Reader) ([]todo.ImportRecord, error) {
+	raw, err := io.ReadAll(r)
+	if err != nil {
+		return nil, badRead(err)
+	}
+
+	var items []json.RawMessage
+	if trimmed := bytes.TrimSpace(raw); len(trimmed) > 0 && trimmed[0] == '{' {
+		var list struct {
+			Items []json.RawMessage `json:"items"`
+		}
+		if err := json.Unmarshal(trimmed, &list); err != nil {
+			return nil, badFile(err.Error())
+		}
+		items = list.Items
+	} else if err := json.Unmarshal(trimmed, &items); err != nil {
+		return nil, badFile("must be a JSON array of tasks")
+	}
+
+	recs := make([]todo.ImportRecord, 0, len(items))
+	for i, item := range items {
+		rec := todo.ImportRecord{Row: i + 1}
+
+		var jt jsonTask
+		if err := json.Unmarshal(item, &jt); err != nil {
+			rec.Err = todo.NewValidationError(todo.FieldIssue{Field: "task", Issue: err.Error()})
+			recs = append(recs, rec)
+			continue
+		}
+
+		var issues []todo.FieldIssue
+		rec.Ref = jt.ID
+		if jt.ParentID != nil {
+			rec.ParentRef = *jt.ParentID
+		}
+		rec.IsDone = jt.IsDone
+		rec.Task = todo.CreateTaskInput{Title: jt.Title, Category: jt.Category, Tags: jt.Tags, Reminders: jt.Reminders}
+		if jt.Priority != "" {
+			rec.Task.Priority = &jt.Priority
+		}
+		if jt.Repeat != "" {
+			rec.Task.Repeat = &jt.Repeat
+		}
+		if jt.DueDate != nil {
+			if due, ok := parseDate(*jt.DueDate); ok {
+				rec.Task.DueDate = &due
+			} else {
+				issues = append(issues, todo.FieldIssue{Field: "due_date", Issue: "must be a date (YYYY-MM-DD) or RFC 3339 time"})
+			}
+		}
+		if jt.CreatedAt != nil {
+			if created, err := time.Parse(time.RFC3339, *jt.CreatedAt); err == nil {
+				rec.CreatedAt = &created
+			} else {
+				issues = append(issues, todo.FieldIssue{Field: "created_at", Issue: "must be an RFC 3339 time"})
+			}
+		}
+		rec.Err = rowError(issues)
+		recs = append(recs, rec)
+	}
+	return recs, nil
+}
@@ -0,0 +1,198 @@
+package transfer
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Saintrad/todo-server-client/internal/todo"
+)
+
+// todo.txt lines map to tasks like this:
+//
+//	x 2026-03-02 2026-02-20 Pay rent +home @errands due:2026-03-01 pri:A
+//	(B) 2026-02-20 Call Bob @phone rec:weekly id:7
+//
+// "x" and the completion date mark a done task; a priority (A) is high,
+// (B) medium and (C) to (Z) low, kept as pri: on done tasks; the creation
+// date is CreatedAt. +project tokens are tags and the first @context is the
+// category, with spaces turned into underscores. due:, rec: (the repeat
+// rule), id: and parent: carry the other fields. The completion date is
+// exported from UpdatedAt and ignored on import.
+
+var todoTxtPriorities = map[todo.Priority]string{
+	todo.PriorityHigh:   "A",
+	todo.PriorityMedium: "B",
+	todo.PriorityLow:    "C",
+}
+
+func encodeTodoTxt(w io.Writer, tasks []todo.Task) error {
+	// id: is only written for parents of exported tasks, and parent: only
+	// when the parent is exported too.
+	exported := map[int]bool{}
+	for _, t := range tasks {
+		exported[t.ID] = true
+	}
+	parents := map[int]bool{}
+	for _, t := range tasks {
+		if t.ParentID != nil && exported[*t.ParentID] {
+			parents[*t.ParentID] = true
+		}
+	}
+
+	bw := bufio.NewWriter(w)
+	for _, t := range tasks {
+		var parts []string
+		pri, hasPri := todoTxtPriorities[t.Priority]
+
+		if t.IsDone {
+			parts = append(parts, "x", t.UpdatedAt.UTC().Format(time.DateOnly))
+		} else if hasPri {
+			parts = append(parts, "("+pri+")")
+		}
+		parts = append(parts, t.CreatedAt.UTC().Format(time.DateOnly), t.Title)
+
+		for _, tag := range t.Tags {
+			parts = append(parts, "+"+tag)
+		}
+		if t.Category != nil && strings.TrimSpace(*t.Category) != "" {
+			parts = append(parts, "@"+strings.Join(strings.Fields(*t.Category), "_"))
+		}
+		if t.DueDate != nil {
+			parts = append(parts, "due:"+formatDate(*t.DueDate))
+		}
+		if t.Recurrence != nil {
+			parts = append(parts, "rec:"+t.Recurrence.String())
+		}
+		if t.IsDone && hasPri {
+			parts = append(parts, "pri:"+pri)
+		}
+		if parents[t.ID] {
+			parts = append(parts, "id:"+strconv.Itoa(t.ID))
+		}
+		if t.ParentID != nil && exported[*t.ParentID] {
+			parts = append(parts, "parent:"+strconv.Itoa(*t.ParentID))
+		}
+
+		if _, err := fmt.Fprintln(bw, strings.Join(parts, " ")); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// decodeTodoTxt reads one task per non-blank line. Row is the line number.
+func decodeTodoTxt(r io.Reader) ([]todo.ImportRecord, error) {
+	var recs []todo.ImportRecord
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for line := 1; sc.Scan(); line++ {
+		if strings.TrimSpace(sc.Text()) == "" {
+			continue
+		}
+		recs = append(recs, todoTxtRecord(line, sc.Text()))
+	}
+	switch err := sc.Err(); {
+	case errors.Is(err, bufio.ErrTooLong):
+		return nil, badFile(err.Error())
+	case err != nil:
+		return nil, badRead(err)
+	}
+	return recs, nil
+}
+
+func todoTxtRecord(row int, line string) todo.ImportRecord {
+	rec := todo.ImportRecord{Row: row}
+	var issues []todo.FieldIssue
+	tokens := strings.Fields(line)
+
+	isDate := func(i int) bool {
+		if i >= len(tokens) {
+			return false
+		}
+		_, err := time.Parse(time.DateOnly, tokens[i])
+		return err == nil
+	}
+
+	// Leading "x [completed] [created]" or "(A) [created]"
+	if tokens[0] == "x" {
+		rec.IsDone = true
+		tokens = tokens[1:]
+		if isDate(0) {
+			tokens = tokens[1:]
+		}
+	} else if len(tokens[0]) == 3 && tokens[0][0] == '(' && tokens[0][2] == ')' {
+		if p, ok := todoTxtPriority(tokens[0][1:2]); ok {
+			rec.Task.Priority = &p
+			tokens = tokens[1:]
+		}
+	}
+	if isDate(0) {
+		created, _ := time.Parse(time.DateOnly, tokens[0])
+		rec.CreatedAt = &created
+		tokens = tokens[1:]
+	}
+
+	var words []string
+	for _, tok := range tokens {
+		key, value, hasValue := strings.Cut(tok, ":")
+		switch {
+		case len(tok) > 1 && tok[0] == '+':
+			rec.Task.Tags = append(rec.Task.Tags, tok[1:])
+		case len(tok) > 1 && tok[0] == '@' && rec.Task.Category == nil:
+			category := tok[1:]
+			rec.Task.Category = &category
+		case !hasValue || value == "":
+			words = append(words, tok)
+		case key == "due":
+			if due, ok := parseDate(value); ok {
+				rec.Task.DueDate = &due
+			} else {
+				issues = append(issues, todo.FieldIssue{Field: "due", Issue: "must be a date (YYYY-MM-DD) or RFC 3339 time"})
+			}
+		case key == "rec":
+			rec.Task.Repeat = &value
+		case key == "pri":
+			if p, ok := todoTxtPriority(value); ok {
+				rec.Task.Priority = &p
+			} else {
+				issues = append(issues, todo.FieldIssue{Field: "pri", Issue: "must be a letter from A to Z"})
+			}
+		case key == "id" || key == "parent":
+			n, err := strconv.Atoi(value)
+			if err != nil || n <= 0 {
+				issues = append(issues, todo.FieldIssue{Field: key, Issue: "must be a positive integer"})
+			}
+			if key == "id" {
+				rec.Ref = n
+			} else {
+				rec.ParentRef = n
+			}
+		default:
+			words = append(words, tok)
+		}
+	}
+	rec.Task.Title = strings.Join(words, " ")
+
+	rec.Err = rowError(issues)
+	return rec
+}
+
+// todoTxtPriority maps a todo.txt priority letter to a priority name.
+func todoTxtPriority(letter string) (string, bool) {
+	if len(letter) != 1 || letter[0] < 'A' || letter[0] > 'Z' {
+		return "", false
+	}
+	switch letter {
+	case "A":
+		return todo.PriorityHigh.String(), true
+	case "B":
+		return todo.PriorityMedium.String(), true
+	default:
+		return todo.PriorityLow.String(), true
+	}
+}
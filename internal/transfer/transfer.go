@@ -0,0 +1,168 @@
+// Package transfer reads and writes tasks in file formats for import and
+// export: the API's JSON, CSV and todo.txt, plus iCalendar for export only.
+package transfer
+
+import (
+	"io"
+	"mime"
+	"strings"
+	"time"
+
+	"github.com/Saintrad/todo-server-client/internal/todo"
+)
+
+type Format string
+
+const (
+	FormatJSON    Format = "json"
+	FormatCSV     Format = "csv"
+	FormatTodoTxt Format = "todotxt"
+	FormatICS     Format = "ics"
+)
+
+var (
+	ErrInvalidFormat = todo.NewValidationError(todo.FieldIssue{Field: "format", Issue: "must be json, csv, todotxt or ics"})
+	ErrExportOnly    = todo.NewValidationError(todo.FieldIssue{Field: "format", Issue: "ics can only be exported"})
+)
+
+// ParseFormat parses a format name. "todo.txt" and "txt" are accepted for
+// todo.txt, "ical" and "icalendar" for ics.
+func ParseFormat(s string) (Format, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "json":
+		return FormatJSON, nil
+	case "csv":
+		return FormatCSV, nil
+	case "todotxt", "todo.txt", "txt":
+		return FormatTodoTxt, nil
+	case "ics", "ical", "icalendar":
+		return FormatICS, nil
+	default:
+		return "", ErrInvalidFormat
+	}
+}
+
+// FormatOfMediaType returns the format of a Content-Type, if it names one.
+func FormatOfMediaType(contentType string) (Format, bool) {
+	mt, _, _ := mime.ParseMediaType(contentType)
+	switch mt {
+	case "application/json":
+		return FormatJSON, true
+	case "text/csv":
+		return FormatCSV, true
+	case "text/plain":
+		return FormatTodoTxt, true
+	case "text/calendar":
+		return FormatICS, true
+	default:
+		return "", false
+	}
+}
+
+// ContentType is the media type files of f are served with.
+func (f Format) ContentType() string {
+	switch f {
+	case FormatJSON:
+		return "application/json"
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatICS:
+		return "text/calendar; charset=utf-8"
+	default:
+		return "text/plain; charset=utf-8"
+	}
+}
+
+// Ext is the usual file name extension of f, with the dot.
+func (f Format) Ext() string {
+	switch f {
+	case FormatJSON:
+		return ".json"
+	case FormatCSV:
+		return ".csv"
+	case FormatICS:
+		return ".ics"
+	default:
+		return ".txt"
+	}
+}
+
+// Encode writes tasks to w in format f.
+func Encode(w io.Writer, f Format, tasks []todo.Task) error {
+	switch f {
+	case FormatJSON:
+		return encodeJSON(w, tasks)
+	case FormatCSV:
+		return encodeCSV(w, tasks)
+	case FormatTodoTxt:
+		return encodeTodoTxt(w, tasks)
+	case FormatICS:
+		return encodeICS(w, tasks)
+	default:
+		return ErrInvalidFormat
+	}
+}
+
+// Decode reads the records of a file in format f, which must not be ics.
+// A row that cannot be read becomes a record with Err set; only a file that
+// cannot be read at all is an error, a validation error on the "body" field.
+func Decode(r io.Reader, f Format) ([]todo.ImportRecord, error) {
+	switch f {
+	case FormatJSON:
+		return decodeJSON(r)
+	case FormatCSV:
+		return decodeCSV(r)
+	case FormatTodoTxt:
+		return decodeTodoTxt(r)
+	case FormatICS:
+		return nil, ErrExportOnly
+	default:
+		return nil, ErrInvalidFormat
+	}
+}
+
+// badFile reports a file that cannot be read.
+func badFile(issue string) error {
+	de := todo.NewValidationError(todo.FieldIssue{Field: "body", Issue: issue})
+	de.Message = "invalid import file"
+	return de
+}
+
+// badRead reports a file whose reader failed, keeping err as the cause so
+// the transport can tell a body that was too large.
+func badRead(err error) error {
+	de := todo.NewValidationError(todo.FieldIssue{Field: "body", Issue: "could not be read"})
+	de.Message = "invalid import file"
+	de.Err = err
+	return de
+}
+
+// formatDate renders a due date as a plain date when it is midnight UTC,
+// like the dates the clients send, and as RFC 3339 otherwise.
+func formatDate(t time.Time) string {
+	t = t.UTC()
+	if t.Equal(todo.StartOfDay(t)) {
+		return t.Format(time.DateOnly)
+	}
+	return t.Format(time.RFC3339)
+}
+
+// parseDate reads a plain date (midnight UTC) or an RFC 3339 time.
+func parseDate(s string) (time.Time, bool) {
+	if t, err := time.Parse(time.DateOnly, s); err == nil {
+		return t, true
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, true
+	}
+	return time.Time{}, false
+}
+
+// rowError builds the error of a row from its field issues, nil if there
+// are none.
+func rowError(issues []todo.FieldIssue) error {
+	if len(issues) == 0 {
+		return nil
+	}
+	return todo.NewValidationError(issues...)
+}
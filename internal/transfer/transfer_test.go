@@ -0,0 +1,154 @@
+package transfer
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"github.com/Saintrad/todo-server-client/internal/todo"
+)
+
+func strPtr(s string) *string {
+	return &s
+}
+
+func TestRoundTrip(t *testing.T) {
+	due := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	created := time.Date(2026, 2, 20, 9, 30, 0, 0, time.UTC)
+	weekly, _ := todo.ParseRecurrence("weekly:mon")
+	parentID := 1
+	tasks := []todo.Task{
+		{ID: 1, Title: "paint fence", Category: strPtr("home"), Tags: []string{"diy"}, DueDate: &due, Priority: todo.PriorityHigh, Recurrence: &weekly, CreatedAt: created, UpdatedAt: created},
+		{ID: 2, Title: "buy paint", ParentID: &parentID, IsDone: true, Priority: todo.PriorityMedium, CreatedAt: created, UpdatedAt: created},
+	}
+
+	for _, f := range []Format{FormatJSON, FormatCSV, FormatTodoTxt} {
+		var buf bytes.Buffer
+		if err := Encode(&buf, f, tasks); err != nil {
+			t.Fatalf("%s: expected no errors, got %v", f, err)
+		}
+		recs, err := Decode(&buf, f)
+		if err != nil {
+			t.Fatalf("%s: expected no errors, got %v", f, err)
+		}
+
+		// Check every format carries the fields back
+		if len(recs) != 2 {
+			t.Fatalf("%s: expected 2 records, got %+v", f, recs)
+		}
+		a, b := recs[0], recs[1]
+		if a.Err != nil || b.Err != nil {
+			t.Fatalf("%s: expected no row errors, got %v and %v", f, a.Err, b.Err)
+		}
+		if a.Task.Title != "paint fence" || *a.Task.Category != "home" || len(a.Task.Tags) != 1 || a.Task.Tags[0] != "diy" {
+			t.Fatalf("%s: expected title, category and tags, got %+v", f, a.Task)
+		}
+		if a.Task.DueDate == nil || !a.Task.DueDate.Equal(due) || *a.Task.Priority != "high" || *a.Task.Repeat != "weekly:mon" {
+			t.Fatalf("%s: expected due date, priority and repeat, got %+v", f, a.Task)
+		}
+		if a.CreatedAt == nil || a.CreatedAt.Format(time.DateOnly) != "2026-02-20" {
+			t.Fatalf("%s: expected creation date, got %v", f, a.CreatedAt)
+		}
+		if !b.IsDone || *b.Task.Priority != "medium" || b.ParentRef != a.Ref || a.Ref == 0 {
+			t.Fatalf("%s: expected done subtask of the first record, got %+v", f, b)
+		}
+	}
+}
+
+func TestDecodeTodoTxt(t *testing.T) {
+	in := "(C) 2026-02-20 Call Bob about +garden @phone @home due:2026-03-01 see http://x.test\n\nx 2026-03-02 Pay rent due:soon\n"
+	recs, err := Decode(strings.NewReader(in), FormatTodoTxt)
+	if err != nil {
+		t.Fatalf("expected no errors, got %v", err)
+	}
+
+	// Check unknown tokens and further contexts stay in the title
+	a := recs[0]
+	if a.Row != 1 || a.Task.Title != "Call Bob about @home see http://x.test" || *a.Task.Category != "phone" || *a.Task.Priority != "low" {
+		t.Fatalf("unexpected record %+v", a)
+	}
+
+	// Check a bad value fails only its row, reported by line
+	if recs[1].Row != 3 || !recs[1].IsDone || todo.CodeOf(recs[1].Err) != todo.CodeValidation {
+		t.Fatalf("expected row 3 to fail validation, got %+v", recs[1])
+	}
+}
+
+func TestDecodeCSV(t *testing.T) {
+	// Check columns are matched by name and only title is required
+	recs, err := Decode(strings.NewReader("Title,is_done\nwater plants,yes\n\"multi\nline\",true\n"), FormatCSV)
+	if err != nil {
+		t.Fatalf("expected no errors, got %v", err)
+	}
+	if len(recs) != 2 || recs[0].Row != 2 || todo.CodeOf(recs[0].Err) != todo.CodeValidation {
+		t.Fatalf("expected row 2 to fail validation, got %+v", recs)
+	}
+	if recs[1].Row != 3 || recs[1].Task.Title != "multi\nline" || !recs[1].IsDone {
+		t.Fatalf("unexpected record %+v", recs[1])
+	}
+
+	// Check a file without a title column is rejected
+	if _, err := Decode(strings.NewReader("name\nx\n"), FormatCSV); todo.CodeOf(err) != todo.CodeValidation {
+		t.Fatalf("expected code %s, got %v", todo.CodeValidation, err)
+	}
+}
+
+func TestDecodeReadError(t *testing.T) {
+	cause := errors.New("connection reset")
+	for _, f := range []Format{FormatJSON, FormatCSV, FormatTodoTxt} {
+		// Check a failing reader is a bad file that keeps the cause
+		_, err := Decode(iotest.ErrReader(cause), f)
+		if todo.CodeOf(err) != todo.CodeValidation || !errors.Is(err, cause) {
+			t.Fatalf("%s: expected a validation error caused by %v, got %v", f, cause, err)
+		}
+	}
+}
+
+func TestEncodeICS(t *testing.T) {
+	due := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	updated := time.Date(2026, 2, 21, 8, 0, 0, 0, time.UTC)
+	parentID := 1
+	tasks := []todo.Task{
+		{ID: 1, Title: "Plan trip; book hotel, car " + strings.Repeat("ä", 40), Category: strPtr("travel"), DueDate: &due, Priority: todo.PriorityHigh, CreatedAt: updated, UpdatedAt: updated},
+		{ID: 2, Title: "pack", ParentID: &parentID, IsDone: true, CreatedAt: updated, UpdatedAt: updated},
+	}
+
+	var buf bytes.Buffer
+	if err := Encode(&buf, FormatICS, tasks); err != nil {
+		t.Fatalf("expected no errors, got %v", err)
+	}
+	out := buf.String()
+
+	// Check lines end in CRLF and are folded at 75 octets
+	for _, line := range strings.Split(strings.TrimSuffix(out, "\r\n"), "\r\n") {
+		if len(line) > 75 || strings.Contains(line, "\n") {
+			t.Fatalf("expected folded CRLF lines, got %q", line)
+		}
+	}
+
+	// Check the fields, with the title escaped
+	unfolded := strings.ReplaceAll(out, "\r\n ", "")
+	for _, want := range []string{
+		"UID:task-1@todo-server-client\r\n",
+		`SUMMARY:Plan trip\; book hotel\, car ä`,
+		"DUE;VALUE=DATE:20260301\r\n",
+		"STATUS:NEEDS-ACTION\r\n",
+		"PRIORITY:1\r\n",
+		"CATEGORIES:travel\r\n",
+		"LAST-MODIFIED:20260221T080000Z\r\n",
+		"STATUS:COMPLETED\r\n",
+		"RELATED-TO;RELTYPE=PARENT:task-1@todo-server-client\r\n",
+	} {
+		if !strings.Contains(unfolded, want) {
+			t.Fatalf("expected %q in %q", want, unfolded)
+		}
+	}
+
+	// Check ics cannot be imported
+	if _, err := Decode(strings.NewReader(out), FormatICS); err != ErrExportOnly {
+		t.Fatalf("expected error %v, got %v", ErrExportOnly, err)
+	}
+}
@@ -0,0 +1,347 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/Saintrad/todo-server-client/internal/todo"
+)
+
+const (
+	DefaultWorkers     = 4
+	DefaultMaxAttempts = 6
+	DefaultBackoff     = 10 * time.Second
+	maxBackoff         = time.Hour
+	// eventQueue is how many events may wait for the fan-out before new
+	// ones are dropped.
+	eventQueue = 1000
+)
+
+// Headers of a delivery.
+const (
+	HeaderEvent     = "X-Todo-Event"
+	HeaderDelivery  = "X-Todo-Delivery"
+	HeaderSignature = "X-Todo-Signature"
+)
+
+// Encoder turns a task change into a delivery body.
+type Encoder func(todo.Event, todo.Task) ([]byte, error)
+
+var _ todo.Publisher = (*Dispatcher)(nil)
+
+// Dispatcher delivers published task events to the matching webhooks of
+// their owner. Deliveries are made asynchronously by a pool of workers.
+// Each POST body is signed with the webhook secret:
+//
+//	X-Todo-Signature: sha256=hex(HMAC-SHA256(secret, body))
+//
+// A failed delivery (no 2xx response) is retried after backoff, doubling
+// each time, up to maxAttempts attempts. Every attempt is logged in the
+// repo.
+type Dispatcher struct {
+	repo        Repo
+	encode      Encoder
+	client      *http.Client
+	workers     int
+	maxAttempts int
+	backoff     time.Duration
+	log         *slog.Logger
+
+	events chan eventJob
+	jobs   chan job
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu     sync.Mutex
+	closed bool
+}
+
+type eventJob struct {
+	e todo.Event
+	t todo.Task
+}
+
+// job is one delivery attempt.
+type job struct {
+	hook    Webhook
+	key     string
+	event   todo.Event
+	body    []byte
+	attempt int
+}
+
+type DispatcherOption func(*Dispatcher)
+
+// WithWorkers sets how many deliveries run at once.
+func WithWorkers(n int) DispatcherOption {
+	return func(d *Dispatcher) { d.workers = n }
+}
+
+// WithRetries sets the number of attempts per delivery and the delay
+// before the first retry.
+func WithRetries(maxAttempts int, backoff time.Duration) DispatcherOption {
+	return func(d *Dispatcher) { d.maxAttempts, d.backoff = maxAttempts, backoff }
+}
+
+// WithHTTPClient sets the client making the deliveries.
+func WithHTTPClient(c *http.Client) DispatcherOption {
+	return func(d *Dispatcher) { d.client = c }
+}
+
+func WithLogger(l *slog.Logger) DispatcherOption {
+	return func(d *Dispatcher) { d.log = l }
+}
+
+// NewDispatcher starts the workers; stop them with Close.
+func NewDispatcher(repo Repo, encode Encoder, opts ...DispatcherOption) *Dispatcher {
+	d := &Dispatcher{
+		repo:        repo,
+		encode:      encode,
+		client:      &http.Client{Timeout: 10 * time.Second},
+		workers:     DefaultWorkers,
+		maxAttempts: DefaultMaxAttempts,
+		backoff:     DefaultBackoff,
+		log:         slog.Default(),
+		events:      make(chan eventJob, eventQueue),
+		jobs:        make(chan job),
+	}
+	for _, opt := range opts {
+		opt(d)
+	}
+	d.workers = max(d.workers, 1)
+	d.maxAttempts = max(d.maxAttempts, 1)
+	d.ctx, d.cancel = context.WithCancel(context.Background())
+
+	d.wg.Add(1 + d.workers)
+	go d.fanOut()
+	for range d.workers {
+		go d.work()
+	}
+	return d
+}
+
+// Publish queues an event for delivery without blocking. If the queue is
+// full the event is dropped and logged.
+func (d *Dispatcher) Publish(e todo.Event, t todo.Task) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.closed {
+		return
+	}
+	select {
+	case d.events <- eventJob{e, t}:
+	default:
+		d.log.Warn("webhook queue full, event dropped", "task", e.TaskID, "event", e.Kind)
+	}
+}
+
+// Close stops the workers, aborting deliveries in flight. Queued events and
+// pending retries are dropped.
+func (d *Dispatcher) Close() {
+	d.mu.Lock()
+	if d.closed {
+		d.mu.Unlock()
+		return
+	}
+	d.closed = true
+	d.mu.Unlock()
+
+	d.cancel()
+	d.wg.Wait()
+}
+
+// fanOut turns events into a delivery per matching webhook.
+func (d *Dispatcher) fanOut() {
+	defer d.wg.Done()
+
+	for {
+		var ev eventJob
+		select {
+		case <-d.ctx.Done():
+			return
+		case ev = <-d.events:
+		}
+
+		hooks, err := d.repo.ListWebhooks(d.ctx, ev.e.OwnerID)
+		if err != nil {
+			if d.ctx.Err() == nil {
+				d.log.Error("list webhooks", "owner", ev.e.OwnerID, "err", err)
+			}
+			continue
+		}
+
+		var body []byte
+		for _, h := range hooks {
+			if !h.Wants(ev.e.Kind) {
+				continue
+			}
+			if body == nil {
+				if body, err = d.encode(ev.e, ev.t); err != nil {
+					d.log.Error("encode webhook event", "task", ev.e.TaskID, "err", err)
+					break
+				}
+			}
+			d.enqueue(job{hook: h, key: newKey(), event: ev.e, body: body, attempt: 1})
+		}
+	}
+}
+
+// enqueue hands j to a worker, giving up when the dispatcher is closed.
+func (d *Dispatcher) enqueue(j job) {
+	select {
+	case d.jobs <- j:
+	case <-d.ctx.Done():
+	}
+}
+
+func (d *Dispatcher) work() {
+	defer d.wg.Done()
+
+	for {
+		select {
+		case <-d.ctx.Done():
+			return
+		case j := <-d.jobs:
+			d.deliver(j)
+		}
+	}
+}
+
+// deliver makes one attempt, logs it and schedules a retry if it failed.
+// The webhook is read again first, so a retry goes to its current URL with
+// its current secret, and stops once it is deleted or no longer wants the
+// event.
+func (d *Dispatcher) deliver(j job) {
+	hook, err := d.repo.GetWebhook(d.ctx, j.hook.ID)
+	switch {
+	case errors.Is(err, ErrWebhookNotFound):
+		return
+	case err != nil:
+		if d.ctx.Err() == nil {
+			d.log.Error("load webhook", "webhook", j.hook.ID, "err", err)
+			d.retry(j)
+		}
+		return
+	case !hook.Wants(j.event.Kind):
+		return
+	}
+	j.hook = hook
+
+	del := Delivery{
+		WebhookID: j.hook.ID,
+		Key:       j.key,
+		Event:     j.event.Kind,
+		TaskID:    j.event.TaskID,
+		Attempt:   j.attempt,
+		At:        time.Now(),
+	}
+
+	status, err := d.post(j)
+	del.Duration = time.Since(del.At)
+	del.StatusCode = status
+	if err != nil {
+		if d.ctx.Err() != nil {
+			return
+		}
+		del.Error = err.Error()
+	} else if !del.OK() {
+		del.Error = http.StatusText(status)
+	}
+
+	_, err = d.repo.AppendDelivery(d.ctx, del)
+	switch {
+	case errors.Is(err, ErrWebhookNotFound):
+		// Deleted meanwhile: stop retrying
+		return
+	case err != nil && d.ctx.Err() == nil:
+		d.log.Error("log webhook delivery", "webhook", j.hook.ID, "err", err)
+	}
+
+	if del.OK() || j.attempt >= d.maxAttempts {
+		if !del.OK() {
+			d.log.Warn("webhook delivery failed", "webhook", j.hook.ID, "attempts", j.attempt, "err", del.Error)
+		}
+		return
+	}
+
+	d.retry(j)
+}
+
+// retry queues the next attempt of j after its backoff, unless it was the
+// last one.
+func (d *Dispatcher) retry(j job) {
+	if j.attempt >= d.maxAttempts {
+		return
+	}
+	delay := d.delay(j.attempt)
+	j.attempt++
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+
+		t := time.NewTimer(delay)
+		defer t.Stop()
+		select {
+		case <-t.C:
+			d.enqueue(j)
+		case <-d.ctx.Done():
+		}
+	}()
+}
+
+// delay returns how long to wait after a failed attempt: the backoff,
+// doubled for every attempt before it, at most maxBackoff.
+func (d *Dispatcher) delay(attempt int) time.Duration {
+	delay := d.backoff
+	for i := 1; i < attempt && delay < maxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, maxBackoff)
+}
+
+// post sends the body of j and returns the response status.
+func (d *Dispatcher) post(j job) (int, error) {
+	req, err := http.NewRequestWithContext(d.ctx, http.MethodPost, j.hook.URL, bytes.NewReader(j.body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "todo-server-webhook")
+	req.Header.Set(HeaderEvent, string(j.event.Kind))
+	req.Header.Set(HeaderDelivery, j.key)
+	req.Header.Set(HeaderSignature, Sign(j.hook.Secret, j.body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	// Drain so the connection can be reused
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	return resp.StatusCode, nil
+}
+
+// Sign returns the X-Todo-Signature value of body.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func newKey() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
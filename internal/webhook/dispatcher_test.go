@@ -0,0 +1,339 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Saintrad/todo-server-client/internal/todo"
+)
+
+type fakeRepo struct {
+	mu         sync.Mutex
+	hooks      []Webhook
+	deliveries []Delivery
+}
+
+func (r *fakeRepo) CreateWebhook(ctx context.Context, w Webhook) (Webhook, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	w.ID = len(r.hooks) + 1
+	r.hooks = append(r.hooks, w)
+	return w, nil
+}
+
+func (r *fakeRepo) ListWebhooks(ctx context.Context, ownerID int) ([]Webhook, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var out []Webhook
+	for _, w := range r.hooks {
+		if w.OwnerID == ownerID {
+			out = append(out, w)
+		}
+	}
+	return out, nil
+}
+
+func (r *fakeRepo) GetWebhook(ctx context.Context, id int) (Webhook, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, w := range r.hooks {
+		if w.ID == id {
+			return w, nil
+		}
+	}
+	return Webhook{}, ErrWebhookNotFound
+}
+
+func (r *fakeRepo) UpdateWebhook(ctx context.Context, w Webhook) (Webhook, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.hooks {
+		if r.hooks[i].ID == w.ID {
+			r.hooks[i] = w
+			return w, nil
+		}
+	}
+	return Webhook{}, ErrWebhookNotFound
+}
+
+func (r *fakeRepo) DeleteWebhook(ctx context.Context, id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.hooks {
+		if r.hooks[i].ID == id {
+			r.hooks = append(r.hooks[:i], r.hooks[i+1:]...)
+			return nil
+		}
+	}
+	return ErrWebhookNotFound
+}
+
+func (r *fakeRepo) AppendDelivery(ctx context.Context, d Delivery) (Delivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	d.ID = len(r.deliveries) + 1
+	r.deliveries = append(r.deliveries, d)
+	return d, nil
+}
+
+func (r *fakeRepo) ListDeliveries(ctx context.Context, webhookID int) ([]Delivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var out []Delivery
+	for i := len(r.deliveries) - 1; i >= 0; i-- {
+		if r.deliveries[i].WebhookID == webhookID {
+			out = append(out, r.deliveries[i])
+		}
+	}
+	return out, nil
+}
+
+func (r *fakeRepo) Close() error { return nil }
+
+func encodeJSON(e todo.Event, t todo.Task) ([]byte, error) {
+	return json.Marshal(map[string]any{"kind": e.Kind, "task_id": e.TaskID})
+}
+
+// waitFor polls cond until it holds or a second has passed.
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+
+	for deadline := time.Now().Add(time.Second); !cond(); {
+		if time.Now().After(deadline) {
+			t.Fatalf("condition not met in time")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestDispatcherDelivers(t *testing.T) {
+	type request struct {
+		header http.Header
+		body   []byte
+	}
+	received := make(chan request, 10)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received <- request{r.Header, body}
+	}))
+	defer ts.Close()
+
+	repo := &fakeRepo{}
+	svc := NewService(repo)
+	hook, err := svc.Create(t.Context(), 1, CreateInput{URL: ts.URL, Events: []string{"Completed"}, Secret: "s3cret"})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	// Another owner's webhook gets nothing
+	svc.Create(t.Context(), 2, CreateInput{URL: ts.URL})
+
+	d := NewDispatcher(repo, encodeJSON)
+	defer d.Close()
+
+	d.Publish(todo.Event{TaskID: 7, OwnerID: 1, Kind: todo.EventCreated}, todo.Task{ID: 7})
+	d.Publish(todo.Event{TaskID: 7, OwnerID: 1, Kind: todo.EventCompleted}, todo.Task{ID: 7})
+
+	// Check only the subscribed event arrives, signed
+	var req request
+	select {
+	case req = <-received:
+	case <-time.After(time.Second):
+		t.Fatalf("expected a delivery")
+	}
+	if req.header.Get(HeaderEvent) != "completed" || string(req.body) != `{"kind":"completed","task_id":7}` {
+		t.Fatalf("unexpected delivery %v %s", req.header, req.body)
+	}
+	if got, want := req.header.Get(HeaderSignature), Sign("s3cret", req.body); got != want || len(want) != len("sha256=")+64 {
+		t.Fatalf("expected signature %s, got %s", want, got)
+	}
+
+	// Check the attempt is logged
+	waitFor(t, func() bool {
+		log, _ := svc.Deliveries(t.Context(), 1, hook.ID)
+		return len(log) == 1 && log[0].OK() && log[0].Key == req.header.Get(HeaderDelivery)
+	})
+	select {
+	case req := <-received:
+		t.Fatalf("unexpected delivery %s", req.body)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestDispatcherRetries(t *testing.T) {
+	var mu sync.Mutex
+	var keys []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		keys = append(keys, r.Header.Get(HeaderDelivery))
+		if len(keys) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer ts.Close()
+
+	repo := &fakeRepo{}
+	hook, _ := NewService(repo).Create(t.Context(), 0, CreateInput{URL: ts.URL})
+
+	d := NewDispatcher(repo, encodeJSON, WithRetries(5, time.Millisecond), WithWorkers(2))
+	defer d.Close()
+	d.Publish(todo.Event{TaskID: 1, Kind: todo.EventUpdated}, todo.Task{ID: 1})
+
+	// Check it succeeds on the third attempt, with the same key
+	waitFor(t, func() bool {
+		log, _ := repo.ListDeliveries(t.Context(), hook.ID)
+		return len(log) == 3 && log[0].OK()
+	})
+	log, _ := repo.ListDeliveries(t.Context(), hook.ID)
+	if log[2].Attempt != 1 || log[2].StatusCode != 503 || log[2].Error == "" || log[0].Attempt != 3 {
+		t.Fatalf("unexpected log %+v", log)
+	}
+	mu.Lock()
+	if keys[0] != keys[2] {
+		t.Fatalf("expected one key across retries, got %v", keys)
+	}
+	mu.Unlock()
+}
+
+func TestDispatcherGivesUp(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer ts.Close()
+
+	repo := &fakeRepo{}
+	hook, _ := NewService(repo).Create(t.Context(), 0, CreateInput{URL: ts.URL})
+
+	d := NewDispatcher(repo, encodeJSON, WithRetries(2, time.Millisecond))
+	d.Publish(todo.Event{TaskID: 1, Kind: todo.EventUpdated}, todo.Task{ID: 1})
+	waitFor(t, func() bool {
+		log, _ := repo.ListDeliveries(t.Context(), hook.ID)
+		return len(log) == 2
+	})
+	time.Sleep(20 * time.Millisecond)
+	d.Close()
+
+	if log, _ := repo.ListDeliveries(t.Context(), hook.ID); len(log) != 2 {
+		t.Fatalf("expected 2 attempts, got %d", len(log))
+	}
+	// Publishing after Close is ignored
+	d.Publish(todo.Event{TaskID: 1, Kind: todo.EventUpdated}, todo.Task{ID: 1})
+}
+
+func TestServiceValidates(t *testing.T) {
+	svc := NewService(&fakeRepo{})
+
+	for _, url := range []string{"", "example.com/hook", "ftp://example.com", "http://"} {
+		if _, err := svc.Create(t.Context(), 1, CreateInput{URL: url}); !errors.Is(err, ErrInvalidURL) {
+			t.Fatalf("%q: expected error %v, got %v", url, ErrInvalidURL, err)
+		}
+	}
+	if _, err := svc.Create(t.Context(), 1, CreateInput{URL: "http://x", Events: []string{"created", "exploded"}}); !errors.Is(err, ErrInvalidEvents) {
+		t.Fatalf("expected error %v, got %v", ErrInvalidEvents, err)
+	}
+
+	// Check a secret is generated and events are normalized
+	w, err := svc.Create(t.Context(), 1, CreateInput{URL: "http://x", Events: []string{" Created", "created"}})
+	if err != nil || len(w.Secret) != 64 || len(w.Events) != 1 || w.Events[0] != todo.EventCreated {
+		t.Fatalf("unexpected webhook %+v, %v", w, err)
+	}
+
+	// Check other owners cannot see or change it
+	if _, err := svc.Get(t.Context(), 2, w.ID); !errors.Is(err, ErrWebhookNotFound) {
+		t.Fatalf("expected error %v, got %v", ErrWebhookNotFound, err)
+	}
+	if err := svc.Delete(t.Context(), 2, w.ID); !errors.Is(err, ErrWebhookNotFound) {
+		t.Fatalf("expected error %v, got %v", ErrWebhookNotFound, err)
+	}
+
+	events := []string{}
+	w, err = svc.Update(t.Context(), 1, w.ID, UpdateInput{Events: &events})
+	if err != nil || len(w.Events) != 0 || !w.Wants(todo.EventPurged) {
+		t.Fatalf("expected webhook for all events, got %+v, %v", w, err)
+	}
+}
+
+func TestDispatcherRetriesFollowChanges(t *testing.T) {
+	var mu sync.Mutex
+	var got []string
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer failing.Close()
+	moved := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		defer mu.Unlock()
+		got = append(got, r.Header.Get(HeaderSignature), Sign("rotated", body))
+	}))
+	defer moved.Close()
+
+	repo := &fakeRepo{}
+	hook, _ := NewService(repo).Create(t.Context(), 0, CreateInput{URL: failing.URL, Secret: "old"})
+
+	d := NewDispatcher(repo, encodeJSON, WithRetries(50, 20*time.Millisecond))
+	defer d.Close()
+	d.Publish(todo.Event{TaskID: 1, Kind: todo.EventUpdated}, todo.Task{ID: 1})
+	waitFor(t, func() bool {
+		log, _ := repo.ListDeliveries(t.Context(), hook.ID)
+		return len(log) > 0
+	})
+
+	// Check a retry goes to the new URL, signed with the new secret
+	hook.URL, hook.Secret = moved.URL, "rotated"
+	repo.UpdateWebhook(t.Context(), hook)
+	waitFor(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(got) > 0
+	})
+	mu.Lock()
+	if got[0] != got[1] {
+		t.Fatalf("expected the rotated secret's signature %s, got %s", got[1], got[0])
+	}
+	mu.Unlock()
+
+	// Check a deleted webhook is not retried
+	hook.URL = failing.URL
+	repo.UpdateWebhook(t.Context(), hook)
+	d.Publish(todo.Event{TaskID: 2, Kind: todo.EventUpdated}, todo.Task{ID: 2})
+	waitFor(t, func() bool {
+		log, _ := repo.ListDeliveries(t.Context(), hook.ID)
+		return len(log) > 0 && log[0].TaskID == 2
+	})
+	repo.DeleteWebhook(t.Context(), hook.ID)
+	time.Sleep(5 * time.Millisecond) // let an attempt in flight finish
+	repo.mu.Lock()
+	n := len(repo.deliveries)
+	repo.mu.Unlock()
+	time.Sleep(100 * time.Millisecond)
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	if len(repo.deliveries) != n {
+		t.Fatalf("expected no attempts after the delete, got %d more", len(repo.deliveries)-n)
+	}
+}
+
+func TestDispatcherBackoffIsCapped(t *testing.T) {
+	d := &Dispatcher{backoff: DefaultBackoff}
+	for attempt, want := range map[int]time.Duration{1: DefaultBackoff, 3: 4 * DefaultBackoff, 100: maxBackoff, 1 << 20: maxBackoff} {
+		if got := d.delay(attempt); got != want {
+			t.Fatalf("attempt %d: expected %v, got %v", attempt, want, got)
+		}
+	}
+}
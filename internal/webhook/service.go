@@ -0,0 +1,148 @@
+package webhook
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Saintrad/todo-server-client/internal/todo"
+)
+
+var eventKinds = []todo.EventKind{
+	todo.EventCreated, todo.EventUpdated, todo.EventCompleted,
+	todo.EventDeleted, todo.EventRestored, todo.EventPurged, todo.EventReminder,
+}
+
+// Service manages the webhooks of task owners.
+type Service struct {
+	repo Repo
+	now  func() time.Time
+}
+
+func NewService(repo Repo) *Service {
+	return &Service{repo: repo, now: time.Now}
+}
+
+type CreateInput struct {
+	URL    string
+	Events []string
+	// Secret signs the deliveries; empty generates one.
+	Secret string
+}
+
+type UpdateInput struct {
+	URL    *string
+	Events *[]string
+	Secret *string
+}
+
+func (s *Service) Create(ctx context.Context, owner int, in CreateInput) (Webhook, error) {
+	w := Webhook{OwnerID: owner, Secret: in.Secret}
+	var err error
+	if w.URL, err = parseURL(in.URL); err != nil {
+		return Webhook{}, err
+	}
+	if w.Events, err = parseEvents(in.Events); err != nil {
+		return Webhook{}, err
+	}
+	if w.Secret == "" {
+		if w.Secret, err = newSecret(); err != nil {
+			return Webhook{}, todo.NewInternalError(err)
+		}
+	}
+	w.CreatedAt = s.now()
+	w.UpdatedAt = w.CreatedAt
+	return s.repo.CreateWebhook(ctx, w)
+}
+
+func (s *Service) List(ctx context.Context, owner int) ([]Webhook, error) {
+	return s.repo.ListWebhooks(ctx, owner)
+}
+
+// Get returns a webhook of owner; other owners' webhooks are not found.
+func (s *Service) Get(ctx context.Context, owner, id int) (Webhook, error) {
+	w, err := s.repo.GetWebhook(ctx, id)
+	if err != nil {
+		return Webhook{}, err
+	}
+	if w.OwnerID != owner {
+		return Webhook{}, ErrWebhookNotFound
+	}
+	return w, nil
+}
+
+func (s *Service) Update(ctx context.Context, owner, id int, in UpdateInput) (Webhook, error) {
+	w, err := s.Get(ctx, owner, id)
+	if err != nil {
+		return Webhook{}, err
+	}
+
+	if in.URL != nil {
+		if w.URL, err = parseURL(*in.URL); err != nil {
+			return Webhook{}, err
+		}
+	}
+	if in.Events != nil {
+		if w.Events, err = parseEvents(*in.Events); err != nil {
+			return Webhook{}, err
+		}
+	}
+	if in.Secret != nil {
+		if w.Secret = *in.Secret; w.Secret == "" {
+			return Webhook{}, todo.NewValidationError(todo.FieldIssue{Field: "secret", Issue: "must not be empty"})
+		}
+	}
+	w.UpdatedAt = s.now()
+	return s.repo.UpdateWebhook(ctx, w)
+}
+
+func (s *Service) Delete(ctx context.Context, owner, id int) error {
+	if _, err := s.Get(ctx, owner, id); err != nil {
+		return err
+	}
+	return s.repo.DeleteWebhook(ctx, id)
+}
+
+// Deliveries returns the delivery log of a webhook, newest first.
+func (s *Service) Deliveries(ctx context.Context, owner, id int) ([]Delivery, error) {
+	if _, err := s.Get(ctx, owner, id); err != nil {
+		return nil, err
+	}
+	return s.repo.ListDeliveries(ctx, id)
+}
+
+func parseURL(raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", ErrInvalidURL
+	}
+	return raw, nil
+}
+
+// parseEvents returns the event kinds named, without duplicates.
+func parseEvents(names []string) ([]todo.EventKind, error) {
+	var out []todo.EventKind
+	for _, n := range names {
+		k := todo.EventKind(strings.ToLower(strings.TrimSpace(n)))
+		if !slices.Contains(eventKinds, k) {
+			return nil, ErrInvalidEvents
+		}
+		if !slices.Contains(out, k) {
+			out = append(out, k)
+		}
+	}
+	return out, nil
+}
+
+func newSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,84 @@
+// Package webhook manages webhook subscriptions and delivers task events to
+// them.
+package webhook
+
+import (
+	"context"
+	"time"
+
+	"github.com/Saintrad/todo-server-client/internal/todo"
+)
+
+// MaxDeliveryLog is how many delivery attempts are kept per webhook.
+const MaxDeliveryLog = 100
+
+var (
+	ErrWebhookNotFound = todo.NewNotFoundError("webhook not found")
+	ErrInvalidURL      = todo.NewValidationError(todo.FieldIssue{Field: "url", Issue: "must be an absolute http or https URL"})
+	ErrInvalidEvents   = todo.NewValidationError(todo.FieldIssue{Field: "events", Issue: "must only contain created, updated, completed, deleted, restored, purged or reminder"})
+)
+
+// Webhook is a subscription of an owner's task events. An empty Events
+// subscribes to all of them.
+type Webhook struct {
+	ID        int
+	OwnerID   int
+	URL       string
+	Events    []todo.EventKind
+	Secret    string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// Wants reports whether the webhook subscribes to kind.
+func (w Webhook) Wants(kind todo.EventKind) bool {
+	if len(w.Events) == 0 {
+		return true
+	}
+	for _, k := range w.Events {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// Delivery is one attempt to deliver an event to a webhook.
+type Delivery struct {
+	ID        int
+	WebhookID int
+	// Key identifies the event delivery; retries share it so receivers can
+	// drop duplicates.
+	Key        string
+	Event      todo.EventKind
+	TaskID     int
+	Attempt    int
+	StatusCode int // 0 if no response was received
+	Error      string
+	Duration   time.Duration
+	At         time.Time
+}
+
+// OK reports whether the receiver accepted the delivery.
+func (d Delivery) OK() bool {
+	return d.Error == "" && d.StatusCode >= 200 && d.StatusCode < 300
+}
+
+// Repo stores webhooks and their delivery log.
+type Repo interface {
+	CreateWebhook(context.Context, Webhook) (Webhook, error)
+	// ListWebhooks returns the webhooks of an owner, oldest first.
+	ListWebhooks(ctx context.Context, ownerID int) ([]Webhook, error)
+	GetWebhook(context.Context, int) (Webhook, error)
+	UpdateWebhook(context.Context, Webhook) (Webhook, error)
+	// DeleteWebhook removes a webhook and its delivery log.
+	DeleteWebhook(context.Context, int) error
+
+	// AppendDelivery logs an attempt, keeping the last MaxDeliveryLog of
+	// its webhook. It fails with ErrWebhookNotFound if the webhook is gone.
+	AppendDelivery(context.Context, Delivery) (Delivery, error)
+	// ListDeliveries returns the logged attempts of a webhook, newest first.
+	ListDeliveries(ctx context.Context, webhookID int) ([]Delivery, error)
+
+	Close() error
+}